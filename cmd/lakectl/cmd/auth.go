@@ -65,6 +65,11 @@ var authUsersList = &cobra.Command{
 		})
 		DieOnResponseError(resp, err)
 
+		if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+			PrintJSON(resp.JSON200)
+			return
+		}
+
 		users := resp.JSON200.Results
 		rows := make([][]interface{}, len(users))
 		for i, user := range users {
@@ -127,6 +132,11 @@ var authUsersGroupsList = &cobra.Command{
 		})
 		DieOnResponseError(resp, err)
 
+		if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+			PrintJSON(resp.JSON200)
+			return
+		}
+
 		groups := resp.JSON200.Results
 		rows := make([][]interface{}, len(groups))
 		for i, group := range groups {
@@ -162,6 +172,11 @@ var authUsersPoliciesList = &cobra.Command{
 		})
 		DieOnResponseError(resp, err)
 
+		if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+			PrintJSON(resp.JSON200)
+			return
+		}
+
 		policies := resp.JSON200.Results
 		rows := make([][]interface{}, 0)
 		for _, policy := range policies {
@@ -272,6 +287,11 @@ var authUsersCredentialsList = &cobra.Command{
 		})
 		DieOnResponseError(resp, err)
 
+		if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+			PrintJSON(resp.JSON200)
+			return
+		}
+
 		credentials := resp.JSON200.Results
 		rows := make([][]interface{}, len(credentials))
 		for i, c := range credentials {
@@ -304,6 +324,11 @@ var authGroupsList = &cobra.Command{
 		})
 		DieOnResponseError(resp, err)
 
+		if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+			PrintJSON(resp.JSON200)
+			return
+		}
+
 		groups := resp.JSON200.Results
 		rows := make([][]interface{}, len(groups))
 		for i, group := range groups {
@@ -366,6 +391,11 @@ var authGroupsListMembers = &cobra.Command{
 		})
 		DieOnResponseError(resp, err)
 
+		if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+			PrintJSON(resp.JSON200)
+			return
+		}
+
 		users := resp.JSON200.Results
 		rows := make([][]interface{}, len(users))
 		for i, user := range users {
@@ -426,6 +456,11 @@ var authGroupsPoliciesList = &cobra.Command{
 		})
 		DieOnResponseError(resp, err)
 
+		if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+			PrintJSON(resp.JSON200)
+			return
+		}
+
 		policies := resp.JSON200.Results
 		rows := make([][]interface{}, 0)
 		for _, policy := range policies {
@@ -492,6 +527,11 @@ var authPoliciesList = &cobra.Command{
 		})
 		DieOnResponseError(resp, err)
 
+		if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+			PrintJSON(resp.JSON200)
+			return
+		}
+
 		policies := resp.JSON200.Results
 		rows := make([][]interface{}, len(policies))
 		for i, policy := range policies {
@@ -604,14 +644,17 @@ func init() {
 	_ = authUsersDelete.MarkFlagRequired("id")
 
 	addPaginationFlags(authUsersList)
+	AssignOutputFlag(authUsersList.Flags())
 
 	addPaginationFlags(authUsersGroupsList)
+	AssignOutputFlag(authUsersGroupsList.Flags())
 	authUsersGroupsList.Flags().String("id", "", "user identifier")
 	_ = authUsersGroupsList.MarkFlagRequired("id")
 
 	authUsersGroups.AddCommand(authUsersGroupsList)
 
 	addPaginationFlags(authUsersPoliciesList)
+	AssignOutputFlag(authUsersPoliciesList.Flags())
 	authUsersPoliciesList.Flags().Bool("effective", false,
 		"list all distinct policies attached to the user, even through group memberships")
 	authUsersPoliciesList.Flags().String("id", "", "user identifier")
@@ -633,6 +676,7 @@ func init() {
 
 	authUsersCredentialsList.Flags().String("id", "", "user identifier (default: current user)")
 	addPaginationFlags(authUsersCredentialsList)
+	AssignOutputFlag(authUsersCredentialsList.Flags())
 
 	authUsersCredentialsCreate.Flags().String("id", "", "user identifier (default: current user)")
 
@@ -661,6 +705,7 @@ func init() {
 	_ = authGroupsDelete.MarkFlagRequired("id")
 
 	addPaginationFlags(authGroupsList)
+	AssignOutputFlag(authGroupsList.Flags())
 
 	authGroupsAddMember.Flags().String("id", "", "group identifier")
 	authGroupsAddMember.Flags().String("user", "", "user identifier to add to the group")
@@ -672,6 +717,7 @@ func init() {
 	_ = authGroupsRemoveMember.MarkFlagRequired("user")
 	authGroupsListMembers.Flags().String("id", "", "group identifier")
 	addPaginationFlags(authGroupsListMembers)
+	AssignOutputFlag(authGroupsListMembers.Flags())
 	_ = authGroupsListMembers.MarkFlagRequired("id")
 
 	authGroupsMembers.AddCommand(authGroupsAddMember)
@@ -679,6 +725,7 @@ func init() {
 	authGroupsMembers.AddCommand(authGroupsListMembers)
 
 	addPaginationFlags(authGroupsPoliciesList)
+	AssignOutputFlag(authGroupsPoliciesList.Flags())
 	authGroupsPoliciesList.Flags().String("id", "", "group identifier")
 	_ = authGroupsPoliciesList.MarkFlagRequired("id")
 
@@ -716,6 +763,7 @@ func init() {
 	_ = authPoliciesShow.MarkFlagRequired("id")
 
 	addPaginationFlags(authPoliciesList)
+	AssignOutputFlag(authPoliciesList.Flags())
 
 	authPolicies.AddCommand(authPoliciesDelete)
 	authPolicies.AddCommand(authPoliciesCreate)
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+func TestAuthUsersCreate(t *testing.T) {
+	var body api.CreateUserJSONRequestBody
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusCreated, api.User{Id: body.Id, CreationDate: 1})
+	})
+
+	out := runCmd(t, mux, "auth", "users", "create", "--id", "jane")
+	if body.Id != "jane" {
+		t.Errorf("create user request id = %q, expected %q", body.Id, "jane")
+	}
+	if !strings.Contains(out, "jane") {
+		t.Errorf("output %q does not mention created user", out)
+	}
+}
+
+func TestAuthGroupsMembersAdd(t *testing.T) {
+	var method, path string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/groups/", func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	runCmd(t, mux, "auth", "groups", "members", "add", "--id", "admins", "--user", "jane")
+	if method != http.MethodPut || path != "/auth/groups/admins/members/jane" {
+		t.Errorf("add member request = %s %s, expected PUT /auth/groups/admins/members/jane", method, path)
+	}
+}
+
+func TestAuthUsersListJSON(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/users", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.UserList{
+			Pagination: api.Pagination{HasMore: true, NextOffset: "jane", Results: 1},
+			Results:    []api.User{{Id: "jane", CreationDate: 1}},
+		})
+	})
+
+	out := runCmd(t, mux, "auth", "users", "list", "--output", "json")
+	var list api.UserList
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("output %q is not a user list: %s", out, err)
+	}
+	if len(list.Results) != 1 || list.Results[0].Id != "jane" || list.Pagination.NextOffset != "jane" {
+		t.Errorf("unexpected user list %+v", list)
+	}
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/spf13/cobra"
 	"github.com/treeverse/lakefs/pkg/api"
@@ -11,9 +14,41 @@ import (
 const (
 	ParentNumberFlagName = "parent-number"
 
-	branchRevertCmdArgs = 2
+	branchRevertCmdArgs    = 2
+	branchMergeBaseCmdArgs = 2
+	branchRenameCmdArgs    = 2
+	branchFromTagCmdArgs   = 2
 )
 
+var (
+	ErrBranchExists           = errors.New("branch already exists")
+	ErrUncommittedChanges     = errors.New("branch has uncommitted changes")
+	ErrRenameDefaultBranch    = errors.New("cannot rename the default branch of a repository")
+	ErrRenameAcrossRepository = errors.New("cannot rename a branch to another repository")
+	ErrTagNotFound            = errors.New("tag not found")
+	ErrTagInOtherRepository   = errors.New("tag must belong to the repository of the branch")
+	ErrSyncAcrossRepository   = errors.New("cannot sync a branch from another repository")
+	ErrSyncConflicts          = errors.New("conflicts merging into branch")
+)
+
+const branchMergeBaseTemplate = `Merge base: {{ .Id|yellow }}
+Message: {{ .Message }}
+`
+
+const branchRenameTemplate = `Renamed branch '{{ .From }}' to '{{ .To|yellow }}'
+Head: {{ .Commit.Id|yellow }}
+Message: {{ .Commit.Message }}
+`
+
+const branchSyncTemplate = `{{ if .UpToDate }}Branch '{{ .Branch }}' is up to date with '{{ .From }}'
+{{ else if .FastForwarded }}Fast-forwarded branch '{{ .Branch }}' to '{{ .From }}' at {{ .Result.Reference|yellow }}
+{{ else }}Merged '{{ .From }}' into '{{ .Branch }}' to get {{ .Result.Reference|yellow }}
+
+Added: {{ .Result.Summary.Added }}
+Changed: {{ .Result.Summary.Changed }}
+Removed: {{ .Result.Summary.Removed }}
+{{ end }}`
+
 // branchCmd represents the branch command
 var branchCmd = &cobra.Command{
 	Use:   "branch",
@@ -71,6 +106,18 @@ var branchCreateCmd = &cobra.Command{
 		})
 		DieOnResponseError(resp, err)
 		Fmt("created branch '%s' %s\n", u.Ref, string(resp.Body))
+
+		protect, _ := cmd.Flags().GetBool("protect")
+		if !protect {
+			return
+		}
+		protectResp, err := client.CreateBranchProtectionRuleWithResponse(cmd.Context(), u.Repository, api.CreateBranchProtectionRuleJSONRequestBody{
+			Pattern: u.Ref,
+		})
+		if err := responseError(protectResp, err); err != nil {
+			DieFmt("branch '%s' was created but is NOT protected: %s", u.Ref, err)
+		}
+		Fmt("protected branch '%s'\n", u.Ref)
 	},
 }
 
@@ -172,21 +219,305 @@ var branchResetCmd = &cobra.Command{
 	},
 }
 
+const branchShowTemplate = `Commit ID: {{ .Commit.Id|yellow }}
+Author:    {{ .Commit.Committer }}
+Date:      {{ .Commit.CreationDate|date }}
+Message:   {{ .Commit.Message }}
+{{ with .Compare }}
+Compared to {{ .Ref|yellow }}: {{ .Ahead|green }} commits ahead, {{ .Behind|red }} commits behind
+{{ end -}}
+`
+
+type branchDivergence struct {
+	Ref           string
+	Ahead, Behind int
+}
+
 var branchShowCmd = &cobra.Command{
-	Use:   "show <branch uri>",
-	Short: "show branch latest commit reference",
-	Args:  cobra.ExactArgs(1),
+	Use:     "show <branch uri>",
+	Short:   "show branch latest commit reference",
+	Example: "lakectl branch show lakefs://<repository>/<branch> --compare lakefs://<repository>/main",
+	Args:    cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		client := getClient()
 		u := MustParseRefURI("branch", args[0])
+		compare := MustString(cmd.Flags().GetString("compare"))
+		var compareURI *uri.URI
+		if compare != "" {
+			compareURI = MustParseRefURI("compare ref", compare)
+			if compareURI.Repository != u.Repository {
+				Die("both references must belong to the same repository", 1)
+			}
+		}
 		Fmt("Branch: %s\n", u.String())
 		resp, err := client.GetBranchWithResponse(cmd.Context(), u.Repository, u.Ref)
 		DieOnResponseError(resp, err)
 		branch := resp.JSON200
-		Fmt("%s\n", branch)
+
+		commitResp, err := client.GetCommitWithResponse(cmd.Context(), u.Repository, branch.CommitId)
+		DieOnResponseError(commitResp, err)
+
+		data := struct {
+			Commit  *api.Commit
+			Compare *branchDivergence
+		}{
+			Commit: commitResp.JSON200,
+		}
+		if compareURI != nil {
+			branchLog := logAllCommits(cmd.Context(), client, u.Repository, branch.CommitId)
+			compareLog := logAllCommits(cmd.Context(), client, compareURI.Repository, compareURI.Ref)
+			ahead, behind := commitsAheadBehind(branchLog, compareLog)
+			data.Compare = &branchDivergence{Ref: compareURI.String(), Ahead: ahead, Behind: behind}
+		}
+		Write(branchShowTemplate, data)
+	},
+}
+
+// logAllCommits returns all commits reachable from ref.
+func logAllCommits(ctx context.Context, client api.ClientWithResponsesInterface, repository, ref string) []api.Commit {
+	var commits []api.Commit
+	var after string
+	for {
+		resp, err := client.LogCommitsWithResponse(ctx, repository, ref, &api.LogCommitsParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+		})
+		DieOnResponseError(resp, err)
+		commits = append(commits, resp.JSON200.Results...)
+		if !resp.JSON200.Pagination.HasMore {
+			return commits
+		}
+		after = resp.JSON200.Pagination.NextOffset
+	}
+}
+
+// commitsAheadBehind returns the number of commits in log that are not in otherLog, and the
+// number of commits in otherLog that are not in log.
+func commitsAheadBehind(log, otherLog []api.Commit) (ahead, behind int) {
+	countMissing := func(commits, from []api.Commit) int {
+		ids := make(map[string]struct{}, len(from))
+		for _, c := range from {
+			ids[c.Id] = struct{}{}
+		}
+		missing := 0
+		for _, c := range commits {
+			if _, ok := ids[c.Id]; !ok {
+				missing++
+			}
+		}
+		return missing
+	}
+	return countMissing(log, otherLog), countMissing(otherLog, log)
+}
+
+var branchMergeBaseCmd = &cobra.Command{
+	Use:     "merge-base <ref uri> <ref uri>",
+	Short:   "show the best common ancestor commit of two references",
+	Example: "lakectl branch merge-base lakefs://<repository>/main lakefs://<repository>/feature",
+	Args:    cobra.ExactArgs(branchMergeBaseCmdArgs),
+	Run: func(cmd *cobra.Command, args []string) {
+		leftURI := MustParseRefURI("ref", args[0])
+		rightURI := MustParseRefURI("other ref", args[1])
+		if leftURI.Repository != rightURI.Repository {
+			Die("both references must belong to the same repository", 1)
+		}
+		resp, err := getClient().FindMergeBaseWithResponse(cmd.Context(), leftURI.Repository, leftURI.Ref, rightURI.Ref)
+		DieOnResponseError(resp, err)
+		Write(branchMergeBaseTemplate, resp.JSON200)
 	},
 }
 
+var branchRenameCmd = &cobra.Command{
+	Use:   "rename <branch uri> <new name>",
+	Short: "rename a branch in a repository",
+	Long: `rename a branch by creating a branch with the new name at its head and deleting it.
+References to the old name, e.g. in scripts or hooks, stop working.  The branch must have no
+uncommitted changes, and cannot be the default branch of the repository.`,
+	Example: "lakectl branch rename lakefs://example-repo/feature new-feature",
+	Args:    cobra.ExactArgs(branchRenameCmdArgs),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := MustParseRefURI("branch", args[0])
+		newName := args[1]
+		confirmation, err := Confirm(cmd.Flags(), fmt.Sprintf("Are you sure you want to rename branch %s to %s, breaking references to it", u.Ref, newName))
+		if err != nil || !confirmation {
+			Die("Rename branch aborted", 1)
+		}
+		client := getClient()
+		head, err := renameBranch(cmd.Context(), client, u.Repository, u.Ref, newName)
+		if err != nil {
+			DieErr(err)
+		}
+		commitResp, err := client.GetCommitWithResponse(cmd.Context(), u.Repository, head.CommitId)
+		DieOnResponseError(commitResp, err)
+		Write(branchRenameTemplate, struct {
+			From   string
+			To     string
+			Commit *api.Commit
+		}{From: u.Ref, To: newName, Commit: commitResp.JSON200})
+	},
+}
+
+var branchFromTagCmd = &cobra.Command{
+	Use:     "from-tag <branch uri> <tag uri>",
+	Short:   "create a new branch at the commit a tag points at",
+	Example: "lakectl branch from-tag lakefs://example-repo/release-1.2 lakefs://example-repo/v1.2.0",
+	Args:    cobra.ExactArgs(branchFromTagCmdArgs),
+	Run: func(cmd *cobra.Command, args []string) {
+		branchURI := MustParseRefURI("branch", args[0])
+		tagURI := MustParseRefURI("tag", args[1])
+		branch, err := createBranchFromTag(cmd.Context(), getClient(), branchURI, tagURI)
+		if err != nil {
+			DieErr(err)
+		}
+		Fmt("created branch '%s' from tag '%s' at %s\n", branch.Id, tagURI.Ref, branch.CommitId)
+	},
+}
+
+// createBranchFromTag creates the branch of branchURI at the commit the tag of tagURI points at,
+// and returns the new branch.
+func createBranchFromTag(ctx context.Context, client api.ClientWithResponsesInterface, branchURI, tagURI *uri.URI) (*api.Ref, error) {
+	if tagURI.Repository != branchURI.Repository {
+		return nil, fmt.Errorf("%w: %s", ErrTagInOtherRepository, tagURI)
+	}
+	tagResp, err := client.GetTagWithResponse(ctx, tagURI.Repository, tagURI.Ref)
+	if err == nil && tagResp.StatusCode() == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrTagNotFound, tagURI.Ref)
+	}
+	if err := responseError(tagResp, err); err != nil {
+		return nil, err
+	}
+	commitID := tagResp.JSON200.CommitId
+	createResp, err := client.CreateBranchWithResponse(ctx, branchURI.Repository, api.CreateBranchJSONRequestBody{
+		Name:   branchURI.Ref,
+		Source: commitID,
+	})
+	if err := responseError(createResp, err); err != nil {
+		return nil, err
+	}
+	return &api.Ref{Id: branchURI.Ref, CommitId: commitID}, nil
+}
+
+// renameBranch renames branch in repository to newName, and returns the renamed branch.  The API
+// cannot rename branches, so it creates newName at the head of branch and deletes branch.  The
+// uncommitted changes of branch would be lost, so it must have none.
+func renameBranch(ctx context.Context, client api.ClientWithResponsesInterface, repository, branch, newName string) (*api.Ref, error) {
+	if newURI, err := uri.Parse(newName); err == nil && newURI.IsRef() {
+		if newURI.Repository != repository {
+			return nil, fmt.Errorf("%w: %s", ErrRenameAcrossRepository, newName)
+		}
+		newName = newURI.Ref
+	}
+	existingResp, err := client.GetBranchWithResponse(ctx, repository, newName)
+	if err == nil && existingResp.StatusCode() == http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrBranchExists, newName)
+	}
+	if err == nil && existingResp.StatusCode() != http.StatusNotFound {
+		err = responseError(existingResp, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	repoResp, err := client.GetRepositoryWithResponse(ctx, repository)
+	if err := responseError(repoResp, err); err != nil {
+		return nil, err
+	}
+	if repoResp.JSON200.DefaultBranch == branch {
+		return nil, fmt.Errorf("%w: %s", ErrRenameDefaultBranch, branch)
+	}
+	branchResp, err := client.GetBranchWithResponse(ctx, repository, branch)
+	if err := responseError(branchResp, err); err != nil {
+		return nil, err
+	}
+	head := branchResp.JSON200.CommitId
+	diffResp, err := client.DiffBranchWithResponse(ctx, repository, branch, &api.DiffBranchParams{
+		Amount: api.PaginationAmountPtr(1),
+	})
+	if err := responseError(diffResp, err); err != nil {
+		return nil, err
+	}
+	if len(diffResp.JSON200.Results) > 0 {
+		return nil, fmt.Errorf("%w: commit or reset them before renaming %s", ErrUncommittedChanges, branch)
+	}
+
+	createResp, err := client.CreateBranchWithResponse(ctx, repository, api.CreateBranchJSONRequestBody{
+		Name:   newName,
+		Source: head,
+	})
+	if err := responseError(createResp, err); err != nil {
+		return nil, err
+	}
+	deleteResp, err := client.DeleteBranchWithResponse(ctx, repository, branch)
+	if err := responseError(deleteResp, err); err != nil {
+		return nil, fmt.Errorf("created %s but could not delete %s: %w", newName, branch, err)
+	}
+	return &api.Ref{Id: newName, CommitId: head}, nil
+}
+
+var branchSyncCmd = &cobra.Command{
+	Use:   "sync <branch uri> --from <ref uri>",
+	Short: "bring a branch up to date with a reference, fast-forwarding it when possible",
+	Long: `Bring a branch up to date with a reference of the same repository.  If the head of the
+branch is an ancestor of the reference, the branch is fast-forwarded to it without creating a
+commit.  Otherwise the reference is merged into the branch.  Prints which of these happened.`,
+	Example: "lakectl branch sync lakefs://example-repo/feature --from lakefs://example-repo/main",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		branchURI := MustParseRefURI("branch", args[0])
+		fromURI := MustParseRefURI("from", MustString(cmd.Flags().GetString("from")))
+		result, err := syncBranch(cmd.Context(), getClient(), branchURI, fromURI)
+		if err != nil {
+			DieErr(err)
+		}
+		Write(branchSyncTemplate, result)
+	},
+}
+
+type branchSyncResult struct {
+	Branch        string
+	From          string
+	UpToDate      bool
+	FastForwarded bool
+	Result        *api.MergeResult
+}
+
+// syncBranch brings the branch of branchURI up to date with the ref of fromURI: nothing to do if
+// the ref is already on the branch, and otherwise a merge that the server fast-forwards if the
+// branch head is an ancestor of the ref.
+func syncBranch(ctx context.Context, client api.ClientWithResponsesInterface, branchURI, fromURI *uri.URI) (*branchSyncResult, error) {
+	if fromURI.Repository != branchURI.Repository {
+		return nil, fmt.Errorf("%w: %s", ErrSyncAcrossRepository, fromURI)
+	}
+	result := &branchSyncResult{Branch: branchURI.Ref, From: fromURI.Ref}
+	baseResp, err := client.FindMergeBaseWithResponse(ctx, branchURI.Repository, branchURI.Ref, fromURI.Ref)
+	if err := responseError(baseResp, err); err != nil {
+		return nil, err
+	}
+	fromResp, err := client.LogCommitsWithResponse(ctx, fromURI.Repository, fromURI.Ref, &api.LogCommitsParams{
+		Amount: api.PaginationAmountPtr(1),
+	})
+	if err := responseError(fromResp, err); err != nil {
+		return nil, err
+	}
+	if len(fromResp.JSON200.Results) > 0 && fromResp.JSON200.Results[0].Id == baseResp.JSON200.Id {
+		result.UpToDate = true
+		return result, nil
+	}
+	fastForward := true
+	resp, err := client.MergeIntoBranchWithResponse(ctx, branchURI.Repository, fromURI.Ref, branchURI.Ref, api.MergeIntoBranchJSONRequestBody{
+		FastForward: &fastForward,
+	})
+	if err == nil && resp.JSON409 != nil && !mergeBaseMoved(resp) {
+		return nil, fmt.Errorf("%w %s: %d conflicts", ErrSyncConflicts, branchURI.Ref, resp.JSON409.Summary.Conflict)
+	}
+	if err := responseError(resp, err); err != nil {
+		return nil, err
+	}
+	result.Result = resp.JSON200
+	result.FastForwarded = api.BoolValue(resp.JSON200.FastForwarded)
+	return result, nil
+}
+
 //nolint:gochecknoinits
 func init() {
 	rootCmd.AddCommand(branchCmd)
@@ -196,12 +527,22 @@ func init() {
 	branchCmd.AddCommand(branchShowCmd)
 	branchCmd.AddCommand(branchResetCmd)
 	branchCmd.AddCommand(branchRevertCmd)
+	branchCmd.AddCommand(branchMergeBaseCmd)
+	branchCmd.AddCommand(branchRenameCmd)
+	branchCmd.AddCommand(branchFromTagCmd)
+	branchCmd.AddCommand(branchSyncCmd)
 
 	branchListCmd.Flags().Int("amount", defaultAmountArgumentValue, "number of results to return")
 	branchListCmd.Flags().String("after", "", "show results after this value (used for pagination)")
 
+	branchShowCmd.Flags().String("compare", "", "ref uri to count commits ahead and behind of")
+
 	branchCreateCmd.Flags().StringP("source", "s", "", "source branch uri")
 	_ = branchCreateCmd.MarkFlagRequired("source")
+	branchCreateCmd.Flags().Bool("protect", false, "protect the new branch from direct writes and commits once created")
+
+	branchSyncCmd.Flags().String("from", "", "ref uri to bring the branch up to date with")
+	_ = branchSyncCmd.MarkFlagRequired("from")
 
 	branchResetCmd.Flags().String("prefix", "", "prefix of the objects to be reset")
 	branchResetCmd.Flags().String("object", "", "path to object to be reset")
@@ -211,4 +552,5 @@ func init() {
 	AssignAutoConfirmFlag(branchResetCmd.Flags())
 	AssignAutoConfirmFlag(branchRevertCmd.Flags())
 	AssignAutoConfirmFlag(branchDeleteCmd.Flags())
+	AssignAutoConfirmFlag(branchRenameCmd.Flags())
 }
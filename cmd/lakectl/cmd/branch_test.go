@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
+)
+
+// divergentHistoryHandler serves a repository "repo" whose branch "feature" has two commits not
+// on "main", and "main" has one commit not on "feature".  Branch "stale" is at their merge base:
+//
+//	c1 - c2 - c3        (main)
+//	      |\
+//	      | c4 - c5     (feature)
+//	      (stale)
+func divergentHistoryHandler() http.Handler {
+	commits := map[string]api.Commit{
+		"c1": {Id: "c1", Message: "first"},
+		"c2": {Id: "c2", Message: "second", Parents: []string{"c1"}},
+		"c3": {Id: "c3", Message: "on main", Parents: []string{"c2"}},
+		"c4": {Id: "c4", Message: "on feature", Parents: []string{"c2"}},
+		"c5": {Id: "c5", Message: "more on feature", Committer: "jane", Parents: []string{"c4"}},
+	}
+	branches := map[string]string{"main": "c3", "feature": "c5", "stale": "c2"}
+	logOf := func(id string) []api.Commit {
+		var log []api.Commit
+		for {
+			c := commits[id]
+			log = append(log, c)
+			if len(c.Parents) == 0 {
+				return log
+			}
+			id = c.Parents[0]
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/branches/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/repositories/repo/branches/")
+		writeJSON(w, http.StatusOK, api.Ref{Id: name, CommitId: branches[name]})
+	})
+	mux.HandleFunc("/repositories/repo/commits/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, commits[strings.TrimPrefix(r.URL.Path, "/repositories/repo/commits/")])
+	})
+	resolve := func(ref string) string {
+		if id, ok := branches[ref]; ok {
+			return id
+		}
+		return ref
+	}
+	mux.HandleFunc("/repositories/repo/refs/", func(w http.ResponseWriter, r *http.Request) {
+		ref := strings.TrimPrefix(r.URL.Path, "/repositories/repo/refs/")
+		if i := strings.Index(ref, "/merge-base/"); i >= 0 {
+			onRight := make(map[string]bool)
+			for _, c := range logOf(resolve(ref[i+len("/merge-base/"):])) {
+				onRight[c.Id] = true
+			}
+			for _, c := range logOf(resolve(ref[:i])) {
+				if onRight[c.Id] {
+					writeJSON(w, http.StatusOK, c)
+					return
+				}
+			}
+			writeJSON(w, http.StatusNotFound, api.Error{Message: "no merge base"})
+			return
+		}
+		writeJSON(w, http.StatusOK, api.CommitList{Results: logOf(resolve(strings.TrimSuffix(ref, "/commits")))})
+	})
+	return mux
+}
+
+func TestBranchShowCompare(t *testing.T) {
+	out := runCmd(t, divergentHistoryHandler(), "branch", "show", "lakefs://repo/feature", "--compare", "lakefs://repo/main")
+	for _, expected := range []string{"c5", "more on feature", "jane", "2 commits ahead, 1 commits behind"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("output %q does not contain %q", out, expected)
+		}
+	}
+}
+
+func TestCommitsAheadBehind(t *testing.T) {
+	log := []api.Commit{{Id: "c3"}, {Id: "c2"}, {Id: "c1"}}
+	ahead, behind := commitsAheadBehind(log, log)
+	if ahead != 0 || behind != 0 {
+		t.Errorf("same log: ahead %d behind %d, expected 0 0", ahead, behind)
+	}
+	ahead, behind = commitsAheadBehind([]api.Commit{{Id: "c4"}, {Id: "c3"}, {Id: "c2"}, {Id: "c1"}}, log[1:])
+	if ahead != 2 || behind != 0 {
+		t.Errorf("fast forward: ahead %d behind %d, expected 2 0", ahead, behind)
+	}
+}
+
+func TestBranchMergeBase(t *testing.T) {
+	out := runCmd(t, divergentHistoryHandler(), "branch", "merge-base", "lakefs://repo/feature", "lakefs://repo/main")
+	if !strings.Contains(out, "Merge base: c2") || !strings.Contains(out, "second") {
+		t.Errorf("output %q does not show merge base c2", out)
+	}
+}
+
+// renameHandler serves a repository "repo" with default branch "main" and branches "feature"
+// and "taken", and records branches created and deleted.
+func renameHandler(t *testing.T, created *[]api.BranchCreation, deleted *[]string) http.Handler {
+	branches := map[string]string{"main": "c1", "feature": "c2", "taken": "c1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.Repository{Id: "repo", DefaultBranch: "main"})
+	})
+	mux.HandleFunc("/repositories/repo/branches", func(w http.ResponseWriter, r *http.Request) {
+		var creation api.BranchCreation
+		if err := json.NewDecoder(r.Body).Decode(&creation); err != nil {
+			t.Errorf("decode branch creation: %s", err)
+		}
+		*created = append(*created, creation)
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/repositories/repo/branches/feature/diff", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.DiffList{Results: []api.Diff{}})
+	})
+	mux.HandleFunc("/repositories/repo/branches/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/repositories/repo/branches/")
+		if r.Method == http.MethodDelete {
+			*deleted = append(*deleted, name)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		commitID, ok := branches[name]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, api.Error{Message: "branch not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, api.Ref{Id: name, CommitId: commitID})
+	})
+	mux.HandleFunc("/repositories/repo/commits/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.Commit{Id: strings.TrimPrefix(r.URL.Path, "/repositories/repo/commits/"), Message: "feature work", Parents: []string{}})
+	})
+	return mux
+}
+
+func TestBranchRename(t *testing.T) {
+	var created []api.BranchCreation
+	var deleted []string
+	out := runCmd(t, renameHandler(t, &created, &deleted), "branch", "rename", "lakefs://repo/feature", "renamed", "--yes")
+
+	if diffs := deep.Equal(created, []api.BranchCreation{{Name: "renamed", Source: "c2"}}); diffs != nil {
+		t.Errorf("unexpected branches created: %s", diffs)
+	}
+	if diffs := deep.Equal(deleted, []string{"feature"}); diffs != nil {
+		t.Errorf("unexpected branches deleted: %s", diffs)
+	}
+	if !strings.Contains(out, "Renamed branch 'feature' to 'renamed'") || !strings.Contains(out, "c2") {
+		t.Errorf("output %q does not show the renamed branch head", out)
+	}
+}
+
+func TestRenameBranchRejected(t *testing.T) {
+	var created []api.BranchCreation
+	var deleted []string
+	client := newTestClient(t, renameHandler(t, &created, &deleted))
+	ctx := context.Background()
+
+	if _, err := renameBranch(ctx, client, "repo", "feature", "taken"); !errors.Is(err, ErrBranchExists) {
+		t.Errorf("rename to an existing branch returned %v, expected %s", err, ErrBranchExists)
+	}
+	if _, err := renameBranch(ctx, client, "repo", "main", "trunk"); !errors.Is(err, ErrRenameDefaultBranch) {
+		t.Errorf("rename of the default branch returned %v, expected %s", err, ErrRenameDefaultBranch)
+	}
+	if _, err := renameBranch(ctx, client, "repo", "feature", "lakefs://other/renamed"); !errors.Is(err, ErrRenameAcrossRepository) {
+		t.Errorf("rename to another repository returned %v, expected %s", err, ErrRenameAcrossRepository)
+	}
+	if len(created) != 0 || len(deleted) != 0 {
+		t.Errorf("rejected renames created %v and deleted %v", created, deleted)
+	}
+}
+
+// fromTagHandler serves tag v1 pointing at commit c1, and records the branches created.
+func fromTagHandler(t *testing.T, created *[]api.BranchCreation) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/tags/", func(w http.ResponseWriter, r *http.Request) {
+		if tag := strings.TrimPrefix(r.URL.Path, "/repositories/repo/tags/"); tag != "v1" {
+			writeJSON(w, http.StatusNotFound, api.Error{Message: "tag not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, api.Ref{Id: "v1", CommitId: "c1"})
+	})
+	mux.HandleFunc("/repositories/repo/branches", func(w http.ResponseWriter, r *http.Request) {
+		var creation api.BranchCreation
+		if err := json.NewDecoder(r.Body).Decode(&creation); err != nil {
+			t.Errorf("decode branch creation: %s", err)
+		}
+		*created = append(*created, creation)
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(creation.Source))
+	})
+	return mux
+}
+
+func TestBranchFromTag(t *testing.T) {
+	var created []api.BranchCreation
+	out := runCmd(t, fromTagHandler(t, &created), "branch", "from-tag", "lakefs://repo/release-1", "lakefs://repo/v1")
+	if diffs := deep.Equal(created, []api.BranchCreation{{Name: "release-1", Source: "c1"}}); diffs != nil {
+		t.Errorf("unexpected branches created: %s", diffs)
+	}
+	if !strings.Contains(out, "created branch 'release-1' from tag 'v1' at c1") {
+		t.Errorf("output %q does not show the branch created at the tagged commit", out)
+	}
+}
+
+func TestCreateBranchFromTagRejected(t *testing.T) {
+	var created []api.BranchCreation
+	client := newTestClient(t, fromTagHandler(t, &created))
+	ctx := context.Background()
+	branchURI := &uri.URI{Repository: "repo", Ref: "release-1"}
+
+	if _, err := createBranchFromTag(ctx, client, branchURI, &uri.URI{Repository: "repo", Ref: "v2"}); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("branch from a missing tag returned %v, expected %s", err, ErrTagNotFound)
+	}
+	if _, err := createBranchFromTag(ctx, client, branchURI, &uri.URI{Repository: "other", Ref: "v1"}); !errors.Is(err, ErrTagInOtherRepository) {
+		t.Errorf("branch from a tag of another repository returned %v, expected %s", err, ErrTagInOtherRepository)
+	}
+	if len(created) != 0 {
+		t.Errorf("rejected branches created %v", created)
+	}
+}
+
+func TestBranchSync(t *testing.T) {
+	tests := []struct {
+		name          string
+		branch        string
+		from          string
+		fastForwarded bool
+		wantMerge     bool
+		wantOutput    string
+	}{
+		{
+			name:          "fast-forward",
+			branch:        "stale",
+			from:          "main",
+			fastForwarded: true,
+			wantMerge:     true,
+			wantOutput:    "Fast-forwarded branch 'stale' to 'main' at c3",
+		},
+		{
+			name:       "merge required",
+			branch:     "feature",
+			from:       "main",
+			wantMerge:  true,
+			wantOutput: "Merged 'main' into 'feature' to get c0ffee",
+		},
+		{
+			name:       "up to date",
+			branch:     "main",
+			from:       "stale",
+			wantOutput: "Branch 'main' is up to date with 'stale'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var merges []api.MergeIntoBranchJSONRequestBody
+			mux := http.NewServeMux()
+			mux.Handle("/", divergentHistoryHandler())
+			mux.HandleFunc("/repositories/repo/refs/"+tt.from+"/merge/"+tt.branch, func(w http.ResponseWriter, r *http.Request) {
+				var body api.MergeIntoBranchJSONRequestBody
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				merges = append(merges, body)
+				// the server decides whether to fast-forward
+				if tt.fastForwarded {
+					writeJSON(w, http.StatusOK, api.MergeResult{Reference: "c3", FastForwarded: &tt.fastForwarded})
+					return
+				}
+				writeJSON(w, http.StatusOK, api.MergeResult{Reference: "c0ffee"})
+			})
+
+			out := runCmd(t, mux, "branch", "sync", "lakefs://repo/"+tt.branch, "--from", "lakefs://repo/"+tt.from)
+			if tt.wantMerge != (len(merges) == 1) {
+				t.Fatalf("got merge requests %+v, expected merge %t", merges, tt.wantMerge)
+			}
+			if tt.wantMerge && !api.BoolValue(merges[0].FastForward) {
+				t.Error("merge request does not allow fast-forward")
+			}
+			if !strings.Contains(out, tt.wantOutput) {
+				t.Errorf("output %q does not contain %q", out, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestSyncBranchAcrossRepositories(t *testing.T) {
+	branchURI, err := uri.Parse("lakefs://repo/feature")
+	if err != nil {
+		t.Fatalf("parse branch uri: %s", err)
+	}
+	fromURI, err := uri.Parse("lakefs://other/main")
+	if err != nil {
+		t.Fatalf("parse from uri: %s", err)
+	}
+	_, err = syncBranch(context.Background(), newTestClient(t, divergentHistoryHandler()), branchURI, fromURI)
+	if !errors.Is(err, ErrSyncAcrossRepository) {
+		t.Errorf("syncBranch across repositories returned %v, expected %s", err, ErrSyncAcrossRepository)
+	}
+}
+
+func TestBranchCreateProtect(t *testing.T) {
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/branches", func(w http.ResponseWriter, r *http.Request) {
+		var creation api.BranchCreation
+		_ = json.NewDecoder(r.Body).Decode(&creation)
+		calls = append(calls, r.Method+" branch "+creation.Name)
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("c1"))
+	})
+	mux.HandleFunc("/repositories/repo/branch_protection", func(w http.ResponseWriter, r *http.Request) {
+		var rule api.BranchProtectionRule
+		_ = json.NewDecoder(r.Body).Decode(&rule)
+		calls = append(calls, r.Method+" protection "+rule.Pattern)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	out := runCmd(t, mux, "branch", "create", "lakefs://repo/release", "--source", "lakefs://repo/main", "--protect")
+	if diff := deep.Equal(calls, []string{"POST branch release", "POST protection release"}); diff != nil {
+		t.Errorf("unexpected API calls: %s", diff)
+	}
+	if !strings.Contains(out, "protected branch 'release'") {
+		t.Errorf("output %q does not report protecting the branch", out)
+	}
+
+	calls = nil
+	runCmd(t, mux, "branch", "create", "lakefs://repo/feature", "--source", "lakefs://repo/main")
+	if diff := deep.Equal(calls, []string{"POST branch feature"}); diff != nil {
+		t.Errorf("unexpected API calls without --protect: %s", diff)
+	}
+}
@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/mail"
+	"os"
+	"path"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -18,15 +23,39 @@ Parents: {{.Commit.Parents|join ", "}}
 
 `
 
+var commitAmendTemplate = `Commit {{.Previous}} of branch "{{.Branch.Ref}}" amended.
+
+ID: {{.Commit.Id|yellow}}
+Message: {{.Commit.Message}}
+Timestamp: {{.Commit.CreationDate|date}}
+Parents: {{.Commit.Parents|join ", "}}
+
+`
+
+// metaEnvPrefix prefixes environment variables read into commit metadata by --meta-from-env.
+const metaEnvPrefix = "LAKECTL_META_"
+
 var errInvalidKeyValueFormat = fmt.Errorf("invalid key/value pair - should be separated by \"=\"")
 
+var (
+	ErrInvalidParent = errors.New("invalid parent")
+	ErrInvalidEmail  = errors.New("invalid email address")
+)
+
+// Commit metadata keys recording the author given by --author and --author-email, who may
+// differ from the committer, the user making the commit.
+const (
+	authorMetadataKey      = "author"
+	authorEmailMetadataKey = "author_email"
+)
+
 var commitCmd = &cobra.Command{
 	Use:   "commit <branch uri>",
 	Short: "commit changes on a given branch",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		// validate message
-		kvPairs, err := getKV(cmd, "meta")
+		kvPairs, err := getCommitMetadata(cmd)
 		if err != nil {
 			DieErr(err)
 		}
@@ -37,11 +66,15 @@ var commitCmd = &cobra.Command{
 		branchURI := MustParseRefURI("branch", args[0])
 		Fmt("Branch: %s\n", branchURI.String())
 
+		client := getClient()
+		if err := setCommitAuthor(cmd.Context(), cmd, client, kvPairs); err != nil {
+			DieErr(err)
+		}
+
 		// do commit
 		metadata := api.CommitCreation_Metadata{
 			AdditionalProperties: kvPairs,
 		}
-		client := getClient()
 		resp, err := client.CommitWithResponse(cmd.Context(), branchURI.Repository, branchURI.Ref, api.CommitJSONRequestBody{
 			Message:  message,
 			Metadata: &metadata,
@@ -56,6 +89,135 @@ var commitCmd = &cobra.Command{
 	},
 }
 
+var commitAmendCmd = &cobra.Command{
+	Use:   "amend <branch uri>",
+	Short: "replace the last commit of a branch with one with a new message or metadata",
+	Long: `Replace the last commit of a branch with a commit of the same content and parents, but with
+the message given by --message and metadata updated by --meta.  Commits cannot change, so this
+creates a commit with a different ID and moves the branch to it.  Amending a protected branch
+requires confirmation.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		kvPairs, err := getCommitMetadata(cmd)
+		if err != nil {
+			DieErr(err)
+		}
+		message := MustString(cmd.Flags().GetString("message"))
+		branchURI := MustParseRefURI("branch", args[0])
+		client := getClient()
+		if err := setCommitAuthor(cmd.Context(), cmd, client, kvPairs); err != nil {
+			DieErr(err)
+		}
+
+		protected, err := isBranchProtected(cmd.Context(), client, branchURI)
+		if err != nil {
+			DieErr(err)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: amending changes the ID of the last commit of branch %s\n", branchURI.Ref)
+		if protected {
+			confirmation, err := Confirm(cmd.Flags(), fmt.Sprintf("Branch %s is protected, are you sure you want to amend its last commit", branchURI.Ref))
+			if err != nil || !confirmation {
+				Die("Amend commit aborted", 1)
+			}
+		}
+
+		previous, commit, err := amendCommit(cmd.Context(), client, branchURI, message, kvPairs, protected)
+		if err != nil {
+			DieErr(err)
+		}
+		Write(commitAmendTemplate, struct {
+			Branch   *uri.URI
+			Previous string
+			Commit   *api.Commit
+		}{Branch: branchURI, Previous: previous, Commit: commit})
+	},
+}
+
+// isBranchProtected returns true if a branch protection rule of the repository matches the
+// branch of branchURI.
+func isBranchProtected(ctx context.Context, client api.ClientWithResponsesInterface, branchURI *uri.URI) (bool, error) {
+	resp, err := client.GetBranchProtectionRulesWithResponse(ctx, branchURI.Repository)
+	if err := responseError(resp, err); err != nil {
+		return false, err
+	}
+	for _, rule := range *resp.JSON200 {
+		if matched, _ := path.Match(rule.Pattern, branchURI.Ref); matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// amendCommit amends the last commit of branchURI, replacing its message if message is set and
+// adding metadata to its metadata.  It returns the ID of the replaced commit and the amended
+// commit.  The server refuses to amend if the branch moved past the commit read here, or if the
+// branch is protected and force is not set.
+func amendCommit(ctx context.Context, client api.ClientWithResponsesInterface, branchURI *uri.URI, message string, metadata map[string]string, force bool) (string, *api.Commit, error) {
+	branchResp, err := client.GetBranchWithResponse(ctx, branchURI.Repository, branchURI.Ref)
+	if err := responseError(branchResp, err); err != nil {
+		return "", nil, err
+	}
+	resp, err := client.GetCommitWithResponse(ctx, branchURI.Repository, branchResp.JSON200.CommitId)
+	if err := responseError(resp, err); err != nil {
+		return "", nil, err
+	}
+	head := resp.JSON200
+	if message == "" {
+		message = head.Message
+	}
+	amendedMetadata := make(map[string]string)
+	if head.Metadata != nil {
+		for k, v := range head.Metadata.AdditionalProperties {
+			amendedMetadata[k] = v
+		}
+	}
+	for k, v := range metadata {
+		amendedMetadata[k] = v
+	}
+	amendResp, err := client.AmendCommitWithResponse(ctx, branchURI.Repository, branchURI.Ref, api.AmendCommitJSONRequestBody{
+		Message:      message,
+		Metadata:     &api.CommitAmendment_Metadata{AdditionalProperties: amendedMetadata},
+		HeadCommitId: &head.Id,
+		Force:        &force,
+	})
+	if err := responseError(amendResp, err); err != nil {
+		return "", nil, err
+	}
+	return head.Id, amendResp.JSON201, nil
+}
+
+var commitDiffCmd = &cobra.Command{
+	Use:   "diff <commit uri>",
+	Short: "show the changes introduced by a commit",
+	Long:  "diff a commit against its parent, or with --parent against any parent of a merge commit",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		parent := MustInt(cmd.Flags().GetInt("parent"))
+		amount := MustInt(cmd.Flags().GetInt("amount"))
+		commitURI := MustParseRefURI("commit", args[0])
+		client := getClient()
+		commit, parentID, err := commitParent(cmd.Context(), client, commitURI.Repository, commitURI.Ref, parent)
+		if err != nil {
+			DieErr(err)
+		}
+		Fmt("Commit: %s\nParent: %s\n", commit.Id, parentID)
+		printDiffRefs(cmd.Context(), client, commitURI.Repository, parentID, commit.Id, &diffPrinter{amount: amount})
+	},
+}
+
+// commitParent returns the commit at ref and the ID of its parent numbered parent, counting from 1.
+func commitParent(ctx context.Context, client api.ClientWithResponsesInterface, repository, ref string, parent int) (*api.Commit, string, error) {
+	resp, err := client.GetCommitWithResponse(ctx, repository, ref)
+	if err := responseError(resp, err); err != nil {
+		return nil, "", err
+	}
+	commit := resp.JSON200
+	if parent < 1 || parent > len(commit.Parents) {
+		return nil, "", fmt.Errorf("%w: commit %s has %d parents, cannot use parent %d", ErrInvalidParent, commit.Id, len(commit.Parents), parent)
+	}
+	return commit, commit.Parents[parent-1], nil
+}
+
 func getKV(cmd *cobra.Command, name string) (map[string]string, error) {
 	kvList, err := cmd.Flags().GetStringSlice(name)
 	if err != nil {
@@ -73,6 +235,74 @@ func getKV(cmd *cobra.Command, name string) (map[string]string, error) {
 	return kv, nil
 }
 
+// getCommitMetadata returns the commit metadata given by --meta flags, and with
+// --meta-from-env also by LAKECTL_META_<key> environment variables.  Keys given by --meta take
+// precedence over the same keys in the environment.
+func getCommitMetadata(cmd *cobra.Command) (map[string]string, error) {
+	kv, err := getKV(cmd, "meta")
+	if err != nil {
+		return nil, err
+	}
+	fromEnv, err := cmd.Flags().GetBool("meta-from-env")
+	if err != nil || !fromEnv {
+		return kv, err
+	}
+	const keyValueParts = 2
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, metaEnvPrefix) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(env, metaEnvPrefix), "=", keyValueParts)
+		if len(parts) != keyValueParts || parts[0] == "" {
+			continue
+		}
+		if _, ok := kv[parts[0]]; !ok {
+			kv[parts[0]] = parts[1]
+		}
+	}
+	return kv, nil
+}
+
+// setCommitAuthor records the author given by --author and --author-email in metadata,
+// overriding the same keys given by --meta.  With only --author-email the author is the
+// authenticated user.
+func setCommitAuthor(ctx context.Context, cmd *cobra.Command, client api.ClientWithResponsesInterface, metadata map[string]string) error {
+	author, err := cmd.Flags().GetString("author")
+	if err != nil {
+		return err
+	}
+	email, err := cmd.Flags().GetString("author-email")
+	if err != nil {
+		return err
+	}
+	if author == "" && email == "" {
+		return nil
+	}
+	if email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return fmt.Errorf("%w: %s", ErrInvalidEmail, email)
+		}
+		metadata[authorEmailMetadataKey] = email
+	}
+	if author == "" {
+		resp, err := client.GetCurrentUserWithResponse(ctx)
+		if err := responseError(resp, err); err != nil {
+			return err
+		}
+		author = resp.JSON200.User.Id
+	}
+	metadata[authorMetadataKey] = author
+	return nil
+}
+
+// assignCommitMetadataFlags defines the flags read by getCommitMetadata and setCommitAuthor.
+func assignCommitMetadataFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("meta", []string{}, "key value pair in the form of key=value")
+	cmd.Flags().Bool("meta-from-env", false, "add metadata from "+metaEnvPrefix+"<key> environment variables (--meta takes precedence)")
+	cmd.Flags().String("author", "", "author to record in the commit metadata, if not the committer (default is the authenticated user when --author-email is set)")
+	cmd.Flags().String("author-email", "", "email address of the author to record in the commit metadata")
+}
+
 //nolint:gochecknoinits
 func init() {
 	rootCmd.AddCommand(commitCmd)
@@ -80,5 +310,14 @@ func init() {
 	commitCmd.Flags().StringP("message", "m", "", "commit message")
 	_ = commitCmd.MarkFlagRequired("message")
 
-	commitCmd.Flags().StringSlice("meta", []string{}, "key value pair in the form of key=value")
+	assignCommitMetadataFlags(commitCmd)
+
+	commitCmd.AddCommand(commitAmendCmd)
+	commitAmendCmd.Flags().StringP("message", "m", "", "new commit message (default is the message of the amended commit)")
+	assignCommitMetadataFlags(commitAmendCmd)
+	AssignAutoConfirmFlag(commitAmendCmd.Flags())
+
+	commitCmd.AddCommand(commitDiffCmd)
+	commitDiffCmd.Flags().Int("parent", 1, "number of the parent to diff against, for merge commits")
+	commitDiffCmd.Flags().Int("amount", defaultDiffAmount, "maximal number of changes to show, or 0 for all changes")
 }
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
+)
+
+func TestCommitMetaFromEnv(t *testing.T) {
+	setEnv(t, "LAKECTL_META_git_commit", "c0ffee")
+	setEnv(t, "LAKECTL_META_build", "from-env")
+
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{
+			name: "meta only",
+			args: []string{"--meta", "build=42"},
+			want: map[string]string{"build": "42"},
+		},
+		{
+			name: "meta from env",
+			args: []string{"--meta-from-env"},
+			want: map[string]string{"git_commit": "c0ffee", "build": "from-env"},
+		},
+		{
+			name: "meta overrides env",
+			args: []string{"--meta-from-env", "--meta", "build=42"},
+			want: map[string]string{"git_commit": "c0ffee", "build": "42"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body api.CommitJSONRequestBody
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repositories/repo/branches/main/commits", func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				writeJSON(w, http.StatusCreated, api.Commit{Id: "c1", Message: body.Message, Parents: []string{}})
+			})
+
+			runCmd(t, mux, append([]string{"commit", "lakefs://repo/main", "-m", "ci build"}, tt.args...)...)
+			if body.Metadata == nil {
+				t.Fatal("commit request has no metadata")
+			}
+			if diff := deep.Equal(body.Metadata.AdditionalProperties, tt.want); diff != nil {
+				t.Errorf("commit request metadata: %s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeMetaFromEnv(t *testing.T) {
+	setEnv(t, "LAKECTL_META_git_commit", "c0ffee")
+	var body api.MergeIntoBranchJSONRequestBody
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/feature/merge/main", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, api.MergeResult{Reference: "c2"})
+	})
+
+	runCmd(t, mux, "merge", "lakefs://repo/feature", "lakefs://repo/main", "--meta-from-env")
+	if body.Metadata == nil || body.Metadata.AdditionalProperties["git_commit"] != "c0ffee" {
+		t.Errorf("merge request metadata = %+v, expected git_commit from environment", body.Metadata)
+	}
+}
+
+func commitDiffHandler(diffed *[]string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/commits/merge1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.Commit{Id: "merge1", Parents: []string{"main1", "feature1"}})
+	})
+	for _, parent := range []string{"main1", "feature1"} {
+		parent := parent
+		mux.HandleFunc("/repositories/repo/refs/"+parent+"/diff/merge1", func(w http.ResponseWriter, r *http.Request) {
+			*diffed = append(*diffed, parent+".."+"merge1")
+			writeJSON(w, http.StatusOK, api.DiffList{
+				Results: []api.Diff{{Path: "from-" + parent, PathType: "object", Type: "added"}},
+			})
+		})
+	}
+	return mux
+}
+
+func TestCommitDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantDiffed string
+	}{
+		{name: "first parent", wantDiffed: "main1..merge1"},
+		{name: "second parent", args: []string{"--parent", "2"}, wantDiffed: "feature1..merge1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diffed []string
+			out := runCmd(t, commitDiffHandler(&diffed), append([]string{"commit", "diff", "lakefs://repo/merge1"}, tt.args...)...)
+			if diff := deep.Equal(diffed, []string{tt.wantDiffed}); diff != nil {
+				t.Errorf("unexpected diffed refs: %s", diff)
+			}
+			parent := strings.Split(tt.wantDiffed, "..")[0]
+			if !strings.Contains(out, "from-"+parent) {
+				t.Errorf("output %q does not contain the diff against %s", out, parent)
+			}
+		})
+	}
+}
+
+func TestCommitParentInvalid(t *testing.T) {
+	var diffed []string
+	client := newTestClient(t, commitDiffHandler(&diffed))
+	for _, parent := range []int{0, 3} {
+		if _, _, err := commitParent(context.Background(), client, "repo", "merge1", parent); !errors.Is(err, ErrInvalidParent) {
+			t.Errorf("commitParent(%d) returned %v, expected %s", parent, err, ErrInvalidParent)
+		}
+	}
+}
+
+func TestCommitAuthor(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{
+			name: "commit",
+			args: []string{"commit", "lakefs://repo/main", "-m", "on behalf", "--author", "alice", "--author-email", "alice@example.com"},
+			want: map[string]string{"author": "alice", "author_email": "alice@example.com"},
+		},
+		{
+			name: "commit overrides meta",
+			args: []string{"commit", "lakefs://repo/main", "-m", "on behalf", "--meta", "author=bob", "--author", "alice"},
+			want: map[string]string{"author": "alice"},
+		},
+		{
+			name: "merge defaults to authenticated user",
+			args: []string{"merge", "lakefs://repo/feature", "lakefs://repo/main", "--author-email", "svc@example.com"},
+			want: map[string]string{"author": "svc-account", "author_email": "svc@example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var metadata map[string]string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, api.CurrentUser{User: api.User{Id: "svc-account"}})
+			})
+			mux.HandleFunc("/repositories/repo/branches/main/commits", func(w http.ResponseWriter, r *http.Request) {
+				var body api.CommitJSONRequestBody
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				metadata = body.Metadata.AdditionalProperties
+				writeJSON(w, http.StatusCreated, api.Commit{Id: "c1", Message: body.Message, Parents: []string{}})
+			})
+			mux.HandleFunc("/repositories/repo/refs/feature/merge/main", func(w http.ResponseWriter, r *http.Request) {
+				var body api.MergeIntoBranchJSONRequestBody
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				metadata = body.Metadata.AdditionalProperties
+				writeJSON(w, http.StatusOK, api.MergeResult{Reference: "c2"})
+			})
+
+			runCmd(t, mux, tt.args...)
+			if diff := deep.Equal(metadata, tt.want); diff != nil {
+				t.Errorf("request metadata: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCommitAuthorInvalidEmail(t *testing.T) {
+	for _, email := range []string{"alice", "Alice <alice@example.com>", "alice@"} {
+		resetFlags(commitCmd)
+		if err := commitCmd.Flags().Parse([]string{"--author-email", email}); err != nil {
+			t.Fatalf("parse flags: %s", err)
+		}
+		err := setCommitAuthor(context.Background(), commitCmd, nil, map[string]string{})
+		if !errors.Is(err, ErrInvalidEmail) {
+			t.Errorf("setCommitAuthor with email %q returned %v, expected %s", email, err, ErrInvalidEmail)
+		}
+	}
+	resetFlags(commitCmd)
+}
+
+// amendHandler serves a repository with protected branch main, whose branches main and feature
+// are at commit c1, recording the bodies of amend requests in amended.
+func amendHandler(amended map[string]api.AmendCommitJSONRequestBody) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/branch_protection", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []api.BranchProtectionRule{{Pattern: "ma*"}})
+	})
+	mux.HandleFunc("/repositories/repo/commits/c1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.Commit{
+			Id:       "c1",
+			Message:  "tpyo",
+			Metadata: &api.Commit_Metadata{AdditionalProperties: map[string]string{"build": "41"}},
+			Parents:  []string{"c0"},
+		})
+	})
+	for _, branch := range []string{"main", "feature"} {
+		branch := branch
+		mux.HandleFunc("/repositories/repo/branches/"+branch, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, api.Ref{Id: branch, CommitId: "c1"})
+		})
+		mux.HandleFunc("/repositories/repo/branches/"+branch+"/commits/amend", func(w http.ResponseWriter, r *http.Request) {
+			var body api.AmendCommitJSONRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			amended[branch] = body
+			writeJSON(w, http.StatusCreated, api.Commit{Id: "c2", Message: body.Message, Parents: []string{"c0"}})
+		})
+	}
+	return mux
+}
+
+func TestCommitAmend(t *testing.T) {
+	amended := make(map[string]api.AmendCommitJSONRequestBody)
+	handler := amendHandler(amended)
+	out := runCmd(t, handler, "commit", "amend", "lakefs://repo/main", "-m", "typo", "--meta", "build=42", "--yes")
+	runCmd(t, handler, "commit", "amend", "lakefs://repo/feature", "-m", "typo")
+	expected := map[string]api.AmendCommitJSONRequestBody{
+		"main": {
+			Message:      "typo",
+			Metadata:     &api.CommitAmendment_Metadata{AdditionalProperties: map[string]string{"build": "42"}},
+			HeadCommitId: api.StringPtr("c1"),
+			Force:        swag.Bool(true),
+		},
+		"feature": {
+			Message:      "typo",
+			Metadata:     &api.CommitAmendment_Metadata{AdditionalProperties: map[string]string{"build": "41"}},
+			HeadCommitId: api.StringPtr("c1"),
+			Force:        swag.Bool(false),
+		},
+	}
+	if diff := deep.Equal(amended, expected); diff != nil {
+		t.Errorf("amend requests: %s", diff)
+	}
+	if !strings.Contains(out, "Commit c1 of branch \"main\" amended") || !strings.Contains(out, "ID: c2") {
+		t.Errorf("output %q does not show commit c1 amended to c2", out)
+	}
+}
+
+func TestAmendCommitKeepsMessage(t *testing.T) {
+	amended := make(map[string]api.AmendCommitJSONRequestBody)
+	client := newTestClient(t, amendHandler(amended))
+	branchURI := &uri.URI{Repository: "repo", Ref: "feature"}
+	previous, commit, err := amendCommit(context.Background(), client, branchURI, "", map[string]string{"author": "alice"}, false)
+	if err != nil {
+		t.Fatalf("amendCommit: %s", err)
+	}
+	if previous != "c1" || commit.Id != "c2" {
+		t.Errorf("amended commit %s to %s, expected c1 to c2", previous, commit.Id)
+	}
+	expected := api.AmendCommitJSONRequestBody{
+		Message:      "tpyo",
+		Metadata:     &api.CommitAmendment_Metadata{AdditionalProperties: map[string]string{"build": "41", "author": "alice"}},
+		HeadCommitId: api.StringPtr("c1"),
+		Force:        swag.Bool(false),
+	}
+	if diff := deep.Equal(amended["feature"], expected); diff != nil {
+		t.Errorf("amend request: %s", diff)
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/jedib0t/go-pretty/table"
 	"github.com/jedib0t/go-pretty/text"
+	"github.com/spf13/pflag"
 	"github.com/treeverse/lakefs/pkg/api"
 	"github.com/treeverse/lakefs/pkg/api/helpers"
 	"golang.org/x/term"
@@ -28,6 +29,15 @@ const (
 	DeathMessage              = "Error executing command: {{.Error|red}}\n"
 )
 
+const (
+	OutputFlagName   = "output"
+	OutputFormatText = "text"
+	OutputFormatJSON = "json"
+	// OutputFormatJSONL is newline-delimited JSON, a JSON object per line, for output
+	// streamed as it arrives.
+	OutputFormatJSONL = "jsonl"
+)
+
 const internalPageSize = 1000          // when retreiving all records, use this page size under the hood
 const defaultAmountArgumentValue = 100 // when no amount is specified, use this value for the argument
 
@@ -94,19 +104,8 @@ func WriteTo(tpl string, data interface{}, w io.Writer) {
 			}
 			return ""
 		},
-		"lower": strings.ToLower,
-		"human_bytes": func(b int64) string {
-			var unit int64 = 1000
-			if b < unit {
-				return fmt.Sprintf("%d B", b)
-			}
-			div, exp := unit, 0
-			for n := b / unit; n >= unit; n /= unit {
-				div *= unit
-				exp++
-			}
-			return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "kMGTPE"[exp])
-		},
+		"lower":       strings.ToLower,
+		"human_bytes": humanBytes,
 		"join": func(sep string, args []string) string {
 			return strings.Join(args, sep)
 		},
@@ -146,6 +145,24 @@ func Write(tpl string, data interface{}) {
 	WriteTo(tpl, data, os.Stdout)
 }
 
+// PrintJSON writes v to stdout as indented JSON.
+func PrintJSON(v interface{}) {
+	Write("{{ . | json }}\n", v)
+}
+
+func AssignOutputFlag(flags *pflag.FlagSet) {
+	flags.StringP(OutputFlagName, "o", OutputFormatText, fmt.Sprintf("output format, one of %q or %q", OutputFormatText, OutputFormatJSON))
+}
+
+// MustOutputFormat returns the output format requested on flags, dying on an unknown format.
+func MustOutputFormat(flags *pflag.FlagSet) string {
+	format := MustString(flags.GetString(OutputFlagName))
+	if format != OutputFormatText && format != OutputFormatJSON {
+		DieFmt("Invalid output format '%s', use one of %q or %q", format, OutputFormatText, OutputFormatJSON)
+	}
+	return format
+}
+
 func Die(err string, code int) {
 	WriteTo(DeathMessage, struct{ Error string }{err}, os.Stderr)
 	os.Exit(code)
@@ -190,6 +207,19 @@ func Fmt(msg string, args ...interface{}) {
 	fmt.Printf(msg, args...)
 }
 
+func humanBytes(b int64) string {
+	var unit int64 = 1000
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := unit, 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "kMGTPE"[exp])
+}
+
 func PrintTable(rows [][]interface{}, headers []interface{}, paginator *api.Pagination, amount int) {
 	ctx := struct {
 		Table      *Table
@@ -3,13 +3,16 @@ package cmd
 import (
 	"fmt"
 	"net/url"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/manifoldco/promptui"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/treeverse/lakefs/cmd/lakectl/cmd/config"
+	"github.com/treeverse/lakefs/pkg/api"
 )
 
 // configCmd represents the config command
@@ -59,7 +62,90 @@ var configCmd = &cobra.Command{
 	},
 }
 
+const redactedConfigValue = "********"
+
+// configValue is the effective value of a configuration key, and where it was set.
+type configValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show the effective configuration, and where each value is set",
+	Long:  "show the effective configuration, merged from LAKECTL_* environment variables, the config file and defaults (in that order of precedence).  Secrets are redacted.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := MustOutputFormat(cmd.Flags())
+		configFile := viper.ConfigFileUsed()
+		values, err := effectiveConfig(configFile)
+		if err != nil {
+			DieErr(err)
+		}
+		if format == OutputFormatJSON {
+			PrintJSON(struct {
+				ConfigFile string        `json:"config_file"`
+				Values     []configValue `json:"values"`
+			}{ConfigFile: configFile, Values: values})
+			return
+		}
+		if configFile == "" {
+			configFile = "none"
+		}
+		Fmt("Config file: %s\n", configFile)
+		rows := make([][]interface{}, len(values))
+		for i, v := range values {
+			rows[i] = []interface{}{v.Key, v.Value, v.Source}
+		}
+		PrintTable(rows, []interface{}{"Key", "Value", "Source"}, &api.Pagination{}, len(rows))
+	},
+}
+
+// effectiveConfig returns the effective value of every configuration key, with secrets
+// redacted, and whether it was set by an environment variable, configFile or a default.
+func effectiveConfig(configFile string) ([]configValue, error) {
+	fileConfig := viper.New()
+	if configFile != "" {
+		fileConfig.SetConfigFile(configFile)
+		if err := fileConfig.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("read config file %s: %w", configFile, err)
+		}
+	}
+	keys := config.Keys()
+	values := make([]configValue, 0, len(keys))
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		v := configValue{Key: key}
+		if value := viper.Get(key); value != nil {
+			v.Value = fmt.Sprint(value)
+		}
+		envVar := "LAKECTL_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		switch _, inEnv := os.LookupEnv(envVar); {
+		case inEnv:
+			v.Source = "env " + envVar
+		case fileConfig.IsSet(key):
+			v.Source = "config file"
+		case v.Value != "":
+			v.Source = "default"
+		default:
+			v.Source = "unset"
+		}
+		if v.Value != "" && isSecretConfigKey(key) {
+			v.Value = redactedConfigValue
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func isSecretConfigKey(key string) bool {
+	return strings.Contains(key, "secret") || strings.Contains(key, "token")
+}
+
 //nolint:gochecknoinits
 func init() {
 	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	AssignOutputFlag(configShowCmd.Flags())
 }
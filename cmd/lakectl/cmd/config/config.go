@@ -52,6 +52,11 @@ type Config struct {
 	err    error
 }
 
+// Keys returns the keys of all configuration values.
+func Keys() []string {
+	return config.GetStructKeys(reflect.TypeOf(configuration{}), "mapstructure", "squash")
+}
+
 // ReadConfig loads according to the current viper configuration into a Config, which will
 // have non-nil Err() if loading fails.
 func ReadConfig() (c *Config) {
@@ -59,8 +64,7 @@ func ReadConfig() (c *Config) {
 
 	// Inform viper of all expected fields.  Otherwise it fails to deserialize from the
 	// environment.
-	keys := config.GetStructKeys(reflect.TypeOf(c.Values), "mapstructure", "squash")
-	for _, key := range keys {
+	for _, key := range Keys() {
 		viper.SetDefault(key, nil)
 	}
 
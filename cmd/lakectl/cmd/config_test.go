@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+func TestConfigShow(t *testing.T) {
+	home := t.TempDir()
+	setEnv(t, "HOME", home)
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+	// viper keeps the config file it found, which is removed along with home
+	t.Cleanup(viper.Reset)
+	configFile := filepath.Join(home, ".lakectl.yaml")
+	const contents = `server:
+  endpoint_url: http://file.example.com
+metastore:
+  hive:
+    uri: thrift://hive.example.com:9083
+`
+	if err := ioutil.WriteFile(configFile, []byte(contents), configFilePerm); err != nil {
+		t.Fatalf("write config file: %s", err)
+	}
+
+	out := runCmd(t, http.NotFoundHandler(), "config", "show", "--output", "json")
+	var shown struct {
+		ConfigFile string        `json:"config_file"`
+		Values     []configValue `json:"values"`
+	}
+	if err := json.Unmarshal([]byte(out), &shown); err != nil {
+		t.Fatalf("parse JSON output %q: %s", out, err)
+	}
+	if shown.ConfigFile != configFile {
+		t.Errorf("showed config file %q, expected %q", shown.ConfigFile, configFile)
+	}
+	values := make(map[string]configValue)
+	for _, v := range shown.Values {
+		values[v.Key] = v
+	}
+
+	tests := []struct {
+		key        string
+		wantValue  string
+		wantSource string
+	}{
+		// the environment takes precedence over the config file
+		{key: "server.endpoint_url", wantSource: "env LAKECTL_SERVER_ENDPOINT_URL"},
+		{key: "metastore.hive.uri", wantValue: "thrift://hive.example.com:9083", wantSource: "config file"},
+		{key: "metastore.hive.db_location_uri", wantValue: "file:/user/hive/warehouse/", wantSource: "default"},
+		{key: "metastore.glue.region", wantValue: "", wantSource: "unset"},
+		{key: "credentials.secret_access_key", wantValue: redactedConfigValue, wantSource: "env LAKECTL_CREDENTIALS_SECRET_ACCESS_KEY"},
+	}
+	for _, tt := range tests {
+		v, ok := values[tt.key]
+		if !ok {
+			t.Errorf("key %s not shown", tt.key)
+			continue
+		}
+		if tt.wantValue != "" && v.Value != tt.wantValue {
+			t.Errorf("key %s shown with value %q, expected %q", tt.key, v.Value, tt.wantValue)
+		}
+		if v.Source != tt.wantSource {
+			t.Errorf("key %s shown from %q, expected %q", tt.key, v.Source, tt.wantSource)
+		}
+	}
+	if endpoint := values["server.endpoint_url"].Value; !strings.HasPrefix(endpoint, "http://127.0.0.1:") {
+		t.Errorf("endpoint shown as %q, expected the test server from the environment", endpoint)
+	}
+	if strings.Contains(out, "EXAMPLEKEY") {
+		t.Errorf("output %q shows the secret access key", out)
+	}
+}
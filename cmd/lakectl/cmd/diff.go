@@ -2,11 +2,18 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/jedib0t/go-pretty/text"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
 )
 
 const (
@@ -15,32 +22,161 @@ const (
 
 	minDiffPageSize = 50
 	maxDiffPageSize = 100000
+
+	defaultDiffAmount = 1000
+
+	// diffChangesExitCode is the exit code of diff --exit-code when there are changes,
+	// distinct from the exit code of errors.
+	diffChangesExitCode = 2
 )
 
+var ErrConflictingDiffFilters = errors.New("conflicting diff filters")
+
+// diffExit exits lakectl with the exit code of diff; tests replace it.
+var diffExit = os.Exit
+
+// diffTypeFilterFlags maps each diff filter flag to the diff type it selects.
+var diffTypeFilterFlags = []struct {
+	flag     string
+	diffType string
+}{
+	{flag: "added-only", diffType: "added"},
+	{flag: "removed-only", diffType: "removed"},
+	{flag: "changed-only", diffType: "changed"},
+}
+
 var diffCmd = &cobra.Command{
 	Use:   "diff <ref uri> [other ref uri]",
 	Short: "diff between commits/hashes",
 	Long:  "see the list of paths added/changed/removed in a branch or between two references (could be either commit hash or branch name)",
 	Args:  cobra.RangeArgs(diffCmdMinArgs, diffCmdMaxArgs),
 	Run: func(cmd *cobra.Command, args []string) {
+		typeFilter, err := diffTypeFilter(cmd.Flags())
+		if err != nil {
+			DieErr(err)
+		}
+		amount := MustInt(cmd.Flags().GetInt("amount"))
+		withChecksums, _ := cmd.Flags().GetBool("checksums")
+		groupByPrefix := MustBool(cmd.Flags().GetBool("group-by-prefix"))
+		depth := MustInt(cmd.Flags().GetInt("depth"))
+		if depth < 1 {
+			DieFmt("Invalid depth %d, must be at least 1", depth)
+		}
+		findRenames := MustBool(cmd.Flags().GetBool("find-renames"))
+		renameSimilarity, _ := cmd.Flags().GetFloat64("rename-similarity")
+		if renameSimilarity < 0 || renameSimilarity > 1 {
+			DieFmt("Invalid rename similarity %g, must be between 0 and 1", renameSimilarity)
+		}
+		if findRenames && typeFilter != "" {
+			DieErr(fmt.Errorf("%w: --find-renames and --%s-only", ErrConflictingDiffFilters, typeFilter))
+		}
+		nameOnly := MustBool(cmd.Flags().GetBool("name-only"))
+		output := MustString(cmd.Flags().GetString(OutputFlagName))
+		if output != OutputFormatText && output != OutputFormatJSONL {
+			DieFmt("Invalid output format '%s', use one of %q or %q", output, OutputFormatText, OutputFormatJSONL)
+		}
+		jsonl := output == OutputFormatJSONL
+		if jsonl && nameOnly {
+			DieErr(fmt.Errorf("%w: --name-only and --output %s", ErrConflictingDiffFilters, OutputFormatJSONL))
+		}
+		// only changes are printed, for scripts
+		plain := nameOnly || jsonl
+		plainFlag := "--name-only"
+		if jsonl {
+			plainFlag = "--output " + OutputFormatJSONL
+		}
+		for _, flag := range []string{"checksums", "group-by-prefix", "find-renames"} {
+			if set, _ := cmd.Flags().GetBool(flag); plain && set {
+				DieErr(fmt.Errorf("%w: %s and --%s", ErrConflictingDiffFilters, plainFlag, flag))
+			}
+		}
+		showBase := MustBool(cmd.Flags().GetBool("show-base"))
+		if showBase && len(args) != diffCmdMaxArgs {
+			DieFmt("--show-base requires two refs")
+		}
+		if showBase && plain {
+			DieErr(fmt.Errorf("%w: %s and --show-base", ErrConflictingDiffFilters, plainFlag))
+		}
+		exitCode := MustBool(cmd.Flags().GetBool("exit-code"))
 		client := getClient()
-		if len(args) == diffCmdMaxArgs {
+		printer := &diffPrinter{typeFilter: typeFilter, amount: amount, nameOnly: nameOnly}
+		if jsonl {
+			printer.encoder = json.NewEncoder(os.Stdout)
+		}
+		if withChecksums {
+			printer.checksums = newDiffChecksums(client, args)
+		}
+		if showBase {
+			printDiffBase(cmd.Context(), client, args)
+		}
+		var changes int
+		switch {
+		case groupByPrefix:
+			changes = printDiffGroups(cmd.Context(), client, args, typeFilter, depth)
+		case findRenames:
+			changes = printDiffRenames(cmd.Context(), client, args, printer, renameSimilarity)
+		case len(args) == diffCmdMaxArgs:
 			leftRefURI := MustParseRefURI("left ref", args[0])
 			rightRefURI := MustParseRefURI("right ref", args[1])
-			Fmt("Left ref: %s\nRight ref: %s\n", leftRefURI.String(), rightRefURI.String())
+			if !plain {
+				Fmt("Left ref: %s\nRight ref: %s\n", leftRefURI.String(), rightRefURI.String())
+			}
 
 			if leftRefURI.Repository != rightRefURI.Repository {
 				Die("both references must belong to the same repository", 1)
 			}
-			printDiffRefs(cmd.Context(), client, leftRefURI.Repository, leftRefURI.Ref, rightRefURI.Ref)
-		} else {
+			changes = printDiffRefs(cmd.Context(), client, leftRefURI.Repository, leftRefURI.Ref, rightRefURI.Ref, printer)
+		default:
 			branchURI := MustParseRefURI("ref", args[0])
-			Fmt("Ref: %s\n", branchURI.String())
-			printDiffBranch(cmd.Context(), client, branchURI.Repository, branchURI.Ref)
+			if !plain {
+				Fmt("Ref: %s\n", branchURI.String())
+			}
+			changes = printDiffBranch(cmd.Context(), client, branchURI.Repository, branchURI.Ref, printer)
+		}
+		if code := diffExitCode(exitCode, changes); code != 0 {
+			diffExit(code)
 		}
 	},
 }
 
+// printDiffBase prints the merge base of the two refs in args, the baseline against which the
+// diff of their changes is computed.
+func printDiffBase(ctx context.Context, client api.ClientWithResponsesInterface, args []string) {
+	leftRefURI := MustParseRefURI("left ref", args[0])
+	rightRefURI := MustParseRefURI("right ref", args[1])
+	if leftRefURI.Repository != rightRefURI.Repository {
+		Die("both references must belong to the same repository", 1)
+	}
+	resp, err := client.FindMergeBaseWithResponse(ctx, leftRefURI.Repository, leftRefURI.Ref, rightRefURI.Ref)
+	DieOnResponseError(resp, err)
+	Write(branchMergeBaseTemplate, resp.JSON200)
+}
+
+// diffExitCode returns the exit code of diff given the number of changes it found: 0 unless
+// exitCode is set and there are changes.
+func diffExitCode(exitCode bool, changes int) int {
+	if exitCode && changes > 0 {
+		return diffChangesExitCode
+	}
+	return 0
+}
+
+// diffTypeFilter returns the only diff type to show according to the filter flags, or "" to
+// show all diff types.
+func diffTypeFilter(flags *pflag.FlagSet) (string, error) {
+	var typeFilter, typeFilterFlag string
+	for _, f := range diffTypeFilterFlags {
+		if set, _ := flags.GetBool(f.flag); !set {
+			continue
+		}
+		if typeFilter != "" {
+			return "", fmt.Errorf("%w: --%s and --%s", ErrConflictingDiffFilters, typeFilterFlag, f.flag)
+		}
+		typeFilter, typeFilterFlag = f.diffType, f.flag
+	}
+	return typeFilter, nil
+}
+
 type pageSize int
 
 func (p *pageSize) Value() int { return int(*p) }
@@ -53,52 +189,386 @@ func (p *pageSize) Next() int {
 	return p.Value()
 }
 
-func printDiffBranch(ctx context.Context, client api.ClientWithResponsesInterface, repository string, branch string) {
+// diffChecksums annotates changed objects with their checksums before and after the change.
+type diffChecksums struct {
+	client     api.ClientWithResponsesInterface
+	repository string
+	beforeRef  string
+	afterRef   string
+}
+
+// newDiffChecksums returns the checksums of the diff given by args: between its two refs, or
+// between the committed state of its branch and its uncommitted changes.
+func newDiffChecksums(client api.ClientWithResponsesInterface, args []string) *diffChecksums {
+	if len(args) == diffCmdMaxArgs {
+		leftRefURI := MustParseRefURI("left ref", args[0])
+		rightRefURI := MustParseRefURI("right ref", args[1])
+		return &diffChecksums{client: client, repository: leftRefURI.Repository, beforeRef: leftRefURI.Ref, afterRef: rightRefURI.Ref}
+	}
+	branchURI := MustParseRefURI("ref", args[0])
+	// the committed state of the branch is before its uncommitted changes
+	return &diffChecksums{client: client, repository: branchURI.Repository, beforeRef: branchURI.Ref + "@", afterRef: branchURI.Ref}
+}
+
+// annotate returns the checksums of the object at path before and after its change, noting
+// changes that kept the same content.
+func (c *diffChecksums) annotate(ctx context.Context, path string) string {
+	before := c.checksum(ctx, c.beforeRef, path)
+	after := c.checksum(ctx, c.afterRef, path)
+	if before == after {
+		return fmt.Sprintf("checksum %s, metadata-only change", after)
+	}
+	return fmt.Sprintf("checksum %s -> %s", before, after)
+}
+
+func (c *diffChecksums) checksum(ctx context.Context, ref, path string) string {
+	resp, err := c.client.StatObjectWithResponse(ctx, c.repository, ref, &api.StatObjectParams{Path: path})
+	DieOnResponseError(resp, err)
+	return resp.JSON200.Checksum
+}
+
+// diffPrinter prints diff lines of a single type (or of all types if typeFilter is empty) up to
+// amount lines (or all lines if amount is not positive), and counts the matching lines beyond
+// that.  Changed objects are annotated with their checksums if checksums is set.  With nameOnly,
+// only the paths are printed, one per line, for scripts.  With encoder, each line is encoded as
+// a JSON object on its own line as soon as its page arrives, for tools reading huge diffs.
+type diffPrinter struct {
+	typeFilter string
+	amount     int
+	checksums  *diffChecksums
+	nameOnly   bool
+	encoder    *json.Encoder
+	printed    int
+	more       int
+}
+
+func (p *diffPrinter) print(ctx context.Context, lines []api.Diff) {
+	for _, line := range lines {
+		if p.typeFilter != "" && line.Type != p.typeFilter {
+			continue
+		}
+		if p.amount > 0 && p.printed >= p.amount {
+			p.more++
+			continue
+		}
+		if p.nameOnly {
+			_, _ = os.Stdout.WriteString(line.Path + "\n")
+			p.printed++
+			continue
+		}
+		if p.encoder != nil {
+			if err := p.encoder.Encode(line); err != nil {
+				DieErr(err)
+			}
+			p.printed++
+			continue
+		}
+		var annotation string
+		if p.checksums != nil && line.Type == "changed" && line.PathType == "object" {
+			annotation = p.checksums.annotate(ctx, line.Path)
+		}
+		fmtDiff(line, annotation)
+		p.printed++
+	}
+}
+
+// pageAmount returns the number of lines to fetch next: no more than one line beyond amount,
+// enough to tell whether the diff is truncated.
+func (p *diffPrinter) pageAmount(size pageSize) int {
+	if p.amount <= 0 || size.Value() <= p.amount-p.printed {
+		return size.Value()
+	}
+	return p.amount - p.printed + 1
+}
+
+// truncated returns true once lines beyond amount were seen, so no more pages are needed.
+func (p *diffPrinter) truncated() bool {
+	return p.more > 0
+}
+
+// changes returns the number of matching lines seen, printed or not.
+func (p *diffPrinter) changes() int {
+	return p.printed + p.more
+}
+
+func (p *diffPrinter) printFooter() {
+	if !p.truncated() {
+		return
+	}
+	const footer = "... truncated, %d+ more changes, use --amount to see more\n"
+	if p.nameOnly || p.encoder != nil {
+		// keep the output a list of changes
+		_, _ = fmt.Fprintf(os.Stderr, footer, p.more)
+		return
+	}
+	Fmt(footer, p.more)
+}
+
+func printDiffBranch(ctx context.Context, client api.ClientWithResponsesInterface, repository string, branch string, printer *diffPrinter) int {
 	var after string
 	pageSize := pageSize(minDiffPageSize)
 	for {
 		resp, err := client.DiffBranchWithResponse(ctx, repository, branch, &api.DiffBranchParams{
 			After:  api.PaginationAfterPtr(after),
-			Amount: api.PaginationAmountPtr(int(pageSize)),
+			Amount: api.PaginationAmountPtr(printer.pageAmount(pageSize)),
 		})
 		DieOnResponseError(resp, err)
 
-		for _, line := range resp.JSON200.Results {
-			FmtDiff(line, false)
-		}
+		printer.print(ctx, resp.JSON200.Results)
 		pagination := resp.JSON200.Pagination
-		if !pagination.HasMore {
+		if !pagination.HasMore || printer.truncated() {
 			break
 		}
 		after = pagination.NextOffset
 		pageSize.Next()
 	}
+	printer.printFooter()
+	return printer.changes()
 }
 
-func printDiffRefs(ctx context.Context, client api.ClientWithResponsesInterface, repository string, leftRef string, rightRef string) {
+func printDiffRefs(ctx context.Context, client api.ClientWithResponsesInterface, repository string, leftRef string, rightRef string, printer *diffPrinter) int {
 	var after string
 	pageSize := pageSize(minDiffPageSize)
 	for {
-		amount := int(pageSize)
 		resp, err := client.DiffRefsWithResponse(ctx, repository, leftRef, rightRef, &api.DiffRefsParams{
 			After:  api.PaginationAfterPtr(after),
-			Amount: api.PaginationAmountPtr(amount),
+			Amount: api.PaginationAmountPtr(printer.pageAmount(pageSize)),
 		})
 		DieOnResponseError(resp, err)
 
-		for _, line := range resp.JSON200.Results {
-			FmtDiff(line, true)
-		}
+		printer.print(ctx, resp.JSON200.Results)
 		pagination := resp.JSON200.Pagination
-		if !pagination.HasMore {
+		if !pagination.HasMore || printer.truncated() {
 			break
 		}
 		after = pagination.NextOffset
 		pageSize.Next()
 	}
+	printer.printFooter()
+	return printer.changes()
 }
 
-func FmtDiff(diff api.Diff, withDirection bool) {
+// diffPrefix returns the prefix of path made of its first depth segments, or path itself if it
+// has no more segments.
+func diffPrefix(path string, depth int) string {
+	segments := strings.SplitAfterN(path, uri.PathSeparator, depth+1)
+	if len(segments) <= depth {
+		return path
+	}
+	return strings.Join(segments[:depth], "")
+}
+
+// diffGroups counts the changes of each diff type by path prefix.
+type diffGroups map[string]map[string]int
+
+func (g diffGroups) add(lines []api.Diff, typeFilter string, depth int) {
+	for _, line := range lines {
+		if typeFilter != "" && line.Type != typeFilter {
+			continue
+		}
+		if g[line.Type] == nil {
+			g[line.Type] = make(map[string]int)
+		}
+		g[line.Type][diffPrefix(line.Path, depth)]++
+	}
+}
+
+// changes returns the number of changes in all groups.
+func (g diffGroups) changes() int {
+	var changes int
+	for _, counts := range g {
+		for _, count := range counts {
+			changes += count
+		}
+	}
+	return changes
+}
+
+// print prints a line for each diff type listing its prefixes with their counts.
+func (g diffGroups) print() {
+	for _, diffType := range []string{"added", "removed", "changed", "conflict"} {
+		counts := g[diffType]
+		if len(counts) == 0 {
+			continue
+		}
+		prefixes := make([]string, 0, len(counts))
+		for prefix := range counts {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+		groups := make([]string, len(prefixes))
+		for i, prefix := range prefixes {
+			groups[i] = fmt.Sprintf("%s (%d)", prefix, counts[prefix])
+		}
+		Fmt("%s: %s\n", diffType, strings.Join(groups, ", "))
+	}
+}
+
+// diffLister returns a function listing the pages of the diff given by args, after printing
+// the refs of the diff.
+func diffLister(ctx context.Context, client api.ClientWithResponsesInterface, args []string) func(after string) *api.DiffList {
+	if len(args) == diffCmdMaxArgs {
+		leftRefURI := MustParseRefURI("left ref", args[0])
+		rightRefURI := MustParseRefURI("right ref", args[1])
+		if leftRefURI.Repository != rightRefURI.Repository {
+			Die("both references must belong to the same repository", 1)
+		}
+		Fmt("Left ref: %s\nRight ref: %s\n", leftRefURI.String(), rightRefURI.String())
+		return func(after string) *api.DiffList {
+			resp, err := client.DiffRefsWithResponse(ctx, leftRefURI.Repository, leftRefURI.Ref, rightRefURI.Ref, &api.DiffRefsParams{
+				After:  api.PaginationAfterPtr(after),
+				Amount: api.PaginationAmountPtr(internalPageSize),
+			})
+			DieOnResponseError(resp, err)
+			return resp.JSON200
+		}
+	}
+	branchURI := MustParseRefURI("ref", args[0])
+	Fmt("Ref: %s\n", branchURI.String())
+	return func(after string) *api.DiffList {
+		resp, err := client.DiffBranchWithResponse(ctx, branchURI.Repository, branchURI.Ref, &api.DiffBranchParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+		})
+		DieOnResponseError(resp, err)
+		return resp.JSON200
+	}
+}
+
+// printDiffGroups prints the counts of all changes in the diff given by args, grouped by the
+// first depth segments of their paths, and returns the number of changes.
+func printDiffGroups(ctx context.Context, client api.ClientWithResponsesInterface, args []string, typeFilter string, depth int) int {
+	listPage := diffLister(ctx, client, args)
+	groups := make(diffGroups)
+	var after string
+	for {
+		page := listPage(after)
+		groups.add(page.Results, typeFilter, depth)
+		if !page.Pagination.HasMore {
+			break
+		}
+		after = page.Pagination.NextOffset
+	}
+	groups.print()
+	return groups.changes()
+}
+
+// diffRename is a removed object and an added object with the same checksum.
+type diffRename struct {
+	from string
+	to   string
+}
+
+// findDiffRenames pairs removed and added objects in lines with identical checksums into
+// renames, and returns them along with the remaining lines.  An added object is paired with the
+// removed object whose path is most similar to its own, if the similarity is at least
+// minSimilarity.
+func findDiffRenames(lines []api.Diff, minSimilarity float64) ([]diffRename, []api.Diff) {
+	removedByChecksum := make(map[string][]string)
+	for _, line := range lines {
+		if line.Type == "removed" && line.PathType == "object" && line.Checksum != nil && *line.Checksum != "" {
+			removedByChecksum[*line.Checksum] = append(removedByChecksum[*line.Checksum], line.Path)
+		}
+	}
+	var renames []diffRename
+	renamed := make(map[string]bool)
+	for _, line := range lines {
+		if line.Type != "added" || line.PathType != "object" || line.Checksum == nil {
+			continue
+		}
+		candidates := removedByChecksum[*line.Checksum]
+		best, bestSimilarity := -1, minSimilarity
+		for i, candidate := range candidates {
+			if similarity := pathSimilarity(candidate, line.Path); similarity >= bestSimilarity && (best < 0 || similarity > bestSimilarity) {
+				best, bestSimilarity = i, similarity
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		renames = append(renames, diffRename{from: candidates[best], to: line.Path})
+		renamed[candidates[best]] = true
+		renamed[line.Path] = true
+		removedByChecksum[*line.Checksum] = append(candidates[:best:best], candidates[best+1:]...)
+	}
+	remaining := make([]api.Diff, 0, len(lines)-len(renamed))
+	for _, line := range lines {
+		if line.PathType != "object" || !renamed[line.Path] {
+			remaining = append(remaining, line)
+		}
+	}
+	return renames, remaining
+}
+
+// pathSimilarity returns the similarity of paths a and b between 0 (nothing in common) and 1
+// (identical), by their edit distance.
+func pathSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	// Levenshtein distance, keeping one row of the table
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			next := diagonal + cost
+			if row[j]+1 < next {
+				next = row[j] + 1
+			}
+			if row[j-1]+1 < next {
+				next = row[j-1] + 1
+			}
+			diagonal, row[j] = row[j], next
+		}
+	}
+	return 1 - float64(row[len(rb)])/float64(longest)
+}
+
+// printDiffRenames prints changes of the diff given by args with printer, reporting removed and
+// added objects with identical checksums as renames.  Renames may pair objects on different
+// pages, so the entire diff is read first.  It returns the number of changes, counting each
+// rename once.
+func printDiffRenames(ctx context.Context, client api.ClientWithResponsesInterface, args []string, printer *diffPrinter, minSimilarity float64) int {
+	listPage := diffLister(ctx, client, args)
+	var lines []api.Diff
+	var after string
+	for {
+		page := listPage(after)
+		lines = append(lines, page.Results...)
+		if !page.Pagination.HasMore {
+			break
+		}
+		after = page.Pagination.NextOffset
+	}
+	renames, remaining := findDiffRenames(lines, minSimilarity)
+	for _, r := range renames {
+		if printer.amount > 0 && printer.printed >= printer.amount {
+			printer.more++
+			continue
+		}
+		_, _ = os.Stdout.WriteString(text.FgCyan.Sprintf("> renamed: %s -> %s\n", r.from, r.to))
+		printer.printed++
+	}
+	printer.print(ctx, remaining)
+	printer.printFooter()
+	return printer.changes()
+}
+
+// fmtDiff prints diff, followed by annotation in parentheses if there is one.
+func fmtDiff(diff api.Diff, annotation string) {
 	var color text.Color
 	var action string
 
@@ -118,13 +588,12 @@ func FmtDiff(diff api.Diff, withDirection bool) {
 	default:
 	}
 
-	if !withDirection {
+	if annotation != "" {
 		_, _ = os.Stdout.WriteString(
-			color.Sprintf("%s %s\n", action, diff.Path),
+			color.Sprintf("%s %s (%s)\n", action, diff.Path, annotation),
 		)
 		return
 	}
-
 	_, _ = os.Stdout.WriteString(
 		color.Sprintf("%s %s\n", action, diff.Path),
 	)
@@ -133,4 +602,17 @@ func FmtDiff(diff api.Diff, withDirection bool) {
 //nolint:gochecknoinits
 func init() {
 	rootCmd.AddCommand(diffCmd)
+	for _, f := range diffTypeFilterFlags {
+		diffCmd.Flags().Bool(f.flag, false, "show only "+f.diffType+" paths")
+	}
+	diffCmd.Flags().Int("amount", defaultDiffAmount, "maximal number of changes to show, or 0 for all changes")
+	diffCmd.Flags().Bool("checksums", false, "show checksums of changed objects before and after the change, noting metadata-only changes")
+	diffCmd.Flags().Bool("group-by-prefix", false, "show only the number of changes of each type under each path prefix")
+	diffCmd.Flags().Int("depth", 1, "number of path segments in the prefixes of --group-by-prefix")
+	diffCmd.Flags().Bool("find-renames", false, "show removed and added objects with identical checksums as renamed")
+	diffCmd.Flags().Float64("rename-similarity", 0, "minimal similarity of paths, between 0 and 1, of objects shown as renamed by --find-renames")
+	diffCmd.Flags().Bool("show-base", false, "show the merge base of the two refs, from which their changes are compared")
+	diffCmd.Flags().Bool("name-only", false, "show only the paths of changes, one per line")
+	diffCmd.Flags().StringP(OutputFlagName, "o", OutputFormatText, fmt.Sprintf("output format, one of %q or %q (a JSON object per change, on its own line)", OutputFormatText, OutputFormatJSONL))
+	diffCmd.Flags().Bool("exit-code", false, fmt.Sprintf("exit with code %d if there are changes, and 0 otherwise", diffChangesExitCode))
 }
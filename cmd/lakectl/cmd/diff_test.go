@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+// diffHandler serves a diff with one path of each type, both for a branch and between refs.
+func diffHandler() http.Handler {
+	diff := api.DiffList{
+		Pagination: api.Pagination{Results: 3},
+		Results: []api.Diff{
+			{Path: "new", PathType: "object", Type: "added"},
+			{Path: "gone", PathType: "object", Type: "removed"},
+			{Path: "edited", PathType: "object", Type: "changed"},
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/branches/main/diff", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, diff)
+	})
+	mux.HandleFunc("/repositories/repo/refs/main/diff/feature", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, diff)
+	})
+	return mux
+}
+
+func TestDiffTypeFilters(t *testing.T) {
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{flag: "--added-only", want: "new"},
+		{flag: "--removed-only", want: "gone"},
+		{flag: "--changed-only", want: "edited"},
+	}
+	paths := []string{"new", "gone", "edited"}
+	for _, refs := range [][]string{{"lakefs://repo/main"}, {"lakefs://repo/main", "lakefs://repo/feature"}} {
+		for _, tt := range tests {
+			t.Run(strings.Join(refs, " ")+" "+tt.flag, func(t *testing.T) {
+				out := runCmd(t, diffHandler(), append(append([]string{"diff"}, refs...), tt.flag)...)
+				for _, p := range paths {
+					shown := strings.Contains(out, " "+p+"\n")
+					if shown != (p == tt.want) {
+						t.Errorf("output %q shows %s: %t, expected only %s", out, p, shown, tt.want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestDiffTypeFilterConflict(t *testing.T) {
+	resetFlags(diffCmd)
+	if err := diffCmd.Flags().Parse([]string{"--added-only", "--removed-only"}); err != nil {
+		t.Fatalf("parse flags: %s", err)
+	}
+	if _, err := diffTypeFilter(diffCmd.Flags()); !errors.Is(err, ErrConflictingDiffFilters) {
+		t.Errorf("diffTypeFilter() with conflicting flags returned %v, expected %s", err, ErrConflictingDiffFilters)
+	}
+	resetFlags(diffCmd)
+	if typeFilter, err := diffTypeFilter(diffCmd.Flags()); err != nil || typeFilter != "" {
+		t.Errorf("diffTypeFilter() without flags = %q, %v, expected no filter", typeFilter, err)
+	}
+}
+
+func TestDiffNameOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "branch", args: []string{"lakefs://repo/main"}, want: "new\ngone\nedited\n"},
+		{name: "refs", args: []string{"lakefs://repo/main", "lakefs://repo/feature"}, want: "new\ngone\nedited\n"},
+		{name: "added only", args: []string{"lakefs://repo/main", "--added-only"}, want: "new\n"},
+		{name: "changed only", args: []string{"lakefs://repo/main", "lakefs://repo/feature", "--changed-only"}, want: "edited\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(append([]string{"diff"}, tt.args...), "--name-only")
+			if out := runCmd(t, diffHandler(), args...); out != tt.want {
+				t.Errorf("output %q, expected only the paths %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffShowBase(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/", divergentHistoryHandler())
+	mux.HandleFunc("/repositories/repo/refs/main/diff/feature", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.DiffList{
+			Pagination: api.Pagination{Results: 1},
+			Results:    []api.Diff{{Path: "new", PathType: "object", Type: "added"}},
+		})
+	})
+
+	out := runCmd(t, mux, "diff", "lakefs://repo/main", "lakefs://repo/feature", "--show-base")
+	baseAt, diffAt := strings.Index(out, "Merge base: c2"), strings.Index(out, "+ added new")
+	if baseAt < 0 || diffAt < 0 || baseAt > diffAt {
+		t.Errorf("output %q does not show the merge base c2 above the diff", out)
+	}
+
+	out = runCmd(t, mux, "diff", "lakefs://repo/main", "lakefs://repo/feature")
+	if strings.Contains(out, "Merge base") {
+		t.Errorf("output %q shows the merge base without --show-base", out)
+	}
+}
+
+// pagedDiffHandler serves a branch diff of size added paths honoring pagination, and records
+// the number of paths it served.
+func pagedDiffHandler(size int, served *int) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/branches/main/diff", func(w http.ResponseWriter, r *http.Request) {
+		start := 0
+		if after := r.URL.Query().Get("after"); after != "" {
+			start, _ = strconv.Atoi(strings.TrimPrefix(after, "p"))
+			start++
+		}
+		amount, _ := strconv.Atoi(r.URL.Query().Get("amount"))
+		end := start + amount
+		if end > size {
+			end = size
+		}
+		var list api.DiffList
+		for i := start; i < end; i++ {
+			list.Results = append(list.Results, api.Diff{Path: fmt.Sprintf("p%03d", i), PathType: "object", Type: "added"})
+		}
+		*served += len(list.Results)
+		list.Pagination = api.Pagination{HasMore: end < size, NextOffset: fmt.Sprintf("p%03d", end-1), Results: len(list.Results)}
+		writeJSON(w, http.StatusOK, list)
+	})
+	return mux
+}
+
+func TestDiffAmountTruncates(t *testing.T) {
+	var served int
+	out := runCmd(t, pagedDiffHandler(200, &served), "diff", "lakefs://repo/main", "--amount", "120")
+	if !strings.Contains(out, " p119\n") || strings.Contains(out, " p120\n") {
+		t.Errorf("output %q does not show exactly the first 120 changes", out)
+	}
+	if !strings.Contains(out, "... truncated, 1+ more changes, use --amount to see more") {
+		t.Errorf("output %q does not contain the truncation footer", out)
+	}
+	if served != 121 {
+		t.Errorf("served %d changes, expected to fetch only up to one beyond the amount", served)
+	}
+}
+
+func TestDiffAmountNotTruncated(t *testing.T) {
+	var served int
+	out := runCmd(t, pagedDiffHandler(120, &served), "diff", "lakefs://repo/main", "--amount", "120")
+	if !strings.Contains(out, " p119\n") {
+		t.Errorf("output %q does not show all changes", out)
+	}
+	if strings.Contains(out, "truncated") {
+		t.Errorf("output %q has a truncation footer for a diff within the amount", out)
+	}
+}
+
+func TestDiffJSONL(t *testing.T) {
+	const size = 120
+	var served int
+	out := runCmd(t, pagedDiffHandler(size, &served), "diff", "lakefs://repo/main", "--output", "jsonl", "--amount", "0")
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != size {
+		t.Fatalf("output has %d lines, expected a line per change", len(lines))
+	}
+	for i, line := range lines {
+		var diff api.Diff
+		if err := json.Unmarshal([]byte(line), &diff); err != nil {
+			t.Fatalf("line %d %q is not a JSON change: %s", i, line, err)
+		}
+		expected := api.Diff{Path: fmt.Sprintf("p%03d", i), PathType: "object", Type: "added"}
+		if diff != expected {
+			t.Errorf("line %d is %+v, expected %+v", i, diff, expected)
+		}
+	}
+	if served != size {
+		t.Errorf("served %d changes, expected %d", served, size)
+	}
+}
+
+func TestDiffChecksums(t *testing.T) {
+	checksums := map[string]string{
+		"main@/edited":  "aaa111",
+		"main/edited":   "bbb222",
+		"main@/touched": "ccc333",
+		"main/touched":  "ccc333",
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/branches/main/diff", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.DiffList{
+			Results: []api.Diff{
+				{Path: "edited", PathType: "object", Type: "changed"},
+				{Path: "new", PathType: "object", Type: "added"},
+				{Path: "touched", PathType: "object", Type: "changed"},
+			},
+		})
+	})
+	for _, ref := range []string{"main", "main@"} {
+		ref := ref
+		mux.HandleFunc("/repositories/repo/refs/"+ref+"/objects/stat", func(w http.ResponseWriter, r *http.Request) {
+			p := r.URL.Query().Get("path")
+			checksum, ok := checksums[ref+"/"+p]
+			if !ok {
+				writeJSON(w, http.StatusNotFound, api.Error{Message: "not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, api.ObjectStats{Path: p, PathType: "object", Checksum: checksum})
+		})
+	}
+
+	for _, args := range [][]string{{}, {"--find-renames"}} {
+		out := runCmd(t, mux, append([]string{"diff", "lakefs://repo/main", "--checksums"}, args...)...)
+		for _, want := range []string{
+			"edited (checksum aaa111 -> bbb222)",
+			"touched (checksum ccc333, metadata-only change)",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output %q with %v does not contain %q", out, args, want)
+			}
+		}
+		if strings.Contains(out, "new (") {
+			t.Errorf("output %q with %v annotates an added object", out, args)
+		}
+	}
+}
+
+func TestDiffGroupByPrefix(t *testing.T) {
+	diff := api.DiffList{
+		Pagination: api.Pagination{Results: 6},
+		Results: []api.Diff{
+			{Path: "README.md", PathType: "object", Type: "changed"},
+			{Path: "data/2021/a.csv", PathType: "object", Type: "added"},
+			{Path: "data/2021/b.csv", PathType: "object", Type: "added"},
+			{Path: "data/2022/c.csv", PathType: "object", Type: "added"},
+			{Path: "data/old.csv", PathType: "object", Type: "removed"},
+			{Path: "models/m1", PathType: "object", Type: "added"},
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/main/diff/feature", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, diff)
+	})
+
+	tests := []struct {
+		depth string
+		want  []string
+	}{
+		{
+			depth: "1",
+			want: []string{
+				"added: data/ (3), models/ (1)\n",
+				"removed: data/ (1)\n",
+				"changed: README.md (1)\n",
+			},
+		},
+		{
+			depth: "2",
+			want: []string{
+				"added: data/2021/ (2), data/2022/ (1), models/m1 (1)\n",
+				"removed: data/old.csv (1)\n",
+				"changed: README.md (1)\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("depth "+tt.depth, func(t *testing.T) {
+			out := runCmd(t, mux, "diff", "lakefs://repo/main", "lakefs://repo/feature", "--group-by-prefix", "--depth", tt.depth)
+			if !strings.HasSuffix(out, strings.Join(tt.want, "")) {
+				t.Errorf("output %q does not end with the groups %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffFindRenames(t *testing.T) {
+	diff := api.DiffList{
+		Pagination: api.Pagination{Results: 4},
+		Results: []api.Diff{
+			{Path: "data/a.csv", PathType: "object", Type: "added", Checksum: api.StringPtr("c1")},
+			{Path: "data/b.csv", PathType: "object", Type: "added", Checksum: api.StringPtr("c2")},
+			{Path: "gone.csv", PathType: "object", Type: "removed", Checksum: api.StringPtr("c3")},
+			{Path: "staging/a.csv", PathType: "object", Type: "removed", Checksum: api.StringPtr("c1")},
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/main/diff/feature", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, diff)
+	})
+
+	out := runCmd(t, mux, "diff", "lakefs://repo/main", "lakefs://repo/feature", "--find-renames")
+	for _, expected := range []string{"renamed: staging/a.csv -> data/a.csv\n", "+ added data/b.csv\n", "- removed gone.csv\n"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("output %q does not contain %q", out, expected)
+		}
+	}
+	for _, unexpected := range []string{"added data/a.csv", "removed staging/a.csv"} {
+		if strings.Contains(out, unexpected) {
+			t.Errorf("output %q shows renamed object as %q", out, unexpected)
+		}
+	}
+
+	// the paths are too different to be renamed
+	out = runCmd(t, mux, "diff", "lakefs://repo/main", "lakefs://repo/feature", "--find-renames", "--rename-similarity", "0.9")
+	if strings.Contains(out, "renamed") || !strings.Contains(out, "+ added data/a.csv\n") {
+		t.Errorf("output %q reports a rename of paths less similar than the threshold", out)
+	}
+}
+
+func TestDiffExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		args     []string
+		wantCode int
+	}{
+		{name: "empty", size: 0, args: []string{"--exit-code"}, wantCode: 0},
+		{name: "changes", size: 3, args: []string{"--exit-code"}, wantCode: diffChangesExitCode},
+		{name: "changes beyond amount", size: 3, args: []string{"--exit-code", "--amount", "1"}, wantCode: diffChangesExitCode},
+		{name: "changes without flag", size: 3, wantCode: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := 0
+			diffExit = func(c int) { code = c }
+			t.Cleanup(func() { diffExit = os.Exit })
+			var served int
+			out := runCmd(t, pagedDiffHandler(tt.size, &served), append([]string{"diff", "lakefs://repo/main"}, tt.args...)...)
+			if code != tt.wantCode {
+				t.Errorf("diff exited with code %d, expected %d", code, tt.wantCode)
+			}
+			if tt.size > 0 && !strings.Contains(out, " p000\n") {
+				t.Errorf("output %q does not show the changes", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/api/helpers"
+	"github.com/treeverse/lakefs/pkg/version"
+)
+
+const doctorTemplate = `{{ range . }}{{ if .Err }}{{ "[FAIL]" | red }}{{ else }}{{ "[ OK ]" | green }}{{ end }} {{ .Name }}{{ if .Err }}: {{ .Err }}{{ else if .Details }}: {{ .Details }}{{ end }}
+{{ end }}`
+
+var (
+	ErrIncompatibleVersion     = errors.New("incompatible version")
+	ErrInvalidStorageNamespace = errors.New("invalid storage namespace")
+)
+
+type doctorCheck struct {
+	Name    string
+	Details string
+	Err     error
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "run a basic diagnosis of the lakectl configuration",
+	Long:  "check that the configured endpoint is reachable, credentials are valid, the server version is compatible and the storage namespace format is valid for the server blockstore",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		storageNamespace, _ := cmd.Flags().GetString("storage-namespace")
+		Fmt("Endpoint: %s\n", cfg.Values.Server.EndpointURL)
+		checks := runDoctorChecks(cmd.Context(), getClient(), storageNamespace)
+		Write(doctorTemplate, checks)
+		for _, check := range checks {
+			if check.Err != nil {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// runDoctorChecks runs every check against the server and returns all results, failed or not.
+func runDoctorChecks(ctx context.Context, client api.ClientWithResponsesInterface, storageNamespace string) []doctorCheck {
+	var checks []doctorCheck
+
+	healthResp, err := client.HealthCheckWithResponse(ctx)
+	checks = append(checks, doctorCheck{Name: "endpoint reachable", Err: responseError(healthResp, err)})
+
+	userResp, err := client.GetCurrentUserWithResponse(ctx)
+	check := doctorCheck{Name: "credentials valid", Err: responseError(userResp, err)}
+	if check.Err == nil && userResp.JSON200 != nil {
+		check.Details = "authenticated as " + userResp.JSON200.User.Id
+	}
+	checks = append(checks, check)
+
+	versionResp, err := client.GetLakeFSVersionWithResponse(ctx)
+	check = doctorCheck{Name: "server version compatible", Err: responseError(versionResp, err)}
+	if check.Err == nil && versionResp.JSON200 != nil && versionResp.JSON200.Version != nil {
+		serverVersion := *versionResp.JSON200.Version
+		if isVersionCompatible(version.Version, serverVersion) {
+			check.Details = "server " + serverVersion
+		} else {
+			check.Err = fmt.Errorf("%w: lakectl %s, server %s", ErrIncompatibleVersion, version.Version, serverVersion)
+		}
+	}
+	checks = append(checks, check)
+
+	storageResp, err := client.GetStorageConfigWithResponse(ctx)
+	check = doctorCheck{Name: "storage namespace format", Err: responseError(storageResp, err)}
+	if check.Err == nil && storageResp.JSON200 != nil {
+		check.Details, check.Err = checkStorageNamespace(storageResp.JSON200, storageNamespace)
+	}
+	checks = append(checks, check)
+
+	return checks
+}
+
+// responseError returns the error of an API call, whether a transport error or an error response.
+func responseError(response interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+	return helpers.ResponseAsError(response)
+}
+
+// checkStorageNamespace checks that storageNamespace matches the namespace format of the server
+// blockstore.  It does not access the storage namespace.
+func checkStorageNamespace(storageConfig *api.StorageConfig, storageNamespace string) (string, error) {
+	if storageNamespace == "" {
+		return fmt.Sprintf("blockstore %s, e.g. %s", storageConfig.BlockstoreType, storageConfig.BlockstoreNamespaceExample), nil
+	}
+	re, err := regexp.Compile(storageConfig.BlockstoreNamespaceValidityRegex)
+	if err != nil {
+		return "", fmt.Errorf("server namespace validity regex: %w", err)
+	}
+	if !re.MatchString(storageNamespace) {
+		return "", fmt.Errorf("%w: %s does not match blockstore %s (e.g. %s)",
+			ErrInvalidStorageNamespace, storageNamespace, storageConfig.BlockstoreType, storageConfig.BlockstoreNamespaceExample)
+	}
+	return storageNamespace + " valid for blockstore " + storageConfig.BlockstoreType, nil
+}
+
+// isVersionCompatible returns true if the client and server versions share their major and
+// minor components.  Development builds are compatible with everything.
+func isVersionCompatible(clientVersion, serverVersion string) bool {
+	if clientVersion == version.UnreleasedVersion || serverVersion == version.UnreleasedVersion {
+		return true
+	}
+	return majorMinor(clientVersion) == majorMinor(serverVersion)
+}
+
+func majorMinor(v string) string {
+	const majorMinorParts = 2
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", majorMinorParts+1)
+	if len(parts) > majorMinorParts {
+		parts = parts[:majorMinorParts]
+	}
+	return strings.Join(parts, ".")
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().String("storage-namespace", "", "storage namespace whose format to validate against the server blockstore")
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+func newDoctorHandler(authorized bool, serverVersion string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthcheck", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, _ *http.Request) {
+		if !authorized {
+			writeJSON(w, http.StatusUnauthorized, api.Error{Message: "error authenticating request"})
+			return
+		}
+		writeJSON(w, http.StatusOK, api.CurrentUser{User: api.User{Id: "admin"}})
+	})
+	mux.HandleFunc("/config/version", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, api.VersionConfig{Version: api.StringPtr(serverVersion)})
+	})
+	mux.HandleFunc("/config/storage", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, api.StorageConfig{
+			BlockstoreNamespaceExample:       "s3://example-bucket/",
+			BlockstoreNamespaceValidityRegex: "^s3://",
+			BlockstoreType:                   "s3",
+		})
+	})
+	return mux
+}
+
+func TestRunDoctorChecks(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name             string
+		authorized       bool
+		serverVersion    string
+		storageNamespace string
+		wantFailed       []string
+	}{
+		{name: "healthy", authorized: true, serverVersion: "dev", storageNamespace: "s3://bucket/repo"},
+		{name: "unauthorized", authorized: false, serverVersion: "dev", wantFailed: []string{"credentials valid"}},
+		{name: "bad namespace", authorized: true, serverVersion: "dev", storageNamespace: "gs://bucket", wantFailed: []string{"storage namespace format"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, newDoctorHandler(tt.authorized, tt.serverVersion))
+			checks := runDoctorChecks(ctx, client, tt.storageNamespace)
+			if len(checks) != 4 {
+				t.Fatalf("got %d checks, expected 4", len(checks))
+			}
+			var failed []string
+			for _, check := range checks {
+				if check.Err != nil {
+					failed = append(failed, check.Name)
+				}
+			}
+			if len(failed) != len(tt.wantFailed) {
+				t.Fatalf("failed checks %v, expected %v", failed, tt.wantFailed)
+			}
+			for i := range failed {
+				if failed[i] != tt.wantFailed[i] {
+					t.Errorf("failed checks %v, expected %v", failed, tt.wantFailed)
+				}
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		client, err := api.NewClientWithResponses("http://127.0.0.1:1" + api.BaseURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, check := range runDoctorChecks(ctx, client, "") {
+			if check.Err == nil {
+				t.Errorf("check %s passed on unreachable server", check.Name)
+			}
+		}
+	})
+}
+
+func TestIsVersionCompatible(t *testing.T) {
+	tests := []struct {
+		client, server string
+		want           bool
+	}{
+		{"0.40.1", "0.40.3", true},
+		{"v0.40.1", "0.40.0", true},
+		{"0.40.1", "0.41.0", false},
+		{"1.0.0", "0.40.0", false},
+		{"dev", "0.41.0", true},
+	}
+	for _, tt := range tests {
+		if got := isVersionCompatible(tt.client, tt.server); got != tt.want {
+			t.Errorf("isVersionCompatible(%s, %s) = %t, expected %t", tt.client, tt.server, got, tt.want)
+		}
+	}
+}
+
+func TestCheckStorageNamespaceError(t *testing.T) {
+	_, err := checkStorageNamespace(&api.StorageConfig{BlockstoreNamespaceValidityRegex: "^local://"}, "s3://bucket")
+	if !errors.Is(err, ErrInvalidStorageNamespace) {
+		t.Errorf("got error %v, expected %s", err, ErrInvalidStorageNamespace)
+	}
+}
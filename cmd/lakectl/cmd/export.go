@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
+)
+
+const exportSummaryTemplate = `Exported {{ .Exported }} objects ({{ .SizeBytes|human_bytes }}) from {{ .Ref|yellow }} to {{ .Destination|yellow }}
+`
+
+type exportSummary struct {
+	Ref         string
+	Destination string
+	Exported    int
+	SizeBytes   int64
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export <ref uri> --to <storage uri>",
+	Short: "copy all objects at a reference to external storage",
+	Long:  "copy all objects at a reference to a storage URI on the same blockstore, e.g. s3://bucket/prefix, under their logical paths.  The copy is done by the lakeFS server.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		refURI := MustParseRefURI("ref", args[0])
+		destination := MustString(cmd.Flags().GetString("to"))
+		prefix := MustString(cmd.Flags().GetString("prefix"))
+		summary := exportRef(cmd.Context(), getClient(), refURI, destination, prefix, func(s exportSummary) {
+			Fmt("Exported %d objects (%s)...\n", s.Exported, humanBytes(s.SizeBytes))
+		})
+		Write(exportSummaryTemplate, summary)
+	},
+}
+
+// exportRef exports all objects under prefix at refURI to destination page by page, calling
+// progress after every page.
+func exportRef(ctx context.Context, client api.ClientWithResponsesInterface, refURI *uri.URI, destination, prefix string, progress func(exportSummary)) exportSummary {
+	summary := exportSummary{Ref: refURI.String(), Destination: destination}
+	pfx := api.PaginationPrefix(prefix)
+	var after string
+	for {
+		resp, err := client.ExportRefWithResponse(ctx, refURI.Repository, refURI.Ref, &api.ExportRefParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+			Prefix: &pfx,
+		}, api.ExportRefJSONRequestBody{Destination: destination})
+		DieOnResponseError(resp, err)
+		summary.Exported += resp.JSON200.Exported
+		summary.SizeBytes += resp.JSON200.SizeBytes
+		pagination := resp.JSON200.Pagination
+		if !pagination.HasMore {
+			return summary
+		}
+		progress(summary)
+		after = pagination.NextOffset
+	}
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().String("to", "", "storage URI to export to, e.g. s3://bucket/prefix")
+	exportCmd.Flags().String("prefix", "", "export only objects with this path prefix")
+	_ = exportCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+func TestExport(t *testing.T) {
+	var bodies []api.ExportRefJSONRequestBody
+	var prefixes, afters []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/v1/export", func(w http.ResponseWriter, r *http.Request) {
+		var body api.ExportRefJSONRequestBody
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		bodies = append(bodies, body)
+		after := r.URL.Query().Get("after")
+		prefixes, afters = append(prefixes, r.URL.Query().Get("prefix")), append(afters, after)
+		result := api.ExportResult{Exported: 2, SizeBytes: 1024}
+		if after == "" {
+			result.Pagination = api.Pagination{HasMore: true, NextOffset: "tables/b"}
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+
+	out := runCmd(t, mux, "export", "lakefs://repo/v1", "--to", "s3://bucket/snapshot", "--prefix", "tables/")
+	if len(bodies) != 2 {
+		t.Fatalf("sent %d export requests, expected one per page", len(bodies))
+	}
+	for i, body := range bodies {
+		if body.Destination != "s3://bucket/snapshot" || prefixes[i] != "tables/" {
+			t.Errorf("export request %d to %s with prefix %q, expected s3://bucket/snapshot with prefix tables/", i, body.Destination, prefixes[i])
+		}
+	}
+	if afters[1] != "tables/b" {
+		t.Errorf("second export request after %q, expected tables/b", afters[1])
+	}
+	if !strings.Contains(out, "Exported 4 objects (2.0 kB)") {
+		t.Errorf("output %q does not contain the export summary", out)
+	}
+}
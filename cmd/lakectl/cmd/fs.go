@@ -2,20 +2,28 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 	"github.com/treeverse/lakefs/pkg/api"
 	"github.com/treeverse/lakefs/pkg/api/helpers"
+	"github.com/treeverse/lakefs/pkg/block"
 	"github.com/treeverse/lakefs/pkg/uri"
+	"golang.org/x/term"
 )
 
 const fsStatTemplate = `Path: {{.Path | yellow }}
@@ -24,7 +32,18 @@ Size: {{ .SizeBytes }} bytes
 Human Size: {{ .SizeBytes|human_bytes }}
 Physical Address: {{ .PhysicalAddress }}
 Checksum: {{ .Checksum }}
-`
+{{ with .ContentType }}Content-Type: {{ . }}
+{{ end }}`
+
+const fsPresignTemplate = `URL: {{ .Url }}
+Expires: {{ .Expiry|date }}
+{{ if .Upload }}Physical Address: {{ .PhysicalAddress }}
+{{ end }}`
+
+var (
+	ErrPresignNotSupported = errors.New("pre-signed URLs are not supported by the lakeFS server blockstore")
+	ErrInvalidStageObject  = errors.New("invalid object to stage")
+)
 
 const fsRecursiveTemplate = `Files: {{.Count}}
 Total Size: {{.Bytes}} bytes
@@ -136,18 +155,57 @@ var fsCatCmd = &cobra.Command{
 	},
 }
 
-func upload(ctx context.Context, client api.ClientWithResponsesInterface, sourcePathname string, destURI *uri.URI, direct bool) (*api.ObjectStats, error) {
+// inferContentType returns the content-type to set on an object uploaded from pathname: override
+// if set, otherwise the type registered for its extension unless infer is false.
+func inferContentType(pathname, override string, infer bool) string {
+	if override != "" || !infer {
+		return override
+	}
+	return mime.TypeByExtension(filepath.Ext(pathname))
+}
+
+// contentTypePtr returns contentType for an optional API field, leaving it out if unset.
+func contentTypePtr(contentType string) *string {
+	if contentType == "" {
+		return nil
+	}
+	return &contentType
+}
+
+// upload uploads sourcePathname to destURI with contentType (if set).  If showProgress, it
+// shows a progress bar of uploads through the lakeFS server.
+func upload(ctx context.Context, client api.ClientWithResponsesInterface, sourcePathname string, destURI *uri.URI, contentType string, direct, showProgress bool) (*api.ObjectStats, error) {
 	fp := OpenByPath(sourcePathname)
 	defer func() {
 		_ = fp.Close()
 	}()
 	if direct {
-		return helpers.ClientUpload(ctx, client, destURI.Repository, destURI.Ref, *destURI.Path, nil, fp)
+		return helpers.ClientUpload(ctx, client, destURI.Repository, destURI.Ref, *destURI.Path, nil, contentType, fp)
+	}
+	var reader io.Reader = fp
+	if showProgress {
+		size, err := fp.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fp.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		bar := progressbar.NewOptions64(size,
+			progressbar.OptionSetWriter(os.Stderr),
+			progressbar.OptionSetDescription("uploading "+path.Base(*destURI.Path)),
+			progressbar.OptionShowBytes(true),
+			progressbar.OptionClearOnFinish())
+		reader = block.NewProgressReader(fp, func(written int64) {
+			_ = bar.Set64(written)
+		})
 	}
-	return uploadObject(ctx, client, destURI.Repository, destURI.Ref, *destURI.Path, fp)
+	return uploadObject(ctx, client, destURI.Repository, destURI.Ref, *destURI.Path, contentType, reader)
 }
 
-func uploadObject(ctx context.Context, client api.ClientWithResponsesInterface, repoID, branchID, filePath string, fp io.Reader) (*api.ObjectStats, error) {
+// uploadObject uploads fp through the lakeFS server.  The server takes the object
+// content-type from the Content-Type of the uploaded form part.
+func uploadObject(ctx context.Context, client api.ClientWithResponsesInterface, repoID, branchID, filePath, objectContentType string, fp io.Reader) (*api.ObjectStats, error) {
 	pr, pw := io.Pipe()
 	mpw := multipart.NewWriter(pw)
 	contentType := mpw.FormDataContentType()
@@ -155,7 +213,16 @@ func uploadObject(ctx context.Context, client api.ClientWithResponsesInterface,
 		defer func() {
 			_ = pw.Close()
 		}()
-		cw, err := mpw.CreateFormFile("content", path.Base(filePath))
+		if objectContentType == "" {
+			objectContentType = "application/octet-stream"
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+			"name":     "content",
+			"filename": path.Base(filePath),
+		}))
+		h.Set("Content-Type", objectContentType)
+		cw, err := mpw.CreatePart(h)
 		if err != nil {
 			_ = pw.CloseWithError(err)
 			return
@@ -189,8 +256,11 @@ var fsUploadCmd = &cobra.Command{
 		source, _ := cmd.Flags().GetString("source")
 		recursive, _ := cmd.Flags().GetBool("recursive")
 		direct, _ := cmd.Flags().GetBool("direct")
+		contentType, _ := cmd.Flags().GetString("content-type")
+		noContentType, _ := cmd.Flags().GetBool("no-content-type")
 		if !recursive {
-			stat, err := upload(cmd.Context(), client, source, pathURI, direct)
+			showProgress := term.IsTerminal(int(os.Stderr.Fd()))
+			stat, err := upload(cmd.Context(), client, source, pathURI, inferContentType(source, contentType, !noContentType), direct, showProgress)
 			if err != nil {
 				DieErr(err)
 			}
@@ -213,7 +283,7 @@ var fsUploadCmd = &cobra.Command{
 			uri := *pathURI
 			p := filepath.Join(*uri.Path, relPath)
 			uri.Path = &p
-			stat, err := upload(cmd.Context(), client, path, &uri, direct)
+			stat, err := upload(cmd.Context(), client, path, &uri, inferContentType(path, contentType, !noContentType), direct, false)
 			if err != nil {
 				return fmt.Errorf("upload %s: %w", path, err)
 			}
@@ -230,59 +300,289 @@ var fsUploadCmd = &cobra.Command{
 	},
 }
 
+// stageObjectCreation returns the staging request for an existing object at physicalAddress,
+// validating the fields the lakeFS server cannot check without accessing the object.
+func stageObjectCreation(physicalAddress string, size int64, checksum, contentType string, mtime int64, meta map[string]string) (*api.ObjectStageCreation, error) {
+	if physicalAddress == "" {
+		return nil, fmt.Errorf("%w: missing physical address", ErrInvalidStageObject)
+	}
+	addressURL, err := url.Parse(physicalAddress)
+	if err != nil {
+		return nil, fmt.Errorf("%w: physical address %s: %s", ErrInvalidStageObject, physicalAddress, err)
+	}
+	if _, err := block.GetStorageType(addressURL); err != nil {
+		return nil, fmt.Errorf("%w: physical address %s: unsupported scheme %q", ErrInvalidStageObject, physicalAddress, addressURL.Scheme)
+	}
+	if addressURL.Host == "" {
+		return nil, fmt.Errorf("%w: physical address %s: missing bucket", ErrInvalidStageObject, physicalAddress)
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("%w: negative size %d", ErrInvalidStageObject, size)
+	}
+	if checksum == "" {
+		return nil, fmt.Errorf("%w: missing checksum", ErrInvalidStageObject)
+	}
+	obj := &api.ObjectStageCreation{
+		Checksum:        checksum,
+		PhysicalAddress: physicalAddress,
+		SizeBytes:       size,
+		ContentType:     contentTypePtr(contentType),
+	}
+	if mtime != 0 {
+		obj.Mtime = &mtime
+	}
+	if len(meta) > 0 {
+		obj.Metadata = &api.ObjectStageCreation_Metadata{AdditionalProperties: meta}
+	}
+	return obj, nil
+}
+
 var fsStageCmd = &cobra.Command{
-	Use:    "stage <path uri>",
-	Short:  "stages a reference to an existing object, to be managed in lakeFS",
-	Hidden: true,
-	Args:   cobra.ExactArgs(1),
+	Use:   "stage <path uri> --physical-address <object store URI> --size <bytes> --checksum <etag>",
+	Short: "stages a reference to an existing object, to be managed in lakeFS",
+	Long: `Stages a reference to an existing object on the underlying storage at a path on a branch,
+without copying its data.  This is the single object form of 'ingest --manifest'.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		client := getClient()
 		pathURI := MustParsePathURI("path", args[0])
-		size, _ := cmd.Flags().GetInt64("size")
-		mtimeSeconds, _ := cmd.Flags().GetInt64("mtime")
-		location, _ := cmd.Flags().GetString("location")
-		checksum, _ := cmd.Flags().GetString("checksum")
-		meta, metaErr := getKV(cmd, "meta")
-
-		var mtime *int64
-		if mtimeSeconds != 0 {
-			mtime = &mtimeSeconds
-		}
-
-		obj := api.ObjectStageCreation{
-			Checksum:        checksum,
-			Mtime:           mtime,
-			PhysicalAddress: location,
-			SizeBytes:       size,
+		size := MustInt64(cmd.Flags().GetInt64("size"))
+		mtime := MustInt64(cmd.Flags().GetInt64("mtime"))
+		physicalAddress := MustString(cmd.Flags().GetString("physical-address"))
+		if location := MustString(cmd.Flags().GetString("location")); physicalAddress == "" {
+			physicalAddress = location
 		}
-		if metaErr == nil {
-			metadata := api.ObjectStageCreation_Metadata{
-				AdditionalProperties: meta,
-			}
-			obj.Metadata = &metadata
+		checksum := MustString(cmd.Flags().GetString("checksum"))
+		contentType := MustString(cmd.Flags().GetString("content-type"))
+		noContentType := MustBool(cmd.Flags().GetBool("no-content-type"))
+		meta, err := getKV(cmd, "meta")
+		if err != nil {
+			DieErr(err)
 		}
 
+		obj, err := stageObjectCreation(physicalAddress, size, checksum, inferContentType(*pathURI.Path, contentType, !noContentType), mtime, meta)
+		if err != nil {
+			DieErr(err)
+		}
+		client := getClient()
 		resp, err := client.StageObjectWithResponse(cmd.Context(), pathURI.Repository, pathURI.Ref, &api.StageObjectParams{
 			Path: *pathURI.Path,
-		}, api.StageObjectJSONRequestBody(obj))
+		}, api.StageObjectJSONRequestBody(*obj))
 		DieOnResponseError(resp, err)
 
 		Write(fsStatTemplate, resp.JSON201)
 	},
 }
 
+var fsPresignCmd = &cobra.Command{
+	Use:   "presign <path uri>",
+	Short: "get a pre-signed URL to access an object directly on the underlying storage",
+	Long:  "get a pre-signed URL to download an object, or with --upload to upload a new object that can then be added using 'fs stage', directly on the underlying storage",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pathURI := MustParsePathURI("path", args[0])
+		expiry := MustDuration(cmd.Flags().GetDuration("expiry"))
+		upload := MustBool(cmd.Flags().GetBool("upload"))
+		preSigned, err := presignObject(cmd.Context(), getClient(), pathURI, expiry, upload)
+		if err != nil {
+			DieErr(err)
+		}
+		Write(fsPresignTemplate, struct {
+			*api.PreSignedURL
+			Upload bool
+		}{preSigned, upload})
+	},
+}
+
+// presignObject requests a pre-signed URL for the object at pathURI, returning
+// ErrPresignNotSupported if the server blockstore cannot pre-sign URLs.
+func presignObject(ctx context.Context, client api.ClientWithResponsesInterface, pathURI *uri.URI, expiry time.Duration, upload bool) (*api.PreSignedURL, error) {
+	expirySeconds := int(expiry.Seconds())
+	resp, err := client.PresignObjectWithResponse(ctx, pathURI.Repository, pathURI.Ref, &api.PresignObjectParams{
+		Path:   *pathURI.Path,
+		Expiry: &expirySeconds,
+		Upload: &upload,
+	})
+	if err == nil && resp.StatusCode() == http.StatusNotImplemented {
+		return nil, ErrPresignNotSupported
+	}
+	if err = responseError(resp, err); err != nil {
+		return nil, err
+	}
+	return resp.JSON200, nil
+}
+
+const fsLogTemplate = `{{ range $val := .Changes }}
+ID:            {{ $val.Commit.Id|yellow }}{{ if $val.Commit.Committer }}
+Author:        {{ $val.Commit.Committer }}{{ end }}
+Date:          {{ $val.Commit.CreationDate|date }}
+Change:        {{ $val.Type }}
+
+	{{ $val.Commit.Message }}
+{{ end }}{{ if .Pagination }}
+{{ .Pagination|paginate }}{{ end }}`
+
+// objectChange is a commit that changed an object.
+type objectChange struct {
+	Commit api.Commit
+	Type   string
+}
+
+var fsLogCmd = &cobra.Command{
+	Use:   "log <path uri>",
+	Short: "show the commits that changed an object",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		amount := MustInt(cmd.Flags().GetInt("amount"))
+		after := MustString(cmd.Flags().GetString("after"))
+		pathURI := MustParsePathURI("path", args[0])
+		changes, next := objectHistory(cmd.Context(), getClient(), pathURI, after, amount)
+		var pagination *Pagination
+		if next != "" {
+			pagination = &Pagination{Amount: amount, HasNext: true, After: next}
+		}
+		Write(fsLogTemplate, struct {
+			Changes    []objectChange
+			Pagination *Pagination
+		}{changes, pagination})
+	},
+}
+
+// objectHistory returns up to amount commits that changed the object at pathURI, following the
+// log of its ref after commit after.  If more commits may follow it also returns the commit
+// to continue after.
+func objectHistory(ctx context.Context, client api.ClientWithResponsesInterface, pathURI *uri.URI, after string, amount int) ([]objectChange, string) {
+	var changes []objectChange
+	for {
+		resp, err := client.LogCommitsWithResponse(ctx, pathURI.Repository, pathURI.Ref, &api.LogCommitsParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+		})
+		DieOnResponseError(resp, err)
+		for _, commit := range resp.JSON200.Results {
+			if changeType := objectChangeType(ctx, client, pathURI, commit); changeType != "" {
+				changes = append(changes, objectChange{Commit: commit, Type: changeType})
+			}
+			after = commit.Id
+			if amount > 0 && len(changes) == amount {
+				return changes, after
+			}
+		}
+		if !resp.JSON200.Pagination.HasMore {
+			return changes, ""
+		}
+	}
+}
+
+// objectChangeType returns how commit changed the object at pathURI relative to its first
+// parent: "added", "modified", "deleted", or "" if it did not change it.
+func objectChangeType(ctx context.Context, client api.ClientWithResponsesInterface, pathURI *uri.URI, commit api.Commit) string {
+	if len(commit.Parents) == 0 {
+		resp, err := client.StatObjectWithResponse(ctx, pathURI.Repository, commit.Id, &api.StatObjectParams{Path: *pathURI.Path})
+		if err == nil && resp.StatusCode() == http.StatusNotFound {
+			return ""
+		}
+		DieOnResponseError(resp, err)
+		return "added"
+	}
+	prefix := api.PaginationPrefix(*pathURI.Path)
+	var after string
+	for {
+		resp, err := client.DiffRefsWithResponse(ctx, pathURI.Repository, commit.Parents[0], commit.Id, &api.DiffRefsParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+			Prefix: &prefix,
+		})
+		DieOnResponseError(resp, err)
+		for _, d := range resp.JSON200.Results {
+			if d.Path != *pathURI.Path {
+				continue
+			}
+			switch d.Type {
+			case "changed":
+				return "modified"
+			case "removed":
+				return "deleted"
+			default:
+				return d.Type
+			}
+		}
+		if !resp.JSON200.Pagination.HasMore {
+			return ""
+		}
+		after = resp.JSON200.Pagination.NextOffset
+	}
+}
+
 var fsRmCmd = &cobra.Command{
 	Use:   "rm <path uri>",
 	Short: "delete object",
+	Long:  "delete object, or with --recursive all objects under a prefix",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		recursive, _ := cmd.Flags().GetBool("recursive")
 		pathURI := MustParsePathURI("path", args[0])
 		client := getClient()
-		resp, err := client.DeleteObjectWithResponse(cmd.Context(), pathURI.Repository, pathURI.Ref, &api.DeleteObjectParams{
-			Path: *pathURI.Path,
+		if !recursive {
+			resp, err := client.DeleteObjectWithResponse(cmd.Context(), pathURI.Repository, pathURI.Ref, &api.DeleteObjectParams{
+				Path: *pathURI.Path,
+			})
+			DieOnResponseError(resp, err)
+			return
+		}
+
+		prefix := *pathURI.Path
+		if prefix != "" && !strings.HasSuffix(prefix, uri.PathSeparator) {
+			prefix += uri.PathSeparator
+		}
+		confirmation, err := Confirm(cmd.Flags(), fmt.Sprintf("Delete all objects under %s", pathURI.String()))
+		if err != nil || !confirmation {
+			Die("Delete aborted", 1)
+		}
+		deleted, failed := deletePrefix(cmd.Context(), client, pathURI.Repository, pathURI.Ref, prefix)
+		Fmt("Deleted %d objects\n", deleted)
+		if len(failed) > 0 {
+			for _, objectErr := range failed {
+				_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", api.StringValue(objectErr.Path), objectErr.Message)
+			}
+			DieFmt("Failed to delete %d objects", len(failed))
+		}
+	},
+}
+
+// deletePrefix deletes all objects under prefix on branch, one page of the listing at a time.
+// It returns the number of objects deleted and the errors of objects that failed to delete.
+func deletePrefix(ctx context.Context, client api.ClientWithResponsesInterface, repository, branch, prefix string) (int, []api.ObjectError) {
+	var (
+		deleted int
+		failed  []api.ObjectError
+		after   string
+	)
+	pfx := api.PaginationPrefix(prefix)
+	for {
+		resp, err := client.ListObjectsWithResponse(ctx, repository, branch, &api.ListObjectsParams{
+			Prefix: &pfx,
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
 		})
 		DieOnResponseError(resp, err)
-	},
+
+		paths := make([]string, 0, len(resp.JSON200.Results))
+		for _, obj := range resp.JSON200.Results {
+			paths = append(paths, obj.Path)
+		}
+		if len(paths) > 0 {
+			delResp, err := client.DeleteObjectsWithResponse(ctx, repository, branch, api.DeleteObjectsJSONRequestBody{Paths: paths})
+			DieOnResponseError(delResp, err)
+			deleted += len(paths) - len(delResp.JSON200.Errors)
+			failed = append(failed, delResp.JSON200.Errors...)
+		}
+
+		pagination := resp.JSON200.Pagination
+		if !pagination.HasMore {
+			return deleted, failed
+		}
+		after = pagination.NextOffset
+	}
 }
 
 // fsCmd represents the fs command
@@ -301,22 +601,38 @@ func init() {
 	fsCmd.AddCommand(fsUploadCmd)
 	fsCmd.AddCommand(fsStageCmd)
 	fsCmd.AddCommand(fsRmCmd)
+	fsCmd.AddCommand(fsPresignCmd)
+	fsCmd.AddCommand(fsLogCmd)
 
 	fsCatCmd.Flags().BoolP("direct", "d", false, "read directly from backing store (faster but requires more credentials)")
 
 	fsUploadCmd.Flags().StringP("source", "s", "", "local file to upload, or \"-\" for stdin")
 	fsUploadCmd.Flags().BoolP("recursive", "r", false, "recursively copy all files under local source")
 	fsUploadCmd.Flags().BoolP("direct", "d", false, "write directly to backing store (faster but requires more credentials)")
+	fsUploadCmd.Flags().String("content-type", "", "content-type to set on uploaded objects, instead of inferring it from file extensions")
+	fsUploadCmd.Flags().Bool("no-content-type", false, "do not infer content-types from file extensions")
 	_ = fsUploadCmd.MarkFlagRequired("source")
 
+	fsRmCmd.Flags().BoolP("recursive", "r", false, "recursively delete all objects under the specified path")
+	AssignAutoConfirmFlag(fsRmCmd.Flags())
+
+	fsStageCmd.Flags().String("physical-address", "", "fully qualified storage location (i.e. \"s3://bucket/path/to/object\")")
 	fsStageCmd.Flags().String("location", "", "fully qualified storage location (i.e. \"s3://bucket/path/to/object\")")
+	_ = fsStageCmd.Flags().MarkDeprecated("location", "use --physical-address")
 	fsStageCmd.Flags().Int64("size", 0, "Object size in bytes")
-	fsStageCmd.Flags().String("checksum", "", "Object MD5 checksum as a hexadecimal string")
+	fsStageCmd.Flags().String("checksum", "", "Object ETag, e.g. its MD5 checksum as a hexadecimal string")
 	fsStageCmd.Flags().Int64("mtime", 0, "Object modified time (Unix Epoch in seconds). Defaults to current time")
 	fsStageCmd.Flags().StringSlice("meta", []string{}, "key value pairs in the form of key=value")
-	_ = fsStageCmd.MarkFlagRequired("location")
+	fsStageCmd.Flags().String("content-type", "", "content-type to set on the object, instead of inferring it from its path")
+	fsStageCmd.Flags().Bool("no-content-type", false, "do not infer the content-type from the path extension")
 	_ = fsStageCmd.MarkFlagRequired("size")
 	_ = fsStageCmd.MarkFlagRequired("checksum")
 
 	fsListCmd.Flags().Bool("recursive", false, "list all objects under the specified prefix")
+
+	fsLogCmd.Flags().Int("amount", defaultAmountArgumentValue, "number of results to return, or 0 for all results")
+	fsLogCmd.Flags().String("after", "", "show results after this commit ID (used for pagination)")
+
+	fsPresignCmd.Flags().Duration("expiry", time.Hour, "how long the URL remains valid")
+	fsPresignCmd.Flags().Bool("upload", false, "get a URL for uploading a new object instead of downloading the object")
 }
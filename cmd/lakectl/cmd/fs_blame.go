@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/text"
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
+)
+
+const (
+	defaultBlameMaxSize = 1024 * 1024
+	defaultBlameDepth   = 100
+
+	blameCommitIDLength = 10
+)
+
+var ErrObjectTooLarge = errors.New("object too large")
+
+// blameLine is a line of an object along with the commit that last introduced or changed it.
+type blameLine struct {
+	Commit api.Commit
+	Text   string
+}
+
+var fsBlameCmd = &cobra.Command{
+	Use:   "blame <path uri>",
+	Short: "show the commit that last changed each line of a text object",
+	Long:  "show, for each line of a text object, the commit that last introduced or changed it, by diffing consecutive versions of the object",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pathURI := MustParsePathURI("path", args[0])
+		maxSize := MustInt64(cmd.Flags().GetInt64("max-size"))
+		depth := MustInt(cmd.Flags().GetInt("depth"))
+		lines, err := blameObject(cmd.Context(), getClient(), pathURI, maxSize, depth)
+		if err != nil {
+			DieErr(err)
+		}
+		for i, line := range lines {
+			id := line.Commit.Id
+			if len(id) > blameCommitIDLength {
+				id = id[:blameCommitIDLength]
+			}
+			Fmt("%s (%s %s %4d) %s\n", text.FgYellow.Sprint(id), line.Commit.Committer,
+				time.Unix(line.Commit.CreationDate, 0).Format("2006-01-02 15:04:05"), i+1, line.Text)
+		}
+	},
+}
+
+// blameObject attributes each line of the object at pathURI to the commit that last introduced
+// or changed it, examining up to depth versions of the object.  Lines older than the oldest
+// examined version are attributed to that version.
+func blameObject(ctx context.Context, client api.ClientWithResponsesInterface, pathURI *uri.URI, maxSize int64, depth int) ([]blameLine, error) {
+	statResp, err := client.StatObjectWithResponse(ctx, pathURI.Repository, pathURI.Ref, &api.StatObjectParams{Path: *pathURI.Path})
+	if err := responseError(statResp, err); err != nil {
+		return nil, err
+	}
+	if size := api.Int64Value(statResp.JSON200.SizeBytes); size > maxSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes, over --max-size %d", ErrObjectTooLarge, *pathURI.Path, size, maxSize)
+	}
+
+	changes, _ := objectHistory(ctx, client, pathURI, "", depth)
+	// versions are the versions of the object since it was last created, newest first
+	var versions []objectChange
+	for _, change := range changes {
+		if change.Type == "deleted" {
+			break
+		}
+		versions = append(versions, change)
+		if change.Type == "added" {
+			break
+		}
+	}
+	if len(versions) == 0 {
+		return nil, nil
+	}
+
+	lines, err := getObjectLines(ctx, client, pathURI.Repository, versions[0].Commit.Id, *pathURI.Path)
+	if err != nil {
+		return nil, err
+	}
+	blamed := make([]blameLine, len(lines))
+	// pending holds the lines not yet attributed along with their index in blamed
+	pending := make([]pendingLine, len(lines))
+	for i, l := range lines {
+		blamed[i].Text = l
+		pending[i] = pendingLine{index: i, text: l}
+	}
+	last := 0
+	for i := 1; i < len(versions) && len(pending) > 0; i++ {
+		older, err := getObjectLines(ctx, client, pathURI.Repository, versions[i].Commit.Id, *pathURI.Path)
+		if err != nil {
+			return nil, err
+		}
+		// lines missing from the older version were introduced by the newer one
+		pending = carryLines(pending, older, func(p pendingLine) {
+			blamed[p.index].Commit = versions[i-1].Commit
+		})
+		last = i
+	}
+	// the remaining lines are at least as old as the oldest version examined
+	for _, p := range pending {
+		blamed[p.index].Commit = versions[last].Commit
+	}
+	return blamed, nil
+}
+
+type pendingLine struct {
+	index int
+	text  string
+}
+
+// carryLines matches pending lines with older lines using their longest common subsequence.
+// It calls introduced for every pending line with no match and returns the matched lines,
+// tracking their positions in older.
+func carryLines(pending []pendingLine, older []string, introduced func(pendingLine)) []pendingLine {
+	// lcs[i][j] is the length of the longest common subsequence of pending[i:] and older[j:]
+	lcs := make([][]int, len(pending)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(older)+1)
+	}
+	for i := len(pending) - 1; i >= 0; i-- {
+		for j := len(older) - 1; j >= 0; j-- {
+			switch {
+			case pending[i].text == older[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var carried []pendingLine
+	i, j := 0, 0
+	for i < len(pending) {
+		switch {
+		case j < len(older) && pending[i].text == older[j]:
+			carried = append(carried, pending[i])
+			i++
+			j++
+		case j < len(older) && lcs[i][j+1] > lcs[i+1][j]:
+			j++
+		default:
+			introduced(pending[i])
+			i++
+		}
+	}
+	return carried
+}
+
+// getObjectLines returns the lines of the object at path on ref.
+func getObjectLines(ctx context.Context, client api.ClientWithResponsesInterface, repository, ref, path string) ([]string, error) {
+	resp, err := client.GetObjectWithResponse(ctx, repository, ref, &api.GetObjectParams{Path: path})
+	if err := responseError(resp, err); err != nil {
+		return nil, err
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(resp.Body))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+//nolint:gochecknoinits
+func init() {
+	fsCmd.AddCommand(fsBlameCmd)
+	fsBlameCmd.Flags().Int64("max-size", defaultBlameMaxSize, "largest object size to blame, in bytes")
+	fsBlameCmd.Flags().Int("depth", defaultBlameDepth, "maximal number of object versions to examine")
+}
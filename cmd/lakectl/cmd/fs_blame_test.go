@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
+)
+
+// blameHandler serves a text object "data" added in commit c1 and modified in commit c2.
+func blameHandler() http.Handler {
+	versions := map[string]string{
+		"main": "alpha\nBETA\ngamma\ndelta\n",
+		"c2":   "alpha\nBETA\ngamma\ndelta\n",
+		"c1":   "alpha\nbeta\ngamma\n",
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/repositories/repo/refs/"), "/", 2)
+		ref, rest := parts[0], parts[1]
+		switch rest {
+		case "commits":
+			writeJSON(w, http.StatusOK, api.CommitList{Results: []api.Commit{
+				{Id: "c2", Committer: "jane", Message: "update data", Parents: []string{"c1"}},
+				{Id: "c1", Committer: "john", Message: "add data"},
+			}})
+		case "diff/c2":
+			writeJSON(w, http.StatusOK, api.DiffList{Results: []api.Diff{{Path: "data", PathType: "object", Type: "changed"}}})
+		case "objects/stat":
+			size := int64(len(versions[ref]))
+			writeJSON(w, http.StatusOK, api.ObjectStats{Path: "data", SizeBytes: &size})
+		case "objects":
+			_, _ = w.Write([]byte(versions[ref]))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	return mux
+}
+
+func TestFsBlame(t *testing.T) {
+	out := runCmd(t, blameHandler(), "fs", "blame", "lakefs://repo/main/data")
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	expected := []struct {
+		commit string
+		text   string
+	}{
+		{"c1", "alpha"},
+		{"c2", "BETA"},
+		{"c1", "gamma"},
+		{"c2", "delta"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("blame output %q has %d lines, expected %d", out, len(lines), len(expected))
+	}
+	for i, e := range expected {
+		if !strings.Contains(lines[i], e.commit) || !strings.HasSuffix(lines[i], ") "+e.text) {
+			t.Errorf("blame line %d = %q, expected %s attributed to %s", i+1, lines[i], e.text, e.commit)
+		}
+	}
+}
+
+func TestBlameObjectDepth(t *testing.T) {
+	client := newTestClient(t, blameHandler())
+	pathURI, err := uri.Parse("lakefs://repo/main/data")
+	if err != nil {
+		t.Fatalf("parse uri: %s", err)
+	}
+
+	lines, err := blameObject(context.Background(), client, pathURI, defaultBlameMaxSize, 1)
+	if err != nil {
+		t.Fatalf("blameObject: %s", err)
+	}
+	for _, l := range lines {
+		if l.Commit.Id != "c2" {
+			t.Errorf("line %q attributed to %s, expected the only examined version c2", l.Text, l.Commit.Id)
+		}
+	}
+}
+
+func TestBlameObjectMaxSize(t *testing.T) {
+	client := newTestClient(t, blameHandler())
+	pathURI, err := uri.Parse("lakefs://repo/main/data")
+	if err != nil {
+		t.Fatalf("parse uri: %s", err)
+	}
+
+	_, err = blameObject(context.Background(), client, pathURI, 4, defaultBlameDepth)
+	if !errors.Is(err, ErrObjectTooLarge) {
+		t.Errorf("blameObject() over max size returned %v, expected %s", err, ErrObjectTooLarge)
+	}
+}
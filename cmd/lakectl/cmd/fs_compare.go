@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/text"
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
+)
+
+const (
+	fsCompareCmdArgs = 2
+
+	compareIdentical = "identical"
+	compareDifferent = "different"
+	compareOnlyInA   = "only-in-a"
+	compareOnlyInB   = "only-in-b"
+)
+
+// objectComparison compares an object on two paths.  A is nil if the object is missing from
+// the first path, and B if it is missing from the second.
+type objectComparison struct {
+	Path   string
+	A, B   *api.ObjectStats
+	Result string
+}
+
+func compareObjectStats(path string, a, b *api.ObjectStats) objectComparison {
+	c := objectComparison{Path: path, A: a, B: b}
+	switch {
+	case b == nil:
+		c.Result = compareOnlyInA
+	case a == nil:
+		c.Result = compareOnlyInB
+	case api.Int64Value(a.SizeBytes) != api.Int64Value(b.SizeBytes) || a.Checksum != b.Checksum:
+		c.Result = compareDifferent
+	default:
+		c.Result = compareIdentical
+	}
+	return c
+}
+
+// String describes how the object differs between the paths, or that it is identical.
+func (c objectComparison) String() string {
+	switch c.Result {
+	case compareDifferent:
+		return text.FgRed.Sprintf("different: %s (size %d, checksum %s != size %d, checksum %s)", c.Path,
+			api.Int64Value(c.A.SizeBytes), c.A.Checksum, api.Int64Value(c.B.SizeBytes), c.B.Checksum)
+	case compareOnlyInA:
+		return text.FgYellow.Sprintf("only in first: %s", c.Path)
+	case compareOnlyInB:
+		return text.FgYellow.Sprintf("only in second: %s", c.Path)
+	default:
+		return text.FgGreen.Sprintf("identical: %s", c.Path)
+	}
+}
+
+var fsCompareCmd = &cobra.Command{
+	Use:   "compare <path uri> <path uri>",
+	Short: "compare objects by size and checksum",
+	Long: `compare two objects, possibly in different repositories, by size and checksum, e.g. to verify a migration copied them faithfully.
+
+With --recursive, compare all objects under two prefixes, listing those that differ or exist under only one of them.  Objects with the same contents may have different checksums if they were uploaded differently, e.g. in multipart uploads with different part sizes.  Exits with an error if any object differs.`,
+	Example: "lakectl fs compare --recursive lakefs://old-repo/main/tables/ lakefs://new-repo/main/tables/",
+	Args:    cobra.ExactArgs(fsCompareCmdArgs),
+	Run: func(cmd *cobra.Command, args []string) {
+		a := MustParsePathURI("first path", args[0])
+		b := MustParsePathURI("second path", args[1])
+		client := getClient()
+		if !MustBool(cmd.Flags().GetBool("recursive")) {
+			c, err := compareObjects(cmd.Context(), client, a, b)
+			if err != nil {
+				DieErr(err)
+			}
+			Fmt("%s\n", c)
+			if c.Result != compareIdentical {
+				DieFmt("%s and %s differ", a, b)
+			}
+			return
+		}
+
+		comparisons, err := comparePrefixes(cmd.Context(), client, a, b)
+		if err != nil {
+			DieErr(err)
+		}
+		counts := make(map[string]int)
+		for _, c := range comparisons {
+			counts[c.Result]++
+			if c.Result != compareIdentical {
+				Fmt("%s\n", c)
+			}
+		}
+		Fmt("Compared %d objects: %d identical, %d different, %d only in first, %d only in second\n",
+			len(comparisons), counts[compareIdentical], counts[compareDifferent], counts[compareOnlyInA], counts[compareOnlyInB])
+		if mismatches := len(comparisons) - counts[compareIdentical]; mismatches > 0 {
+			DieFmt("%d objects differ between %s and %s", mismatches, a, b)
+		}
+	},
+}
+
+// compareObjects compares the objects at a and b.
+func compareObjects(ctx context.Context, client api.ClientWithResponsesInterface, a, b *uri.URI) (objectComparison, error) {
+	uris := [fsCompareCmdArgs]*uri.URI{a, b}
+	var stats [fsCompareCmdArgs]*api.ObjectStats
+	for i, u := range uris {
+		resp, err := client.StatObjectWithResponse(ctx, u.Repository, u.Ref, &api.StatObjectParams{Path: *u.Path})
+		if err := responseError(resp, err); err != nil {
+			return objectComparison{}, fmt.Errorf("%s: %w", u, err)
+		}
+		stats[i] = resp.JSON200
+	}
+	return compareObjectStats(*a.Path, stats[0], stats[1]), nil
+}
+
+// comparePrefixes compares all objects under a with those under b, matching them by their path
+// relative to their prefix.  It returns the comparisons ordered by relative path.
+func comparePrefixes(ctx context.Context, client api.ClientWithResponsesInterface, a, b *uri.URI) ([]objectComparison, error) {
+	objectsA, err := listPrefixObjects(ctx, client, a)
+	if err != nil {
+		return nil, err
+	}
+	objectsB, err := listPrefixObjects(ctx, client, b)
+	if err != nil {
+		return nil, err
+	}
+	comparisons := make([]objectComparison, 0, len(objectsA))
+	for path, statsA := range objectsA {
+		comparisons = append(comparisons, compareObjectStats(path, statsA, objectsB[path]))
+	}
+	for path, statsB := range objectsB {
+		if _, ok := objectsA[path]; !ok {
+			comparisons = append(comparisons, compareObjectStats(path, nil, statsB))
+		}
+	}
+	sort.Slice(comparisons, func(i, j int) bool { return comparisons[i].Path < comparisons[j].Path })
+	return comparisons, nil
+}
+
+// listPrefixObjects returns the stats of all objects under the prefix of u, keyed by their
+// path relative to the prefix.
+func listPrefixObjects(ctx context.Context, client api.ClientWithResponsesInterface, u *uri.URI) (map[string]*api.ObjectStats, error) {
+	prefix := api.StringValue(u.Path)
+	if prefix != "" && !strings.HasSuffix(prefix, uri.PathSeparator) {
+		prefix += uri.PathSeparator
+	}
+	pfx := api.PaginationPrefix(prefix)
+	objects := make(map[string]*api.ObjectStats)
+	var after string
+	for {
+		resp, err := client.ListObjectsWithResponse(ctx, u.Repository, u.Ref, &api.ListObjectsParams{
+			Prefix: &pfx,
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+		})
+		if err := responseError(resp, err); err != nil {
+			return nil, fmt.Errorf("%s: %w", u, err)
+		}
+		for i := range resp.JSON200.Results {
+			obj := &resp.JSON200.Results[i]
+			objects[strings.TrimPrefix(obj.Path, prefix)] = obj
+		}
+		if !resp.JSON200.Pagination.HasMore {
+			return objects, nil
+		}
+		after = resp.JSON200.Pagination.NextOffset
+	}
+}
+
+//nolint:gochecknoinits
+func init() {
+	fsCmd.AddCommand(fsCompareCmd)
+	fsCompareCmd.Flags().BoolP("recursive", "r", false, "compare all objects under the prefixes of the paths")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
+)
+
+// compareHandler serves the objects of branch "main" of each repository, keyed by repository and
+// path.
+func compareHandler(repositories map[string][]api.ObjectStats) http.Handler {
+	mux := http.NewServeMux()
+	for repository, objects := range repositories {
+		objects := objects
+		sort.Slice(objects, func(i, j int) bool { return objects[i].Path < objects[j].Path })
+		mux.HandleFunc("/repositories/"+repository+"/refs/main/objects/stat", func(w http.ResponseWriter, r *http.Request) {
+			for _, obj := range objects {
+				if obj.Path == r.URL.Query().Get("path") {
+					writeJSON(w, http.StatusOK, obj)
+					return
+				}
+			}
+			writeJSON(w, http.StatusNotFound, api.Error{Message: "not found"})
+		})
+		mux.HandleFunc("/repositories/"+repository+"/refs/main/objects/ls", func(w http.ResponseWriter, r *http.Request) {
+			results := make([]api.ObjectStats, 0)
+			for _, obj := range objects {
+				if strings.HasPrefix(obj.Path, r.URL.Query().Get("prefix")) {
+					results = append(results, obj)
+				}
+			}
+			writeJSON(w, http.StatusOK, api.ObjectStatsList{Results: results})
+		})
+	}
+	return mux
+}
+
+func compareObject(path string, size int64, checksum string) api.ObjectStats {
+	return api.ObjectStats{Path: path, PathType: "object", SizeBytes: &size, Checksum: checksum}
+}
+
+func compareRepositories() map[string][]api.ObjectStats {
+	return map[string][]api.ObjectStats{
+		"old": {
+			compareObject("tables/a", 10, "aaa"),
+			compareObject("tables/b", 20, "bbb"),
+			compareObject("tables/sub/c", 30, "ccc"),
+			compareObject("tables/removed", 40, "ddd"),
+		},
+		"new": {
+			compareObject("copy/a", 10, "aaa"),
+			compareObject("copy/b", 20, "BBB"),
+			compareObject("copy/sub/c", 30, "ccc"),
+			compareObject("copy/added", 50, "eee"),
+		},
+	}
+}
+
+func TestFsCompareIdentical(t *testing.T) {
+	handler := compareHandler(compareRepositories())
+	out := runCmd(t, handler, "fs", "compare", "lakefs://old/main/tables/a", "lakefs://new/main/copy/a")
+	if !strings.Contains(out, "identical: tables/a") {
+		t.Errorf("output %q does not report identical objects", out)
+	}
+
+	out = runCmd(t, handler, "fs", "compare", "--recursive", "lakefs://old/main/tables/sub", "lakefs://new/main/copy/sub/")
+	if !strings.Contains(out, "Compared 1 objects: 1 identical, 0 different") {
+		t.Errorf("output %q does not report identical prefixes", out)
+	}
+}
+
+func TestCompareObjects(t *testing.T) {
+	client := newTestClient(t, compareHandler(compareRepositories()))
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{a: "lakefs://old/main/tables/a", b: "lakefs://new/main/copy/a", want: compareIdentical},
+		{a: "lakefs://old/main/tables/b", b: "lakefs://new/main/copy/b", want: compareDifferent},
+		{a: "lakefs://old/main/tables/a", b: "lakefs://new/main/copy/b", want: compareDifferent},
+	}
+	for _, tt := range tests {
+		c, err := compareObjects(context.Background(), client, uri.Must(uri.Parse(tt.a)), uri.Must(uri.Parse(tt.b)))
+		if err != nil {
+			t.Fatalf("compare %s to %s: %s", tt.a, tt.b, err)
+		}
+		if c.Result != tt.want {
+			t.Errorf("compare %s to %s = %s, expected %s", tt.a, tt.b, c.Result, tt.want)
+		}
+	}
+
+	if _, err := compareObjects(context.Background(), client, uri.Must(uri.Parse("lakefs://old/main/tables/a")), uri.Must(uri.Parse("lakefs://new/main/missing"))); err == nil {
+		t.Error("compare to a missing object succeeded")
+	}
+}
+
+func TestComparePrefixes(t *testing.T) {
+	client := newTestClient(t, compareHandler(compareRepositories()))
+	comparisons, err := comparePrefixes(context.Background(), client, uri.Must(uri.Parse("lakefs://old/main/tables")), uri.Must(uri.Parse("lakefs://new/main/copy/")))
+	if err != nil {
+		t.Fatalf("compare prefixes: %s", err)
+	}
+	results := make(map[string]string, len(comparisons))
+	paths := make([]string, 0, len(comparisons))
+	for _, c := range comparisons {
+		results[c.Path] = c.Result
+		paths = append(paths, c.Path)
+	}
+	expected := map[string]string{
+		"a":       compareIdentical,
+		"added":   compareOnlyInB,
+		"b":       compareDifferent,
+		"removed": compareOnlyInA,
+		"sub/c":   compareIdentical,
+	}
+	if diff := deep.Equal(results, expected); diff != nil {
+		t.Errorf("unexpected comparisons: %s", diff)
+	}
+	if !sort.StringsAreSorted(paths) {
+		t.Errorf("comparisons not ordered by path: %v", paths)
+	}
+	if s := comparisons[2].String(); !strings.Contains(s, "size 20, checksum bbb != size 20, checksum BBB") {
+		t.Errorf("difference %q does not show both checksums", s)
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+var fsMetaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "manage object user metadata",
+}
+
+var fsMetaGetCmd = &cobra.Command{
+	Use:   "get <path uri>",
+	Short: "show the user metadata of an object",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pathURI := MustParsePathURI("path", args[0])
+		format := MustOutputFormat(cmd.Flags())
+		client := getClient()
+		res, err := client.StatObjectWithResponse(cmd.Context(), pathURI.Repository, pathURI.Ref, &api.StatObjectParams{
+			Path: *pathURI.Path,
+		})
+		DieOnResponseError(res, err)
+		printUserMetadata(res.JSON200.Metadata, format)
+	},
+}
+
+var fsMetaSetCmd = &cobra.Command{
+	Use:   "set <path uri> [key=value...]",
+	Short: "set and remove user metadata of an object, without rewriting its content",
+	Long:  "set user metadata keys of an object to values.  An empty value, as in \"key=\", removes the key.",
+	Example: `lakectl fs meta set lakefs://example-repo/main/data/file.csv owner=data-team stage=raw
+lakectl fs meta set lakefs://example-repo/main/data/file.csv stage=
+lakectl fs meta set lakefs://example-repo/main/data/file.csv --remove stage`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pathURI := MustParsePathURI("path", args[0])
+		format := MustOutputFormat(cmd.Flags())
+		remove, err := cmd.Flags().GetStringSlice("remove")
+		if err != nil {
+			DieErr(err)
+		}
+		update, err := userMetadataUpdate(args[1:], remove)
+		if err != nil {
+			DieErr(err)
+		}
+		client := getClient()
+		res, err := client.UpdateObjectUserMetadataWithResponse(cmd.Context(), pathURI.Repository, pathURI.Ref, &api.UpdateObjectUserMetadataParams{
+			Path: *pathURI.Path,
+		}, api.UpdateObjectUserMetadataJSONRequestBody(update))
+		DieOnResponseError(res, err)
+		printUserMetadata(res.JSON200.Metadata, format)
+	},
+}
+
+// userMetadataUpdate returns the update setting keys to values in pairs of the form key=value,
+// and removing keys with empty values and keys in remove.
+func userMetadataUpdate(pairs []string, remove []string) (api.ObjectUserMetadataUpdate, error) {
+	const keyValueParts = 2
+	set := make(map[string]string)
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", keyValueParts)
+		if len(parts) != keyValueParts || parts[0] == "" {
+			return api.ObjectUserMetadataUpdate{}, fmt.Errorf("%w: %q", errInvalidKeyValueFormat, pair)
+		}
+		if parts[1] == "" {
+			remove = append(remove, parts[0])
+		} else {
+			set[parts[0]] = parts[1]
+		}
+	}
+	update := api.ObjectUserMetadataUpdate{}
+	if len(set) > 0 {
+		update.Set = &api.ObjectUserMetadataUpdate_Set{AdditionalProperties: set}
+	}
+	if len(remove) > 0 {
+		update.Remove = &remove
+	}
+	return update, nil
+}
+
+func printUserMetadata(metadata *api.ObjectStats_Metadata, format string) {
+	values := make(map[string]string)
+	if metadata != nil && metadata.AdditionalProperties != nil {
+		values = metadata.AdditionalProperties
+	}
+	if format == OutputFormatJSON {
+		PrintJSON(values)
+		return
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	rows := make([][]interface{}, len(keys))
+	for i, k := range keys {
+		rows[i] = []interface{}{k, values[k]}
+	}
+	PrintTable(rows, []interface{}{"Key", "Value"}, &api.Pagination{}, len(rows))
+}
+
+//nolint:gochecknoinits
+func init() {
+	fsCmd.AddCommand(fsMetaCmd)
+	fsMetaCmd.AddCommand(fsMetaGetCmd)
+	fsMetaCmd.AddCommand(fsMetaSetCmd)
+	AssignOutputFlag(fsMetaGetCmd.Flags())
+	AssignOutputFlag(fsMetaSetCmd.Flags())
+	fsMetaSetCmd.Flags().StringSlice("remove", nil, "user metadata keys to remove")
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+// metadataHandler serves the user metadata of a single object at repo/main/file, and
+// records the updates it gets.
+func metadataHandler(t *testing.T, metadata map[string]string, updates *[]api.ObjectUserMetadataUpdate) http.Handler {
+	mux := http.NewServeMux()
+	stats := func() api.ObjectStats {
+		return api.ObjectStats{Path: "file", PathType: "object", Metadata: &api.ObjectStats_Metadata{AdditionalProperties: metadata}}
+	}
+	mux.HandleFunc("/repositories/repo/refs/main/objects/stat", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, stats())
+	})
+	mux.HandleFunc("/repositories/repo/branches/main/objects/metadata", func(w http.ResponseWriter, r *http.Request) {
+		if path := r.URL.Query().Get("path"); path != "file" {
+			t.Errorf("got update of %q, expected file", path)
+		}
+		var update api.ObjectUserMetadataUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			t.Errorf("decode update: %s", err)
+		}
+		*updates = append(*updates, update)
+		if update.Set != nil {
+			for k, v := range update.Set.AdditionalProperties {
+				metadata[k] = v
+			}
+		}
+		if update.Remove != nil {
+			for _, k := range *update.Remove {
+				delete(metadata, k)
+			}
+		}
+		writeJSON(w, http.StatusOK, stats())
+	})
+	return mux
+}
+
+func TestFsMetaGet(t *testing.T) {
+	var updates []api.ObjectUserMetadataUpdate
+	handler := metadataHandler(t, map[string]string{"owner": "data-team", "stage": "raw"}, &updates)
+
+	out := runCmd(t, handler, "fs", "meta", "get", "lakefs://repo/main/file")
+	ownerAt, stageAt := strings.Index(out, "owner"), strings.Index(out, "stage")
+	if ownerAt < 0 || stageAt < 0 || ownerAt > stageAt || !strings.Contains(out, "data-team") {
+		t.Errorf("output %q does not list the metadata sorted by key", out)
+	}
+
+	out = runCmd(t, handler, "fs", "meta", "get", "lakefs://repo/main/file", "--output", "json")
+	var got map[string]string
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("parse JSON output %q: %s", out, err)
+	}
+	if diffs := deep.Equal(got, map[string]string{"owner": "data-team", "stage": "raw"}); diffs != nil {
+		t.Errorf("unexpected JSON metadata: %s", diffs)
+	}
+	if len(updates) != 0 {
+		t.Errorf("get updated metadata: %+v", updates)
+	}
+}
+
+func TestFsMetaSet(t *testing.T) {
+	var updates []api.ObjectUserMetadataUpdate
+	metadata := map[string]string{"stage": "raw"}
+	handler := metadataHandler(t, metadata, &updates)
+
+	runCmd(t, handler, "fs", "meta", "set", "lakefs://repo/main/file", "owner=data-team", "stage=clean")
+	if diffs := deep.Equal(metadata, map[string]string{"owner": "data-team", "stage": "clean"}); diffs != nil {
+		t.Errorf("unexpected metadata after set: %s", diffs)
+	}
+	if len(updates) != 1 || updates[0].Remove != nil {
+		t.Errorf("set sent updates %+v, expected a single update setting keys", updates)
+	}
+}
+
+func TestFsMetaRemove(t *testing.T) {
+	var updates []api.ObjectUserMetadataUpdate
+	metadata := map[string]string{"owner": "data-team", "stage": "raw", "tier": "hot"}
+	handler := metadataHandler(t, metadata, &updates)
+
+	runCmd(t, handler, "fs", "meta", "set", "lakefs://repo/main/file", "stage=", "--remove", "tier")
+	if diffs := deep.Equal(metadata, map[string]string{"owner": "data-team"}); diffs != nil {
+		t.Errorf("unexpected metadata after remove: %s", diffs)
+	}
+	if len(updates) != 1 || updates[0].Remove == nil || updates[0].Set != nil {
+		t.Fatalf("remove sent updates %+v, expected a single update removing keys", updates)
+	}
+	removed := *updates[0].Remove
+	sort.Strings(removed)
+	if diffs := deep.Equal(removed, []string{"stage", "tier"}); diffs != nil {
+		t.Errorf("unexpected removed keys: %s", diffs)
+	}
+}
+
+func TestUserMetadataUpdateInvalid(t *testing.T) {
+	for _, pair := range []string{"owner", "=value"} {
+		if _, err := userMetadataUpdate([]string{pair}, nil); err == nil {
+			t.Errorf("userMetadataUpdate accepted invalid pair %q", pair)
+		}
+	}
+}
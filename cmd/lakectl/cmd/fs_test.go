@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
+)
+
+func TestFsPresign(t *testing.T) {
+	var expiry, upload string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/main/objects/presign", func(w http.ResponseWriter, r *http.Request) {
+		expiry, upload = r.URL.Query().Get("expiry"), r.URL.Query().Get("upload")
+		writeJSON(w, http.StatusOK, api.PreSignedURL{
+			Url:             "https://bucket.s3.amazonaws.com/data?X-Amz-Signature=abc",
+			PhysicalAddress: "s3://bucket/data",
+			Expiry:          time.Now().Add(time.Hour).Unix(),
+		})
+	})
+
+	out := runCmd(t, mux, "fs", "presign", "lakefs://repo/main/data", "--expiry", "90m")
+	if expiry != "5400" {
+		t.Errorf("presign request expiry = %q, expected 5400 seconds", expiry)
+	}
+	if upload != "false" {
+		t.Errorf("presign request upload = %q, expected false", upload)
+	}
+	if !strings.Contains(out, "X-Amz-Signature=abc") {
+		t.Errorf("output %q does not contain the pre-signed URL", out)
+	}
+}
+
+func TestPresignObjectNotSupported(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/main/objects/presign", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotImplemented, api.Error{Message: "operation not supported: local blockstore cannot pre-sign URLs"})
+	})
+	client := newTestClient(t, mux)
+	pathURI, err := uri.Parse("lakefs://repo/main/data")
+	if err != nil {
+		t.Fatalf("parse uri: %s", err)
+	}
+
+	_, err = presignObject(context.Background(), client, pathURI, time.Hour, true)
+	if !errors.Is(err, ErrPresignNotSupported) {
+		t.Errorf("presignObject() on unsupported blockstore returned %v, expected %s", err, ErrPresignNotSupported)
+	}
+}
+
+func objectHistoryHandler() http.Handler {
+	diffs := map[string][]api.Diff{
+		"c1/diff/c2": {{Path: "data2", Type: "added", PathType: "object"}},
+		"c2/diff/c3": {{Path: "data", Type: "changed", PathType: "object"}},
+		"c3/diff/c4": {{Path: "data", Type: "removed", PathType: "object"}},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/main/commits", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.CommitList{Results: []api.Commit{
+			{Id: "c4", Message: "remove data", Parents: []string{"c3"}},
+			{Id: "c3", Message: "update data", Parents: []string{"c2"}},
+			{Id: "c2", Message: "add data2", Parents: []string{"c1"}},
+			{Id: "c1", Message: "add data"},
+		}})
+	})
+	mux.HandleFunc("/repositories/repo/refs/c1/objects/stat", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.ObjectStats{Path: r.URL.Query().Get("path")})
+	})
+	mux.HandleFunc("/repositories/repo/refs/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.DiffList{Results: diffs[strings.TrimPrefix(r.URL.Path, "/repositories/repo/refs/")]})
+	})
+	return mux
+}
+
+func TestFsLog(t *testing.T) {
+	out := runCmd(t, objectHistoryHandler(), "fs", "log", "lakefs://repo/main/data")
+	expected := []string{"c4", "deleted", "c3", "modified", "c1", "added"}
+	pos := 0
+	for _, s := range expected {
+		i := strings.Index(out[pos:], s)
+		if i < 0 {
+			t.Fatalf("output %q does not contain %q in order %v", out, s, expected)
+		}
+		pos += i + len(s)
+	}
+	if strings.Contains(out, "c2") {
+		t.Errorf("output %q contains commit c2 which did not change the object", out)
+	}
+}
+
+func TestObjectHistoryAmount(t *testing.T) {
+	client := newTestClient(t, objectHistoryHandler())
+	pathURI, err := uri.Parse("lakefs://repo/main/data")
+	if err != nil {
+		t.Fatalf("parse uri: %s", err)
+	}
+
+	changes, next := objectHistory(context.Background(), client, pathURI, "", 2)
+	if len(changes) != 2 || changes[0].Type != "deleted" || changes[1].Type != "modified" {
+		t.Errorf("objectHistory() returned %+v, expected deleted and modified changes", changes)
+	}
+	if next != "c3" {
+		t.Errorf("objectHistory() next = %q, expected c3", next)
+	}
+}
+
+func TestFsRm(t *testing.T) {
+	var deleted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/branches/main/objects", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("got %s object request, expected %s", r.Method, http.MethodDelete)
+		}
+		deleted = append(deleted, r.URL.Query().Get("path"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/repositories/repo/branches/main/objects/delete", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected bulk delete request")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	runCmd(t, mux, "fs", "rm", "lakefs://repo/main/dir/data")
+	if diff := deep.Equal(deleted, []string{"dir/data"}); diff != nil {
+		t.Errorf("unexpected deleted objects: %s", diff)
+	}
+}
+
+func TestFsRmRecursive(t *testing.T) {
+	pages := map[string]api.ObjectStatsList{
+		"": {
+			Pagination: api.Pagination{HasMore: true, NextOffset: "dir/b"},
+			Results:    []api.ObjectStats{{Path: "dir/a"}, {Path: "dir/b"}},
+		},
+		"dir/b": {
+			Results: []api.ObjectStats{{Path: "dir/sub/c"}},
+		},
+	}
+	var (
+		prefixes []string
+		batches  [][]string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/main/objects/ls", func(w http.ResponseWriter, r *http.Request) {
+		prefixes = append(prefixes, r.URL.Query().Get("prefix"))
+		page, ok := pages[r.URL.Query().Get("after")]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, api.Error{Message: "no such page"})
+			return
+		}
+		writeJSON(w, http.StatusOK, page)
+	})
+	mux.HandleFunc("/repositories/repo/branches/main/objects/delete", func(w http.ResponseWriter, r *http.Request) {
+		var body api.PathList
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		batches = append(batches, body.Paths)
+		writeJSON(w, http.StatusOK, api.ObjectErrorList{Errors: []api.ObjectError{}})
+	})
+
+	out := runCmd(t, mux, "fs", "rm", "--recursive", "--yes", "lakefs://repo/main/dir")
+	if diff := deep.Equal(batches, [][]string{{"dir/a", "dir/b"}, {"dir/sub/c"}}); diff != nil {
+		t.Errorf("unexpected delete batches: %s", diff)
+	}
+	if diff := deep.Equal(prefixes, []string{"dir/", "dir/"}); diff != nil {
+		t.Errorf("unexpected listing prefixes: %s", diff)
+	}
+	if !strings.Contains(out, "Deleted 3 objects") {
+		t.Errorf("output %q does not report the deleted objects", out)
+	}
+}
+
+func TestFsUploadContentType(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "data.json")
+	if err := ioutil.WriteFile(source, []byte(`{"a":1}`), 0600); err != nil {
+		t.Fatalf("write source: %s", err)
+	}
+	var contentTypes []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/branches/main/objects", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("got %s object request, expected %s", r.Method, http.MethodPost)
+		}
+		_, header, err := r.FormFile("content")
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, api.Error{Message: err.Error()})
+			return
+		}
+		contentTypes = append(contentTypes, header.Header.Get("Content-Type"))
+		writeJSON(w, http.StatusCreated, api.ObjectStats{Path: r.URL.Query().Get("path"), PathType: "object", SizeBytes: api.Int64Ptr(7)})
+	})
+
+	cases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "inferred", expected: "application/json"},
+		{name: "override", args: []string{"--content-type", "text/plain"}, expected: "text/plain"},
+		{name: "override without inference", args: []string{"--no-content-type", "--content-type", "text/plain"}, expected: "text/plain"},
+		{name: "disabled", args: []string{"--no-content-type"}, expected: "application/octet-stream"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			contentTypes = nil
+			args := append([]string{"fs", "upload", "--source", source, "lakefs://repo/main/data.json"}, tc.args...)
+			runCmd(t, mux, args...)
+			if diff := deep.Equal(contentTypes, []string{tc.expected}); diff != nil {
+				t.Errorf("uploaded content types: %s", diff)
+			}
+		})
+	}
+}
+
+func TestInferContentType(t *testing.T) {
+	cases := []struct {
+		pathname string
+		override string
+		infer    bool
+		expected string
+	}{
+		{pathname: "a/b.json", infer: true, expected: "application/json"},
+		{pathname: "a/b.json", override: "text/csv", infer: true, expected: "text/csv"},
+		{pathname: "a/b.json", expected: ""},
+		{pathname: "a/b", infer: true, expected: ""},
+	}
+	for _, tc := range cases {
+		if got := inferContentType(tc.pathname, tc.override, tc.infer); got != tc.expected {
+			t.Errorf("inferContentType(%q, %q, %t) = %q, expected %q", tc.pathname, tc.override, tc.infer, got, tc.expected)
+		}
+	}
+}
+
+func TestFsStage(t *testing.T) {
+	h := newStagingHandler()
+	out := runCmd(t, h, "fs", "stage", "lakefs://repo/main/tables/events.json",
+		"--physical-address", "s3://bucket/raw/events", "--size", "42", "--checksum", "etag1",
+		"--mtime", "1600000000", "--meta", "source=manual")
+	mtime := int64(1600000000)
+	expected := map[string]api.StageObjectJSONRequestBody{
+		"tables/events.json": {
+			PhysicalAddress: "s3://bucket/raw/events",
+			SizeBytes:       42,
+			Checksum:        "etag1",
+			Mtime:           &mtime,
+			ContentType:     api.StringPtr("application/json"),
+			Metadata:        &api.ObjectStageCreation_Metadata{AdditionalProperties: map[string]string{"source": "manual"}},
+		},
+	}
+	if diff := deep.Equal(h.staged, expected); diff != nil {
+		t.Errorf("staged entries: %s", diff)
+	}
+	if !strings.Contains(out, "tables/events.json") {
+		t.Errorf("output %q does not show the staged object", out)
+	}
+}
+
+func TestStageObjectCreation(t *testing.T) {
+	cases := []struct {
+		name            string
+		physicalAddress string
+		size            int64
+		checksum        string
+		valid           bool
+	}{
+		{name: "s3", physicalAddress: "s3://bucket/path/to/object", size: 10, checksum: "etag", valid: true},
+		{name: "gs", physicalAddress: "gs://bucket/object", checksum: "etag", valid: true},
+		{name: "azure", physicalAddress: "https://account.blob.core.windows.net/container/object", size: 1, checksum: "etag", valid: true},
+		{name: "missing address", size: 10, checksum: "etag"},
+		{name: "no scheme", physicalAddress: "bucket/object", size: 10, checksum: "etag"},
+		{name: "unknown scheme", physicalAddress: "ftp://host/object", size: 10, checksum: "etag"},
+		{name: "missing bucket", physicalAddress: "s3:///object", size: 10, checksum: "etag"},
+		{name: "negative size", physicalAddress: "s3://bucket/object", size: -1, checksum: "etag"},
+		{name: "missing checksum", physicalAddress: "s3://bucket/object", size: 10},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			obj, err := stageObjectCreation(c.physicalAddress, c.size, c.checksum, "", 0, nil)
+			if !c.valid {
+				if !errors.Is(err, ErrInvalidStageObject) {
+					t.Errorf("got error %v, expected %s", err, ErrInvalidStageObject)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("stageObjectCreation: %s", err)
+			}
+			if obj.PhysicalAddress != c.physicalAddress || obj.SizeBytes != c.size || obj.Checksum != c.checksum {
+				t.Errorf("got %+v, expected %s of %d bytes with checksum %s", obj, c.physicalAddress, c.size, c.checksum)
+			}
+			if obj.Mtime != nil || obj.Metadata != nil || obj.ContentType != nil {
+				t.Errorf("got %+v, expected no optional fields", obj)
+			}
+		})
+	}
+}
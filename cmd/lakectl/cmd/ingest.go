@@ -2,6 +2,12 @@ package cmd
 
 import (
 	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -9,12 +15,30 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/treeverse/lakefs/cmd/lakectl/cmd/store"
 	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
 )
 
 const ingestSummaryTemplate = `
 Staged {{ .Objects | yellow }} external objects (total of {{ .Bytes | human_bytes | yellow }})
 `
 
+const (
+	manifestFields = 4
+	manifestHeader = "physical-address"
+)
+
+var ErrInvalidManifestRow = errors.New("invalid manifest row")
+
+// manifestEntry is a row of an ingest manifest: an existing object to stage at a path.
+type manifestEntry struct {
+	Row             int
+	PhysicalAddress string
+	Path            string
+	SizeBytes       int64
+	ETag            string
+	ContentType     string
+}
+
 type stageRequest struct {
 	repository string
 	branch     string
@@ -33,16 +57,34 @@ func stageWorker(ctx context.Context, client api.ClientWithResponsesInterface, w
 }
 
 var ingestCmd = &cobra.Command{
-	Use:   "ingest --from <object store URI> --to <lakeFS path URI> [--dry-run]",
+	Use:   "ingest --from <object store URI> | --manifest <manifest file> --to <lakeFS path URI> [--dry-run]",
 	Short: "Ingest objects from an external source into a lakeFS branch (without actually copying them)",
+	Long: `Ingest objects from an external source into a lakeFS branch (without actually copying them).
+Objects are either listed from an object store prefix (--from), or read from a CSV manifest file
+(--manifest) with rows of the form: physical-address,logical-path,size,etag
+With the global --verbose flag, stats are printed for each individual object staged.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := cmd.Context()
-		verbose := MustBool(cmd.Flags().GetBool("verbose"))
+		verboseStats := verboseLevel() > 0
 		dryRun := MustBool(cmd.Flags().GetBool("dry-run"))
 		from := MustString(cmd.Flags().GetString("from"))
+		manifest := MustString(cmd.Flags().GetString("manifest"))
 		to := MustString(cmd.Flags().GetString("to"))
 		concurrency := MustInt(cmd.Flags().GetInt("concurrency"))
+		contentType := MustString(cmd.Flags().GetString("content-type"))
+		noContentType := MustBool(cmd.Flags().GetBool("no-content-type"))
 		lakefsURI := MustParsePathURI("to", to)
+		if (from == "") == (manifest == "") {
+			DieFmt("exactly one of --from or --manifest is required")
+		}
+		contentTypeOf := func(path string) string {
+			return inferContentType(path, contentType, !noContentType)
+		}
+		if manifest != "" {
+			commit := MustBool(cmd.Flags().GetBool("commit"))
+			ingestFromManifest(ctx, getClient(), lakefsURI, manifest, concurrency, contentTypeOf, dryRun, commit)
+			return
+		}
 
 		// initialize worker pool
 		client := getClient()
@@ -54,10 +96,7 @@ var ingestCmd = &cobra.Command{
 			go stageWorker(ctx, client, &wg, requests, responses)
 		}
 
-		summary := struct {
-			Objects int64
-			Bytes   int64
-		}{}
+		var summary ingestSummary
 
 		var path string
 		if lakefsURI.Path != nil {
@@ -86,6 +125,7 @@ var ingestCmd = &cobra.Command{
 						Mtime:           &mtime,
 						PhysicalAddress: e.Address,
 						SizeBytes:       e.Size,
+						ContentType:     contentTypePtr(contentTypeOf(key)),
 					},
 				}
 				return nil
@@ -103,7 +143,7 @@ var ingestCmd = &cobra.Command{
 			summary.Objects += 1
 			summary.Bytes += api.Int64Value(response.JSON201.SizeBytes)
 
-			if verbose {
+			if verboseStats {
 				Write("Staged "+fsStatTemplate+"\n", response.JSON201)
 				continue
 			}
@@ -115,7 +155,7 @@ var ingestCmd = &cobra.Command{
 			}
 
 		}
-		if !verbose {
+		if !verboseStats {
 			Fmt("\n")
 		}
 
@@ -124,14 +164,158 @@ var ingestCmd = &cobra.Command{
 	},
 }
 
+// ingestFromManifest stages the objects listed in manifest under lakefsURI, setting the
+// content-type returned by contentTypeOf for each of their paths.
+func ingestFromManifest(ctx context.Context, client api.ClientWithResponsesInterface, lakefsURI *uri.URI, manifest string, concurrency int, contentTypeOf func(path string) string, dryRun, commit bool) {
+	f, err := os.Open(manifest)
+	if err != nil {
+		DieErr(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	entries, failures := readManifest(f)
+	for i := range entries {
+		entries[i].ContentType = contentTypeOf(entries[i].Path)
+	}
+	if dryRun {
+		for _, e := range entries {
+			Fmt("%s -> %s\n", e.PhysicalAddress, e.Path)
+		}
+	} else {
+		summary, stageFailures := stageManifest(ctx, client, lakefsURI, entries, concurrency)
+		failures = append(failures, stageFailures...)
+		Write(ingestSummaryTemplate, summary)
+	}
+	for _, failure := range failures {
+		Fmt("%s\n", failure)
+	}
+	if len(failures) > 0 {
+		DieFmt("%d manifest rows failed", len(failures))
+	}
+	if commit && !dryRun {
+		resp, err := client.CommitWithResponse(ctx, lakefsURI.Repository, lakefsURI.Ref, api.CommitJSONRequestBody{
+			Message: fmt.Sprintf("Ingest %d objects from manifest %s", len(entries), manifest),
+		})
+		DieOnResponseError(resp, err)
+		Fmt("Committed %s\n", resp.JSON201.Id)
+	}
+}
+
+// readManifest parses an ingest manifest, returning its valid entries and an error for each
+// invalid row.
+func readManifest(r io.Reader) ([]manifestEntry, []error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	var entries []manifestEntry
+	var failures []error
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			failures = append(failures, fmt.Errorf("row %d: %w", row, err))
+			continue
+		}
+		if row == 1 && len(record) > 0 && record[0] == manifestHeader {
+			continue
+		}
+		entry, err := parseManifestRecord(row, record)
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, failures
+}
+
+func parseManifestRecord(row int, record []string) (manifestEntry, error) {
+	if len(record) != manifestFields {
+		return manifestEntry{}, fmt.Errorf("%w %d: got %d fields, expected %d", ErrInvalidManifestRow, row, len(record), manifestFields)
+	}
+	entry := manifestEntry{
+		Row:             row,
+		PhysicalAddress: strings.TrimSpace(record[0]),
+		Path:            strings.TrimSpace(record[1]),
+		ETag:            strings.TrimSpace(record[3]),
+	}
+	if entry.PhysicalAddress == "" || entry.Path == "" {
+		return manifestEntry{}, fmt.Errorf("%w %d: empty physical address or path", ErrInvalidManifestRow, row)
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(record[2]), 10, 64)
+	if err != nil || size < 0 {
+		return manifestEntry{}, fmt.Errorf("%w %d: invalid size %q", ErrInvalidManifestRow, row, record[2])
+	}
+	entry.SizeBytes = size
+	return entry, nil
+}
+
+type ingestSummary struct {
+	Objects int64
+	Bytes   int64
+}
+
+// stageManifest stages all entries under lakefsURI, returning an error for each entry that
+// failed to stage.
+func stageManifest(ctx context.Context, client api.ClientWithResponsesInterface, lakefsURI *uri.URI, entries []manifestEntry, concurrency int) (ingestSummary, []error) {
+	var prefix string
+	if lakefsURI.Path != nil && *lakefsURI.Path != "" {
+		prefix = strings.TrimSuffix(*lakefsURI.Path, "/") + "/"
+	}
+	errs := make([]error, len(entries))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				e := entries[i]
+				resp, err := client.StageObjectWithResponse(ctx, lakefsURI.Repository, lakefsURI.Ref,
+					&api.StageObjectParams{Path: prefix + e.Path},
+					api.StageObjectJSONRequestBody{
+						Checksum:        e.ETag,
+						PhysicalAddress: e.PhysicalAddress,
+						SizeBytes:       e.SizeBytes,
+						ContentType:     contentTypePtr(e.ContentType),
+					})
+				if err = responseError(resp, err); err != nil {
+					errs[i] = fmt.Errorf("row %d (%s): %w", e.Row, e.Path, err)
+				}
+			}
+		}()
+	}
+	for i := range entries {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var summary ingestSummary
+	var failures []error
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		summary.Objects++
+		summary.Bytes += entries[i].SizeBytes
+	}
+	return summary, failures
+}
+
 //nolint:gochecknoinits
 func init() {
 	ingestCmd.Flags().String("from", "", "prefix to read from (e.g. \"s3://bucket/sub/path/\")")
-	_ = ingestCmd.MarkFlagRequired("from")
+	ingestCmd.Flags().String("manifest", "", "CSV file listing objects to ingest, with rows of physical-address,logical-path,size,etag")
+	ingestCmd.Flags().Bool("commit", false, "commit the branch after ingesting a manifest")
 	ingestCmd.Flags().String("to", "", "lakeFS path to load objects into (e.g. \"lakefs://repo/branch/sub/path/\")")
 	_ = ingestCmd.MarkFlagRequired("to")
 	ingestCmd.Flags().Bool("dry-run", false, "only print the paths to be ingested")
-	ingestCmd.Flags().BoolP("verbose", "v", false, "print stats for each individual object staged")
 	ingestCmd.Flags().IntP("concurrency", "C", 64, "max concurrent API calls to make to the lakeFS server")
+	ingestCmd.Flags().String("content-type", "", "content-type to set on ingested objects, instead of inferring it from their extensions")
+	ingestCmd.Flags().Bool("no-content-type", false, "do not infer content-types from object extensions")
 	rootCmd.AddCommand(ingestCmd)
 }
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
+)
+
+// stagingHandler records the paths and bodies of stage object requests, failing paths in fail.
+type stagingHandler struct {
+	mu      sync.Mutex
+	staged  map[string]api.StageObjectJSONRequestBody
+	commits int
+	fail    map[string]bool
+}
+
+func newStagingHandler(fail ...string) *stagingHandler {
+	h := &stagingHandler{staged: make(map[string]api.StageObjectJSONRequestBody), fail: make(map[string]bool)}
+	for _, p := range fail {
+		h.fail[p] = true
+	}
+	return h
+}
+
+func (h *stagingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch r.URL.Path {
+	case "/repositories/repo/branches/main/objects":
+		p := r.URL.Query().Get("path")
+		if h.fail[p] {
+			writeJSON(w, http.StatusBadRequest, api.Error{Message: "bad object"})
+			return
+		}
+		var body api.StageObjectJSONRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		h.staged[p] = body
+		writeJSON(w, http.StatusCreated, api.ObjectStats{Path: p, SizeBytes: &body.SizeBytes})
+	case "/repositories/repo/branches/main/commits":
+		h.commits++
+		writeJSON(w, http.StatusCreated, api.Commit{Id: "c1", Parents: []string{}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestIngestManifest(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "manifest.csv")
+	const contents = `physical-address,logical-path,size,etag
+s3://bucket/raw/1,events/1.json,10,etag1
+s3://bucket/raw/2,events/2.json,20,etag2
+`
+	if err := ioutil.WriteFile(manifest, []byte(contents), 0600); err != nil {
+		t.Fatalf("write manifest: %s", err)
+	}
+	h := newStagingHandler()
+
+	runCmd(t, h, "ingest", "--manifest", manifest, "--to", "lakefs://repo/main/imported/", "--commit")
+	expected := map[string]api.StageObjectJSONRequestBody{
+		"imported/events/1.json": {PhysicalAddress: "s3://bucket/raw/1", SizeBytes: 10, Checksum: "etag1", ContentType: api.StringPtr("application/json")},
+		"imported/events/2.json": {PhysicalAddress: "s3://bucket/raw/2", SizeBytes: 20, Checksum: "etag2", ContentType: api.StringPtr("application/json")},
+	}
+	if diff := deep.Equal(h.staged, expected); diff != nil {
+		t.Errorf("staged entries: %s", diff)
+	}
+	if h.commits != 1 {
+		t.Errorf("got %d commits, expected 1", h.commits)
+	}
+}
+
+func TestIngestManifestRowFailures(t *testing.T) {
+	const contents = `s3://bucket/raw/1,a,10,etag1
+s3://bucket/raw/2,b,not-a-size,etag2
+s3://bucket/raw/3,c,30
+s3://bucket/raw/4,d,40,etag4
+s3://bucket/raw/5,e,50,etag5
+`
+	entries, failures := readManifest(strings.NewReader(contents))
+	if len(failures) != 2 {
+		t.Fatalf("readManifest returned failures %v, expected 2", failures)
+	}
+	for _, failure := range failures {
+		if !errors.Is(failure, ErrInvalidManifestRow) {
+			t.Errorf("readManifest failure %v, expected %s", failure, ErrInvalidManifestRow)
+		}
+	}
+
+	h := newStagingHandler("d")
+	lakefsURI, err := uri.Parse("lakefs://repo/main/")
+	if err != nil {
+		t.Fatalf("parse uri: %s", err)
+	}
+	summary, failures := stageManifest(context.Background(), newTestClient(t, h), lakefsURI, entries, 2)
+	if len(failures) != 1 || !strings.Contains(failures[0].Error(), "row 4") {
+		t.Errorf("stageManifest failures %v, expected a failure of row 4", failures)
+	}
+	var staged []string
+	for p := range h.staged {
+		staged = append(staged, p)
+	}
+	sort.Strings(staged)
+	if diff := deep.Equal(staged, []string{"a", "e"}); diff != nil {
+		t.Errorf("staged paths: %s", diff)
+	}
+	if summary.Objects != 2 || summary.Bytes != 60 {
+		t.Errorf("summary %+v, expected 2 objects of 60 bytes", summary)
+	}
+}
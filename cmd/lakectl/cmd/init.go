@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/manifoldco/promptui"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/treeverse/lakefs/cmd/lakectl/cmd/config"
+	"github.com/treeverse/lakefs/pkg/api"
+	"gopkg.in/yaml.v3"
+)
+
+const configFilePerm = 0600
+
+var (
+	ErrConfigFileExists = errors.New("config file already exists")
+	ErrMissingInitValue = errors.New("missing value")
+)
+
+// initValues are the answers to lakectl init, either passed as flags or prompted for.
+type initValues struct {
+	EndpointURL      string
+	AccessKeyID      string
+	SecretAccessKey  string
+	Repository       string
+	StorageNamespace string
+	DefaultBranch    string
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "set up lakectl: write its config file, verify the connection and optionally create a first repository",
+	Example: `lakectl init
+lakectl init --non-interactive --endpoint-url https://lakefs.example.com --access-key-id AKIA... --secret-access-key ... \
+	--repository lakefs://example-repo --storage-namespace s3://example-bucket/example-repo`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		force := MustBool(cmd.Flags().GetBool("force"))
+		nonInteractive := MustBool(cmd.Flags().GetBool("non-interactive"))
+		values := initValues{
+			EndpointURL:      MustString(cmd.Flags().GetString("endpoint-url")),
+			AccessKeyID:      MustString(cmd.Flags().GetString("access-key-id")),
+			SecretAccessKey:  MustString(cmd.Flags().GetString("secret-access-key")),
+			Repository:       MustString(cmd.Flags().GetString("repository")),
+			StorageNamespace: MustString(cmd.Flags().GetString("storage-namespace")),
+			DefaultBranch:    MustString(cmd.Flags().GetString("default-branch")),
+		}
+
+		configFile := viper.ConfigFileUsed()
+		if configFile == "" {
+			home, err := homedir.Dir()
+			if err != nil {
+				DieErr(err)
+			}
+			configFile = filepath.Join(home, ".lakectl.yaml")
+		}
+		if _, err := os.Stat(configFile); err == nil && !force {
+			DieFmt("%s: %s (use --force to overwrite it)", configFile, ErrConfigFileExists)
+		}
+
+		// values not passed as flags default to the current configuration, e.g. from LAKECTL_*
+		// environment variables
+		defaults := initValues{
+			EndpointURL:     viper.GetString(config.ConfigServerEndpointURLKey),
+			AccessKeyID:     viper.GetString(config.ConfigAccessKeyIDKey),
+			SecretAccessKey: viper.GetString(config.ConfigSecretAccessKey),
+		}
+		var err error
+		if nonInteractive {
+			fillInitDefaults(&values, defaults)
+			err = checkInitValues(values)
+		} else {
+			err = promptInitValues(&values, defaults)
+		}
+		if err != nil {
+			DieErr(err)
+		}
+
+		// verify the connection before writing a config file that cannot be used
+		cfg.Values.Server.EndpointURL = values.EndpointURL
+		cfg.Values.Credentials.AccessKeyID = values.AccessKeyID
+		cfg.Values.Credentials.SecretAccessKey = values.SecretAccessKey
+		clt := getClient()
+		userResp, err := clt.GetCurrentUserWithResponse(cmd.Context())
+		DieOnResponseError(userResp, err)
+		Fmt("Connected to lakeFS at %s as user '%s'\n", values.EndpointURL, userResp.JSON200.User.Id)
+
+		if err := writeConfigFile(configFile, values, force); err != nil {
+			DieErr(err)
+		}
+		Fmt("Config file %s written\n", configFile)
+
+		if values.Repository == "" {
+			return
+		}
+		u := MustParseRepoURI("repository", values.Repository)
+		resp, err := clt.CreateRepositoryWithResponse(cmd.Context(), &api.CreateRepositoryParams{}, api.CreateRepositoryJSONRequestBody{
+			Name:             u.Repository,
+			StorageNamespace: values.StorageNamespace,
+			DefaultBranch:    &values.DefaultBranch,
+		})
+		DieOnResponseError(resp, err)
+		Fmt("Repository '%s' created:\nstorage namespace: %s\ndefault branch: %s\n",
+			resp.JSON201.Id, resp.JSON201.StorageNamespace, resp.JSON201.DefaultBranch)
+	},
+}
+
+// fillInitDefaults sets every connection value missing in values to its default.
+func fillInitDefaults(values *initValues, defaults initValues) {
+	if values.EndpointURL == "" {
+		values.EndpointURL = defaults.EndpointURL
+	}
+	if values.AccessKeyID == "" {
+		values.AccessKeyID = defaults.AccessKeyID
+	}
+	if values.SecretAccessKey == "" {
+		values.SecretAccessKey = defaults.SecretAccessKey
+	}
+}
+
+// checkInitValues returns an error if values lack anything needed to run lakectl init
+// without prompting.
+func checkInitValues(values initValues) error {
+	type requiredValue struct {
+		flag  string
+		value string
+	}
+	required := []requiredValue{
+		{flag: "endpoint-url", value: values.EndpointURL},
+		{flag: "access-key-id", value: values.AccessKeyID},
+		{flag: "secret-access-key", value: values.SecretAccessKey},
+	}
+	if values.Repository != "" {
+		required = append(required, requiredValue{flag: "storage-namespace", value: values.StorageNamespace})
+	}
+	for _, r := range required {
+		if r.value == "" {
+			return fmt.Errorf("%w: --%s is required with --non-interactive", ErrMissingInitValue, r.flag)
+		}
+	}
+	if _, err := url.ParseRequestURI(values.EndpointURL); err != nil {
+		return fmt.Errorf("endpoint URL: %w", err)
+	}
+	return nil
+}
+
+// promptInitValues prompts for every value not already passed as a flag, offering its default.
+// An empty repository name skips creating a repository.
+func promptInitValues(values *initValues, defaults initValues) error {
+	validateURL := func(rawurl string) error {
+		_, err := url.ParseRequestURI(rawurl)
+		return err
+	}
+	validateRequired := func(s string) error {
+		if s == "" {
+			return ErrMissingInitValue
+		}
+		return nil
+	}
+	questions := []struct {
+		value  *string
+		prompt promptui.Prompt
+	}{
+		{value: &values.EndpointURL, prompt: promptui.Prompt{Label: "Server endpoint URL", Default: defaults.EndpointURL, Validate: validateURL}},
+		{value: &values.AccessKeyID, prompt: promptui.Prompt{Label: "Access key ID", Default: defaults.AccessKeyID, Validate: validateRequired}},
+		{value: &values.SecretAccessKey, prompt: promptui.Prompt{Label: "Secret access key", Default: defaults.SecretAccessKey, Mask: '*', Validate: validateRequired}},
+		{value: &values.Repository, prompt: promptui.Prompt{Label: "Repository to create (leave empty to skip), e.g. lakefs://example-repo"}},
+	}
+	for _, q := range questions {
+		if *q.value != "" {
+			continue
+		}
+		answer, err := q.prompt.Run()
+		if err != nil {
+			return err
+		}
+		*q.value = answer
+	}
+	if values.Repository != "" && values.StorageNamespace == "" {
+		prompt := promptui.Prompt{Label: "Storage namespace, e.g. s3://example-bucket/example-repo", Validate: validateRequired}
+		answer, err := prompt.Run()
+		if err != nil {
+			return err
+		}
+		values.StorageNamespace = answer
+	}
+	return nil
+}
+
+// writeConfigFile writes the lakectl configuration in values to path, readable only by its
+// owner.  It fails with ErrConfigFileExists rather than replace an existing file, unless
+// force is set.
+func writeConfigFile(path string, values initValues, force bool) error {
+	contents := map[string]interface{}{
+		"credentials": map[string]string{
+			"access_key_id":     values.AccessKeyID,
+			"secret_access_key": values.SecretAccessKey,
+		},
+		"server": map[string]string{
+			"endpoint_url": values.EndpointURL,
+		},
+	}
+	data, err := yaml.Marshal(contents)
+	if err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, configFilePerm)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s: %w", path, ErrConfigFileExists)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().Bool("force", false, "overwrite an existing config file")
+	initCmd.Flags().Bool("non-interactive", false, "do not prompt, take all values from flags")
+	initCmd.Flags().String("endpoint-url", "", "lakeFS server endpoint URL (default from the current configuration)")
+	initCmd.Flags().String("access-key-id", "", "access key ID (default from the current configuration)")
+	initCmd.Flags().String("secret-access-key", "", "secret access key (default from the current configuration)")
+	initCmd.Flags().String("repository", "", "URI of a first repository to create, e.g. lakefs://example-repo")
+	initCmd.Flags().String("storage-namespace", "", "storage namespace of the repository to create")
+	initCmd.Flags().String("default-branch", DefaultBranch, "default branch of the repository to create")
+}
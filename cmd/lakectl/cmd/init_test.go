@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/treeverse/lakefs/pkg/api"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInitNonInteractive(t *testing.T) {
+	home := t.TempDir()
+	setEnv(t, "HOME", home)
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+
+	var created api.RepositoryCreation
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.CurrentUser{User: api.User{Id: "admin"}})
+	})
+	mux.HandleFunc("/repositories", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("got %s /repositories, expected POST", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+			t.Errorf("decode repository creation: %s", err)
+		}
+		writeJSON(w, http.StatusCreated, api.Repository{Id: created.Name, StorageNamespace: created.StorageNamespace, DefaultBranch: *created.DefaultBranch})
+	})
+
+	out := runCmd(t, mux, "init", "--non-interactive", "--access-key-id", "AKIAINIT",
+		"--repository", "lakefs://example-repo", "--storage-namespace", "local://example-repo")
+
+	if !strings.Contains(out, "as user 'admin'") {
+		t.Errorf("output %q does not report the connected user", out)
+	}
+	if created.Name != "example-repo" || created.StorageNamespace != "local://example-repo" || *created.DefaultBranch != DefaultBranch {
+		t.Errorf("created repository %+v, expected example-repo on local://example-repo with branch %s", created, DefaultBranch)
+	}
+
+	configFile := filepath.Join(home, ".lakectl.yaml")
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("read config file: %s", err)
+	}
+	var written struct {
+		Credentials struct {
+			AccessKeyID     string `yaml:"access_key_id"`
+			SecretAccessKey string `yaml:"secret_access_key"`
+		}
+		Server struct {
+			EndpointURL string `yaml:"endpoint_url"`
+		}
+	}
+	if err := yaml.Unmarshal(data, &written); err != nil {
+		t.Fatalf("parse config file: %s", err)
+	}
+	// flags take precedence over the current configuration, which supplies the rest
+	if written.Credentials.AccessKeyID != "AKIAINIT" {
+		t.Errorf("config file has access key ID %q, expected the flag value AKIAINIT", written.Credentials.AccessKeyID)
+	}
+	if expected := os.Getenv("LAKECTL_CREDENTIALS_SECRET_ACCESS_KEY"); written.Credentials.SecretAccessKey != expected {
+		t.Errorf("config file has secret access key %q, expected %q", written.Credentials.SecretAccessKey, expected)
+	}
+	if expected := os.Getenv("LAKECTL_SERVER_ENDPOINT_URL"); written.Server.EndpointURL != expected {
+		t.Errorf("config file has endpoint URL %q, expected %q", written.Server.EndpointURL, expected)
+	}
+	info, err := os.Stat(configFile)
+	if err != nil {
+		t.Fatalf("stat config file: %s", err)
+	}
+	if perm := info.Mode().Perm(); perm != configFilePerm {
+		t.Errorf("config file has permissions %o, expected %o", perm, configFilePerm)
+	}
+}
+
+func TestWriteConfigFileExists(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), ".lakectl.yaml")
+	if err := ioutil.WriteFile(configFile, []byte("existing"), configFilePerm); err != nil {
+		t.Fatalf("write existing config file: %s", err)
+	}
+	values := initValues{EndpointURL: "http://lakefs.example.com", AccessKeyID: "AKIAINIT", SecretAccessKey: "secret"}
+
+	if err := writeConfigFile(configFile, values, false); !errors.Is(err, ErrConfigFileExists) {
+		t.Errorf("writeConfigFile over an existing file returned %v, expected %s", err, ErrConfigFileExists)
+	}
+	if data, _ := ioutil.ReadFile(configFile); string(data) != "existing" {
+		t.Errorf("existing config file changed to %q", data)
+	}
+
+	if err := writeConfigFile(configFile, values, true); err != nil {
+		t.Fatalf("writeConfigFile with force: %s", err)
+	}
+	if data, _ := ioutil.ReadFile(configFile); !strings.Contains(string(data), "AKIAINIT") {
+		t.Errorf("forced config file holds %q, expected the new configuration", data)
+	}
+}
@@ -1,8 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/treeverse/lakefs/pkg/api"
+	"github.com/treeverse/lakefs/pkg/uri"
 )
 
 const commitsTemplate = `{{ range $val := .Commits }}
@@ -22,6 +31,14 @@ Merge:         {{ $val.Parents|join ", "|bold }}
 {{ end }}{{ if .Pagination  }}
 {{.Pagination | paginate }}{{ end }}`
 
+var ErrInvalidLogTime = errors.New("invalid time, expected RFC 3339 (2006-01-02T15:04:05Z) or a duration ago (7d, 12h)")
+
+// logTimeUnits are the units of durations ago accepted beyond those of time.ParseDuration.
+var logTimeUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
 // logCmd represents the log command
 var logCmd = &cobra.Command{
 	Use:   "log <branch uri>",
@@ -32,8 +49,17 @@ var logCmd = &cobra.Command{
 		after := MustString(cmd.Flags().GetString("after"))
 		pagination := api.Pagination{HasMore: true}
 		showMetaRangeID, _ := cmd.Flags().GetBool("show-meta-range-id")
+		timeRange, err := logTimeRangeFromFlags(cmd.Flags(), time.Now())
+		if err != nil {
+			DieErr(err)
+		}
 		client := getClient()
 		branchURI := MustParseRefURI("branch", args[0])
+		firstParent := MustBool(cmd.Flags().GetBool("first-parent"))
+		if firstParent || !timeRange.since.IsZero() || !timeRange.until.IsZero() {
+			printFilteredLog(cmd.Context(), client, branchURI, after, amount, showMetaRangeID, timeRange, firstParent)
+			return
+		}
 		amountForPagination := amount
 		if amountForPagination <= 0 {
 			amountForPagination = internalPageSize
@@ -68,10 +94,144 @@ var logCmd = &cobra.Command{
 	},
 }
 
+// logTimeRange bounds the creation times of commits to show.  Zero bounds are open.
+type logTimeRange struct {
+	since time.Time
+	until time.Time
+}
+
+func logTimeRangeFromFlags(flags *pflag.FlagSet, now time.Time) (logTimeRange, error) {
+	var r logTimeRange
+	for _, bound := range []struct {
+		flag string
+		t    *time.Time
+	}{
+		{flag: "since", t: &r.since},
+		{flag: "until", t: &r.until},
+	} {
+		value, err := flags.GetString(bound.flag)
+		if err != nil {
+			return logTimeRange{}, err
+		}
+		if value == "" {
+			continue
+		}
+		if *bound.t, err = parseLogTime(value, now); err != nil {
+			return logTimeRange{}, fmt.Errorf("--%s: %w", bound.flag, err)
+		}
+	}
+	return r, nil
+}
+
+// parseLogTime parses value as an RFC 3339 time, or as a duration before now such as "7d".
+func parseLogTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for suffix, unit := range logTimeUnits {
+		if !strings.HasSuffix(value, suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(value, suffix))
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidLogTime, value)
+		}
+		return now.Add(-time.Duration(n) * unit), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidLogTime, value)
+	}
+	return now.Add(-d), nil
+}
+
+// firstParentOf returns the ID of the first parent of commit, or "" if it has none.
+func firstParentOf(commit api.Commit) string {
+	if len(commit.Parents) == 0 {
+		return ""
+	}
+	return commit.Parents[0]
+}
+
+// printFilteredLog prints up to amount commits of the log of branchURI created within r, or all
+// of them if amount is not positive.  If firstParent, it follows only the first parent of merge
+// commits, skipping commits merged in from other branches.  The API cannot filter the log, so
+// it is filtered here.  It lists the newest commits first, so listing stops at the first commit
+// before r.
+func printFilteredLog(ctx context.Context, client api.ClientWithResponsesInterface, branchURI *uri.URI, after string, amount int, showMetaRangeID bool, r logTimeRange, firstParent bool) {
+	var (
+		commits []api.Commit
+		// next is the ID of the next commit on the first parent chain once started, or "" past
+		// its root commit.
+		next    string
+		started bool
+	)
+	hasMore := false
+	done := false
+	if firstParent && after != "" {
+		res, err := client.GetCommitWithResponse(ctx, branchURI.Repository, after)
+		DieOnResponseError(res, err)
+		next, started = firstParentOf(*res.JSON200), true
+		done = next == ""
+	}
+	for !done {
+		res, err := client.LogCommitsWithResponse(ctx, branchURI.Repository, branchURI.Ref, &api.LogCommitsParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+		})
+		DieOnResponseError(res, err)
+		for _, commit := range res.JSON200.Results {
+			if firstParent {
+				if started && next == "" {
+					done = true
+					break
+				}
+				if started && commit.Id != next {
+					continue
+				}
+				next, started = firstParentOf(commit), true
+			}
+			created := time.Unix(commit.CreationDate, 0)
+			if !r.since.IsZero() && created.Before(r.since) {
+				done = true
+				break
+			}
+			if !r.until.IsZero() && created.After(r.until) {
+				continue
+			}
+			if amount > 0 && len(commits) == amount {
+				hasMore, done = true, true
+				break
+			}
+			commits = append(commits, commit)
+		}
+		pagination := res.JSON200.Pagination
+		if !pagination.HasMore {
+			break
+		}
+		after = pagination.NextOffset
+	}
+	data := struct {
+		Commits         []api.Commit
+		Pagination      *Pagination
+		ShowMetaRangeID bool
+	}{
+		Commits:         commits,
+		ShowMetaRangeID: showMetaRangeID,
+	}
+	if hasMore {
+		data.Pagination = &Pagination{Amount: amount, HasNext: true, After: commits[len(commits)-1].Id}
+	}
+	Write(commitsTemplate, data)
+}
+
 //nolint:gochecknoinits
 func init() {
 	rootCmd.AddCommand(logCmd)
 	logCmd.Flags().Int("amount", 0, "number of results to return. By default, all results are returned.")
 	logCmd.Flags().String("after", "", "show results after this value (used for pagination)")
 	logCmd.Flags().Bool("show-meta-range-id", false, "also show meta range ID")
+	logCmd.Flags().String("since", "", "show only commits created at or after this time, in RFC 3339 or as a duration ago (e.g. 7d)")
+	logCmd.Flags().Bool("first-parent", false, "follow only the first parent of merge commits, showing the history of the branch without commits merged into it")
+	logCmd.Flags().String("until", "", "show only commits created at or before this time, in RFC 3339 or as a duration ago (e.g. 12h)")
 }
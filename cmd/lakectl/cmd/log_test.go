@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+// logHandler serves the log of branch "main" of repository "repo", newest commit first.
+func logHandler(commits []api.Commit) *http.ServeMux {
+	for i := range commits {
+		// the server always sends metadata
+		commits[i].Metadata = &api.Commit_Metadata{}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/main/commits", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.CommitList{
+			Pagination: api.Pagination{Results: len(commits)},
+			Results:    commits,
+		})
+	})
+	return mux
+}
+
+func TestLogSinceUntil(t *testing.T) {
+	day := func(d int) int64 {
+		return time.Date(2021, 3, d, 12, 0, 0, 0, time.UTC).Unix()
+	}
+	handler := logHandler([]api.Commit{
+		{Id: "c5", Message: "fifth", CreationDate: day(5)},
+		{Id: "c4", Message: "fourth", CreationDate: day(4)},
+		{Id: "c3", Message: "third", CreationDate: day(3)},
+		{Id: "c2", Message: "second", CreationDate: day(2)},
+		{Id: "c1", Message: "first", CreationDate: day(1)},
+	})
+
+	out := runCmd(t, handler, "log", "lakefs://repo/main", "--since", "2021-03-02T00:00:00Z", "--until", "2021-03-04T00:00:00Z")
+	for _, id := range []string{"c2", "c3"} {
+		if !strings.Contains(out, id) {
+			t.Errorf("output %q does not show commit %s within the range", out, id)
+		}
+	}
+	for _, id := range []string{"c1", "c4", "c5"} {
+		if strings.Contains(out, id) {
+			t.Errorf("output %q shows commit %s outside the range", out, id)
+		}
+	}
+}
+
+func TestLogSinceDurationAgo(t *testing.T) {
+	now := time.Now()
+	handler := logHandler([]api.Commit{
+		{Id: "recent", CreationDate: now.Add(-time.Hour).Unix()},
+		{Id: "last-week", CreationDate: now.Add(-5 * 24 * time.Hour).Unix()},
+		{Id: "old", CreationDate: now.Add(-10 * 24 * time.Hour).Unix()},
+	})
+
+	out := runCmd(t, handler, "log", "lakefs://repo/main", "--since", "7d")
+	if !strings.Contains(out, "recent") || !strings.Contains(out, "last-week") || strings.Contains(out, "old") {
+		t.Errorf("output %q does not show exactly the commits of the last 7 days", out)
+	}
+}
+
+func TestLogFirstParent(t *testing.T) {
+	commits := []api.Commit{
+		{Id: "merge", Message: "merge feature", Parents: []string{"main-2", "feature-2"}},
+		{Id: "feature-2", Parents: []string{"feature-1"}},
+		{Id: "main-2", Parents: []string{"main-1"}},
+		{Id: "feature-1", Parents: []string{"main-1"}},
+		{Id: "main-1"},
+	}
+	handler := logHandler(commits)
+	handler.HandleFunc("/repositories/repo/commits/merge", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, commits[0])
+	})
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "all", want: []string{"merge", "main-2", "main-1"}},
+		{name: "after", args: []string{"--after", "merge"}, want: []string{"main-2", "main-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"log", "lakefs://repo/main", "--first-parent"}, tt.args...)
+			out := runCmd(t, handler, args...)
+			var shown []string
+			for _, line := range strings.Split(out, "\n") {
+				if id := strings.TrimPrefix(line, "ID:"); id != line {
+					shown = append(shown, strings.TrimSpace(id))
+				}
+			}
+			if strings.Join(shown, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("log --first-parent shows commits %v, expected %v", shown, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLogTime(t *testing.T) {
+	now := time.Date(2021, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{value: "2021-03-01T10:00:00Z", want: time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{value: "7d", want: time.Date(2021, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{value: "1w", want: time.Date(2021, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{value: "36h", want: time.Date(2021, 3, 8, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseLogTime(tt.value, now)
+		if err != nil {
+			t.Errorf("parseLogTime(%q): %s", tt.value, err)
+		} else if !got.Equal(tt.want) {
+			t.Errorf("parseLogTime(%q) = %s, expected %s", tt.value, got, tt.want)
+		}
+	}
+	for _, value := range []string{"yesterday", "2021-03-01", "-3d", "d"} {
+		if _, err := parseLogTime(value, now); !errors.Is(err, ErrInvalidLogTime) {
+			t.Errorf("parseLogTime(%q) returned %v, expected %s", value, err, ErrInvalidLogTime)
+		}
+	}
+}
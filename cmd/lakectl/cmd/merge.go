@@ -1,10 +1,18 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/treeverse/lakefs/pkg/api"
+	"golang.org/x/term"
 )
 
 const (
@@ -12,14 +20,21 @@ const (
 	mergeCmdMaxArgs = 2
 )
 
-var mergeCreateTemplate = `Merged "{{.Merge.FromRef|yellow}}" into "{{.Merge.ToRef|yellow}}" to get "{{.Result.Reference|green}}".
+var mergeCreateTemplate = `{{ if .Squash }}Squash merged{{ else }}Merged{{ end }} "{{.Merge.FromRef|yellow}}" into "{{.Merge.ToRef|yellow}}" to get "{{.Result.Reference|green}}".
 
 Added: {{.Result.Summary.Added}}
 Changed: {{.Result.Summary.Changed}}
-Removed: {{.Result.Summary.Removed}}
+Removed: {{.Result.Summary.Removed}}{{ if .Result.Summary.Conflict }}
+Resolved conflicts: {{.Result.Summary.Conflict}}{{ end }}
 
 `
 
+var ErrMergeAborted = errors.New("merge aborted")
+
+// mergeRetryBackoff is the wait before retrying a merge whose destination branch changed while
+// merging.  It doubles on every retry.
+var mergeRetryBackoff = time.Second
+
 type FromTo struct {
 	FromRef, ToRef string
 }
@@ -34,12 +49,61 @@ var mergeCmd = &cobra.Command{
 		client := getClient()
 		sourceRef := MustParseRefURI("source ref", args[0])
 		destinationRef := MustParseRefURI("destination ref", args[1])
-		Fmt("Source: %s\nDestination: %s\n", sourceRef.String(), destinationRef)
 		if destinationRef.Repository != sourceRef.Repository {
 			Die("both references must belong to the same repository", 1)
 		}
+		if MustBool(cmd.Flags().GetBool("dry-run")) {
+			preview, err := previewMerge(cmd.Context(), client, destinationRef.Repository, sourceRef.Ref, destinationRef.Ref)
+			if err != nil {
+				DieErr(err)
+			}
+			if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+				PrintJSON(preview)
+				return
+			}
+			Fmt("Source: %s\nDestination: %s\n", sourceRef.String(), destinationRef)
+			printMergePreview(preview)
+			return
+		}
+		if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+			DieFmt("--%s %s requires --dry-run", OutputFlagName, OutputFormatJSON)
+		}
+
+		squash, _ := cmd.Flags().GetBool("squash")
+		kvPairs, err := getCommitMetadata(cmd)
+		if err != nil {
+			DieErr(err)
+		}
+		if err := setCommitAuthor(cmd.Context(), cmd, client, kvPairs); err != nil {
+			DieErr(err)
+		}
+		Fmt("Source: %s\nDestination: %s\n", sourceRef.String(), destinationRef)
 
-		resp, err := client.MergeIntoBranchWithResponse(cmd.Context(), destinationRef.Repository, sourceRef.Ref, destinationRef.Ref, api.MergeIntoBranchJSONRequestBody{})
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		if interactive && !term.IsTerminal(int(os.Stdin.Fd())) {
+			_, _ = fmt.Fprintln(os.Stderr, "stdin is not a terminal, interactive conflict resolution disabled")
+			interactive = false
+		}
+
+		retries, _ := cmd.Flags().GetInt("retry-on-change")
+		if retries < 0 {
+			DieFmt("invalid --retry-on-change %d: must not be negative", retries)
+		}
+
+		body := api.MergeIntoBranchJSONRequestBody{
+			Squash:   &squash,
+			Metadata: &api.Merge_Metadata{AdditionalProperties: kvPairs},
+		}
+		resp, err := mergeRetryingOnChange(cmd.Context(), client, destinationRef.Repository, sourceRef.Ref, destinationRef.Ref, body, retries)
+		if mergeBaseMoved(resp) {
+			DieFmt("destination branch %s changed while merging, try again", destinationRef.Ref)
+		}
+		if interactive && resp != nil && resp.JSON409 != nil {
+			resp, err = mergeResolvingConflicts(cmd.Context(), client, destinationRef.Repository, sourceRef.Ref, destinationRef.Ref, body, os.Stdin, os.Stdout)
+			if errors.Is(err, ErrMergeAborted) {
+				DieErr(err)
+			}
+		}
 		if resp != nil && resp.JSON409 != nil {
 			_, _ = fmt.Printf("Conflicts: %d\n", resp.JSON409.Summary.Conflict)
 			return
@@ -48,15 +112,131 @@ var mergeCmd = &cobra.Command{
 
 		Write(mergeCreateTemplate, struct {
 			Merge  FromTo
+			Squash bool
 			Result *api.MergeResult
 		}{
 			Merge:  FromTo{FromRef: sourceRef.Ref, ToRef: destinationRef.Ref},
+			Squash: squash,
 			Result: resp.JSON200,
 		})
 	},
 }
 
+// mergeRetryingOnChange merges sourceRef into destinationBranch, retrying up to retries times
+// with backoff while the merge fails because the destination branch changed while merging.
+// Conflicting changes are returned without retrying.
+func mergeRetryingOnChange(ctx context.Context, client api.ClientWithResponsesInterface, repository, sourceRef, destinationBranch string, body api.MergeIntoBranchJSONRequestBody, retries int) (*api.MergeIntoBranchResponse, error) {
+	backoff := mergeRetryBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := client.MergeIntoBranchWithResponse(ctx, repository, sourceRef, destinationBranch, body)
+		if err != nil || attempt > retries || !mergeBaseMoved(resp) {
+			return resp, err
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "Destination branch changed while merging, retrying in %s (%d/%d)\n", backoff, attempt, retries)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// mergeBaseMoved returns whether resp failed because the destination branch changed while
+// merging, rather than because of conflicting changes.
+func mergeBaseMoved(resp *api.MergeIntoBranchResponse) bool {
+	return resp != nil && resp.JSON409 != nil && api.BoolValue(resp.JSON409.BaseMoved)
+}
+
+// mergeResolvingConflicts prompts for a resolution of every path conflicting between sourceRef
+// and destinationBranch, reading answers from in, and re-submits the merge with these
+// resolutions.  Skipped paths remain conflicts.
+func mergeResolvingConflicts(ctx context.Context, client api.ClientWithResponsesInterface, repository, sourceRef, destinationBranch string, body api.MergeIntoBranchJSONRequestBody, in io.Reader, out io.Writer) (*api.MergeIntoBranchResponse, error) {
+	conflicts, err := listConflicts(ctx, client, repository, sourceRef, destinationBranch)
+	if err != nil {
+		return nil, err
+	}
+	resolutions, err := promptResolutions(conflicts, in, out)
+	if err != nil {
+		return nil, err
+	}
+	body.Resolutions = &api.Merge_Resolutions{AdditionalProperties: resolutions}
+	return client.MergeIntoBranchWithResponse(ctx, repository, sourceRef, destinationBranch, body)
+}
+
+// listConflicts returns the paths changed differently on sourceRef and destinationBranch since
+// their merge base.
+func listConflicts(ctx context.Context, client api.ClientWithResponsesInterface, repository, sourceRef, destinationBranch string) ([]string, error) {
+	var conflicts []string
+	err := walkMergeDiff(ctx, client, repository, sourceRef, destinationBranch, func(d api.Diff) {
+		if d.Type == "conflict" {
+			conflicts = append(conflicts, d.Path)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// walkMergeDiff calls fn on every path a merge of sourceRef into destinationBranch would
+// change, including conflicting paths.
+func walkMergeDiff(ctx context.Context, client api.ClientWithResponsesInterface, repository, sourceRef, destinationBranch string, fn func(d api.Diff)) error {
+	var after string
+	for {
+		resp, err := client.DiffRefsWithResponse(ctx, repository, sourceRef, destinationBranch, &api.DiffRefsParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+		})
+		if err := responseError(resp, err); err != nil {
+			return err
+		}
+		for _, d := range resp.JSON200.Results {
+			fn(d)
+		}
+		if !resp.JSON200.Pagination.HasMore {
+			return nil
+		}
+		after = resp.JSON200.Pagination.NextOffset
+	}
+}
+
+// promptResolutions asks on out how to resolve each conflicting path and reads the answers from
+// in.  It returns the resolution of every path not skipped.
+func promptResolutions(conflicts []string, in io.Reader, out io.Writer) (map[string]string, error) {
+	resolutions := make(map[string]string)
+	scanner := bufio.NewScanner(in)
+	for _, path := range conflicts {
+		for answered := false; !answered; {
+			_, _ = fmt.Fprintf(out, "Conflict: %s\nKeep [s]ource, [d]est or s[k]ip? ", path)
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return nil, err
+				}
+				return nil, fmt.Errorf("%w: no resolution for %s", ErrMergeAborted, path)
+			}
+			answered = true
+			switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+			case "s", "source":
+				resolutions[path] = "source"
+			case "d", "dest":
+				resolutions[path] = "dest"
+			case "k", "skip":
+			default:
+				answered = false
+			}
+		}
+	}
+	return resolutions, nil
+}
+
 //nolint:gochecknoinits
 func init() {
 	rootCmd.AddCommand(mergeCmd)
+	assignCommitMetadataFlags(mergeCmd)
+	mergeCmd.Flags().Bool("squash", false, "create a single commit with the net changes instead of a merge commit preserving source history")
+	mergeCmd.Flags().Bool("interactive", false, "on conflicts, prompt for a resolution of each conflicting path and retry the merge")
+	mergeCmd.Flags().Int("retry-on-change", 0, "retry the merge up to this many times, with backoff, if the destination branch changes while merging")
+	mergeCmd.Flags().Bool("dry-run", false, "only print how each changed path would be merged, without merging")
+	AssignOutputFlag(mergeCmd.Flags())
 }
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/jedib0t/go-pretty/text"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+const (
+	mergeResolutionClean    = "clean"
+	mergeResolutionConflict = "conflict"
+)
+
+var mergePreviewTemplate = `{{ if .Preview.Paths }}{{ .Table | table -}}
+{{ else }}No changes to merge
+{{ end }}
+Added: {{.Preview.Summary.Added}}
+Changed: {{.Preview.Summary.Changed}}
+Removed: {{.Preview.Summary.Removed}}
+{{ if .Preview.Summary.Conflict }}{{ "Conflicts" | red }}: {{.Preview.Summary.Conflict}}, the merge would fail
+{{ else }}No conflicts
+{{ end }}`
+
+// mergePreviewPath is how a merge would resolve a single changed path.
+type mergePreviewPath struct {
+	Path     string `json:"path"`
+	PathType string `json:"path_type"`
+	// Type is the change the source brings to the path: added, changed, removed, or conflict if
+	// both source and destination changed it.
+	Type string `json:"type"`
+	// Resolution is clean if the merge would apply the change, or conflict if it would fail on
+	// it.
+	Resolution string `json:"resolution"`
+}
+
+type mergePreviewSummary struct {
+	Added    int `json:"added"`
+	Changed  int `json:"changed"`
+	Removed  int `json:"removed"`
+	Conflict int `json:"conflict"`
+}
+
+// mergePreview is the outcome a merge of Source into Destination would have, path by path.
+type mergePreview struct {
+	Source      string              `json:"source"`
+	Destination string              `json:"destination"`
+	Summary     mergePreviewSummary `json:"summary"`
+	Paths       []mergePreviewPath  `json:"paths"`
+}
+
+// previewMerge classifies every path a merge of sourceRef into destinationBranch would change as
+// a clean change or a conflict, without merging.
+func previewMerge(ctx context.Context, client api.ClientWithResponsesInterface, repository, sourceRef, destinationBranch string) (*mergePreview, error) {
+	preview := &mergePreview{
+		Source:      sourceRef,
+		Destination: destinationBranch,
+		Paths:       make([]mergePreviewPath, 0),
+	}
+	err := walkMergeDiff(ctx, client, repository, sourceRef, destinationBranch, func(d api.Diff) {
+		resolution := mergeResolutionClean
+		switch d.Type {
+		case "added":
+			preview.Summary.Added++
+		case "changed":
+			preview.Summary.Changed++
+		case "removed":
+			preview.Summary.Removed++
+		case "conflict":
+			preview.Summary.Conflict++
+			resolution = mergeResolutionConflict
+		}
+		preview.Paths = append(preview.Paths, mergePreviewPath{
+			Path:       d.Path,
+			PathType:   d.PathType,
+			Type:       d.Type,
+			Resolution: resolution,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// mergePreviewTable returns a table of the paths in preview, with conflicts highlighted.
+func mergePreviewTable(preview *mergePreview) *Table {
+	rows := make([][]interface{}, len(preview.Paths))
+	for i, p := range preview.Paths {
+		var color text.Color
+		switch p.Type {
+		case "added":
+			color = text.FgGreen
+		case "changed":
+			color = text.FgYellow
+		case "removed":
+			color = text.FgRed
+		case "conflict":
+			color = text.FgHiRed
+		}
+		resolution := text.FgGreen.Sprint(p.Resolution)
+		if p.Resolution == mergeResolutionConflict {
+			resolution = text.Colors{text.FgHiRed, text.Bold}.Sprint(p.Resolution)
+		}
+		rows[i] = []interface{}{p.Path, color.Sprint(p.Type), resolution}
+	}
+	return &Table{
+		Headers: []interface{}{"Path", "Change", "Resolution"},
+		Rows:    rows,
+	}
+}
+
+func printMergePreview(preview *mergePreview) {
+	Write(mergePreviewTemplate, struct {
+		Preview *mergePreview
+		Table   *Table
+	}{
+		Preview: preview,
+		Table:   mergePreviewTable(preview),
+	})
+}
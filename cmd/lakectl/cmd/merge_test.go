@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+func TestMergeSquash(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantSquash bool
+		wantOutput string
+	}{
+		{name: "default", wantSquash: false, wantOutput: `Merged "feature" into "main"`},
+		{name: "squash", args: []string{"--squash"}, wantSquash: true, wantOutput: `Squash merged "feature" into "main"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body api.MergeIntoBranchJSONRequestBody
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repositories/repo/refs/feature/merge/main", func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				result := api.MergeResult{Reference: "c0ffee"}
+				result.Summary.Added = 2
+				writeJSON(w, http.StatusOK, result)
+			})
+
+			args := append([]string{"merge", "lakefs://repo/feature", "lakefs://repo/main"}, tt.args...)
+			out := runCmd(t, mux, args...)
+			if api.BoolValue(body.Squash) != tt.wantSquash {
+				t.Errorf("merge request squash = %t, expected %t", api.BoolValue(body.Squash), tt.wantSquash)
+			}
+			if !strings.Contains(out, tt.wantOutput) {
+				t.Errorf("output %q does not contain %q", out, tt.wantOutput)
+			}
+			if !strings.Contains(out, "Added: 2") {
+				t.Errorf("output %q does not contain merge summary", out)
+			}
+		})
+	}
+}
+
+func TestMergeResolvedConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		conflicts int
+		wantLine  bool
+	}{
+		{name: "none", conflicts: 0, wantLine: false},
+		{name: "resolved", conflicts: 3, wantLine: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repositories/repo/refs/feature/merge/main", func(w http.ResponseWriter, r *http.Request) {
+				result := api.MergeResult{Reference: "c0ffee"}
+				result.Summary.Changed = 1
+				result.Summary.Conflict = tt.conflicts
+				writeJSON(w, http.StatusOK, result)
+			})
+
+			out := runCmd(t, mux, "merge", "lakefs://repo/feature", "lakefs://repo/main")
+			if shown := strings.Contains(out, "Resolved conflicts: 3"); shown != tt.wantLine {
+				t.Errorf("output %q shows resolved conflicts: %t, expected %t", out, shown, tt.wantLine)
+			}
+			if strings.Contains(out, "Resolved conflicts: 0") {
+				t.Errorf("output %q shows zero resolved conflicts", out)
+			}
+		})
+	}
+}
+
+func TestMergeResolvingConflicts(t *testing.T) {
+	var requests []api.MergeIntoBranchJSONRequestBody
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/feature/diff/main", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.DiffList{
+			Results: []api.Diff{
+				{Path: "a", PathType: "object", Type: "conflict"},
+				{Path: "b", PathType: "object", Type: "added"},
+				{Path: "c", PathType: "object", Type: "conflict"},
+				{Path: "d", PathType: "object", Type: "conflict"},
+			},
+		})
+	})
+	mux.HandleFunc("/repositories/repo/refs/feature/merge/main", func(w http.ResponseWriter, r *http.Request) {
+		var body api.MergeIntoBranchJSONRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, body)
+		result := api.MergeResult{Reference: "c0ffee"}
+		result.Summary.Conflict = 2
+		writeJSON(w, http.StatusOK, result)
+	})
+	client := newTestClient(t, mux)
+
+	squash := true
+	in := strings.NewReader("source\nwhat\nk\nd\n")
+	var out bytes.Buffer
+	resp, err := mergeResolvingConflicts(context.Background(), client, "repo", "feature", "main", api.MergeIntoBranchJSONRequestBody{Squash: &squash}, in, &out)
+	if err != nil {
+		t.Fatalf("merge resolving conflicts: %s", err)
+	}
+	if resp.JSON200 == nil || resp.JSON200.Reference != "c0ffee" {
+		t.Errorf("unexpected merge response %+v", resp)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("got %d merge requests, expected 1", len(requests))
+	}
+	if requests[0].Resolutions == nil {
+		t.Fatal("merge request without resolutions")
+	}
+	expected := map[string]string{"a": "source", "d": "dest"}
+	if diff := deep.Equal(requests[0].Resolutions.AdditionalProperties, expected); diff != nil {
+		t.Errorf("unexpected resolutions: %s", diff)
+	}
+	if !api.BoolValue(requests[0].Squash) {
+		t.Error("merge request lost squash")
+	}
+	for _, path := range []string{"Conflict: a", "Conflict: c", "Conflict: d"} {
+		if !strings.Contains(out.String(), path) {
+			t.Errorf("prompt %q does not contain %q", out.String(), path)
+		}
+	}
+	if strings.Contains(out.String(), "Conflict: b") {
+		t.Errorf("prompt %q asks about a path without conflict", out.String())
+	}
+}
+
+func TestMergeResolvingConflictsInputEnded(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/feature/diff/main", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.DiffList{
+			Results: []api.Diff{{Path: "a", PathType: "object", Type: "conflict"}},
+		})
+	})
+	mux.HandleFunc("/repositories/repo/refs/feature/merge/main", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected merge request")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client := newTestClient(t, mux)
+
+	_, err := mergeResolvingConflicts(context.Background(), client, "repo", "feature", "main", api.MergeIntoBranchJSONRequestBody{}, strings.NewReader(""), &bytes.Buffer{})
+	if !errors.Is(err, ErrMergeAborted) {
+		t.Fatalf("got error %v, expected %s", err, ErrMergeAborted)
+	}
+}
+
+func TestMergeRetryOnChange(t *testing.T) {
+	backoff := mergeRetryBackoff
+	mergeRetryBackoff = time.Millisecond
+	t.Cleanup(func() { mergeRetryBackoff = backoff })
+
+	attempts := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/feature/merge/main", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			writeJSON(w, http.StatusConflict, api.MergeResult{BaseMoved: swag.Bool(true)})
+			return
+		}
+		result := api.MergeResult{Reference: "c0ffee"}
+		result.Summary.Added = 1
+		writeJSON(w, http.StatusOK, result)
+	})
+
+	out := runCmd(t, mux, "merge", "lakefs://repo/feature", "lakefs://repo/main", "--retry-on-change", "2")
+	if attempts != 2 {
+		t.Errorf("merged %d times, expected 2", attempts)
+	}
+	if !strings.Contains(out, `Merged "feature" into "main" to get "c0ffee"`) {
+		t.Errorf("output %q does not show the retried merge", out)
+	}
+}
+
+func TestMergeRetryingOnChange(t *testing.T) {
+	backoff := mergeRetryBackoff
+	mergeRetryBackoff = time.Millisecond
+	t.Cleanup(func() { mergeRetryBackoff = backoff })
+
+	tests := []struct {
+		name         string
+		baseMoved    bool
+		retries      int
+		wantAttempts int
+	}{
+		{name: "base moved", baseMoved: true, retries: 2, wantAttempts: 3},
+		{name: "no retries", baseMoved: true, retries: 0, wantAttempts: 1},
+		{name: "conflicting changes", baseMoved: false, retries: 2, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repositories/repo/refs/feature/merge/main", func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				result := api.MergeResult{}
+				if tt.baseMoved {
+					result.BaseMoved = swag.Bool(true)
+				} else {
+					result.Summary.Conflict = 1
+				}
+				writeJSON(w, http.StatusConflict, result)
+			})
+			client := newTestClient(t, mux)
+
+			resp, err := mergeRetryingOnChange(context.Background(), client, "repo", "feature", "main", api.MergeIntoBranchJSONRequestBody{}, tt.retries)
+			if err != nil {
+				t.Fatalf("mergeRetryingOnChange: %s", err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("merged %d times, expected %d", attempts, tt.wantAttempts)
+			}
+			if resp.JSON409 == nil || mergeBaseMoved(resp) != tt.baseMoved {
+				t.Errorf("got response %+v, expected a conflict with base moved %t", resp.JSON409, tt.baseMoved)
+			}
+		})
+	}
+}
+
+func mergePreviewHandler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/feature/diff/main", func(w http.ResponseWriter, r *http.Request) {
+		results := []api.Diff{
+			{Path: "a", PathType: "object", Type: "added"},
+			{Path: "b", PathType: "object", Type: "conflict"},
+			{Path: "c", PathType: "object", Type: "changed"},
+		}
+		next := "c"
+		if r.URL.Query().Get("after") == "c" {
+			results = []api.Diff{
+				{Path: "d", PathType: "object", Type: "removed"},
+				{Path: "e/", PathType: "common_prefix", Type: "conflict"},
+			}
+			next = ""
+		}
+		writeJSON(w, http.StatusOK, api.DiffList{
+			Pagination: api.Pagination{HasMore: next != "", NextOffset: next},
+			Results:    results,
+		})
+	})
+	mux.HandleFunc("/repositories/repo/refs/feature/merge/main", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected merge request")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	return mux
+}
+
+func TestMergeDryRun(t *testing.T) {
+	out := runCmd(t, mergePreviewHandler(t), "merge", "lakefs://repo/feature", "lakefs://repo/main", "--dry-run")
+	for _, row := range []string{
+		"a\tadded\tclean\n",
+		"b\tconflict\tconflict\n",
+		"c\tchanged\tclean\n",
+		"d\tremoved\tclean\n",
+		"e/\tconflict\tconflict\n",
+		"Added: 1\nChanged: 1\nRemoved: 1\n",
+		"Conflicts: 2, the merge would fail\n",
+	} {
+		if !strings.Contains(out, row) {
+			t.Errorf("output %q does not contain %q", out, row)
+		}
+	}
+}
+
+func TestMergeDryRunJSON(t *testing.T) {
+	out := runCmd(t, mergePreviewHandler(t), "merge", "lakefs://repo/feature", "lakefs://repo/main", "--dry-run", "--output", "json")
+	var preview mergePreview
+	if err := json.Unmarshal([]byte(out), &preview); err != nil {
+		t.Fatalf("decode output %q: %s", out, err)
+	}
+	expected := mergePreview{
+		Source:      "feature",
+		Destination: "main",
+		Summary:     mergePreviewSummary{Added: 1, Changed: 1, Removed: 1, Conflict: 2},
+		Paths: []mergePreviewPath{
+			{Path: "a", PathType: "object", Type: "added", Resolution: "clean"},
+			{Path: "b", PathType: "object", Type: "conflict", Resolution: "conflict"},
+			{Path: "c", PathType: "object", Type: "changed", Resolution: "clean"},
+			{Path: "d", PathType: "object", Type: "removed", Resolution: "clean"},
+			{Path: "e/", PathType: "common_prefix", Type: "conflict", Resolution: "conflict"},
+		},
+	}
+	if diff := deep.Equal(preview, expected); diff != nil {
+		t.Errorf("unexpected merge preview: %s", diff)
+	}
+}
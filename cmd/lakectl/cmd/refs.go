@@ -1,80 +1,397 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
 
 	"github.com/spf13/cobra"
 	"github.com/treeverse/lakefs/pkg/api"
 )
 
-var metadataDumpTemplate = `
-{{ .Response | json }}
-`
+// refsDumpVersion is the version of the format of refs dump files.
+const refsDumpVersion = 1
 
-var refsRestoreSuccess = `
-{{ "All references restored successfully!" | green }}
-`
+var (
+	ErrUnsupportedRefsDump = errors.New("unsupported refs dump version")
+	ErrRefsRestoreMismatch = errors.New("restored refs differ from the dump")
+)
 
-var refsRestoreCmd = &cobra.Command{
-	Use:   "refs-restore <repository uri>",
-	Short: "restores refs (branches, commits, tags) from the underlying object store to a bare repository",
-	Long: `restores refs (branches, commits, tags) from the underlying object store to a bare repository.
-
-This command is expected to run on a bare repository (i.e. one created with 'lakectl repo create-bare').
-Since a bare repo is expected, in case of transient failure, delete the repository and recreate it as bare and retry.`,
-	Example: "aws s3 cp s3://bucket/_lakefs/refs_manifest.json - | lakectl refs-load lakefs://my-bare-repository --manifest -",
-	Hidden:  true,
+var refsRestoreSummaryTemplate = `{{ if .Empty }}{{ "All references restored successfully!" | green }}{{ if .Commits }}
+Verified commits: {{ .Commits }}{{ end }}
+{{ else }}Created: {{ .Created | len }}
+Skipped existing: {{ .Existing | len }}{{ if .MissingCommit }}
+{{ "Skipped, commit missing" | red }}: {{ .MissingCommit | join ", " }}{{ end }}
+{{ end }}`
+
+// refsDumpFile is a portable dump of the refs of a repository.  Refs holds the dumps of its
+// branches, tags and commits to its storage namespace, from which a bare repository can be
+// restored.  The refs are also listed for reviewing and for restoring refs missing from a
+// repository that is not bare.
+type refsDumpFile struct {
+	Version    int          `json:"version"`
+	Repository string       `json:"repository"`
+	Refs       api.RefsDump `json:"refs"`
+	Branches   []api.Ref    `json:"branches"`
+	Tags       []api.Ref    `json:"tags"`
+	Commits    []api.Commit `json:"commits"`
+}
+
+// refsRestoreSummary is the outcome of restoring refs to a repository.  If the repository was
+// empty, all refs were restored, along with Commits commits.  Otherwise it lists the refs
+// created, those that already existed and those whose commit is missing, which cannot be
+// restored.
+type refsRestoreSummary struct {
+	Empty         bool
+	Commits       int
+	Created       []string
+	Existing      []string
+	MissingCommit []string
+}
+
+var refsCmd = &cobra.Command{
+	Use:   "refs",
+	Short: "back up and restore the refs (branches, tags and commits) of a repository",
+}
+
+var refsBackupDumpCmd = &cobra.Command{
+	Use:   "dump <repository uri>",
+	Short: "dump the refs (branches, tags and commits) of a repository to a file",
+	Long: `dump the refs (branches, tags and commits) of a repository to a JSON file, for restoring them with 'lakectl refs restore'.
+
+The refs are also dumped to the storage namespace of the repository, where the dump file refers to them.`,
+	Example: "lakectl refs dump lakefs://example-repo --to example-repo-refs.json",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRefsDump(cmd.Context(), args[0], MustString(cmd.Flags().GetString("to")))
+	},
+}
+
+var refsBackupRestoreCmd = &cobra.Command{
+	Use:   "restore <repository uri>",
+	Short: "restore the refs (branches, tags and commits) of a repository from a file",
+	Long: `restore the refs (branches, tags and commits) of a repository from a file written by 'lakectl refs dump', or from a refs manifest dumped by lakeFS to the storage namespace of the repository.
+
+An empty repository, i.e. a bare one (created with 'lakectl repo create-bare') or one holding only the first commit of its default branch and no uncommitted changes, on the storage namespace of the dumped repository is restored entirely.  On other repositories, only missing branches and tags whose commit exists are created, and existing ones are skipped, so restoring again is safe.`,
+	Example: "lakectl refs restore lakefs://example-repo --from example-repo-refs.json",
 	Args:    cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		repoURI := MustParseRepoURI("repository", args[0])
-		Fmt("Repository: %s\n", repoURI.String())
-		manifestFileName, _ := cmd.Flags().GetString("manifest")
-		fp := OpenByPath(manifestFileName)
-		defer func() {
-			_ = fp.Close()
-		}()
-
-		// read and parse the JSON
-		data, err := ioutil.ReadAll(fp)
+		runRefsRestore(cmd.Context(), args[0], MustString(cmd.Flags().GetString("from")))
+	},
+}
+
+var refsDumpCmd = &cobra.Command{
+	Use:        "refs-dump <repository uri>",
+	Short:      "dumps refs (branches, commits, tags) to the underlying object store",
+	Hidden:     true,
+	Deprecated: "use 'lakectl refs dump' instead",
+	Args:       cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRefsDump(cmd.Context(), args[0], StdinFileName)
+	},
+}
+
+var refsRestoreCmd = &cobra.Command{
+	Use:        "refs-restore <repository uri>",
+	Short:      "restores refs (branches, commits, tags) from the underlying object store to a bare repository",
+	Hidden:     true,
+	Deprecated: "use 'lakectl refs restore' instead",
+	Args:       cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRefsRestore(cmd.Context(), args[0], MustString(cmd.Flags().GetString("manifest")))
+	},
+}
+
+func runRefsDump(ctx context.Context, repository, to string) {
+	repoURI := MustParseRepoURI("repository", repository)
+	dump, err := dumpRefs(ctx, getClient(), repoURI.Repository)
+	if err != nil {
+		DieErr(err)
+	}
+	if err := writeRefsDumpFile(to, dump); err != nil {
+		DieErr(err)
+	}
+}
+
+func runRefsRestore(ctx context.Context, repository, from string) {
+	repoURI := MustParseRepoURI("repository", repository)
+	fp := OpenByPath(from)
+	defer func() {
+		_ = fp.Close()
+	}()
+	dump, err := readRefsDump(fp)
+	if err != nil {
+		DieErr(err)
+	}
+	summary, err := restoreRefs(ctx, getClient(), repoURI.Repository, dump)
+	if err != nil {
+		DieErr(err)
+	}
+	Write(refsRestoreSummaryTemplate, summary)
+}
+
+// dumpRefs dumps the refs of repository.  Refs and commits are sorted by ID, so dumping the
+// same refs gives the same dump.
+func dumpRefs(ctx context.Context, client api.ClientWithResponsesInterface, repository string) (*refsDumpFile, error) {
+	dumpResp, err := client.DumpRefsWithResponse(ctx, repository)
+	if err := responseError(dumpResp, err); err != nil {
+		return nil, err
+	}
+	dump := &refsDumpFile{
+		Version:    refsDumpVersion,
+		Repository: repository,
+		Refs:       *dumpResp.JSON201,
+	}
+	dump.Branches, err = listAllRefs(func(after string) (*api.RefList, error) {
+		resp, err := client.ListBranchesWithResponse(ctx, repository, &api.ListBranchesParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+		})
+		if err := responseError(resp, err); err != nil {
+			return nil, err
+		}
+		return resp.JSON200, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	dump.Tags, err = listAllRefs(func(after string) (*api.RefList, error) {
+		resp, err := client.ListTagsWithResponse(ctx, repository, &api.ListTagsParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+		})
+		if err := responseError(resp, err); err != nil {
+			return nil, err
+		}
+		return resp.JSON200, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	dump.Commits, err = listRefsCommits(ctx, client, repository, append(append([]api.Ref{}, dump.Branches...), dump.Tags...))
+	if err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// listAllRefs returns all refs listed page by page by list, sorted by ID.
+func listAllRefs(list func(after string) (*api.RefList, error)) ([]api.Ref, error) {
+	refs := make([]api.Ref, 0)
+	var after string
+	for {
+		page, err := list(after)
 		if err != nil {
-			DieErr(err)
+			return nil, err
 		}
+		refs = append(refs, page.Results...)
+		if !page.Pagination.HasMore {
+			break
+		}
+		after = page.Pagination.NextOffset
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Id < refs[j].Id })
+	return refs, nil
+}
+
+// listRefsCommits returns the commits reachable from refs, sorted by ID.
+func listRefsCommits(ctx context.Context, client api.ClientWithResponsesInterface, repository string, refs []api.Ref) ([]api.Commit, error) {
+	seen := make(map[string]bool)
+	commits := make([]api.Commit, 0)
+	for _, ref := range refs {
+		if seen[ref.CommitId] {
+			// the log of a listed commit lists all its ancestors
+			continue
+		}
+		var after string
+		for {
+			resp, err := client.LogCommitsWithResponse(ctx, repository, ref.CommitId, &api.LogCommitsParams{
+				After:  api.PaginationAfterPtr(after),
+				Amount: api.PaginationAmountPtr(internalPageSize),
+			})
+			if err := responseError(resp, err); err != nil {
+				return nil, err
+			}
+			for _, commit := range resp.JSON200.Results {
+				if !seen[commit.Id] {
+					seen[commit.Id] = true
+					commits = append(commits, commit)
+				}
+			}
+			if !resp.JSON200.Pagination.HasMore {
+				break
+			}
+			after = resp.JSON200.Pagination.NextOffset
+		}
+	}
+	sort.Slice(commits, func(i, j int) bool { return commits[i].Id < commits[j].Id })
+	return commits, nil
+}
+
+// writeRefsDumpFile writes dump to the file at path, or to stdout if path is "-".
+func writeRefsDumpFile(path string, dump *refsDumpFile) (err error) {
+	if path == StdinFileName {
+		return writeRefsDump(os.Stdout, dump)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	return writeRefsDump(f, dump)
+}
+
+func writeRefsDump(w io.Writer, dump *refsDumpFile) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dump)
+}
+
+// readRefsDump reads a dump written by writeRefsDump, or a refs manifest dumped by lakeFS to a
+// storage namespace, which holds only the refs of the dump.
+func readRefsDump(r io.Reader) (*refsDumpFile, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var dump refsDumpFile
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return nil, err
+	}
+	if dump.Version == 0 {
 		var manifest api.RefsDump
-		err = json.Unmarshal(data, &manifest)
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, err
+		}
+		if manifest.CommitsMetaRangeId != "" {
+			return &refsDumpFile{Version: refsDumpVersion, Refs: manifest}, nil
+		}
+	}
+	if dump.Version != refsDumpVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedRefsDump, dump.Version)
+	}
+	return &dump, nil
+}
+
+// restoreRefs restores the refs of dump to repository.  The server restores an empty repository
+// from the refs dumped to its storage namespace, which is then checked to hold the dumped commits.
+// The server refuses any other repository, so each missing branch and tag is created at its
+// commit instead, if the commit exists.
+func restoreRefs(ctx context.Context, client api.ClientWithResponsesInterface, repository string, dump *refsDumpFile) (*refsRestoreSummary, error) {
+	resp, err := client.RestoreRefsWithResponse(ctx, repository, api.RestoreRefsJSONRequestBody(dump.Refs))
+	if err == nil && resp.StatusCode() == http.StatusBadRequest {
+		return restoreMissingRefs(ctx, client, repository, dump)
+	}
+	if err := responseError(resp, err); err != nil {
+		return nil, err
+	}
+	if err := verifyRestoredCommits(ctx, client, repository, dump.Commits); err != nil {
+		return nil, err
+	}
+	return &refsRestoreSummary{Empty: true, Commits: len(dump.Commits)}, nil
+}
+
+// restoreMissingRefs creates each branch and tag of dump missing from repository at its commit,
+// if the commit exists.
+func restoreMissingRefs(ctx context.Context, client api.ClientWithResponsesInterface, repository string, dump *refsDumpFile) (*refsRestoreSummary, error) {
+	summary := &refsRestoreSummary{}
+	for _, branch := range dump.Branches {
+		branch := branch
+		err := restoreRef(ctx, client, repository, summary, "branch "+branch.Id, branch.CommitId, func() (StatusCoder, error) {
+			return client.GetBranchWithResponse(ctx, repository, branch.Id)
+		}, func() (interface{}, error) {
+			return client.CreateBranchWithResponse(ctx, repository, api.CreateBranchJSONRequestBody{
+				Name:   branch.Id,
+				Source: branch.CommitId,
+			})
+		})
 		if err != nil {
-			DieErr(err)
+			return nil, err
 		}
-		// execute the restore operation
-		client := getClient()
-		resp, err := client.RestoreRefsWithResponse(cmd.Context(), repoURI.Repository, api.RestoreRefsJSONRequestBody(manifest))
-		DieOnResponseError(resp, err)
-		Write(refsRestoreSuccess, nil)
-	},
+	}
+	for _, tag := range dump.Tags {
+		tag := tag
+		err := restoreRef(ctx, client, repository, summary, "tag "+tag.Id, tag.CommitId, func() (StatusCoder, error) {
+			return client.GetTagWithResponse(ctx, repository, tag.Id)
+		}, func() (interface{}, error) {
+			return client.CreateTagWithResponse(ctx, repository, api.CreateTagJSONRequestBody{
+				Id:  tag.Id,
+				Ref: tag.CommitId,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return summary, nil
 }
 
-var refsDumpCmd = &cobra.Command{
-	Use:    "refs-dump <repository uri>",
-	Short:  "dumps refs (branches, commits, tags) to the underlying object store",
-	Hidden: true,
-	Args:   cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		repoURI := MustParseRepoURI("repository", args[0])
-		Fmt("Repository: %s\n", repoURI.String())
-		client := getClient()
-		resp, err := client.DumpRefsWithResponse(cmd.Context(), repoURI.Repository)
-		DieOnResponseError(resp, err)
-
-		Write(metadataDumpTemplate, struct {
-			Response interface{}
-		}{resp.JSON201})
-	},
+// verifyRestoredCommits returns an error unless repository holds each of commits, with the same
+// content.
+func verifyRestoredCommits(ctx context.Context, client api.ClientWithResponsesInterface, repository string, commits []api.Commit) error {
+	for _, commit := range commits {
+		resp, err := client.GetCommitWithResponse(ctx, repository, commit.Id)
+		if err := responseError(resp, err); err != nil {
+			return fmt.Errorf("restored commit %s: %w", commit.Id, err)
+		}
+		if resp.JSON200.MetaRangeId != commit.MetaRangeId {
+			return fmt.Errorf("%w: restored commit %s has meta-range %s, dumped with %s", ErrRefsRestoreMismatch, commit.Id, resp.JSON200.MetaRangeId, commit.MetaRangeId)
+		}
+	}
+	return nil
+}
+
+// restoreRef records the ref name in summary as existing if get finds it.  Otherwise it calls
+// create to create it at commitID if that commit exists, and records it as created, or as missing
+// its commit.
+func restoreRef(ctx context.Context, client api.ClientWithResponsesInterface, repository string, summary *refsRestoreSummary, name, commitID string, get func() (StatusCoder, error), create func() (interface{}, error)) error {
+	getResp, err := get()
+	if err != nil {
+		return err
+	}
+	if getResp.StatusCode() != http.StatusNotFound {
+		if err := responseError(getResp, nil); err != nil {
+			return err
+		}
+		summary.Existing = append(summary.Existing, name)
+		return nil
+	}
+	commitResp, err := client.GetCommitWithResponse(ctx, repository, commitID)
+	if err == nil && commitResp.StatusCode() == http.StatusNotFound {
+		summary.MissingCommit = append(summary.MissingCommit, name)
+		return nil
+	}
+	if err := responseError(commitResp, err); err != nil {
+		return err
+	}
+	if err := responseError(create()); err != nil {
+		return err
+	}
+	summary.Created = append(summary.Created, name)
+	return nil
 }
 
 //nolint:gochecknoinits
 func init() {
 	rootCmd.AddCommand(refsDumpCmd)
 	rootCmd.AddCommand(refsRestoreCmd)
+	rootCmd.AddCommand(refsCmd)
+	refsCmd.AddCommand(refsBackupDumpCmd)
+	refsCmd.AddCommand(refsBackupRestoreCmd)
+
+	refsBackupDumpCmd.Flags().String("to", StdinFileName, "path of the file to write the dump to, or \"-\" for stdout")
+	refsBackupRestoreCmd.Flags().String("from", "", "path of a file written by 'lakectl refs dump', or \"-\" to read from stdin")
+	_ = refsBackupRestoreCmd.MarkFlagRequired("from")
 
 	refsRestoreCmd.Flags().String("manifest", "", "path to a refs manifest json file (as generated by `refs-dump`). Alternatively, use \"-\" to read from stdin")
 	_ = refsRestoreCmd.MarkFlagRequired("manifest")
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+// refsHandler serves the refs of repository "repo": branches and tags listed in the given order,
+// and commits listed by each ref.
+func refsHandler(t *testing.T, branches, tags []api.Ref, logs map[string][]api.Commit) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/dump", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusCreated, api.RefsDump{
+			BranchesMetaRangeId: "branches-range",
+			CommitsMetaRangeId:  "commits-range",
+			TagsMetaRangeId:     "tags-range",
+		})
+	})
+	mux.HandleFunc("/repositories/repo/branches", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.RefList{Results: branches})
+	})
+	mux.HandleFunc("/repositories/repo/tags", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.RefList{Results: tags})
+	})
+	mux.HandleFunc("/repositories/repo/refs/", func(w http.ResponseWriter, r *http.Request) {
+		ref := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/repositories/repo/refs/"), "/commits")
+		commits, ok := logs[ref]
+		if !ok {
+			t.Errorf("unexpected log of %s", ref)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for i := range commits {
+			// the server always sends metadata
+			commits[i].Metadata = &api.Commit_Metadata{}
+		}
+		writeJSON(w, http.StatusOK, api.CommitList{Results: commits})
+	})
+	return mux
+}
+
+func TestRefsDump(t *testing.T) {
+	branches := []api.Ref{{Id: "main", CommitId: "c3"}, {Id: "dev", CommitId: "c2"}}
+	tags := []api.Ref{{Id: "v1", CommitId: "c1"}}
+	logs := map[string][]api.Commit{
+		"c3": {
+			{Id: "c3", Message: "third", Parents: []string{"c1"}},
+			{Id: "c1", Message: "first"},
+		},
+		"c2": {
+			{Id: "c2", Message: "second", Parents: []string{"c1"}},
+			{Id: "c1", Message: "first"},
+		},
+	}
+	handler := refsHandler(t, branches, tags, logs)
+
+	out := runCmd(t, handler, "refs", "dump", "lakefs://repo")
+	var dump refsDumpFile
+	if err := json.Unmarshal([]byte(out), &dump); err != nil {
+		t.Fatalf("decode dump %q: %s", out, err)
+	}
+	expected := refsDumpFile{
+		Version:    refsDumpVersion,
+		Repository: "repo",
+		Refs: api.RefsDump{
+			BranchesMetaRangeId: "branches-range",
+			CommitsMetaRangeId:  "commits-range",
+			TagsMetaRangeId:     "tags-range",
+		},
+		Branches: []api.Ref{{Id: "dev", CommitId: "c2"}, {Id: "main", CommitId: "c3"}},
+		Tags:     []api.Ref{{Id: "v1", CommitId: "c1"}},
+		Commits: []api.Commit{
+			{Id: "c1", Message: "first", Metadata: &api.Commit_Metadata{}},
+			{Id: "c2", Message: "second", Parents: []string{"c1"}, Metadata: &api.Commit_Metadata{}},
+			{Id: "c3", Message: "third", Parents: []string{"c1"}, Metadata: &api.Commit_Metadata{}},
+		},
+	}
+	if diff := deep.Equal(dump, expected); diff != nil {
+		t.Errorf("unexpected dump: %s", diff)
+	}
+
+	// listing refs in another order dumps the same file
+	reversed := refsHandler(t, []api.Ref{branches[1], branches[0]}, tags, logs)
+	to := filepath.Join(t.TempDir(), "refs.json")
+	runCmd(t, reversed, "refs", "dump", "lakefs://repo", "--to", to)
+	written, err := os.ReadFile(to)
+	if err != nil {
+		t.Fatalf("read dump file: %s", err)
+	}
+	if string(written) != out {
+		t.Errorf("dump file\n%s\ndiffers from dump\n%s", written, out)
+	}
+}
+
+func TestRefsRestoreBare(t *testing.T) {
+	var restored []api.RefsDump
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/restore", func(w http.ResponseWriter, r *http.Request) {
+		var body api.RefsDump
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		restored = append(restored, body)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	refs := api.RefsDump{BranchesMetaRangeId: "b", CommitsMetaRangeId: "c", TagsMetaRangeId: "t"}
+	from := filepath.Join(t.TempDir(), "refs.json")
+	f, err := os.Create(from)
+	if err != nil {
+		t.Fatalf("create dump file: %s", err)
+	}
+	if err := writeRefsDump(f, &refsDumpFile{Version: refsDumpVersion, Repository: "repo", Refs: refs, Branches: []api.Ref{{Id: "main", CommitId: "c1"}}}); err != nil {
+		t.Fatalf("write dump file: %s", err)
+	}
+	_ = f.Close()
+
+	out := runCmd(t, mux, "refs", "restore", "lakefs://repo", "--from", from)
+	if diff := deep.Equal(restored, []api.RefsDump{refs}); diff != nil {
+		t.Errorf("unexpected restore requests: %s", diff)
+	}
+	if !strings.Contains(out, "All references restored successfully!") {
+		t.Errorf("output %q does not report restoring all references", out)
+	}
+}
+
+func TestRefsRestoreEmpty(t *testing.T) {
+	restored := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/refs/restore", func(w http.ResponseWriter, r *http.Request) {
+		restored = true
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/repositories/repo/commits/", func(w http.ResponseWriter, r *http.Request) {
+		if !restored {
+			writeJSON(w, http.StatusNotFound, api.Error{Message: "not found"})
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/repositories/repo/commits/")
+		writeJSON(w, http.StatusOK, api.Commit{Id: id, MetaRangeId: "range-" + id})
+	})
+
+	// a raw manifest written by an older "lakectl refs-dump" restores into a fresh repository
+	from := filepath.Join(t.TempDir(), "manifest.json")
+	manifest := `{"branches_meta_range_id": "b", "commits_meta_range_id": "c", "tags_meta_range_id": "t"}`
+	if err := os.WriteFile(from, []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write manifest: %s", err)
+	}
+	out := runCmd(t, mux, "refs", "restore", "lakefs://repo", "--from", from)
+	if !restored {
+		t.Error("references not restored into a repository holding only its first commit")
+	}
+	if !strings.Contains(out, "All references restored successfully!") {
+		t.Errorf("output %q does not report restoring all references", out)
+	}
+
+	// commits of a dump are verified once restored
+	restored = false
+	dump := &refsDumpFile{
+		Version: refsDumpVersion,
+		Refs:    api.RefsDump{BranchesMetaRangeId: "b", CommitsMetaRangeId: "c", TagsMetaRangeId: "t"},
+		Commits: []api.Commit{{Id: "c1", MetaRangeId: "range-c1"}, {Id: "c2", MetaRangeId: "range-c2"}},
+	}
+	client := newTestClient(t, mux)
+	summary, err := restoreRefs(context.Background(), client, "repo", dump)
+	if err != nil {
+		t.Fatalf("restore refs: %s", err)
+	}
+	if diff := deep.Equal(summary, &refsRestoreSummary{Empty: true, Commits: 2}); diff != nil {
+		t.Errorf("unexpected restore summary: %s", diff)
+	}
+
+	restored = false
+	dump.Commits[1].MetaRangeId = "other"
+	if _, err := restoreRefs(context.Background(), client, "repo", dump); !errors.Is(err, ErrRefsRestoreMismatch) {
+		t.Errorf("restore of mismatched commits: got error %v, expected %s", err, ErrRefsRestoreMismatch)
+	}
+}
+
+func TestRestoreRefsSkipsExisting(t *testing.T) {
+	branches := map[string]string{"main": "c2"}
+	tags := map[string]string{}
+	commits := map[string]bool{"c1": true, "c2": true}
+	mux := http.NewServeMux()
+	serveRefs := func(prefix string, refs map[string]string) {
+		mux.HandleFunc(prefix, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				var created struct {
+					Name   string `json:"name"`
+					Source string `json:"source"`
+					ID     string `json:"id"`
+					Ref    string `json:"ref"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				refs[created.Name+created.ID] = created.Source + created.Ref
+				writeJSON(w, http.StatusCreated, api.Ref{Id: created.Name + created.ID, CommitId: created.Source + created.Ref})
+				return
+			}
+			id := strings.TrimPrefix(r.URL.Path, prefix)
+			if id == "" {
+				list := make([]api.Ref, 0)
+				for id, commitID := range refs {
+					list = append(list, api.Ref{Id: id, CommitId: commitID})
+				}
+				writeJSON(w, http.StatusOK, api.RefList{Results: list})
+				return
+			}
+			commitID, ok := refs[id]
+			if !ok {
+				writeJSON(w, http.StatusNotFound, api.Error{Message: "not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, api.Ref{Id: id, CommitId: commitID})
+		})
+	}
+	serveRefs("/repositories/repo/branches/", branches)
+	serveRefs("/repositories/repo/tags/", tags)
+	mux.HandleFunc("/repositories/repo/branches", func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path += "/"
+		mux.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("/repositories/repo/tags", func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path += "/"
+		mux.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("/repositories/repo/commits/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/repositories/repo/commits/")
+		if !commits[id] {
+			writeJSON(w, http.StatusNotFound, api.Error{Message: "not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, api.Commit{Id: id})
+	})
+	mux.HandleFunc("/repositories/repo/refs/c2/commits", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.CommitList{Results: []api.Commit{{Id: "c2", Parents: []string{"c1"}}, {Id: "c1", Parents: []string{}}}})
+	})
+	mux.HandleFunc("/repositories/repo/refs/restore", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusBadRequest, api.Error{Message: "can only restore into a bare or empty repository"})
+	})
+	client := newTestClient(t, mux)
+
+	dump := &refsDumpFile{
+		Version:  refsDumpVersion,
+		Branches: []api.Ref{{Id: "dev", CommitId: "c1"}, {Id: "lost", CommitId: "c9"}, {Id: "main", CommitId: "c2"}},
+		Tags:     []api.Ref{{Id: "v1", CommitId: "c1"}},
+	}
+	summary, err := restoreRefs(context.Background(), client, "repo", dump)
+	if err != nil {
+		t.Fatalf("restore refs: %s", err)
+	}
+	expected := &refsRestoreSummary{
+		Created:       []string{"branch dev", "tag v1"},
+		Existing:      []string{"branch main"},
+		MissingCommit: []string{"branch lost"},
+	}
+	if diff := deep.Equal(summary, expected); diff != nil {
+		t.Errorf("unexpected restore summary: %s", diff)
+	}
+	if diff := deep.Equal(branches, map[string]string{"main": "c2", "dev": "c1"}); diff != nil {
+		t.Errorf("unexpected branches after restore: %s", diff)
+	}
+	if diff := deep.Equal(tags, map[string]string{"v1": "c1"}); diff != nil {
+		t.Errorf("unexpected tags after restore: %s", diff)
+	}
+
+	// restoring again creates nothing
+	summary, err = restoreRefs(context.Background(), client, "repo", dump)
+	if err != nil {
+		t.Fatalf("restore refs again: %s", err)
+	}
+	expected = &refsRestoreSummary{
+		Existing:      []string{"branch dev", "branch main", "tag v1"},
+		MissingCommit: []string{"branch lost"},
+	}
+	if diff := deep.Equal(summary, expected); diff != nil {
+		t.Errorf("unexpected summary of restoring again: %s", diff)
+	}
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -8,8 +10,11 @@ import (
 )
 
 const (
-	DefaultBranch     = "main"
-	repoCreateCmdArgs = 2
+	DefaultBranch        = "main"
+	repoCreateCmdArgs    = 2
+	repoUsageConcurrency = 10
+
+	repoUsageNotAvailable = "n/a"
 )
 
 // repoCmd represents the repo command
@@ -24,6 +29,7 @@ var repoListCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		amount := MustInt(cmd.Flags().GetInt("amount"))
 		after := MustString(cmd.Flags().GetString("after"))
+		withUsage := MustBool(cmd.Flags().GetBool("with-usage"))
 		clt := getClient()
 
 		res, err := clt.ListRepositoriesWithResponse(cmd.Context(), &api.ListRepositoriesParams{
@@ -32,16 +38,53 @@ var repoListCmd = &cobra.Command{
 		})
 		DieOnResponseError(res, err)
 		repos := res.JSON200.Results
+		headers := []interface{}{"Repository", "Creation Date", "Default Ref Name", "Storage Namespace"}
+		var usage []string
+		if withUsage {
+			headers = append(headers, "Size")
+			usage = fetchReposUsage(cmd.Context(), clt, repos, repoUsageConcurrency)
+		}
 		rows := make([][]interface{}, len(repos))
 		for i, repo := range repos {
 			ts := time.Unix(repo.CreationDate, 0).String()
 			rows[i] = []interface{}{repo.Id, ts, repo.DefaultBranch, repo.StorageNamespace}
+			if withUsage {
+				rows[i] = append(rows[i], usage[i])
+			}
 		}
 		pagination := res.JSON200.Pagination
-		PrintTable(rows, []interface{}{"Repository", "Creation Date", "Default Ref Name", "Storage Namespace"}, &pagination, amount)
+		PrintTable(rows, headers, &pagination, amount)
 	},
 }
 
+// fetchReposUsage returns the storage usage of each repository for display, fetched
+// concurrently by up to concurrency workers.  The usage of repositories whose usage cannot be
+// fetched, e.g. as their blockstore cannot measure it, is "n/a".
+func fetchReposUsage(ctx context.Context, client api.ClientWithResponsesInterface, repos []api.Repository, concurrency int) []string {
+	usage := make([]string, len(repos))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				usage[i] = repoUsageNotAvailable
+				resp, err := client.GetRepositoryUsageWithResponse(ctx, repos[i].Id)
+				if responseError(resp, err) == nil {
+					usage[i] = humanBytes(resp.JSON200.SizeBytes)
+				}
+			}
+		}()
+	}
+	for i := range repos {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return usage
+}
+
 // repoCreateCmd represents the create repo command
 // lakectl create lakefs://myrepo s3://my-bucket/
 var repoCreateCmd = &cobra.Command{
@@ -138,6 +181,7 @@ func init() {
 
 	repoListCmd.Flags().Int("amount", defaultAmountArgumentValue, "number of results to return")
 	repoListCmd.Flags().String("after", "", "show results after this value (used for pagination)")
+	repoListCmd.Flags().Bool("with-usage", false, "show the storage used by each repository (slow)")
 
 	repoCreateCmd.Flags().StringP("default-branch", "d", DefaultBranch, "the default branch of this repository")
 
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+const repoConfigSetCmdMinArgs = 2
+
+var (
+	ErrUnknownRepoSetting  = errors.New("unknown repository setting")
+	ErrReadOnlyRepoSetting = errors.New("read-only repository setting")
+)
+
+// repoSetting is a repository setting shown by repo config get.  Settings with set can be
+// changed by repo config set.
+type repoSetting struct {
+	key string
+	get func(repo *api.Repository) string
+	set func(update *api.RepositoryUpdate, value string)
+}
+
+var repoSettings = []repoSetting{
+	{
+		key: "default_branch",
+		get: func(repo *api.Repository) string { return repo.DefaultBranch },
+		set: func(update *api.RepositoryUpdate, value string) { update.DefaultBranch = &value },
+	},
+	{
+		key: "storage_namespace",
+		get: func(repo *api.Repository) string { return repo.StorageNamespace },
+	},
+	{
+		key: "creation_date",
+		get: func(repo *api.Repository) string { return time.Unix(repo.CreationDate, 0).String() },
+	},
+}
+
+var repoConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "manage repository settings",
+	Long:  "manage repository settings.  Garbage collection rules are managed by \"lakectl gc\".",
+}
+
+var repoConfigGetCmd = &cobra.Command{
+	Use:     "get <repository uri>",
+	Short:   "show the settings of a repository",
+	Example: "lakectl repo config get lakefs://example-repo --output json",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := MustParseRepoURI("repository", args[0])
+		format := MustOutputFormat(cmd.Flags())
+		client := getClient()
+		resp, err := client.GetRepositoryWithResponse(cmd.Context(), u.Repository)
+		DieOnResponseError(resp, err)
+		printRepoSettings(resp.JSON200, format)
+	},
+}
+
+var repoConfigSetCmd = &cobra.Command{
+	Use:     "set <repository uri> <key=value>...",
+	Short:   "update settings of a repository",
+	Example: "lakectl repo config set lakefs://example-repo default_branch=develop",
+	Args:    cobra.MinimumNArgs(repoConfigSetCmdMinArgs),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := MustParseRepoURI("repository", args[0])
+		format := MustOutputFormat(cmd.Flags())
+		update, err := repoSettingsUpdate(args[1:])
+		if err != nil {
+			DieErr(err)
+		}
+		client := getClient()
+		resp, err := client.UpdateRepositoryWithResponse(cmd.Context(), u.Repository, api.UpdateRepositoryJSONRequestBody(update))
+		DieOnResponseError(resp, err)
+		printRepoSettings(resp.JSON200, format)
+	},
+}
+
+// repoSettingsUpdate returns the update setting keys to values in pairs of the form key=value.
+func repoSettingsUpdate(pairs []string) (api.RepositoryUpdate, error) {
+	const keyValueParts = 2
+	var update api.RepositoryUpdate
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", keyValueParts)
+		if len(parts) != keyValueParts || parts[0] == "" {
+			return api.RepositoryUpdate{}, fmt.Errorf("%w: %q", errInvalidKeyValueFormat, pair)
+		}
+		setting, ok := findRepoSetting(parts[0])
+		if !ok {
+			return api.RepositoryUpdate{}, fmt.Errorf("%w %q, settable settings: %s", ErrUnknownRepoSetting, parts[0], strings.Join(settableRepoSettings(), ", "))
+		}
+		if setting.set == nil {
+			return api.RepositoryUpdate{}, fmt.Errorf("%w %q, settable settings: %s", ErrReadOnlyRepoSetting, parts[0], strings.Join(settableRepoSettings(), ", "))
+		}
+		setting.set(&update, parts[1])
+	}
+	return update, nil
+}
+
+func findRepoSetting(key string) (repoSetting, bool) {
+	for _, setting := range repoSettings {
+		if setting.key == key {
+			return setting, true
+		}
+	}
+	return repoSetting{}, false
+}
+
+func settableRepoSettings() []string {
+	var keys []string
+	for _, setting := range repoSettings {
+		if setting.set != nil {
+			keys = append(keys, setting.key)
+		}
+	}
+	return keys
+}
+
+func printRepoSettings(repo *api.Repository, format string) {
+	if format == OutputFormatJSON {
+		values := make(map[string]string, len(repoSettings))
+		for _, setting := range repoSettings {
+			values[setting.key] = setting.get(repo)
+		}
+		PrintJSON(values)
+		return
+	}
+	rows := make([][]interface{}, len(repoSettings))
+	for i, setting := range repoSettings {
+		rows[i] = []interface{}{setting.key, setting.get(repo)}
+	}
+	PrintTable(rows, []interface{}{"Key", "Value"}, &api.Pagination{}, len(rows))
+}
+
+//nolint:gochecknoinits
+func init() {
+	repoCmd.AddCommand(repoConfigCmd)
+	repoConfigCmd.AddCommand(repoConfigGetCmd)
+	repoConfigCmd.AddCommand(repoConfigSetCmd)
+	AssignOutputFlag(repoConfigGetCmd.Flags())
+	AssignOutputFlag(repoConfigSetCmd.Flags())
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+// repoHandler serves a repository, and records the updates it gets.
+func repoHandler(t *testing.T, updates *[]api.RepositoryUpdate) http.Handler {
+	repo := api.Repository{Id: "repo", DefaultBranch: "main", StorageNamespace: "local://repo"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			var update api.RepositoryUpdate
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Errorf("decode update: %s", err)
+			}
+			*updates = append(*updates, update)
+			if update.DefaultBranch != nil {
+				repo.DefaultBranch = *update.DefaultBranch
+			}
+		}
+		writeJSON(w, http.StatusOK, repo)
+	})
+	return mux
+}
+
+func TestRepoConfigGet(t *testing.T) {
+	var updates []api.RepositoryUpdate
+	out := runCmd(t, repoHandler(t, &updates), "repo", "config", "get", "lakefs://repo", "--output", "json")
+	var got map[string]string
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("parse JSON output %q: %s", out, err)
+	}
+	if got["default_branch"] != "main" || got["storage_namespace"] != "local://repo" {
+		t.Errorf("got settings %v, expected the repository settings", got)
+	}
+	if len(updates) != 0 {
+		t.Errorf("get updated settings: %+v", updates)
+	}
+}
+
+func TestRepoConfigSet(t *testing.T) {
+	var updates []api.RepositoryUpdate
+	out := runCmd(t, repoHandler(t, &updates), "repo", "config", "set", "lakefs://repo", "default_branch=develop")
+	if len(updates) != 1 || updates[0].DefaultBranch == nil || *updates[0].DefaultBranch != "develop" {
+		t.Errorf("set sent updates %+v, expected a single update of the default branch", updates)
+	}
+	if !strings.Contains(out, "develop") {
+		t.Errorf("output %q does not show the updated default branch", out)
+	}
+}
+
+func TestRepoSettingsUpdateRejected(t *testing.T) {
+	tests := []struct {
+		pair    string
+		wantErr error
+	}{
+		{pair: "no_such_setting=value", wantErr: ErrUnknownRepoSetting},
+		{pair: "storage_namespace=s3://bucket", wantErr: ErrReadOnlyRepoSetting},
+		{pair: "default_branch", wantErr: errInvalidKeyValueFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pair, func(t *testing.T) {
+			_, err := repoSettingsUpdate([]string{tt.pair})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("repoSettingsUpdate(%q) returned %v, expected %s", tt.pair, err, tt.wantErr)
+			}
+			if tt.wantErr != errInvalidKeyValueFormat && !strings.Contains(err.Error(), "default_branch") {
+				t.Errorf("error %q does not list the settable settings", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+func TestRepoListWithUsage(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantCalls int32
+		wantSize  bool
+	}{
+		{name: "without usage", wantCalls: 0},
+		{name: "with usage", args: []string{"--with-usage"}, wantCalls: 2, wantSize: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var usageCalls int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repositories", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, api.RepositoryList{
+					Pagination: api.Pagination{Results: 2},
+					Results: []api.Repository{
+						{Id: "first", DefaultBranch: "main", StorageNamespace: "s3://bucket/first"},
+						{Id: "second", DefaultBranch: "main", StorageNamespace: "s3://bucket/second"},
+					},
+				})
+			})
+			mux.HandleFunc("/repositories/first/usage", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&usageCalls, 1)
+				writeJSON(w, http.StatusOK, api.RepositoryUsage{SizeBytes: 1500})
+			})
+			mux.HandleFunc("/repositories/second/usage", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&usageCalls, 1)
+				writeJSON(w, http.StatusNotImplemented, api.Error{Message: "operation not supported"})
+			})
+
+			out := runCmd(t, mux, append([]string{"repo", "list"}, tt.args...)...)
+			if usageCalls != tt.wantCalls {
+				t.Errorf("got %d usage requests, expected %d", usageCalls, tt.wantCalls)
+			}
+			if !strings.Contains(out, "first") || !strings.Contains(out, "second") {
+				t.Errorf("output %q does not list all repositories", out)
+			}
+			if hasSize := strings.Contains(out, "1.5 kB"); hasSize != tt.wantSize {
+				t.Errorf("output %q shows size: %t, expected %t", out, hasSize, tt.wantSize)
+			}
+			// a repository whose usage is not available is still listed
+			if hasNA := strings.Contains(out, repoUsageNotAvailable); hasNA != tt.wantSize {
+				t.Errorf("output %q shows unavailable size: %t, expected %t", out, hasNA, tt.wantSize)
+			}
+		})
+	}
+}
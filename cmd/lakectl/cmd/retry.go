@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryBackoff = 500 * time.Millisecond
+	// maxRetryAfter bounds how long a server may ask lakectl to wait before retrying.
+	maxRetryAfter = time.Minute
+	// maxRetryBackoff bounds the exponential backoff between attempts.
+	maxRetryBackoff = time.Minute
+)
+
+// retryTransport retries requests that fail on a network error or a transient server error,
+// waiting with exponential backoff between attempts, or as long as the server asks with
+// Retry-After.  Only safe requests are retried, unless retryUnsafe is set.
+type retryTransport struct {
+	next        http.RoundTripper
+	retries     int
+	backoff     time.Duration
+	retryUnsafe bool
+}
+
+func newRetryTransport(next http.RoundTripper, retries int, backoff time.Duration, retryUnsafe bool) http.RoundTripper {
+	if retries <= 0 {
+		return next
+	}
+	return &retryTransport{next: next, retries: retries, backoff: backoff, retryUnsafe: retryUnsafe}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.retryable(req) {
+		return t.next.RoundTrip(req)
+	}
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			// a RoundTripper must not modify the request, so send a copy with a fresh body
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+		resp, err := t.next.RoundTrip(attemptReq)
+		if attempt == t.retries || !isTransientFailure(resp, err) {
+			return resp, err
+		}
+		wait := t.backoffWait(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = retryAfter
+			}
+			// drain so the connection can be reused
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoffWait returns the wait before the attempt following attempt: the backoff doubled for
+// each earlier attempt, up to maxRetryBackoff.
+func (t *retryTransport) backoffWait(attempt int) time.Duration {
+	wait := t.backoff
+	for i := 0; i < attempt && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxRetryBackoff {
+		wait = maxRetryBackoff
+	}
+	return wait
+}
+
+// retryable returns true if req may be sent again: it is safe, or unsafe requests may be
+// retried and its body can be replayed.
+func (t *retryTransport) retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return t.retryUnsafe && (req.Body == nil || req.GetBody != nil)
+}
+
+func isTransientFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented
+}
+
+// parseRetryAfter returns the wait requested by a Retry-After header holding either seconds or
+// an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		wait = time.Until(date)
+	} else {
+		return 0, false
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+	return wait, true
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+// flakyHandler fails the first failures requests with 503 Service Unavailable before serving
+// them with next.  It counts all requests in calls.
+func flakyHandler(failures int, calls *int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if *calls <= failures {
+			writeJSON(w, http.StatusServiceUnavailable, api.Error{Message: "try again"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestRetriesTransientErrors(t *testing.T) {
+	var calls int
+	mux := http.NewServeMux()
+	mux.Handle("/repositories/repo/branches", flakyHandler(2, &calls, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.RefList{Results: []api.Ref{{Id: "main", CommitId: "c0ffee"}}})
+	})))
+
+	out := runCmd(t, mux, "branch", "list", "lakefs://repo", "--retries", "2", "--retry-backoff", "1ms")
+	if calls != 3 {
+		t.Errorf("server got %d requests, expected 3", calls)
+	}
+	if !strings.Contains(out, "main") {
+		t.Errorf("output %q does not list the branch", out)
+	}
+}
+
+func TestRetryTransportUnsafe(t *testing.T) {
+	tests := []struct {
+		name        string
+		retryUnsafe bool
+		wantCalls   int
+		wantStatus  int
+	}{
+		{name: "not retried", retryUnsafe: false, wantCalls: 1, wantStatus: http.StatusServiceUnavailable},
+		{name: "retry unsafe", retryUnsafe: true, wantCalls: 2, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			var bodies []string
+			server := httptest.NewServer(flakyHandler(1, &calls, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})))
+			defer server.Close()
+			client := &http.Client{Transport: newRetryTransport(&recordingTransport{bodies: &bodies}, 3, time.Millisecond, tt.retryUnsafe)}
+
+			resp, err := client.Post(server.URL, "text/plain", strings.NewReader("merge"))
+			if err != nil {
+				t.Fatalf("POST: %s", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, expected %d", resp.StatusCode, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("server got %d requests, expected %d", calls, tt.wantCalls)
+			}
+			for _, body := range bodies {
+				if body != "merge" {
+					t.Errorf("request sent with body %q, expected the original body", body)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransportRetryAfter(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, 1, time.Millisecond, false)}
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET: %s", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, expected %d", resp.StatusCode, http.StatusOK)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, expected to wait Retry-After of 1s", elapsed)
+	}
+}
+
+func TestRetryTransportKeepsRequest(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(flakyHandler(1, &calls, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer server.Close()
+	var bodies []string
+	client := &http.Client{Transport: newRetryTransport(&recordingTransport{bodies: &bodies}, 1, time.Millisecond, true)}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("merge"))
+	if err != nil {
+		t.Fatalf("new request: %s", err)
+	}
+	body := req.Body
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("POST: %s", err)
+	}
+	_ = resp.Body.Close()
+	if calls != 2 {
+		t.Errorf("server got %d requests, expected 2", calls)
+	}
+	if req.Body != body {
+		t.Error("retry replaced the body of the caller's request")
+	}
+}
+
+func TestRetryTransportBackoffWait(t *testing.T) {
+	transport := &retryTransport{backoff: time.Second}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: time.Second},
+		{attempt: 3, want: 8 * time.Second},
+		{attempt: 6, want: maxRetryBackoff},
+		{attempt: 100, want: maxRetryBackoff},
+	}
+	for _, tt := range tests {
+		if got := transport.backoffWait(tt.attempt); got != tt.want {
+			t.Errorf("backoffWait(%d) = %s, expected %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// recordingTransport records the body of every request it sends.
+type recordingTransport struct {
+	bodies *[]string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		*t.bodies = append(*t.bodies, string(body))
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/deepmap/oapi-codegen/pkg/securityprovider"
 	"github.com/mitchellh/go-homedir"
@@ -41,6 +45,21 @@ var (
 	logFormat string
 	// logOutput logging output file
 	logOutput string
+
+	// skipVersionCheck disables the warning on servers with an incompatible version
+	skipVersionCheck bool
+	// versionCheck runs the server version check once per lakectl execution
+	versionCheck sync.Once
+
+	// retries is the number of times to retry requests failing with transient errors
+	retries int
+	// retryBackoff is the wait before the first retry, doubled on every further retry
+	retryBackoff time.Duration
+	// retryUnsafe allows retrying requests that may modify the server state
+	retryUnsafe bool
+
+	// verbose is the level of tracing of API requests to stderr
+	verbose int
 )
 
 // rootCmd represents the base command when called without any sub-commands
@@ -57,7 +76,7 @@ lakectl is a CLI tool allowing exploration and manipulation of a lakeFS environm
 		if noColorRequested {
 			DisableColors()
 		}
-		if cmd == configCmd {
+		if cmd == configCmd || cmd == initCmd {
 			return
 		}
 
@@ -106,14 +125,36 @@ func getClient() api.ClientWithResponsesInterface {
 
 	client, err := api.NewClientWithResponses(
 		serverEndpoint,
+		api.WithHTTPClient(&http.Client{Transport: newRetryTransport(newTraceTransport(transport, verboseLevel(), os.Stderr), retries, retryBackoff, retryUnsafe)}),
 		api.WithRequestEditorFn(basicAuthProvider.Intercept),
 	)
 	if err != nil {
 		Die(fmt.Sprintf("could not initialize API client: %s", err), 1)
 	}
+	skipByEnv, _ := strconv.ParseBool(os.Getenv("LAKECTL_SKIP_VERSION_CHECK"))
+	if !skipVersionCheck && !skipByEnv {
+		versionCheck.Do(func() {
+			warnOnVersionMismatch(context.Background(), client, version.Version, os.Stderr)
+		})
+	}
 	return client
 }
 
+// warnOnVersionMismatch writes a warning to w if the server version is incompatible with
+// clientVersion.  Failing to get the server version is not reported: the command itself will
+// fail if the server is unusable.
+func warnOnVersionMismatch(ctx context.Context, client api.ClientWithResponsesInterface, clientVersion string, w io.Writer) {
+	resp, err := client.GetLakeFSVersionWithResponse(ctx)
+	if err != nil || resp.JSON200 == nil || resp.JSON200.Version == nil {
+		return
+	}
+	serverVersion := *resp.JSON200.Version
+	if !isVersionCompatible(clientVersion, serverVersion) {
+		_, _ = fmt.Fprintf(w, "Warning: lakectl version %s may be incompatible with lakeFS server version %s (use --skip-version-check to suppress this warning)\n",
+			clientVersion, serverVersion)
+	}
+}
+
 // isSeekable returns true if f.Seek appears to work.
 func isSeekable(f io.Seeker) bool {
 	_, err := f.Seek(0, io.SeekCurrent)
@@ -144,6 +185,11 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "", "none", "set logging level")
 	rootCmd.PersistentFlags().StringVarP(&logFormat, "log-format", "", "", "set logging output format")
 	rootCmd.PersistentFlags().StringVarP(&logOutput, "log-output", "", "", "set logging output file")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 0, "number of times to retry requests failing with network or transient server errors")
+	rootCmd.PersistentFlags().DurationVar(&retryBackoff, "retry-backoff", defaultRetryBackoff, "wait before the first retry, doubled on every further retry (unless the server sets Retry-After)")
+	rootCmd.PersistentFlags().BoolVar(&retryUnsafe, "retry-unsafe", false, "also retry requests that may modify data, such as commit or merge")
+	rootCmd.PersistentFlags().CountVarP(&verbose, "verbose", "v", "trace API requests to stderr, and with -vv also their bodies with secrets redacted (also set by LAKECTL_VERBOSE)")
+	rootCmd.PersistentFlags().BoolVar(&skipVersionCheck, "skip-version-check", false, "don't warn when the lakeFS server version is incompatible with lakectl (also set by LAKECTL_SKIP_VERSION_CHECK)")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+func TestWarnOnVersionMismatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverVersion string
+		wantWarning   bool
+	}{
+		{name: "same minor", serverVersion: "0.45.3", wantWarning: false},
+		{name: "different minor", serverVersion: "0.46.0", wantWarning: true},
+		{name: "different major", serverVersion: "1.45.0", wantWarning: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, newDoctorHandler(true, tt.serverVersion))
+			var stderr bytes.Buffer
+			warnOnVersionMismatch(context.Background(), client, "0.45.1", &stderr)
+			if warned := strings.Contains(stderr.String(), "Warning"); warned != tt.wantWarning {
+				t.Errorf("warning %q emitted: %t, expected %t", stderr.String(), warned, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestSkipVersionCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		env       string
+		wantCheck bool
+	}{
+		{name: "default", wantCheck: true},
+		{name: "flag", args: []string{"--skip-version-check"}, wantCheck: false},
+		{name: "env", env: "true", wantCheck: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checked bool
+			mux := http.NewServeMux()
+			mux.HandleFunc("/config/version", func(w http.ResponseWriter, _ *http.Request) {
+				checked = true
+				writeJSON(w, http.StatusOK, api.VersionConfig{Version: api.StringPtr("0.45.0")})
+			})
+			mux.HandleFunc("/repositories", func(w http.ResponseWriter, _ *http.Request) {
+				writeJSON(w, http.StatusOK, api.RepositoryList{})
+			})
+			versionCheck = sync.Once{}
+			if tt.env != "" {
+				setEnv(t, "LAKECTL_SKIP_VERSION_CHECK", tt.env)
+			}
+
+			runCmd(t, mux, append([]string{"repo", "list"}, tt.args...)...)
+			if checked != tt.wantCheck {
+				t.Errorf("server version checked: %t, expected %t", checked, tt.wantCheck)
+			}
+		})
+	}
+}
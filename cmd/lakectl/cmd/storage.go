@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+const defaultAbortMultipartOlderThan = 24 * time.Hour
+
+var storageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "manage the underlying storage of repositories",
+}
+
+var storageAbortMultipartCmd = &cobra.Command{
+	Use:   "abort-multipart <repository uri>",
+	Short: "abort stale multipart uploads of the S3 gateway",
+	Long: `abort in-progress multipart uploads of the S3 gateway created longer than --older-than ago,
+removing their uploaded parts.  Uploads failed by clients are never completed, so their parts
+take up storage until aborted.
+
+Uploads created before lakeFS recorded the repository of each upload are not listed and
+cannot be aborted by this command; abort them on the underlying storage.`,
+	Example: "lakectl storage abort-multipart lakefs://example-repo --older-than 24h --dry-run",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := MustParseRepoURI("repository", args[0])
+		olderThan := MustDuration(cmd.Flags().GetDuration("older-than"))
+		dryRun := MustBool(cmd.Flags().GetBool("dry-run"))
+		client := getClient()
+		stale := listStaleMultipartUploads(cmd.Context(), client, u.Repository, time.Now().Add(-olderThan))
+
+		var reclaimed int64
+		var unknownSizes int
+		for _, upload := range stale {
+			if upload.SizeBytes != nil {
+				reclaimed += *upload.SizeBytes
+			} else {
+				unknownSizes++
+			}
+			if dryRun {
+				Fmt("would abort %s %s (created %s)\n", upload.UploadId, upload.Path, time.Unix(upload.CreationDate, 0))
+				continue
+			}
+			resp, err := client.AbortMultipartUploadWithResponse(cmd.Context(), u.Repository, upload.UploadId)
+			DieOnResponseError(resp, err)
+			Fmt("aborted %s %s (created %s)\n", upload.UploadId, upload.Path, time.Unix(upload.CreationDate, 0))
+		}
+		verb := "Aborted"
+		if dryRun {
+			verb = "Would abort"
+		}
+		Fmt("%s %d multipart uploads, reclaiming %d bytes", verb, len(stale), reclaimed)
+		if unknownSizes > 0 {
+			Fmt(" (not counting %d uploads whose sizes the blockstore does not report)", unknownSizes)
+		}
+		Fmt("\n")
+	},
+}
+
+// listStaleMultipartUploads returns the multipart uploads to repository created before
+// createdBefore.
+func listStaleMultipartUploads(ctx context.Context, client api.ClientWithResponsesInterface, repository string, createdBefore time.Time) []api.MultipartUpload {
+	var stale []api.MultipartUpload
+	var after string
+	for {
+		resp, err := client.ListMultipartUploadsWithResponse(ctx, repository, &api.ListMultipartUploadsParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+		})
+		DieOnResponseError(resp, err)
+		for _, upload := range resp.JSON200.Results {
+			if time.Unix(upload.CreationDate, 0).Before(createdBefore) {
+				stale = append(stale, upload)
+			}
+		}
+		if !resp.JSON200.Pagination.HasMore {
+			return stale
+		}
+		after = resp.JSON200.Pagination.NextOffset
+	}
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(storageCmd)
+	storageCmd.AddCommand(storageAbortMultipartCmd)
+	storageAbortMultipartCmd.Flags().Duration("older-than", defaultAbortMultipartOlderThan, "abort only uploads created longer than this ago")
+	storageAbortMultipartCmd.Flags().Bool("dry-run", false, "only print the uploads to abort")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+// multipartUploadsHandler serves a list of uploads created the given durations ago, and records
+// the uploads it aborts.
+func multipartUploadsHandler(t *testing.T, ages map[string]time.Duration, aborted *[]string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/multipart-uploads", func(w http.ResponseWriter, r *http.Request) {
+		list := api.MultipartUploadList{Results: []api.MultipartUpload{}}
+		for uploadID, age := range ages {
+			size := int64(len(uploadID))
+			list.Results = append(list.Results, api.MultipartUpload{
+				UploadId:     uploadID,
+				Path:         "path/" + uploadID,
+				CreationDate: time.Now().Add(-age).Unix(),
+				SizeBytes:    &size,
+			})
+		}
+		list.Pagination = api.Pagination{Results: len(list.Results)}
+		writeJSON(w, http.StatusOK, list)
+	})
+	mux.HandleFunc("/repositories/repo/multipart-uploads/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("got %s %s, expected DELETE", r.Method, r.URL.Path)
+		}
+		*aborted = append(*aborted, strings.TrimPrefix(r.URL.Path, "/repositories/repo/multipart-uploads/"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func TestStorageAbortMultipart(t *testing.T) {
+	ages := map[string]time.Duration{"fresh": time.Hour, "stale": 2 * 24 * time.Hour, "ancient": 30 * 24 * time.Hour}
+	var aborted []string
+	out := runCmd(t, multipartUploadsHandler(t, ages, &aborted), "storage", "abort-multipart", "lakefs://repo", "--older-than", "24h")
+	sort.Strings(aborted)
+	if diffs := deep.Equal(aborted, []string{"ancient", "stale"}); diffs != nil {
+		t.Errorf("unexpected aborted uploads: %s", diffs)
+	}
+	// sizes are the lengths of the upload IDs
+	if !strings.Contains(out, "Aborted 2 multipart uploads, reclaiming 12 bytes\n") {
+		t.Errorf("output %q does not report the aborted uploads", out)
+	}
+}
+
+func TestStorageAbortMultipartDryRun(t *testing.T) {
+	ages := map[string]time.Duration{"fresh": time.Hour, "stale": 2 * 24 * time.Hour}
+	var aborted []string
+	out := runCmd(t, multipartUploadsHandler(t, ages, &aborted), "storage", "abort-multipart", "lakefs://repo", "--older-than", "24h", "--dry-run")
+	if len(aborted) != 0 {
+		t.Errorf("dry run aborted uploads %v", aborted)
+	}
+	if !strings.Contains(out, "would abort stale path/stale") || strings.Contains(out, "fresh") {
+		t.Errorf("output %q does not list exactly the stale upload", out)
+	}
+	if !strings.Contains(out, "Would abort 1 multipart uploads, reclaiming 5 bytes\n") {
+		t.Errorf("output %q does not report the uploads to abort", out)
+	}
+}
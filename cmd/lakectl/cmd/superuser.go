@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+const superuserCreatedTemplate = `Created admin user {{ .UserName|bold }}
+credentials:
+  access_key_id: {{ .Credentials.AccessKeyId|yellow }}
+  secret_access_key: {{ .Credentials.SecretAccessKey|yellow }}
+`
+
+var (
+	ErrAlreadyInitialized       = errors.New("lakeFS instance already initialized")
+	ErrIncompleteAccessKeyFlags = errors.New("--access-key-id and --secret-access-key must be passed together")
+)
+
+var superuserCmd = &cobra.Command{
+	Use:   "superuser",
+	Short: "create the initial admin user of a new lakeFS instance",
+	Long:  "set up a new lakeFS instance by creating its first admin user, and print the credentials of that user",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		userName := MustString(cmd.Flags().GetString("user-name"))
+		accessKeyID := MustString(cmd.Flags().GetString("access-key-id"))
+		secretAccessKey := MustString(cmd.Flags().GetString("secret-access-key"))
+		credentials, err := setupSuperuser(cmd.Context(), getClient(), userName, accessKeyID, secretAccessKey)
+		if err != nil {
+			DieErr(err)
+		}
+		Write(superuserCreatedTemplate, struct {
+			UserName    string
+			Credentials *api.CredentialsWithSecret
+		}{userName, credentials})
+	},
+}
+
+// setupSuperuser sets up lakeFS with an admin user named userName.  The server generates the
+// user credentials unless accessKeyID and secretAccessKey are passed.
+func setupSuperuser(ctx context.Context, client api.ClientWithResponsesInterface, userName, accessKeyID, secretAccessKey string) (*api.CredentialsWithSecret, error) {
+	body := api.SetupJSONRequestBody{Username: userName}
+	switch {
+	case accessKeyID != "" && secretAccessKey != "":
+		body.Key = &api.AccessKeyCredentials{AccessKeyId: accessKeyID, SecretAccessKey: secretAccessKey}
+	case accessKeyID != "" || secretAccessKey != "":
+		return nil, ErrIncompleteAccessKeyFlags
+	}
+	resp, err := client.SetupWithResponse(ctx, body)
+	if err == nil && resp.StatusCode() == http.StatusConflict {
+		return nil, fmt.Errorf("%w: an admin user already exists", ErrAlreadyInitialized)
+	}
+	if err := responseError(resp, err); err != nil {
+		return nil, err
+	}
+	return resp.JSON200, nil
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(superuserCmd)
+	superuserCmd.Flags().String("user-name", "", "an identifier for the admin user (e.g. \"jane.doe\")")
+	superuserCmd.Flags().String("access-key-id", "", "access key ID to create for the admin user, instead of generating one")
+	superuserCmd.Flags().String("secret-access-key", "", "secret access key to create for the admin user, instead of generating one")
+	_ = superuserCmd.MarkFlagRequired("user-name")
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+// setupHandler serves the setup API of an instance that is already initialized if initialized
+// is set, storing the last setup request in body.
+func setupHandler(initialized bool, body *api.SetupJSONRequestBody) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/setup_lakefs", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if initialized {
+			writeJSON(w, http.StatusConflict, api.Error{Message: "lakeFS already initialized"})
+			return
+		}
+		creds := api.CredentialsWithSecret{AccessKeyId: "AKIAGENERATED", SecretAccessKey: "generated-secret", CreationDate: 1}
+		if body.Key != nil {
+			creds.AccessKeyId, creds.SecretAccessKey = body.Key.AccessKeyId, body.Key.SecretAccessKey
+		}
+		writeJSON(w, http.StatusOK, creds)
+	})
+	return mux
+}
+
+func TestSuperuser(t *testing.T) {
+	var body api.SetupJSONRequestBody
+	out := runCmd(t, setupHandler(false, &body), "superuser", "--user-name", "admin")
+	if body.Username != "admin" || body.Key != nil {
+		t.Errorf("setup request = %+v, expected user admin with generated credentials", body)
+	}
+	if !strings.Contains(out, "AKIAGENERATED") || !strings.Contains(out, "generated-secret") {
+		t.Errorf("output %q does not contain the generated credentials", out)
+	}
+}
+
+func TestSuperuserWithCredentials(t *testing.T) {
+	var body api.SetupJSONRequestBody
+	out := runCmd(t, setupHandler(false, &body), "superuser", "--user-name", "admin",
+		"--access-key-id", "AKIASUPPLIED", "--secret-access-key", "supplied-secret")
+	if body.Key == nil || body.Key.AccessKeyId != "AKIASUPPLIED" || body.Key.SecretAccessKey != "supplied-secret" {
+		t.Errorf("setup request key = %+v, expected the supplied credentials", body.Key)
+	}
+	if !strings.Contains(out, "AKIASUPPLIED") {
+		t.Errorf("output %q does not contain the supplied access key", out)
+	}
+}
+
+func TestSetupSuperuserAlreadyInitialized(t *testing.T) {
+	var body api.SetupJSONRequestBody
+	client := newTestClient(t, setupHandler(true, &body))
+	_, err := setupSuperuser(context.Background(), client, "admin", "", "")
+	if !errors.Is(err, ErrAlreadyInitialized) {
+		t.Errorf("setupSuperuser() on initialized instance returned %v, expected %s", err, ErrAlreadyInitialized)
+	}
+}
+
+func TestSetupSuperuserIncompleteCredentials(t *testing.T) {
+	var body api.SetupJSONRequestBody
+	client := newTestClient(t, setupHandler(false, &body))
+	_, err := setupSuperuser(context.Background(), client, "admin", "AKIASUPPLIED", "")
+	if !errors.Is(err, ErrIncompleteAccessKeyFlags) {
+		t.Errorf("setupSuperuser() with only an access key ID returned %v, expected %s", err, ErrIncompleteAccessKeyFlags)
+	}
+	if body.Username != "" {
+		t.Errorf("setupSuperuser() with only an access key ID sent setup request %+v", body)
+	}
+}
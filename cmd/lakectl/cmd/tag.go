@@ -1,11 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
 	"github.com/spf13/cobra"
 	"github.com/treeverse/lakefs/pkg/api"
 )
 
-const tagCreateRequiredArgs = 2
+const (
+	tagCreateRequiredArgs = 2
+	tagVerifyRequiredArgs = 2
+)
+
+var (
+	ErrTagExists = errors.New("tag already exists")
+	ErrTagMoved  = errors.New("tag does not point at the expected commit")
+)
 
 // tagCmd represents the tag command
 var tagCmd = &cobra.Command{
@@ -72,7 +85,11 @@ var tagCreateCmd = &cobra.Command{
 		commitRef := args[1]
 		ctx := cmd.Context()
 		force, _ := cmd.Flags().GetBool("force")
-		if force {
+		if !force {
+			if err := checkTagAbsent(ctx, client, tagURI.Repository, tagURI.Ref); err != nil {
+				DieErr(err)
+			}
+		} else {
 			// checking validity of the commitRef before deleting the old one
 			res, err := client.GetCommitWithResponse(ctx, tagURI.Repository, commitRef)
 			DieOnResponseError(res, err)
@@ -87,6 +104,10 @@ var tagCreateCmd = &cobra.Command{
 			Id:  tagURI.Ref,
 			Ref: commitRef,
 		})
+		if err == nil && resp.JSON409 != nil {
+			// created concurrently since checked
+			DieErr(fmt.Errorf("%w: %s", ErrTagExists, tagURI.Ref))
+		}
 		DieOnResponseError(resp, err)
 
 		commitID := *resp.JSON201
@@ -129,12 +150,59 @@ var tagShowCmd = &cobra.Command{
 	},
 }
 
+var tagVerifyCmd = &cobra.Command{
+	Use:     "verify <tag uri> <commit ref>",
+	Short:   "verify a tag still points at the commit it was created on",
+	Long:    "verify a tag points at the commit of commit ref, and fail if it was moved, e.g. to check a release tag in a pipeline",
+	Example: "lakectl tag verify lakefs://example-repo/v1.0 c0ffee",
+	Args:    cobra.ExactArgs(tagVerifyRequiredArgs),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := MustParseRefURI("tag", args[0])
+		commitID, err := verifyTag(cmd.Context(), getClient(), u.Repository, u.Ref, args[1])
+		if err != nil {
+			DieErr(err)
+		}
+		Fmt("Tag '%s' points at %s\n", u.Ref, commitID)
+	},
+}
+
+// checkTagAbsent returns ErrTagExists if tag exists in repository.  Tags are not supposed to
+// move, so creating a tag that exists requires explicitly deleting it first.
+func checkTagAbsent(ctx context.Context, client api.ClientWithResponsesInterface, repository, tag string) error {
+	resp, err := client.GetTagWithResponse(ctx, repository, tag)
+	if err == nil && resp.StatusCode() == http.StatusNotFound {
+		return nil
+	}
+	if err := responseError(resp, err); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s points at %s (use --force to move it)", ErrTagExists, tag, resp.JSON200.CommitId)
+}
+
+// verifyTag returns the commit ID tag points at in repository, or ErrTagMoved if that is not the
+// commit commitRef resolves to.
+func verifyTag(ctx context.Context, client api.ClientWithResponsesInterface, repository, tag, commitRef string) (string, error) {
+	tagResp, err := client.GetTagWithResponse(ctx, repository, tag)
+	if err := responseError(tagResp, err); err != nil {
+		return "", err
+	}
+	commitResp, err := client.GetCommitWithResponse(ctx, repository, commitRef)
+	if err := responseError(commitResp, err); err != nil {
+		return "", err
+	}
+	tagged, expected := tagResp.JSON200.CommitId, commitResp.JSON200.Id
+	if tagged != expected {
+		return "", fmt.Errorf("%w: %s points at %s, expected %s", ErrTagMoved, tag, tagged, expected)
+	}
+	return tagged, nil
+}
+
 //nolint:gochecknoinits
 func init() {
 	tagCreateCmd.Flags().BoolP("force", "f", false, "override the tag if it exists")
 
 	rootCmd.AddCommand(tagCmd)
-	tagCmd.AddCommand(tagCreateCmd, tagDeleteCmd, tagListCmd, tagShowCmd)
+	tagCmd.AddCommand(tagCreateCmd, tagDeleteCmd, tagListCmd, tagShowCmd, tagVerifyCmd)
 
 	flags := tagListCmd.Flags()
 	flags.Int("amount", defaultAmountArgumentValue, "number of results to return")
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+// tagHandler serves a repository "repo" with tag "v1" on commit c1, and commits c1 and c2.
+func tagHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/repo/tags/", func(w http.ResponseWriter, r *http.Request) {
+		tag := strings.TrimPrefix(r.URL.Path, "/repositories/repo/tags/")
+		if tag != "v1" {
+			writeJSON(w, http.StatusNotFound, api.Error{Message: "tag not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, api.Ref{Id: tag, CommitId: "c1"})
+	})
+	mux.HandleFunc("/repositories/repo/commits/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/repositories/repo/commits/")
+		writeJSON(w, http.StatusOK, api.Commit{Id: id, Parents: []string{}})
+	})
+	return mux
+}
+
+func TestCheckTagAbsent(t *testing.T) {
+	client := newTestClient(t, tagHandler())
+	ctx := context.Background()
+
+	if err := checkTagAbsent(ctx, client, "repo", "v1"); !errors.Is(err, ErrTagExists) {
+		t.Errorf("checkTagAbsent on an existing tag returned %v, expected %s", err, ErrTagExists)
+	}
+	if err := checkTagAbsent(ctx, client, "repo", "v2"); err != nil {
+		t.Errorf("checkTagAbsent on a missing tag: %s", err)
+	}
+}
+
+func TestTagVerify(t *testing.T) {
+	out := runCmd(t, tagHandler(), "tag", "verify", "lakefs://repo/v1", "c1")
+	if !strings.Contains(out, "Tag 'v1' points at c1") {
+		t.Errorf("output %q does not confirm the tag", out)
+	}
+
+	client := newTestClient(t, tagHandler())
+	if _, err := verifyTag(context.Background(), client, "repo", "v1", "c2"); !errors.Is(err, ErrTagMoved) {
+		t.Errorf("verifyTag against another commit returned %v, expected %s", err, ErrTagMoved)
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// traceRequests traces the method, URL, status and latency of each request.
+	traceRequests = 1
+	// traceBodies also traces JSON request bodies, with secrets redacted.
+	traceBodies = 2
+
+	// maxTracedBodySize bounds the size of request bodies traced.
+	maxTracedBodySize = 64 * 1024
+)
+
+// traceTransport writes a line to w for each request it sends: its method, URL, status code and
+// latency, and at traceBodies also its JSON body.
+type traceTransport struct {
+	next  http.RoundTripper
+	level int
+	w     io.Writer
+}
+
+func newTraceTransport(next http.RoundTripper, level int, w io.Writer) http.RoundTripper {
+	if level < traceRequests {
+		return next
+	}
+	return &traceTransport{next: next, level: level, w: w}
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.level >= traceBodies {
+		if body, ok := tracedBody(req); ok {
+			_, _ = fmt.Fprintf(t.w, "> %s %s %s\n", req.Method, req.URL, body)
+		}
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		_, _ = fmt.Fprintf(t.w, "%s %s failed after %s: %s\n", req.Method, req.URL, latency, err)
+	} else {
+		_, _ = fmt.Fprintf(t.w, "%s %s %s in %s\n", req.Method, req.URL, resp.Status, latency)
+	}
+	return resp, err
+}
+
+// tracedBody returns the JSON body of req with secrets redacted, and false if req has no such
+// body.  Other bodies, such as uploaded objects, are streamed and cannot be read twice.
+func tracedBody(req *http.Request) (string, bool) {
+	if req.GetBody == nil || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		return "", false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", false
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxTracedBodySize+1))
+	if err != nil {
+		return "", false
+	}
+	if len(data) > maxTracedBodySize {
+		return fmt.Sprintf("(body larger than %d bytes)", maxTracedBodySize), true
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", false
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(redactSecrets(value)); err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(buf.String()), true
+}
+
+// redactSecrets returns value with the values of all keys that may hold secrets redacted.
+func redactSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			lowerKey := strings.ToLower(key)
+			if isSecretConfigKey(lowerKey) || strings.Contains(lowerKey, "password") {
+				v[key] = redactedConfigValue
+			} else {
+				v[key] = redactSecrets(inner)
+			}
+		}
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = redactSecrets(inner)
+		}
+	}
+	return value
+}
+
+// verboseLevel returns the trace level set by --verbose, or else by LAKECTL_VERBOSE as a level
+// or a boolean.
+func verboseLevel() int {
+	if verbose > 0 {
+		return verbose
+	}
+	value := os.Getenv("LAKECTL_VERBOSE")
+	if level, err := strconv.Atoi(value); err == nil {
+		return level
+	}
+	if on, err := strconv.ParseBool(value); err == nil && on {
+		return traceRequests
+	}
+	return 0
+}
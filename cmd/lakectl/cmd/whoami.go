@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+const whoamiTemplate = `Endpoint: {{ .Endpoint }}
+User ID: {{ .User.Id | yellow }}
+Created: {{ .User.CreationDate | date }}
+Groups: {{ .GroupsSummary }}
+Policies: {{ .PoliciesSummary }}
+`
+
+// identity is the user lakectl acts as, with the groups it belongs to and the policies
+// attached to it or to its groups.  Groups and Policies are nil if the user may not list them.
+type identity struct {
+	Endpoint string   `json:"endpoint"`
+	User     api.User `json:"user"`
+	Groups   []string `json:"groups"`
+	Policies []string `json:"policies"`
+}
+
+func (i *identity) GroupsSummary() string {
+	return identitySummary(i.Groups)
+}
+
+func (i *identity) PoliciesSummary() string {
+	return identitySummary(i.Policies)
+}
+
+func identitySummary(ids []string) string {
+	switch {
+	case ids == nil:
+		return "(not permitted to list)"
+	case len(ids) == 0:
+		return "(none)"
+	default:
+		return strings.Join(ids, ", ")
+	}
+}
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "show the identity of the configured credentials",
+	Long:  "show the user the configured credentials authenticate as, its groups and the policies attached to it or to its groups",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := whoami(cmd.Context(), getClient())
+		if err != nil {
+			DieErr(err)
+		}
+		id.Endpoint = cfg.Values.Server.EndpointURL
+		if MustOutputFormat(cmd.Flags()) == OutputFormatJSON {
+			PrintJSON(id)
+			return
+		}
+		Write(whoamiTemplate, id)
+	},
+}
+
+// whoami returns the identity of the user authenticated by client.
+func whoami(ctx context.Context, client api.ClientWithResponsesInterface) (*identity, error) {
+	userResp, err := client.GetCurrentUserWithResponse(ctx)
+	if err := responseError(userResp, err); err != nil {
+		return nil, err
+	}
+	id := &identity{User: userResp.JSON200.User}
+
+	groups := make([]string, 0)
+	var after string
+	for {
+		resp, err := client.ListUserGroupsWithResponse(ctx, id.User.Id, &api.ListUserGroupsParams{
+			After:  api.PaginationAfterPtr(after),
+			Amount: api.PaginationAmountPtr(internalPageSize),
+		})
+		if err == nil && notPermitted(resp.StatusCode()) {
+			groups = nil
+			break
+		}
+		if err := responseError(resp, err); err != nil {
+			return nil, err
+		}
+		for _, group := range resp.JSON200.Results {
+			groups = append(groups, group.Id)
+		}
+		if !resp.JSON200.Pagination.HasMore {
+			break
+		}
+		after = resp.JSON200.Pagination.NextOffset
+	}
+	id.Groups = groups
+
+	policies := make([]string, 0)
+	effective := true
+	after = ""
+	for {
+		resp, err := client.ListUserPoliciesWithResponse(ctx, id.User.Id, &api.ListUserPoliciesParams{
+			After:     api.PaginationAfterPtr(after),
+			Amount:    api.PaginationAmountPtr(internalPageSize),
+			Effective: &effective,
+		})
+		if err == nil && notPermitted(resp.StatusCode()) {
+			policies = nil
+			break
+		}
+		if err := responseError(resp, err); err != nil {
+			return nil, err
+		}
+		for _, policy := range resp.JSON200.Results {
+			policies = append(policies, policy.Id)
+		}
+		if !resp.JSON200.Pagination.HasMore {
+			break
+		}
+		after = resp.JSON200.Pagination.NextOffset
+	}
+	id.Policies = policies
+	return id, nil
+}
+
+// notPermitted returns true if statusCode rejects a request the user may not make.
+func notPermitted(statusCode int) bool {
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+	AssignOutputFlag(whoamiCmd.Flags())
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/api"
+)
+
+// whoamiHandler serves the current user alice, in groups listed on two pages and with one
+// effective policy.  Unless permitted, listing groups and policies is rejected.
+func whoamiHandler(permitted bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, api.CurrentUser{User: api.User{Id: "alice", CreationDate: 1600000000}})
+	})
+	mux.HandleFunc("/auth/users/alice/groups", func(w http.ResponseWriter, r *http.Request) {
+		if !permitted {
+			writeJSON(w, http.StatusUnauthorized, api.Error{Message: "insufficient permissions"})
+			return
+		}
+		if r.URL.Query().Get("after") == "" {
+			writeJSON(w, http.StatusOK, api.GroupList{
+				Pagination: api.Pagination{HasMore: true, NextOffset: "Developers", Results: 1},
+				Results:    []api.Group{{Id: "Developers"}},
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, api.GroupList{
+			Pagination: api.Pagination{Results: 1},
+			Results:    []api.Group{{Id: "Viewers"}},
+		})
+	})
+	mux.HandleFunc("/auth/users/alice/policies", func(w http.ResponseWriter, r *http.Request) {
+		if !permitted {
+			writeJSON(w, http.StatusUnauthorized, api.Error{Message: "insufficient permissions"})
+			return
+		}
+		if r.URL.Query().Get("effective") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, api.PolicyList{
+			Pagination: api.Pagination{Results: 1},
+			Results:    []api.Policy{{Id: "FSReadAll"}},
+		})
+	})
+	return mux
+}
+
+func TestWhoami(t *testing.T) {
+	out := runCmd(t, whoamiHandler(true), "whoami")
+	for _, expected := range []string{"User ID: alice\n", "Groups: Developers, Viewers\n", "Policies: FSReadAll\n"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("output %q does not contain %q", out, expected)
+		}
+	}
+}
+
+func TestWhoamiJSON(t *testing.T) {
+	out := runCmd(t, whoamiHandler(true), "whoami", "--output", "json")
+	var id identity
+	if err := json.Unmarshal([]byte(out), &id); err != nil {
+		t.Fatalf("output %q is not JSON: %s", out, err)
+	}
+	id.Endpoint = ""
+	expected := identity{
+		User:     api.User{Id: "alice", CreationDate: 1600000000},
+		Groups:   []string{"Developers", "Viewers"},
+		Policies: []string{"FSReadAll"},
+	}
+	if diff := deep.Equal(id, expected); diff != nil {
+		t.Errorf("identity: %s", diff)
+	}
+}
+
+func TestWhoamiNotPermitted(t *testing.T) {
+	out := runCmd(t, whoamiHandler(false), "whoami")
+	for _, expected := range []string{"User ID: alice\n", "Groups: (not permitted to list)\n", "Policies: (not permitted to list)\n"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("output %q does not contain %q", out, expected)
+		}
+	}
+}
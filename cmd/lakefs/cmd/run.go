@@ -143,6 +143,7 @@ var runCmd = &cobra.Command{
 			bufferedCollector,
 			cloudMetadataProvider,
 			actionsService,
+			multipartsTracker,
 			logger.WithField("service", "api_gateway"),
 			cfg.GetS3GatewayDomainNames(),
 		)
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"io"
 	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"reflect"
 	"regexp"
@@ -27,6 +29,7 @@ import (
 	"github.com/treeverse/lakefs/pkg/cloud"
 	"github.com/treeverse/lakefs/pkg/config"
 	"github.com/treeverse/lakefs/pkg/db"
+	"github.com/treeverse/lakefs/pkg/gateway/multiparts"
 	"github.com/treeverse/lakefs/pkg/graveler"
 	"github.com/treeverse/lakefs/pkg/httputil"
 	"github.com/treeverse/lakefs/pkg/logging"
@@ -40,15 +43,19 @@ type contextKey string
 
 const (
 	// DefaultMaxPerPage is the maximum amount of results returned for paginated queries to the API
-	DefaultMaxPerPage int        = 1000
-	lakeFSPrefix                 = "symlinks"
-	UserContextKey    contextKey = "user"
+	DefaultMaxPerPage int = 1000
+	// DefaultMaxDeleteObjects is the maximum number of objects deleted by a single DeleteObjects call
+	DefaultMaxDeleteObjects            = 1000
+	lakeFSPrefix                       = "symlinks"
+	UserContextKey          contextKey = "user"
 
 	actionStatusCompleted = "completed"
 	actionStatusFailed    = "failed"
 
 	entryTypeObject       = "object"
 	entryTypeCommonPrefix = "common_prefix"
+
+	defaultPresignExpiry = time.Hour
 )
 
 type actionsHandler interface {
@@ -68,6 +75,7 @@ type Controller struct {
 	Collector             stats.Collector
 	CloudMetadataProvider cloud.MetadataProvider
 	Actions               actionsHandler
+	MultipartsTracker     multiparts.Tracker
 	Logger                logging.Logger
 }
 
@@ -226,6 +234,7 @@ func (c *Controller) LinkPhysicalAddress(w http.ResponseWriter, r *http.Request,
 		Size:            body.SizeBytes,
 		Checksum:        body.Checksum,
 		Metadata:        metadata,
+		ContentType:     StringValue(body.ContentType),
 	}
 
 	err = c.Catalog.CreateEntry(ctx, repo.Name, branch, entry)
@@ -1168,6 +1177,36 @@ func (c *Controller) DeleteRepository(w http.ResponseWriter, r *http.Request, re
 	writeResponse(w, http.StatusNoContent, nil)
 }
 
+func (c *Controller) UpdateRepository(w http.ResponseWriter, r *http.Request, body UpdateRepositoryJSONRequestBody, repository string) {
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.UpdateRepositoryAction,
+			Resource: permissions.RepoArn(repository),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "update_repo")
+	if body.DefaultBranch != nil {
+		err := c.Catalog.SetDefaultBranch(ctx, repository, *body.DefaultBranch)
+		if handleAPIError(w, err) {
+			return
+		}
+	}
+	repo, err := c.Catalog.GetRepository(ctx, repository)
+	if handleAPIError(w, err) {
+		return
+	}
+	response := Repository{
+		CreationDate:     repo.CreationDate.Unix(),
+		DefaultBranch:    repo.DefaultBranch,
+		Id:               repo.Name,
+		StorageNamespace: repo.StorageNamespace,
+	}
+	writeResponse(w, http.StatusOK, response)
+}
+
 func (c *Controller) GetRepository(w http.ResponseWriter, r *http.Request, repository string) {
 	if !c.authorize(w, r, []permissions.Permission{
 		{
@@ -1198,6 +1237,93 @@ func (c *Controller) GetRepository(w http.ResponseWriter, r *http.Request, repos
 	writeResponse(w, http.StatusOK, response)
 }
 
+func (c *Controller) GetRepositoryUsage(w http.ResponseWriter, r *http.Request, repository string) {
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.ReadRepositoryAction,
+			Resource: permissions.RepoArn(repository),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "get_repo_usage")
+
+	sizer, ok := c.BlockAdapter.(block.StorageSizer)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, fmt.Errorf("%w: %s blockstore cannot measure storage usage",
+			block.ErrOperationNotSupported, c.BlockAdapter.BlockstoreType()))
+		return
+	}
+	repo, err := c.Catalog.GetRepository(ctx, repository)
+	if handleAPIError(w, err) {
+		return
+	}
+	size, err := sizer.StorageSize(ctx, repo.StorageNamespace)
+	if handleAPIError(w, err) {
+		return
+	}
+	writeResponse(w, http.StatusOK, RepositoryUsage{SizeBytes: size})
+}
+
+func (c *Controller) GetBranchProtectionRules(w http.ResponseWriter, r *http.Request, repository string) {
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.GetBranchProtectionRulesAction,
+			Resource: permissions.RepoArn(repository),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "get_branch_protection_rules")
+	patterns, err := c.Catalog.GetBranchProtectionRules(ctx, repository)
+	if handleAPIError(w, err) {
+		return
+	}
+	rules := make([]BranchProtectionRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rules = append(rules, BranchProtectionRule{Pattern: pattern})
+	}
+	writeResponse(w, http.StatusOK, rules)
+}
+
+func (c *Controller) CreateBranchProtectionRule(w http.ResponseWriter, r *http.Request, body CreateBranchProtectionRuleJSONRequestBody, repository string) {
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.SetBranchProtectionRulesAction,
+			Resource: permissions.RepoArn(repository),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "create_branch_protection_rule")
+	err := c.Catalog.CreateBranchProtectionRule(ctx, repository, body.Pattern)
+	if handleAPIError(w, err) {
+		return
+	}
+	writeResponse(w, http.StatusNoContent, nil)
+}
+
+func (c *Controller) DeleteBranchProtectionRule(w http.ResponseWriter, r *http.Request, body DeleteBranchProtectionRuleJSONRequestBody, repository string) {
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.SetBranchProtectionRulesAction,
+			Resource: permissions.RepoArn(repository),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "delete_branch_protection_rule")
+	err := c.Catalog.DeleteBranchProtectionRule(ctx, repository, body.Pattern)
+	if handleAPIError(w, err) {
+		return
+	}
+	writeResponse(w, http.StatusNoContent, nil)
+}
+
 func (c *Controller) ListRepositoryRuns(w http.ResponseWriter, r *http.Request, repository string, params ListRepositoryRunsParams) {
 	if !c.authorize(w, r, []permissions.Permission{
 		{
@@ -1499,36 +1625,49 @@ func (c *Controller) GetBranch(w http.ResponseWriter, r *http.Request, repositor
 }
 
 func handleAPIError(w http.ResponseWriter, err error) bool {
+	if err == nil {
+		return false
+	}
+	code, message := apiErrorResponse(err)
+	writeError(w, code, message)
+	return true
+}
+
+// apiErrorResponse returns the status code and message of the response reporting err.
+func apiErrorResponse(err error) (int, string) {
 	switch {
 	case errors.Is(err, catalog.ErrNotFound),
 		errors.Is(err, graveler.ErrNotFound),
 		errors.Is(err, actions.ErrNotFound):
-		writeError(w, http.StatusNotFound, err)
+		return http.StatusNotFound, err.Error()
 
 	case errors.Is(err, graveler.ErrDirtyBranch),
 		errors.Is(err, catalog.ErrNoDifferenceWasFound),
 		errors.Is(err, graveler.ErrNoChanges),
 		errors.Is(err, permissions.ErrInvalidServiceName),
 		errors.Is(err, permissions.ErrInvalidAction),
-		errors.Is(err, model.ErrValidationError):
-		writeError(w, http.StatusBadRequest, err)
+		errors.Is(err, model.ErrValidationError),
+		errors.Is(err, catalog.ErrInvalid),
+		errors.Is(err, graveler.ErrInvalidBranchPattern):
+		return http.StatusBadRequest, err.Error()
+
+	case errors.Is(err, graveler.ErrWriteToProtectedBranch),
+		errors.Is(err, graveler.ErrCommitToProtectedBranch):
+		return http.StatusForbidden, err.Error()
 
 	case errors.Is(err, graveler.ErrNotUnique):
-		writeError(w, http.StatusConflict, err)
+		return http.StatusConflict, err.Error()
 
-	case errors.Is(err, catalog.ErrFeatureNotSupported):
-		writeError(w, http.StatusNotImplemented, err)
+	case errors.Is(err, catalog.ErrFeatureNotSupported),
+		errors.Is(err, block.ErrOperationNotSupported):
+		return http.StatusNotImplemented, err.Error()
 
 	case errors.Is(err, graveler.ErrLockNotAcquired):
-		writeError(w, http.StatusInternalServerError, "branch is currently locked, try again later")
-
-	case err != nil:
-		writeError(w, http.StatusInternalServerError, err)
+		return http.StatusInternalServerError, "branch is currently locked, try again later"
 
 	default:
-		return false
+		return http.StatusInternalServerError, err.Error()
 	}
-	return true
 }
 
 func (c *Controller) ResetBranch(w http.ResponseWriter, r *http.Request, body ResetBranchJSONRequestBody, repository string, branch string) {
@@ -1609,6 +1748,55 @@ func (c *Controller) Commit(w http.ResponseWriter, r *http.Request, body CommitJ
 	writeResponse(w, http.StatusCreated, response)
 }
 
+func (c *Controller) AmendCommit(w http.ResponseWriter, r *http.Request, body AmendCommitJSONRequestBody, repository string, branch string) {
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.CreateCommitAction,
+			Resource: permissions.BranchArn(repository, branch),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "amend_commit")
+	user, ok := ctx.Value(UserContextKey).(*model.User)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "missing user")
+		return
+	}
+	var metadata map[string]string
+	if body.Metadata != nil {
+		metadata = body.Metadata.AdditionalProperties
+	}
+	newCommit, err := c.Catalog.AmendCommit(ctx, repository, branch, StringValue(body.HeadCommitId), body.Message, user.Username, metadata, BoolValue(body.Force))
+	if errors.Is(err, graveler.ErrPreconditionFailed) {
+		writeError(w, http.StatusPreconditionFailed, err)
+		return
+	}
+	var hookAbortErr *graveler.HookAbortError
+	if errors.As(err, &hookAbortErr) {
+		c.Logger.
+			WithError(err).
+			WithField("run_id", hookAbortErr.RunID).
+			Warn("aborted by hooks")
+		writeError(w, http.StatusPreconditionFailed, err)
+		return
+	}
+	if handleAPIError(w, err) {
+		return
+	}
+	response := Commit{
+		Committer:    newCommit.Committer,
+		CreationDate: newCommit.CreationDate.Unix(),
+		Id:           newCommit.Reference,
+		Message:      newCommit.Message,
+		MetaRangeId:  newCommit.MetaRangeID,
+		Metadata:     &Commit_Metadata{AdditionalProperties: map[string]string(newCommit.Metadata)},
+		Parents:      newCommit.Parents,
+	}
+	writeResponse(w, http.StatusCreated, response)
+}
+
 func (c *Controller) DiffBranch(w http.ResponseWriter, r *http.Request, repository string, branch string, params DiffBranchParams) {
 	if !c.authorize(w, r, []permissions.Permission{
 		{
@@ -1647,6 +1835,7 @@ func (c *Controller) DiffBranch(w http.ResponseWriter, r *http.Request, reposito
 		}
 		if !d.CommonLevel {
 			diff.SizeBytes = &d.Size
+			diff.Checksum = StringPtr(d.Checksum)
 		}
 		results = append(results, diff)
 	}
@@ -1676,6 +1865,54 @@ func (c *Controller) DeleteObject(w http.ResponseWriter, r *http.Request, reposi
 	writeResponse(w, http.StatusNoContent, nil)
 }
 
+func (c *Controller) DeleteObjects(w http.ResponseWriter, r *http.Request, body DeleteObjectsJSONRequestBody, repository string, branch string) {
+	if len(body.Paths) > DefaultMaxDeleteObjects {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("cannot delete more than %d objects at once", DefaultMaxDeleteObjects))
+		return
+	}
+	perms := make([]permissions.Permission, 0, len(body.Paths))
+	for _, objectPath := range body.Paths {
+		perms = append(perms, permissions.Permission{
+			Action:   permissions.DeleteObjectAction,
+			Resource: permissions.ObjectArn(repository, objectPath),
+		})
+	}
+	if !c.authorize(w, r, perms) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "delete_objects")
+
+	// missing objects are skipped below, so a missing branch must be reported up front
+	branchExists, err := c.Catalog.BranchExists(ctx, repository, branch)
+	if handleAPIError(w, err) {
+		return
+	}
+	if !branchExists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("branch '%s' not found", branch))
+		return
+	}
+
+	errs := make([]ObjectError, 0)
+	for _, objectPath := range body.Paths {
+		err := c.Catalog.DeleteEntry(ctx, repository, branch, objectPath)
+		switch {
+		case errors.Is(err, catalog.ErrNotFound), errors.Is(err, graveler.ErrNotFound):
+			// deleting a missing object is not an error, as in S3
+		case err != nil:
+			c.Logger.WithError(err).WithField("path", objectPath).Warn("delete object")
+			p := objectPath
+			code, message := apiErrorResponse(err)
+			errs = append(errs, ObjectError{
+				Path:       &p,
+				StatusCode: code,
+				Message:    message,
+			})
+		}
+	}
+	writeResponse(w, http.StatusOK, ObjectErrorList{Errors: errs})
+}
+
 func (c *Controller) UploadObject(w http.ResponseWriter, r *http.Request, repository string, branch string, params UploadObjectParams) {
 	if !c.authorize(w, r, []permissions.Permission{
 		{
@@ -1752,6 +1989,7 @@ func (c *Controller) UploadObject(w http.ResponseWriter, r *http.Request, reposi
 		CreationDate:    writeTime,
 		Size:            blob.Size,
 		Checksum:        blob.Checksum,
+		ContentType:     uploadContentType(handler),
 	}
 
 	err = c.Catalog.CreateEntry(ctx, repo.Name, branch, entry, graveler.IfAbsent(!allowOverwrite))
@@ -1781,10 +2019,37 @@ func (c *Controller) UploadObject(w http.ResponseWriter, r *http.Request, reposi
 		PathType:        entryTypeObject,
 		PhysicalAddress: qk.Format(),
 		SizeBytes:       Int64Ptr(blob.Size),
+		ContentType:     contentTypePtr(entry.ContentType),
 	}
 	writeResponse(w, http.StatusCreated, response)
 }
 
+// uploadContentType returns the content-type of an uploaded object from the Content-Type of
+// its form part.  The multipart default of application/octet-stream carries no information,
+// so it leaves the content-type unset.
+func uploadContentType(handler *multipart.FileHeader) string {
+	contentType := handler.Header.Get("Content-Type")
+	if contentType == "application/octet-stream" {
+		return ""
+	}
+	return contentType
+}
+
+func contentTypePtr(contentType string) *string {
+	if contentType == "" {
+		return nil
+	}
+	return &contentType
+}
+
+// objectContentType returns the content-type to serve an entry with.
+func objectContentType(entry *catalog.DBEntry) string {
+	if entry.ContentType == "" {
+		return "application/octet-stream"
+	}
+	return entry.ContentType
+}
+
 func (c *Controller) StageObject(w http.ResponseWriter, r *http.Request, body StageObjectJSONRequestBody, repository string, branch string, params StageObjectParams) {
 	if !c.authorize(w, r, []permissions.Permission{
 		{
@@ -1835,6 +2100,9 @@ func (c *Controller) StageObject(w http.ResponseWriter, r *http.Request, body St
 	if body.Metadata != nil {
 		entry.Metadata = body.Metadata.AdditionalProperties
 	}
+	if body.ContentType != nil {
+		entry.ContentType = *body.ContentType
+	}
 
 	err = c.Catalog.CreateEntry(ctx, repo.Name, branch, entry)
 	if handleAPIError(w, err) {
@@ -1847,10 +2115,78 @@ func (c *Controller) StageObject(w http.ResponseWriter, r *http.Request, body St
 		PathType:        entryTypeObject,
 		PhysicalAddress: qk.Format(),
 		SizeBytes:       Int64Ptr(entry.Size),
+		ContentType:     contentTypePtr(entry.ContentType),
 	}
 	writeResponse(w, http.StatusCreated, response)
 }
 
+func (c *Controller) UpdateObjectUserMetadata(w http.ResponseWriter, r *http.Request, body UpdateObjectUserMetadataJSONRequestBody, repository string, branch string, params UpdateObjectUserMetadataParams) {
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.WriteObjectAction,
+			Resource: permissions.ObjectArn(repository, params.Path),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "update_object_user_metadata")
+
+	var set map[string]string
+	if body.Set != nil {
+		set = body.Set.AdditionalProperties
+	}
+	var remove []string
+	if body.Remove != nil {
+		remove = *body.Remove
+	}
+	for _, key := range remove {
+		if _, ok := set[key]; ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("metadata key '%s' both set and removed", key))
+			return
+		}
+	}
+
+	repo, err := c.Catalog.GetRepository(ctx, repository)
+	if handleAPIError(w, err) {
+		return
+	}
+	entry, err := c.Catalog.GetEntry(ctx, repository, branch, params.Path, catalog.GetEntryParams{})
+	if handleAPIError(w, err) {
+		return
+	}
+	metadata := make(catalog.Metadata, len(entry.Metadata)+len(set))
+	for k, v := range entry.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range set {
+		metadata[k] = v
+	}
+	for _, key := range remove {
+		delete(metadata, key)
+	}
+	// restage the same object with its new metadata
+	entry.Metadata = metadata
+	err = c.Catalog.CreateEntry(ctx, repository, branch, *entry)
+	if handleAPIError(w, err) {
+		return
+	}
+
+	qk, err := block.ResolveNamespace(repo.StorageNamespace, entry.PhysicalAddress, entry.AddressType.ToIdentifierType())
+	if handleAPIError(w, err) {
+		return
+	}
+	writeResponse(w, http.StatusOK, ObjectStats{
+		Checksum:        entry.Checksum,
+		Mtime:           entry.CreationDate.Unix(),
+		Path:            params.Path,
+		PathType:        entryTypeObject,
+		PhysicalAddress: qk.Format(),
+		SizeBytes:       Int64Ptr(entry.Size),
+		Metadata:        &ObjectStats_Metadata{AdditionalProperties: metadata},
+	})
+}
+
 func (c *Controller) RevertBranch(w http.ResponseWriter, r *http.Request, body RevertBranchJSONRequestBody, repository string, branch string) {
 	if !c.authorize(w, r, []permissions.Permission{
 		{
@@ -2126,9 +2462,12 @@ func (c *Controller) RestoreRefs(w http.ResponseWriter, r *http.Request, body Re
 	}
 
 	// ensure no refs currently found
-	_, _, err = c.Catalog.ListCommits(ctx, repo.Name, repo.DefaultBranch, "", 1)
-	if !errors.Is(err, graveler.ErrNotFound) {
-		writeError(w, http.StatusBadRequest, "can only restore into a bare repository")
+	empty, err := c.isEmptyRepository(ctx, repo)
+	if handleAPIError(w, err) {
+		return
+	}
+	if !empty {
+		writeError(w, http.StatusBadRequest, "can only restore into a bare or empty repository")
 		return
 	}
 
@@ -2149,6 +2488,32 @@ func (c *Controller) RestoreRefs(w http.ResponseWriter, r *http.Request, body Re
 	}
 }
 
+// isEmptyRepository returns whether repo holds no refs of its own: it is bare, or it was just
+// created and holds only its default branch at its first commit, with no changes.
+func (c *Controller) isEmptyRepository(ctx context.Context, repo *catalog.Repository) (bool, error) {
+	commits, _, err := c.Catalog.ListCommits(ctx, repo.Name, repo.DefaultBranch, "", 2)
+	if errors.Is(err, graveler.ErrNotFound) {
+		// bare
+		return true, nil
+	}
+	if err != nil || len(commits) != 1 || len(commits[0].Parents) != 0 {
+		return false, err
+	}
+	branches, _, err := c.Catalog.ListBranches(ctx, repo.Name, "", 2, "")
+	if err != nil || len(branches) != 1 {
+		return false, err
+	}
+	tags, _, err := c.Catalog.ListTags(ctx, repo.Name, 1, "")
+	if err != nil || len(tags) != 0 {
+		return false, err
+	}
+	changes, _, err := c.Catalog.DiffUncommitted(ctx, repo.Name, repo.DefaultBranch, "", "", 1, "")
+	if err != nil {
+		return false, err
+	}
+	return len(changes) == 0, nil
+}
+
 func (c *Controller) CreateSymlinkFile(w http.ResponseWriter, r *http.Request, repository string, branch string, params CreateSymlinkFileParams) {
 	if !c.authorize(w, r, []permissions.Permission{
 		{
@@ -2276,6 +2641,7 @@ func (c *Controller) DiffRefs(w http.ResponseWriter, r *http.Request, repository
 		}
 		if !d.CommonLevel {
 			diff.SizeBytes = &d.Size
+			diff.Checksum = StringPtr(d.Checksum)
 		}
 		results = append(results, diff)
 	}
@@ -2286,6 +2652,36 @@ func (c *Controller) DiffRefs(w http.ResponseWriter, r *http.Request, repository
 	writeResponse(w, http.StatusOK, response)
 }
 
+func (c *Controller) FindMergeBase(w http.ResponseWriter, r *http.Request, repository string, leftRef string, rightRef string) {
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.ReadCommitAction,
+			Resource: permissions.RepoArn(repository),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "find_merge_base")
+	commit, err := c.Catalog.FindMergeBase(ctx, repository, leftRef, rightRef)
+	if errors.Is(err, graveler.ErrNoMergeBase) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if handleAPIError(w, err) {
+		return
+	}
+	writeResponse(w, http.StatusOK, Commit{
+		Committer:    commit.Committer,
+		CreationDate: commit.CreationDate.Unix(),
+		Id:           commit.Reference,
+		Message:      commit.Message,
+		MetaRangeId:  commit.MetaRangeID,
+		Metadata:     &Commit_Metadata{AdditionalProperties: map[string]string(commit.Metadata)},
+		Parents:      commit.Parents,
+	})
+}
+
 // LogBranchCommits deprecated replaced by LogCommits
 func (c *Controller) LogBranchCommits(w http.ResponseWriter, r *http.Request, repository string, branch string, params LogBranchCommitsParams) {
 	c.logCommitsHelper(w, r, repository, branch, params.After, params.Amount)
@@ -2380,7 +2776,7 @@ func (c *Controller) GetObject(w http.ResponseWriter, r *http.Request, repositor
 	w.Header().Set("Last-Modified", lastModified)
 	cd := mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(entry.Path)})
 	w.Header().Set("Content-Disposition", cd)
-	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Type", objectContentType(entry))
 	_, err = io.Copy(w, reader)
 	if err != nil {
 		c.Logger.
@@ -2500,6 +2896,8 @@ func (c *Controller) StatObject(w http.ResponseWriter, r *http.Request, reposito
 		PathType:        entryTypeObject,
 		PhysicalAddress: qk.Format(),
 		SizeBytes:       Int64Ptr(entry.Size),
+		Metadata:        &ObjectStats_Metadata{AdditionalProperties: entry.Metadata},
+		ContentType:     contentTypePtr(entry.ContentType),
 	}
 	code := http.StatusOK
 	if entry.Expired {
@@ -2508,6 +2906,197 @@ func (c *Controller) StatObject(w http.ResponseWriter, r *http.Request, reposito
 	writeResponse(w, code, objStat)
 }
 
+func (c *Controller) PresignObject(w http.ResponseWriter, r *http.Request, repository string, ref string, params PresignObjectParams) {
+	forUpload := BoolValue(params.Upload)
+	action := permissions.ReadObjectAction
+	if forUpload {
+		action = permissions.WriteObjectAction
+	}
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   action,
+			Resource: permissions.ObjectArn(repository, params.Path),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "presign_object")
+
+	preSigner, ok := c.BlockAdapter.(block.PreSigner)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, fmt.Errorf("%w: %s blockstore cannot pre-sign URLs",
+			block.ErrOperationNotSupported, c.BlockAdapter.BlockstoreType()))
+		return
+	}
+	repo, err := c.Catalog.GetRepository(ctx, repository)
+	if handleAPIError(w, err) {
+		return
+	}
+
+	mode := block.PreSignModeRead
+	pointer := block.ObjectPointer{StorageNamespace: repo.StorageNamespace}
+	if forUpload {
+		// sign a fresh physical address, to be staged on the branch once uploaded
+		mode = block.PreSignModeWrite
+		pointer.Identifier = upload.NewPhysicalAddress()
+		pointer.IdentifierType = block.IdentifierTypeRelative
+	} else {
+		entry, err := c.Catalog.GetEntry(ctx, repository, ref, params.Path, catalog.GetEntryParams{})
+		if handleAPIError(w, err) {
+			return
+		}
+		pointer.Identifier = entry.PhysicalAddress
+		pointer.IdentifierType = entry.AddressType.ToIdentifierType()
+	}
+	qk, err := block.ResolveNamespace(pointer.StorageNamespace, pointer.Identifier, pointer.IdentifierType)
+	if handleAPIError(w, err) {
+		return
+	}
+
+	expiry := defaultPresignExpiry
+	if params.Expiry != nil {
+		expiry = time.Duration(*params.Expiry) * time.Second
+	}
+	expiresAt := time.Now().Add(expiry)
+	url, err := preSigner.GetPreSignedURL(ctx, pointer, mode, expiry)
+	if handleAPIError(w, err) {
+		return
+	}
+	writeResponse(w, http.StatusOK, PreSignedURL{
+		Url:             url,
+		PhysicalAddress: qk.Format(),
+		Expiry:          expiresAt.Unix(),
+	})
+}
+
+func (c *Controller) ExportRef(w http.ResponseWriter, r *http.Request, body ExportRefJSONRequestBody, repository string, ref string, params ExportRefParams) {
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.ListObjectsAction,
+			Resource: permissions.RepoArn(repository),
+		},
+		{
+			Action:   permissions.ReadObjectAction,
+			Resource: permissions.ObjectArn(repository, "*"),
+		},
+		{
+			Action:   permissions.ExportObjectsAction,
+			Resource: permissions.StorageNamespaceArn(body.Destination),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "export_ref")
+
+	repo, err := c.Catalog.GetRepository(ctx, repository)
+	if handleAPIError(w, err) {
+		return
+	}
+	// the destination must be on the same blockstore, to copy objects there
+	repoStorageType, err := storageTypeOf(repo.StorageNamespace)
+	if handleAPIError(w, err) {
+		return
+	}
+	destinationStorageType, err := storageTypeOf(body.Destination)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if destinationStorageType != repoStorageType {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("destination %s is not on the %s blockstore of the repository",
+			body.Destination, repoStorageType))
+		return
+	}
+	// objects are copied with the credentials of the server, which must not write into repositories
+	overlapping, err := c.repositoryUnderNamespace(ctx, body.Destination)
+	if handleAPIError(w, err) {
+		return
+	}
+	if overlapping != "" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("destination %s overlaps the storage namespace of repository %s",
+			body.Destination, overlapping))
+		return
+	}
+
+	entries, hasMore, err := c.Catalog.ListEntries(
+		ctx,
+		repository,
+		ref,
+		paginationPrefix(params.Prefix),
+		paginationAfter(params.After),
+		"",
+		paginationAmount(params.Amount),
+	)
+	if handleAPIError(w, err) {
+		return
+	}
+	var sizeBytes int64
+	for _, entry := range entries {
+		source := block.ObjectPointer{
+			StorageNamespace: repo.StorageNamespace,
+			Identifier:       entry.PhysicalAddress,
+			IdentifierType:   entry.AddressType.ToIdentifierType(),
+		}
+		destination := block.ObjectPointer{
+			StorageNamespace: body.Destination,
+			Identifier:       entry.Path,
+			IdentifierType:   block.IdentifierTypeRelative,
+		}
+		if err := c.BlockAdapter.Copy(ctx, source, destination); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("export %s: %s", entry.Path, err))
+			return
+		}
+		sizeBytes += entry.Size
+	}
+	response := ExportResult{
+		Pagination: Pagination{
+			HasMore:    hasMore,
+			MaxPerPage: DefaultMaxPerPage,
+			Results:    len(entries),
+		},
+		Exported:  len(entries),
+		SizeBytes: sizeBytes,
+	}
+	if len(entries) > 0 && hasMore {
+		response.Pagination.NextOffset = entries[len(entries)-1].Path
+	}
+	writeResponse(w, http.StatusOK, response)
+}
+
+// repositoryUnderNamespace returns the name of a repository whose storage namespace contains, or
+// is contained in, storageNamespace, or "" if there is none.
+func (c *Controller) repositoryUnderNamespace(ctx context.Context, storageNamespace string) (string, error) {
+	namespace := strings.TrimSuffix(storageNamespace, "/") + "/"
+	after := ""
+	for {
+		repos, hasMore, err := c.Catalog.ListRepositories(ctx, -1, "", after)
+		if err != nil {
+			return "", err
+		}
+		for _, repo := range repos {
+			repoNamespace := strings.TrimSuffix(repo.StorageNamespace, "/") + "/"
+			if strings.HasPrefix(namespace, repoNamespace) || strings.HasPrefix(repoNamespace, namespace) {
+				return repo.Name, nil
+			}
+		}
+		if !hasMore || len(repos) == 0 {
+			return "", nil
+		}
+		after = repos[len(repos)-1].Name
+	}
+}
+
+// storageTypeOf returns the storage type of a storage namespace URI.
+func storageTypeOf(storageNamespace string) (block.StorageType, error) {
+	u, err := url.Parse(storageNamespace)
+	if err != nil {
+		return 0, err
+	}
+	return block.GetStorageType(u)
+}
+
 func (c *Controller) GetUnderlyingProperties(w http.ResponseWriter, r *http.Request, repository string, ref string, params GetUnderlyingPropertiesParams) {
 	if !c.authorize(w, r, []permissions.Permission{
 		{
@@ -2564,28 +3153,53 @@ func (c *Controller) MergeIntoBranch(w http.ResponseWriter, r *http.Request, bod
 	if body.Metadata != nil {
 		metadata = body.Metadata.AdditionalProperties
 	}
+	var resolutions map[string]string
+	if body.Resolutions != nil {
+		resolutions = body.Resolutions.AdditionalProperties
+	}
+	if BoolValue(body.FastForward) && !BoolValue(body.Squash) {
+		commitID, err := c.Catalog.FastForward(ctx, repository, destinationBranch, sourceRef)
+		if err == nil {
+			writeResponse(w, http.StatusOK, MergeResult{Reference: commitID, FastForwarded: swag.Bool(true)})
+			return
+		}
+		// a branch that cannot fast-forward is merged as usual, reporting any other failure
+		if !errors.Is(err, graveler.ErrNotFastForward) {
+			c.handleMergeError(w, err, nil)
+			return
+		}
+	}
 	res, err := c.Catalog.Merge(ctx,
 		repository, destinationBranch, sourceRef,
 		user.Username,
 		StringValue(body.Message),
-		metadata)
+		metadata,
+		BoolValue(body.Squash),
+		resolutions)
+	if err != nil {
+		c.handleMergeError(w, err, res)
+		return
+	}
+
+	response := newMergeResultFromCatalog(res)
+	writeResponse(w, http.StatusOK, response)
+}
 
+// handleMergeError writes the response to a merge or fast-forward that failed with err.
+func (c *Controller) handleMergeError(w http.ResponseWriter, err error, res *catalog.MergeResult) {
 	var hookAbortErr *graveler.HookAbortError
 	switch {
 	case errors.As(err, &hookAbortErr):
 		c.Logger.WithError(err).WithField("run_id", hookAbortErr.RunID).Warn("aborted by hooks")
 		writeError(w, http.StatusPreconditionFailed, err)
-		return
 	case errors.Is(err, catalog.ErrConflictFound) || errors.Is(err, graveler.ErrConflictFound):
-		writeError(w, http.StatusConflict, newMergeResultFromCatalog(res))
-		return
-	}
-	if handleAPIError(w, err) {
-		return
+		writeResponse(w, http.StatusConflict, newMergeResultFromCatalog(res))
+	case errors.Is(err, graveler.ErrLockNotAcquired):
+		// a concurrent update holds the destination branch, so its head is moving
+		writeResponse(w, http.StatusConflict, MergeResult{BaseMoved: swag.Bool(true)})
+	default:
+		handleAPIError(w, err)
 	}
-
-	response := newMergeResultFromCatalog(res)
-	writeResponse(w, http.StatusOK, response)
 }
 
 func newMergeResultFromCatalog(res *catalog.MergeResult) MergeResult {
@@ -2682,6 +3296,110 @@ func (c *Controller) DeleteTag(w http.ResponseWriter, r *http.Request, repositor
 	writeResponse(w, http.StatusNoContent, nil)
 }
 
+func (c *Controller) ListMultipartUploads(w http.ResponseWriter, r *http.Request, repository string, params ListMultipartUploadsParams) {
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.ListObjectsAction,
+			Resource: permissions.RepoArn(repository),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	c.LogAction(ctx, "list_multipart_uploads")
+
+	repo, err := c.Catalog.GetRepository(ctx, repository)
+	if handleAPIError(w, err) {
+		return
+	}
+	uploads, hasMore, err := c.MultipartsTracker.List(ctx, repository, paginationAfter(params.After), paginationAmount(params.Amount))
+	if handleAPIError(w, err) {
+		return
+	}
+	// report the sizes of uploads if the blockstore can summarize them
+	uploadSizes := make(map[string]int64)
+	if lister, ok := c.BlockAdapter.(block.MultipartUploadLister); ok && len(uploads) > 0 {
+		infos, err := lister.ListMultipartUploads(ctx, repo.StorageNamespace)
+		if handleAPIError(w, err) {
+			return
+		}
+		for _, info := range infos {
+			uploadSizes[info.UploadID] = info.SizeBytes
+		}
+		// uploads without parts are not listed by the blockstore
+		for _, upload := range uploads {
+			if _, ok := uploadSizes[upload.UploadID]; !ok {
+				uploadSizes[upload.UploadID] = 0
+			}
+		}
+	}
+	results := make([]MultipartUpload, 0, len(uploads))
+	for _, upload := range uploads {
+		result := MultipartUpload{
+			UploadId:     upload.UploadID,
+			Path:         upload.Path,
+			CreationDate: upload.CreationDate.Unix(),
+		}
+		if size, ok := uploadSizes[upload.UploadID]; ok {
+			result.SizeBytes = &size
+		}
+		results = append(results, result)
+	}
+	response := MultipartUploadList{
+		Results:    results,
+		Pagination: paginationFor(hasMore, results, "UploadId"),
+	}
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (c *Controller) AbortMultipartUpload(w http.ResponseWriter, r *http.Request, repository string, uploadId string) {
+	// authorize listing the repository before telling whether the upload exists, then
+	// deleting its object once its path is known
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.ListObjectsAction,
+			Resource: permissions.RepoArn(repository),
+		},
+	}) {
+		return
+	}
+	ctx := r.Context()
+	upload, err := c.MultipartsTracker.Get(ctx, uploadId)
+	if errors.Is(err, db.ErrNotFound) || (err == nil && upload.Repository != repository) {
+		writeError(w, http.StatusNotFound, "multipart upload not found")
+		return
+	}
+	if handleAPIError(w, err) {
+		return
+	}
+	if !c.authorize(w, r, []permissions.Permission{
+		{
+			Action:   permissions.DeleteObjectAction,
+			Resource: permissions.ObjectArn(repository, upload.Path),
+		},
+	}) {
+		return
+	}
+	c.LogAction(ctx, "abort_multipart_upload")
+
+	repo, err := c.Catalog.GetRepository(ctx, repository)
+	if handleAPIError(w, err) {
+		return
+	}
+	err = c.BlockAdapter.AbortMultiPartUpload(ctx, block.ObjectPointer{
+		StorageNamespace: repo.StorageNamespace,
+		Identifier:       upload.PhysicalAddress,
+	}, uploadId)
+	if handleAPIError(w, err) {
+		return
+	}
+	err = c.MultipartsTracker.Delete(ctx, uploadId)
+	if handleAPIError(w, err) {
+		return
+	}
+	writeResponse(w, http.StatusNoContent, nil)
+}
+
 func (c *Controller) GetTag(w http.ResponseWriter, r *http.Request, repository string, tag string) {
 	if !c.authorize(w, r, []permissions.Permission{
 		{
@@ -2791,6 +3509,13 @@ func StringValue(s *string) string {
 	return *s
 }
 
+func BoolValue(p *bool) bool {
+	if p == nil {
+		return false
+	}
+	return *p
+}
+
 func Int64Value(p *int64) int64 {
 	if p == nil {
 		return 0
@@ -2877,6 +3602,7 @@ func NewController(
 	collector stats.Collector,
 	cloudMetadataProvider cloud.MetadataProvider,
 	actions actionsHandler,
+	multipartsTracker multiparts.Tracker,
 	logger logging.Logger,
 ) *Controller {
 	return &Controller{
@@ -2889,6 +3615,7 @@ func NewController(
 		Collector:             collector,
 		CloudMetadataProvider: cloudMetadataProvider,
 		Actions:               actions,
+		MultipartsTracker:     multipartsTracker,
 		Logger:                logger,
 	}
 }
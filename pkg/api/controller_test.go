@@ -10,6 +10,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -17,6 +18,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/go-openapi/swag"
 	"github.com/go-test/deep"
 	"github.com/treeverse/lakefs/pkg/api"
 	"github.com/treeverse/lakefs/pkg/block"
@@ -254,6 +256,37 @@ func TestController_GetCommitHandler(t *testing.T) {
 	})
 }
 
+func TestController_FindMergeBaseHandler(t *testing.T) {
+	clt, deps := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	_, err := deps.catalog.CreateRepository(ctx, "repo-base", onBlock(deps, "repo-base"), "main")
+	testutil.MustDo(t, "create repo repo-base", err)
+	base, err := deps.catalog.CreateBranch(ctx, "repo-base", "feature", "main")
+	testutil.MustDo(t, "create branch feature", err)
+	for _, branch := range []string{"main", "feature"} {
+		testutil.MustDo(t, "create entry on "+branch, deps.catalog.CreateEntry(ctx, "repo-base", branch, catalog.DBEntry{Path: branch + "/obj", PhysicalAddress: "pa", CreationDate: time.Now(), Size: 1, Checksum: "cs"}))
+		_, err := deps.catalog.Commit(ctx, "repo-base", branch, "commit on "+branch, "some_user", nil)
+		testutil.MustDo(t, "commit on "+branch, err)
+	}
+
+	t.Run("diverged branches", func(t *testing.T) {
+		resp, err := clt.FindMergeBaseWithResponse(ctx, "repo-base", "main", "feature")
+		verifyResponseOK(t, resp, err)
+		if resp.JSON200.Id != base.Reference {
+			t.Errorf("FindMergeBase() = %s, expected %s", resp.JSON200.Id, base.Reference)
+		}
+	})
+
+	t.Run("missing ref", func(t *testing.T) {
+		resp, err := clt.FindMergeBaseWithResponse(ctx, "repo-base", "main", "no-such-branch")
+		testutil.Must(t, err)
+		if resp.JSON404 == nil {
+			t.Errorf("FindMergeBase() status %d, expected 404 on a missing ref", resp.StatusCode())
+		}
+	})
+}
+
 func TestController_CommitHandler(t *testing.T) {
 	clt, deps := setupClientWithAdmin(t, "")
 	ctx := context.Background()
@@ -283,6 +316,116 @@ func TestController_CommitHandler(t *testing.T) {
 	})
 }
 
+func TestController_AmendCommitHandler(t *testing.T) {
+	clt, deps := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	_, err := deps.catalog.CreateRepository(ctx, "repo1", onBlock(deps, "foo1"), "main")
+	testutil.MustDo(t, "create repository", err)
+	testutil.MustDo(t, "create entry", deps.catalog.CreateEntry(ctx, "repo1", "main", catalog.DBEntry{Path: "foo/bar", PhysicalAddress: "pa", CreationDate: time.Now(), Size: 666, Checksum: "cs"}))
+	commitResp, err := clt.CommitWithResponse(ctx, "repo1", "main", api.CommitJSONRequestBody{Message: "tpyo"})
+	verifyResponseOK(t, commitResp, err)
+	original := commitResp.JSON201
+
+	resp, err := clt.AmendCommitWithResponse(ctx, "repo1", "main", api.AmendCommitJSONRequestBody{
+		Message:      "typo",
+		Metadata:     &api.CommitAmendment_Metadata{AdditionalProperties: map[string]string{"fixed": "true"}},
+		HeadCommitId: api.StringPtr(original.Id),
+	})
+	verifyResponseOK(t, resp, err)
+	amended := resp.JSON201
+	if amended.Id == original.Id {
+		t.Errorf("amended commit has the original ID %s", original.Id)
+	}
+	if amended.Message != "typo" || amended.Metadata.AdditionalProperties["fixed"] != "true" {
+		t.Errorf("amended commit %+v, expected the new message and metadata", amended)
+	}
+	if amended.MetaRangeId != original.MetaRangeId {
+		t.Errorf("amended commit has meta-range %s, expected %s", amended.MetaRangeId, original.MetaRangeId)
+	}
+	if diff := deep.Equal(amended.Parents, original.Parents); diff != nil {
+		t.Errorf("amended commit parents: %s", diff)
+	}
+	branchResp, err := clt.GetBranchWithResponse(ctx, "repo1", "main")
+	verifyResponseOK(t, branchResp, err)
+	if branchResp.JSON200.CommitId != amended.Id {
+		t.Errorf("branch at %s after amend, expected %s", branchResp.JSON200.CommitId, amended.Id)
+	}
+
+	// the original commit is no longer the head
+	movedResp, err := clt.AmendCommitWithResponse(ctx, "repo1", "main", api.AmendCommitJSONRequestBody{
+		Message:      "again",
+		HeadCommitId: api.StringPtr(original.Id),
+	})
+	testutil.Must(t, err)
+	if movedResp.JSON412 == nil {
+		t.Errorf("AmendCommit of a commit that is not the head returned %s, expected precondition failed", movedResp.Status())
+	}
+
+	emptyResp, err := clt.AmendCommitWithResponse(ctx, "repo1", "main", api.AmendCommitJSONRequestBody{Message: ""})
+	testutil.Must(t, err)
+	if emptyResp.JSON400 == nil {
+		t.Errorf("AmendCommit with an empty message returned %s, expected bad request", emptyResp.Status())
+	}
+
+	missingResp, err := clt.AmendCommitWithResponse(ctx, "repo1", "missing", api.AmendCommitJSONRequestBody{Message: "message"})
+	testutil.Must(t, err)
+	if missingResp.JSON404 == nil {
+		t.Errorf("AmendCommit of a missing branch returned %s, expected not found", missingResp.Status())
+	}
+
+	// a protected branch is amended only when forced
+	protectResp, err := clt.CreateBranchProtectionRuleWithResponse(ctx, "repo1", api.CreateBranchProtectionRuleJSONRequestBody{Pattern: "main"})
+	verifyResponseOK(t, protectResp, err)
+	protectedResp, err := clt.AmendCommitWithResponse(ctx, "repo1", "main", api.AmendCommitJSONRequestBody{Message: "protected"})
+	testutil.Must(t, err)
+	if protectedResp.StatusCode() != http.StatusForbidden {
+		t.Errorf("AmendCommit of a protected branch returned %s, expected forbidden", protectedResp.Status())
+	}
+	forcedResp, err := clt.AmendCommitWithResponse(ctx, "repo1", "main", api.AmendCommitJSONRequestBody{Message: "forced", Force: swag.Bool(true)})
+	verifyResponseOK(t, forcedResp, err)
+	if forcedResp.JSON201.Message != "forced" {
+		t.Errorf("forced AmendCommit of a protected branch returned message %s, expected forced", forcedResp.JSON201.Message)
+	}
+}
+
+func TestController_RestoreRefsHandler(t *testing.T) {
+	clt, deps := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	ns := onBlock(deps, "restore-source")
+	_, err := deps.catalog.CreateRepository(ctx, "restore-source", ns, "main")
+	testutil.MustDo(t, "create repo restore-source", err)
+	testutil.MustDo(t, "create entry", deps.catalog.CreateEntry(ctx, "restore-source", "main", catalog.DBEntry{Path: "foo/bar", PhysicalAddress: "pa", CreationDate: time.Now(), Size: 666, Checksum: "cs"}))
+	commitResp, err := clt.CommitWithResponse(ctx, "restore-source", "main", api.CommitJSONRequestBody{
+		Message: "some message",
+	})
+	verifyResponseOK(t, commitResp, err)
+	dumpResp, err := clt.DumpRefsWithResponse(ctx, "restore-source")
+	verifyResponseOK(t, dumpResp, err)
+	refs := api.RestoreRefsJSONRequestBody(*dumpResp.JSON201)
+
+	t.Run("restore into repository with commits", func(t *testing.T) {
+		resp, err := clt.RestoreRefsWithResponse(ctx, "restore-source", refs)
+		testutil.Must(t, err)
+		if resp.JSON400 == nil {
+			t.Fatalf("RestoreRefs() status %d, expected 400 on a repository that is not empty", resp.StatusCode())
+		}
+	})
+
+	t.Run("restore into new repository", func(t *testing.T) {
+		_, err := deps.catalog.CreateRepository(ctx, "restore-target", ns, "main")
+		testutil.MustDo(t, "create repo restore-target", err)
+		resp, err := clt.RestoreRefsWithResponse(ctx, "restore-target", refs)
+		verifyResponseOK(t, resp, err)
+		branchResp, err := clt.GetBranchWithResponse(ctx, "restore-target", "main")
+		verifyResponseOK(t, branchResp, err)
+		if branchResp.JSON200.CommitId != commitResp.JSON201.Id {
+			t.Errorf("restored branch main at %s, expected %s", branchResp.JSON200.CommitId, commitResp.JSON201.Id)
+		}
+	})
+}
+
 func TestController_CreateRepositoryHandler(t *testing.T) {
 	clt, deps := setupClientWithAdmin(t, "")
 	ctx := context.Background()
@@ -367,6 +510,52 @@ func TestController_DeleteRepositoryHandler(t *testing.T) {
 	})
 }
 
+func TestController_UpdateRepositoryHandler(t *testing.T) {
+	clt, deps := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	// setup test data
+	_, err := deps.catalog.CreateRepository(ctx, "repo1", onBlock(deps, "foo1"), "main")
+	testutil.Must(t, err)
+	_, err = deps.catalog.CreateBranch(ctx, "repo1", "dev", "main")
+	testutil.Must(t, err)
+
+	t.Run("default branch", func(t *testing.T) {
+		resp, err := clt.UpdateRepositoryWithResponse(ctx, "repo1", api.UpdateRepositoryJSONRequestBody{
+			DefaultBranch: api.StringPtr("dev"),
+		})
+		verifyResponseOK(t, resp, err)
+		if resp.JSON200.DefaultBranch != "dev" {
+			t.Errorf("UpdateRepository default branch=%s, expected dev", resp.JSON200.DefaultBranch)
+		}
+		getResp, err := clt.GetRepositoryWithResponse(ctx, "repo1")
+		verifyResponseOK(t, getResp, err)
+		if getResp.JSON200.DefaultBranch != "dev" {
+			t.Errorf("GetRepository default branch=%s after update, expected dev", getResp.JSON200.DefaultBranch)
+		}
+	})
+
+	t.Run("missing branch", func(t *testing.T) {
+		resp, err := clt.UpdateRepositoryWithResponse(ctx, "repo1", api.UpdateRepositoryJSONRequestBody{
+			DefaultBranch: api.StringPtr("missing"),
+		})
+		testutil.Must(t, err)
+		if resp.JSON404 == nil {
+			t.Errorf("UpdateRepository to a missing branch returned %s, expected not found", resp.Status())
+		}
+	})
+
+	t.Run("missing repository", func(t *testing.T) {
+		resp, err := clt.UpdateRepositoryWithResponse(ctx, "repo2", api.UpdateRepositoryJSONRequestBody{
+			DefaultBranch: api.StringPtr("main"),
+		})
+		testutil.Must(t, err)
+		if resp.JSON404 == nil {
+			t.Errorf("UpdateRepository of a missing repository returned %s, expected not found", resp.Status())
+		}
+	})
+}
+
 func TestController_ListBranchesHandler(t *testing.T) {
 	clt, deps := setupClientWithAdmin(t, "")
 	ctx := context.Background()
@@ -518,6 +707,66 @@ func TestController_ListTagsHandler(t *testing.T) {
 	})
 }
 
+func TestController_MultipartUploadsHandlers(t *testing.T) {
+	clt, deps := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	// setup test data
+	repo, err := deps.catalog.CreateRepository(ctx, "repo1", onBlock(deps, "foo1"), "main")
+	testutil.Must(t, err)
+	creationTime := time.Now().Add(-time.Hour).Round(time.Second)
+	var uploadIDs []string
+	for _, path := range []string{"a/obj1", "b/obj2"} {
+		uploadID, err := deps.blocks.CreateMultiPartUpload(ctx, block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: path}, nil, block.CreateMultiPartUploadOpts{})
+		testutil.Must(t, err)
+		testutil.Must(t, deps.multipartsTracker.Create(ctx, "repo1", uploadID, path, path, creationTime))
+		uploadIDs = append(uploadIDs, uploadID)
+	}
+
+	resp, err := clt.ListMultipartUploadsWithResponse(ctx, "repo1", &api.ListMultipartUploadsParams{})
+	verifyResponseOK(t, resp, err)
+	if n := len(resp.JSON200.Results); n != len(uploadIDs) {
+		t.Fatalf("ListMultipartUploads returned %d uploads, expected %d", n, len(uploadIDs))
+	}
+	for _, upload := range resp.JSON200.Results {
+		if upload.CreationDate != creationTime.Unix() {
+			t.Errorf("upload %s created at %d, expected %d", upload.UploadId, upload.CreationDate, creationTime.Unix())
+		}
+	}
+
+	if _, ok := deps.blocks.(block.MultipartUploadLister); ok {
+		const partContents = "part"
+		pointer := block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: "b/obj2"}
+		_, err := deps.blocks.UploadPart(ctx, pointer, int64(len(partContents)), strings.NewReader(partContents), uploadIDs[1], 1)
+		testutil.Must(t, err)
+		resp, err := clt.ListMultipartUploadsWithResponse(ctx, "repo1", &api.ListMultipartUploadsParams{})
+		verifyResponseOK(t, resp, err)
+		for _, upload := range resp.JSON200.Results {
+			expectedSize := int64(len(partContents))
+			if upload.UploadId != uploadIDs[1] {
+				expectedSize = 0
+			}
+			if upload.SizeBytes == nil || *upload.SizeBytes != expectedSize {
+				t.Errorf("upload %s reported size %v, expected %d", upload.UploadId, upload.SizeBytes, expectedSize)
+			}
+		}
+	}
+
+	abortResp, err := clt.AbortMultipartUploadWithResponse(ctx, "repo1", uploadIDs[0])
+	verifyResponseOK(t, abortResp, err)
+	resp, err = clt.ListMultipartUploadsWithResponse(ctx, "repo1", &api.ListMultipartUploadsParams{})
+	verifyResponseOK(t, resp, err)
+	if len(resp.JSON200.Results) != 1 || resp.JSON200.Results[0].UploadId != uploadIDs[1] {
+		t.Errorf("ListMultipartUploads after abort returned %+v, expected only %s", resp.JSON200.Results, uploadIDs[1])
+	}
+
+	abortResp, err = clt.AbortMultipartUploadWithResponse(ctx, "repo1", uploadIDs[0])
+	testutil.Must(t, err)
+	if abortResp.JSON404 == nil {
+		t.Errorf("AbortMultipartUpload of an aborted upload returned %s, expected not found", abortResp.Status())
+	}
+}
+
 func TestController_GetBranchHandler(t *testing.T) {
 	clt, deps := setupClientWithAdmin(t, "")
 	ctx := context.Background()
@@ -815,6 +1064,45 @@ func TestController_UploadObject(t *testing.T) {
 			t.Fatalf("expected 412 for UploadObject, got %d", b.StatusCode())
 		}
 	})
+	t.Run("content type", func(t *testing.T) {
+		var buf bytes.Buffer
+		mpw := multipart.NewWriter(&buf)
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", `form-data; name="content"; filename="data.json"`)
+		h.Set("Content-Type", "application/json")
+		pw, err := mpw.CreatePart(h)
+		testutil.Must(t, err)
+		_, _ = pw.Write([]byte(`{"hello":"world"}`))
+		testutil.Must(t, mpw.Close())
+		b, err := clt.UploadObjectWithBodyWithResponse(ctx, "my-new-repo", "main", &api.UploadObjectParams{
+			Path: "foo/data.json",
+		}, mpw.FormDataContentType(), &buf)
+		testutil.Must(t, err)
+		if b.StatusCode() != 201 {
+			t.Fatalf("expected 201 for UploadObject, got %d", b.StatusCode())
+		}
+		if ct := api.StringValue(b.JSON201.ContentType); ct != "application/json" {
+			t.Errorf("UploadObject content type %q, expected application/json", ct)
+		}
+
+		statResp, err := clt.StatObjectWithResponse(ctx, "my-new-repo", "main", &api.StatObjectParams{Path: "foo/data.json"})
+		testutil.Must(t, err)
+		if statResp.JSON200 == nil {
+			t.Fatalf("StatObject got status %d", statResp.StatusCode())
+		}
+		if ct := api.StringValue(statResp.JSON200.ContentType); ct != "application/json" {
+			t.Errorf("StatObject content type %q, expected application/json", ct)
+		}
+		if _, ok := statResp.JSON200.Metadata.AdditionalProperties["::lakefs::content-type"]; ok {
+			t.Error("content type exposed as user metadata")
+		}
+
+		getResp, err := clt.GetObjectWithResponse(ctx, "my-new-repo", "main", &api.GetObjectParams{Path: "foo/data.json"})
+		testutil.Must(t, err)
+		if ct := getResp.HTTPResponse.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("GetObject Content-Type %q, expected application/json", ct)
+		}
+	})
 }
 
 func TestController_DeleteBranchHandler(t *testing.T) {
@@ -1204,6 +1492,133 @@ func TestController_ObjectsDeleteObjectHandler(t *testing.T) {
 	})
 }
 
+func TestController_ObjectsDeleteObjectsHandler(t *testing.T) {
+	clt, deps := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	_, err := deps.catalog.CreateRepository(ctx, "repo1", onBlock(deps, "some-bucket/prefix"), "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("delete objects", func(t *testing.T) {
+		paths := []string{"foo/a", "foo/b", "foo/c"}
+		for _, p := range paths {
+			resp, err := uploadObjectHelper(t, ctx, clt, p, strings.NewReader("content"), "repo1", "main")
+			verifyResponseOK(t, resp, err)
+		}
+
+		delResp, err := clt.DeleteObjectsWithResponse(ctx, "repo1", "main", api.DeleteObjectsJSONRequestBody{
+			Paths: append(paths, "foo/missing"),
+		})
+		verifyResponseOK(t, delResp, err)
+		if len(delResp.JSON200.Errors) != 0 {
+			t.Fatalf("DeleteObjects errors: %+v", delResp.JSON200.Errors)
+		}
+
+		for _, p := range paths {
+			statResp, err := clt.StatObjectWithResponse(ctx, "repo1", "main", &api.StatObjectParams{Path: p})
+			testutil.Must(t, err)
+			if statResp.JSON404 == nil {
+				t.Fatalf("expected %s to be gone now", p)
+			}
+		}
+	})
+
+	t.Run("missing branch", func(t *testing.T) {
+		delResp, err := clt.DeleteObjectsWithResponse(ctx, "repo1", "no-such-branch", api.DeleteObjectsJSONRequestBody{
+			Paths: []string{"foo/a"},
+		})
+		testutil.Must(t, err)
+		if delResp.JSON404 == nil {
+			t.Fatalf("expected not found, got status %d", delResp.StatusCode())
+		}
+	})
+
+	t.Run("too many objects", func(t *testing.T) {
+		paths := make([]string, api.DefaultMaxDeleteObjects+1)
+		for i := range paths {
+			paths[i] = fmt.Sprintf("foo/%d", i)
+		}
+		delResp, err := clt.DeleteObjectsWithResponse(ctx, "repo1", "main", api.DeleteObjectsJSONRequestBody{Paths: paths})
+		testutil.Must(t, err)
+		if delResp.JSON400 == nil {
+			t.Fatalf("expected bad request, got status %d", delResp.StatusCode())
+		}
+	})
+
+	t.Run("protected branch", func(t *testing.T) {
+		resp, err := uploadObjectHelper(t, ctx, clt, "foo/protected", strings.NewReader("content"), "repo1", "main")
+		verifyResponseOK(t, resp, err)
+		protectResp, err := clt.CreateBranchProtectionRuleWithResponse(ctx, "repo1", api.CreateBranchProtectionRuleJSONRequestBody{Pattern: "main"})
+		verifyResponseOK(t, protectResp, err)
+
+		delResp, err := clt.DeleteObjectsWithResponse(ctx, "repo1", "main", api.DeleteObjectsJSONRequestBody{
+			Paths: []string{"foo/protected"},
+		})
+		verifyResponseOK(t, delResp, err)
+		errs := delResp.JSON200.Errors
+		if len(errs) != 1 || errs[0].StatusCode != http.StatusForbidden {
+			t.Fatalf("DeleteObjects on a protected branch errors: %+v, expected one forbidden", errs)
+		}
+	})
+}
+
+func TestController_UpdateObjectUserMetadataHandler(t *testing.T) {
+	clt, deps := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	_, err := deps.catalog.CreateRepository(ctx, "repo1", onBlock(deps, "some-bucket/prefix"), "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := uploadObjectHelper(t, ctx, clt, "foo/a", strings.NewReader("content"), "repo1", "main")
+	verifyResponseOK(t, resp, err)
+	checksum := resp.JSON201.Checksum
+
+	t.Run("set and remove", func(t *testing.T) {
+		updateResp, err := clt.UpdateObjectUserMetadataWithResponse(ctx, "repo1", "main", &api.UpdateObjectUserMetadataParams{Path: "foo/a"}, api.UpdateObjectUserMetadataJSONRequestBody{
+			Set: &api.ObjectUserMetadataUpdate_Set{AdditionalProperties: map[string]string{"owner": "alice", "stage": "raw"}},
+		})
+		verifyResponseOK(t, updateResp, err)
+
+		updateResp, err = clt.UpdateObjectUserMetadataWithResponse(ctx, "repo1", "main", &api.UpdateObjectUserMetadataParams{Path: "foo/a"}, api.UpdateObjectUserMetadataJSONRequestBody{
+			Remove: &[]string{"stage"},
+		})
+		verifyResponseOK(t, updateResp, err)
+
+		statResp, err := clt.StatObjectWithResponse(ctx, "repo1", "main", &api.StatObjectParams{Path: "foo/a"})
+		verifyResponseOK(t, statResp, err)
+		if diffs := deep.Equal(statResp.JSON200.Metadata.AdditionalProperties, map[string]string{"owner": "alice"}); diffs != nil {
+			t.Errorf("unexpected metadata: %s", diffs)
+		}
+		if statResp.JSON200.Checksum != checksum {
+			t.Errorf("object checksum changed from %s to %s, expected the content to stay", checksum, statResp.JSON200.Checksum)
+		}
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		updateResp, err := clt.UpdateObjectUserMetadataWithResponse(ctx, "repo1", "main", &api.UpdateObjectUserMetadataParams{Path: "foo/missing"}, api.UpdateObjectUserMetadataJSONRequestBody{
+			Remove: &[]string{"stage"},
+		})
+		testutil.Must(t, err)
+		if updateResp.JSON404 == nil {
+			t.Fatalf("expected not found, got status %d", updateResp.StatusCode())
+		}
+	})
+
+	t.Run("set and remove same key", func(t *testing.T) {
+		updateResp, err := clt.UpdateObjectUserMetadataWithResponse(ctx, "repo1", "main", &api.UpdateObjectUserMetadataParams{Path: "foo/a"}, api.UpdateObjectUserMetadataJSONRequestBody{
+			Set:    &api.ObjectUserMetadataUpdate_Set{AdditionalProperties: map[string]string{"owner": "bob"}},
+			Remove: &[]string{"owner"},
+		})
+		testutil.Must(t, err)
+		if updateResp.JSON400 == nil {
+			t.Fatalf("expected bad request, got status %d", updateResp.StatusCode())
+		}
+	})
+}
+
 func TestController_CreatePolicyHandler(t *testing.T) {
 	clt, _ := setupClientWithAdmin(t, "")
 	ctx := context.Background()
@@ -1512,6 +1927,89 @@ func TestController_ListRepositoryRuns(t *testing.T) {
 	})
 }
 
+func TestController_MergeConflict(t *testing.T) {
+	clt, _ := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	const repoName = "repo-merge-conflict"
+	repoResp, err := clt.CreateRepositoryWithResponse(ctx, &api.CreateRepositoryParams{}, api.CreateRepositoryJSONRequestBody{
+		DefaultBranch:    api.StringPtr("main"),
+		Name:             repoName,
+		StorageNamespace: "mem://",
+	})
+	verifyResponseOK(t, repoResp, err)
+	branchResp, err := clt.CreateBranchWithResponse(ctx, repoName, api.CreateBranchJSONRequestBody{Name: "work", Source: "main"})
+	verifyResponseOK(t, branchResp, err)
+	for _, branch := range []string{"main", "work"} {
+		resp, err := uploadObjectHelper(t, ctx, clt, "file1", strings.NewReader("content on "+branch), repoName, branch)
+		verifyResponseOK(t, resp, err)
+		commitResp, err := clt.CommitWithResponse(ctx, repoName, branch, api.CommitJSONRequestBody{Message: "file 1 commit to " + branch})
+		verifyResponseOK(t, commitResp, err)
+	}
+
+	mergeResp, err := clt.MergeIntoBranchWithResponse(ctx, repoName, "work", "main", api.MergeIntoBranchJSONRequestBody{})
+	testutil.Must(t, err)
+	if mergeResp.JSON409 == nil {
+		t.Fatalf("merge of conflicting changes returned status %d, expected %d", mergeResp.StatusCode(), http.StatusConflict)
+	}
+	if mergeResp.JSON409.Summary.Conflict == 0 {
+		t.Errorf("merge conflict response %+v reports no conflicts", mergeResp.JSON409)
+	}
+	if api.BoolValue(mergeResp.JSON409.BaseMoved) {
+		t.Errorf("merge conflict response %+v reports the destination branch moved", mergeResp.JSON409)
+	}
+}
+
+func TestController_MergeFastForward(t *testing.T) {
+	clt, _ := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	const repoName = "repo-merge-fast-forward"
+	repoResp, err := clt.CreateRepositoryWithResponse(ctx, &api.CreateRepositoryParams{}, api.CreateRepositoryJSONRequestBody{
+		DefaultBranch:    api.StringPtr("main"),
+		Name:             repoName,
+		StorageNamespace: "mem://",
+	})
+	verifyResponseOK(t, repoResp, err)
+	branchResp, err := clt.CreateBranchWithResponse(ctx, repoName, api.CreateBranchJSONRequestBody{Name: "work", Source: "main"})
+	verifyResponseOK(t, branchResp, err)
+	resp, err := uploadObjectHelper(t, ctx, clt, "file1", strings.NewReader("content"), repoName, "work")
+	verifyResponseOK(t, resp, err)
+	commitResp, err := clt.CommitWithResponse(ctx, repoName, "work", api.CommitJSONRequestBody{Message: "file 1 commit to work"})
+	verifyResponseOK(t, commitResp, err)
+
+	fastForward := true
+	mergeResp, err := clt.MergeIntoBranchWithResponse(ctx, repoName, "work", "main", api.MergeIntoBranchJSONRequestBody{
+		FastForward: &fastForward,
+	})
+	verifyResponseOK(t, mergeResp, err)
+	if !api.BoolValue(mergeResp.JSON200.FastForwarded) || mergeResp.JSON200.Reference != commitResp.JSON201.Id {
+		t.Errorf("merge result %+v, expected fast-forward to %s", mergeResp.JSON200, commitResp.JSON201.Id)
+	}
+	mainResp, err := clt.GetBranchWithResponse(ctx, repoName, "main")
+	verifyResponseOK(t, mainResp, err)
+	if mainResp.JSON200.CommitId != commitResp.JSON201.Id {
+		t.Errorf("main at %s after fast-forward, expected %s", mainResp.JSON200.CommitId, commitResp.JSON201.Id)
+	}
+
+	// diverge main from work: fast-forward is no longer possible, so a merge commit is created
+	resp, err = uploadObjectHelper(t, ctx, clt, "file2", strings.NewReader("content"), repoName, "main")
+	verifyResponseOK(t, resp, err)
+	mainCommitResp, err := clt.CommitWithResponse(ctx, repoName, "main", api.CommitJSONRequestBody{Message: "file 2 commit to main"})
+	verifyResponseOK(t, mainCommitResp, err)
+	resp, err = uploadObjectHelper(t, ctx, clt, "file3", strings.NewReader("content"), repoName, "work")
+	verifyResponseOK(t, resp, err)
+	commitResp, err = clt.CommitWithResponse(ctx, repoName, "work", api.CommitJSONRequestBody{Message: "file 3 commit to work"})
+	verifyResponseOK(t, commitResp, err)
+	mergeResp, err = clt.MergeIntoBranchWithResponse(ctx, repoName, "work", "main", api.MergeIntoBranchJSONRequestBody{
+		FastForward: &fastForward,
+	})
+	verifyResponseOK(t, mergeResp, err)
+	if api.BoolValue(mergeResp.JSON200.FastForwarded) {
+		t.Errorf("merge of diverged branches %+v reports a fast-forward", mergeResp.JSON200)
+	}
+}
+
 func TestController_MergeDiffWithParent(t *testing.T) {
 	clt, _ := setupClientWithAdmin(t, "")
 	ctx := context.Background()
@@ -1543,9 +2041,122 @@ func TestController_MergeDiffWithParent(t *testing.T) {
 	verifyResponseOK(t, diffResp, err)
 	var expectedSize = int64(len(content))
 	expectedResults := []api.Diff{
-		{Path: "file1", PathType: "object", Type: "added", SizeBytes: &expectedSize},
+		{Path: "file1", PathType: "object", Type: "added", SizeBytes: &expectedSize, Checksum: &resp.JSON201.Checksum},
 	}
 	if diff := deep.Equal(diffResp.JSON200.Results, expectedResults); diff != nil {
 		t.Fatal("Diff results not as expected:", diff)
 	}
 }
+
+func TestController_GetRepositoryUsage(t *testing.T) {
+	clt, _ := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	const repoName = "repo-usage"
+	repoResp, err := clt.CreateRepositoryWithResponse(ctx, &api.CreateRepositoryParams{}, api.CreateRepositoryJSONRequestBody{
+		DefaultBranch:    api.StringPtr("main"),
+		Name:             repoName,
+		StorageNamespace: "mem://" + repoName,
+	})
+	verifyResponseOK(t, repoResp, err)
+
+	// the mem blockstore cannot measure storage usage
+	usageResp, err := clt.GetRepositoryUsageWithResponse(ctx, repoName)
+	testutil.Must(t, err)
+	if usageResp.StatusCode() != http.StatusNotImplemented {
+		t.Errorf("GetRepositoryUsage returned status %d, expected %d", usageResp.StatusCode(), http.StatusNotImplemented)
+	}
+}
+
+func TestController_ExportRef(t *testing.T) {
+	handler, _ := setupHandler(t, "")
+	server := setupServer(t, handler)
+	clt := setupClientByEndpoint(t, server.URL, "", "")
+	cred := createDefaultAdminUser(t, clt)
+	clt = setupClientByEndpoint(t, server.URL, cred.AccessKeyID, cred.SecretAccessKey)
+	ctx := context.Background()
+
+	for _, repoName := range []string{"export-source", "export-other"} {
+		repoResp, err := clt.CreateRepositoryWithResponse(ctx, &api.CreateRepositoryParams{}, api.CreateRepositoryJSONRequestBody{
+			DefaultBranch:    api.StringPtr("main"),
+			Name:             repoName,
+			StorageNamespace: "mem://" + repoName,
+		})
+		verifyResponseOK(t, repoResp, err)
+	}
+
+	t.Run("unauthorized destination", func(t *testing.T) {
+		const userID = "reader"
+		userResp, err := clt.CreateUserWithResponse(ctx, api.CreateUserJSONRequestBody{Id: userID})
+		verifyResponseOK(t, userResp, err)
+		attachResp, err := clt.AttachPolicyToUserWithResponse(ctx, userID, "FSReadAll")
+		verifyResponseOK(t, attachResp, err)
+		credResp, err := clt.CreateCredentialsWithResponse(ctx, userID)
+		verifyResponseOK(t, credResp, err)
+		readerClt := setupClientByEndpoint(t, server.URL, credResp.JSON201.AccessKeyId, credResp.JSON201.SecretAccessKey)
+
+		resp, err := readerClt.ExportRefWithResponse(ctx, "export-source", "main", &api.ExportRefParams{}, api.ExportRefJSONRequestBody{
+			Destination: "mem://exports/",
+		})
+		testutil.Must(t, err)
+		if resp.JSON401 == nil {
+			t.Errorf("ExportRef by a reader returned status %d, expected %d", resp.StatusCode(), http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("repository destination", func(t *testing.T) {
+		for _, destination := range []string{"mem://export-other", "mem://export-other/data/", "mem://"} {
+			resp, err := clt.ExportRefWithResponse(ctx, "export-source", "main", &api.ExportRefParams{}, api.ExportRefJSONRequestBody{
+				Destination: destination,
+			})
+			testutil.Must(t, err)
+			if resp.JSON400 == nil {
+				t.Errorf("ExportRef to %s returned status %d, expected %d", destination, resp.StatusCode(), http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		resp, err := clt.ExportRefWithResponse(ctx, "export-source", "main", &api.ExportRefParams{}, api.ExportRefJSONRequestBody{
+			Destination: "mem://exports/",
+		})
+		verifyResponseOK(t, resp, err)
+	})
+}
+
+func TestController_BranchProtection(t *testing.T) {
+	clt, _ := setupClientWithAdmin(t, "")
+	ctx := context.Background()
+
+	const repoName = "repo-protected"
+	repoResp, err := clt.CreateRepositoryWithResponse(ctx, &api.CreateRepositoryParams{}, api.CreateRepositoryJSONRequestBody{
+		DefaultBranch:    api.StringPtr("main"),
+		Name:             repoName,
+		StorageNamespace: "mem://" + repoName,
+	})
+	verifyResponseOK(t, repoResp, err)
+
+	createResp, err := clt.CreateBranchProtectionRuleWithResponse(ctx, repoName, api.CreateBranchProtectionRuleJSONRequestBody{Pattern: "main"})
+	verifyResponseOK(t, createResp, err)
+	createResp, err = clt.CreateBranchProtectionRuleWithResponse(ctx, repoName, api.CreateBranchProtectionRuleJSONRequestBody{Pattern: "main"})
+	testutil.Must(t, err)
+	if createResp.StatusCode() != http.StatusConflict {
+		t.Errorf("create existing rule returned status %d, expected %d", createResp.StatusCode(), http.StatusConflict)
+	}
+	rulesResp, err := clt.GetBranchProtectionRulesWithResponse(ctx, repoName)
+	verifyResponseOK(t, rulesResp, err)
+	if diff := deep.Equal(*rulesResp.JSON200, []api.BranchProtectionRule{{Pattern: "main"}}); diff != nil {
+		t.Errorf("unexpected branch protection rules: %s", diff)
+	}
+
+	uploadResp, err := uploadObjectHelper(t, ctx, clt, "foo/bar", strings.NewReader("hello"), repoName, "main")
+	testutil.Must(t, err)
+	if uploadResp.StatusCode() != http.StatusForbidden {
+		t.Errorf("upload to protected branch returned status %d, expected %d", uploadResp.StatusCode(), http.StatusForbidden)
+	}
+
+	deleteResp, err := clt.DeleteBranchProtectionRuleWithResponse(ctx, repoName, api.DeleteBranchProtectionRuleJSONRequestBody{Pattern: "main"})
+	verifyResponseOK(t, deleteResp, err)
+	uploadResp, err = uploadObjectHelper(t, ctx, clt, "foo/bar", strings.NewReader("hello"), repoName, "main")
+	verifyResponseOK(t, uploadResp, err)
+}
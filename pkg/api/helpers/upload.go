@@ -14,8 +14,9 @@ import (
 
 // ClientUpload uploads contents as a file using client-side ("direct") access to underlying
 // storage.  It requires credentials both to lakeFS and to underlying storage, but
-// considerably reduces the load on the lakeFS server.
-func ClientUpload(ctx context.Context, client api.ClientWithResponsesInterface, repoID, branchID, filePath string, metadata map[string]string, contents io.ReadSeeker) (*api.ObjectStats, error) {
+// considerably reduces the load on the lakeFS server.  A non-empty contentType sets the
+// content-type of the object.
+func ClientUpload(ctx context.Context, client api.ClientWithResponsesInterface, repoID, branchID, filePath string, metadata map[string]string, contentType string, contents io.ReadSeeker) (*api.ObjectStats, error) {
 	resp, err := client.GetPhysicalAddressWithResponse(ctx, repoID, branchID, &api.GetPhysicalAddressParams{
 		Path: filePath,
 	})
@@ -56,6 +57,7 @@ func ClientUpload(ctx context.Context, client api.ClientWithResponsesInterface,
 			UserMetadata: &api.StagingMetadata_UserMetadata{
 				AdditionalProperties: metadata,
 			},
+			ContentType: contentTypePtr(contentType),
 		})
 		if err != nil {
 			return nil, fmt.Errorf("link object to backing store: %w", err)
@@ -70,6 +72,7 @@ func ClientUpload(ctx context.Context, client api.ClientWithResponsesInterface,
 				PathType:        "object",
 				PhysicalAddress: physicalAddress,
 				SizeBytes:       &stats.Size,
+				ContentType:     contentTypePtr(contentType),
 			}, nil
 		}
 		if resp.JSON409 == nil {
@@ -82,3 +85,10 @@ func ClientUpload(ctx context.Context, client api.ClientWithResponsesInterface,
 		}
 	}
 }
+
+func contentTypePtr(contentType string) *string {
+	if contentType == "" {
+		return nil
+	}
+	return &contentType
+}
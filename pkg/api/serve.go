@@ -20,6 +20,7 @@ import (
 	"github.com/treeverse/lakefs/pkg/cloud"
 	"github.com/treeverse/lakefs/pkg/config"
 	"github.com/treeverse/lakefs/pkg/db"
+	"github.com/treeverse/lakefs/pkg/gateway/multiparts"
 	"github.com/treeverse/lakefs/pkg/httputil"
 	"github.com/treeverse/lakefs/pkg/logging"
 	"github.com/treeverse/lakefs/pkg/stats"
@@ -47,6 +48,7 @@ func Serve(
 	collector stats.Collector,
 	cloudMetadataProvider cloud.MetadataProvider,
 	actions actionsHandler,
+	multipartsTracker multiparts.Tracker,
 	logger logging.Logger,
 	gatewayDomains []string,
 ) http.Handler {
@@ -75,6 +77,7 @@ func Serve(
 		collector,
 		cloudMetadataProvider,
 		actions,
+		multipartsTracker,
 		logger,
 	)
 	HandlerFromMuxWithBaseURL(controller, apiRouter, BaseURL)
@@ -22,6 +22,7 @@ import (
 	"github.com/treeverse/lakefs/pkg/config"
 	"github.com/treeverse/lakefs/pkg/db"
 	dbparams "github.com/treeverse/lakefs/pkg/db/params"
+	"github.com/treeverse/lakefs/pkg/gateway/multiparts"
 	"github.com/treeverse/lakefs/pkg/logging"
 	"github.com/treeverse/lakefs/pkg/stats"
 	"github.com/treeverse/lakefs/pkg/testutil"
@@ -32,10 +33,11 @@ const (
 )
 
 type dependencies struct {
-	blocks      block.Adapter
-	catalog     catalog.Interface
-	authService *auth.DBAuthService
-	collector   *nullCollector
+	blocks            block.Adapter
+	catalog           catalog.Interface
+	authService       *auth.DBAuthService
+	collector         *nullCollector
+	multipartsTracker multiparts.Tracker
 }
 
 type nullCollector struct {
@@ -104,6 +106,7 @@ func setupHandler(t testing.TB, blockstoreType string, opts ...testutil.GetDBOpt
 	})
 
 	collector := &nullCollector{}
+	multipartsTracker := multiparts.NewTracker(conn)
 
 	handler := api.Serve(
 		cfg,
@@ -115,15 +118,17 @@ func setupHandler(t testing.TB, blockstoreType string, opts ...testutil.GetDBOpt
 		collector,
 		nil,
 		actionsService,
+		multipartsTracker,
 		logging.Default(),
 		nil,
 	)
 
 	return handler, &dependencies{
-		blocks:      c.BlockAdapter,
-		authService: authService,
-		catalog:     c,
-		collector:   collector,
+		blocks:            c.BlockAdapter,
+		authService:       authService,
+		catalog:           c,
+		collector:         collector,
+		multipartsTracker: multipartsTracker,
 	}
 }
 
@@ -0,0 +1,167 @@
+// Package accounting wraps a block adapter to count the object data it transfers for each
+// repository, e.g. for multi-tenant billing.
+package accounting
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/treeverse/lakefs/pkg/block"
+	"github.com/treeverse/lakefs/pkg/logging"
+)
+
+// counters holds the byte counts of a single storage namespace, updated atomically.
+type counters struct {
+	uploaded   int64
+	downloaded int64
+}
+
+type Adapter struct {
+	inner    block.Adapter
+	mu       sync.Mutex
+	counters map[string]*counters
+}
+
+// NewAccountingAdapter returns an adapter that counts the bytes of object data uploaded and
+// downloaded through inner for each repository, and a function returning the usage of the
+// repository with a storage namespace.  Only data passing through lakeFS is counted: copies
+// within the underlying store are not.
+func NewAccountingAdapter(inner block.Adapter) (block.Adapter, func(storageNamespace string) block.Usage) {
+	a := &Adapter{
+		inner:    inner,
+		counters: make(map[string]*counters),
+	}
+	return a, a.Usage
+}
+
+// Usage implements block.Accountant.
+func (a *Adapter) Usage(storageNamespace string) block.Usage {
+	c := a.countersOf(storageNamespace)
+	return block.Usage{
+		BytesUploaded:   atomic.LoadInt64(&c.uploaded),
+		BytesDownloaded: atomic.LoadInt64(&c.downloaded),
+	}
+}
+
+func (a *Adapter) countersOf(storageNamespace string) *counters {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c, ok := a.counters[storageNamespace]
+	if !ok {
+		c = &counters{}
+		a.counters[storageNamespace] = c
+	}
+	return c
+}
+
+// reader adds the number of bytes read through it to count.
+type reader struct {
+	r     io.Reader
+	count *int64
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	atomic.AddInt64(r.count, int64(n))
+	return n, err
+}
+
+type readCloser struct {
+	reader
+	io.Closer
+}
+
+func (a *Adapter) countUpload(obj block.ObjectPointer, r io.Reader) io.Reader {
+	return &reader{r: r, count: &a.countersOf(obj.StorageNamespace).uploaded}
+}
+
+func (a *Adapter) countDownload(obj block.ObjectPointer, rc io.ReadCloser) io.ReadCloser {
+	return &readCloser{reader: reader{r: rc, count: &a.countersOf(obj.StorageNamespace).downloaded}, Closer: rc}
+}
+
+func (a *Adapter) Put(ctx context.Context, obj block.ObjectPointer, sizeBytes int64, reader io.Reader, opts block.PutOpts) error {
+	return a.inner.Put(ctx, obj, sizeBytes, a.countUpload(obj, reader), opts)
+}
+
+func (a *Adapter) Get(ctx context.Context, obj block.ObjectPointer, expectedSize int64) (io.ReadCloser, error) {
+	rc, err := a.inner.Get(ctx, obj, expectedSize)
+	if err != nil {
+		return nil, err
+	}
+	return a.countDownload(obj, rc), nil
+}
+
+func (a *Adapter) Walk(ctx context.Context, walkOpt block.WalkOpts, walkFn block.WalkFunc) error {
+	return a.inner.Walk(ctx, walkOpt, walkFn)
+}
+
+func (a *Adapter) Exists(ctx context.Context, obj block.ObjectPointer) (bool, error) {
+	return a.inner.Exists(ctx, obj)
+}
+
+func (a *Adapter) GetRange(ctx context.Context, obj block.ObjectPointer, startPosition int64, endPosition int64) (io.ReadCloser, error) {
+	rc, err := a.inner.GetRange(ctx, obj, startPosition, endPosition)
+	if err != nil {
+		return nil, err
+	}
+	return a.countDownload(obj, rc), nil
+}
+
+func (a *Adapter) GetProperties(ctx context.Context, obj block.ObjectPointer) (block.Properties, error) {
+	return a.inner.GetProperties(ctx, obj)
+}
+
+func (a *Adapter) Remove(ctx context.Context, obj block.ObjectPointer) error {
+	return a.inner.Remove(ctx, obj)
+}
+
+func (a *Adapter) Copy(ctx context.Context, sourceObj, destinationObj block.ObjectPointer) error {
+	return a.inner.Copy(ctx, sourceObj, destinationObj)
+}
+
+func (a *Adapter) CreateMultiPartUpload(ctx context.Context, obj block.ObjectPointer, r *http.Request, opts block.CreateMultiPartUploadOpts) (string, error) {
+	return a.inner.CreateMultiPartUpload(ctx, obj, r, opts)
+}
+
+func (a *Adapter) UploadPart(ctx context.Context, obj block.ObjectPointer, sizeBytes int64, reader io.Reader, uploadID string, partNumber int64) (string, error) {
+	return a.inner.UploadPart(ctx, obj, sizeBytes, a.countUpload(obj, reader), uploadID, partNumber)
+}
+
+func (a *Adapter) UploadCopyPart(ctx context.Context, sourceObj, destinationObj block.ObjectPointer, uploadID string, partNumber int64) (string, error) {
+	return a.inner.UploadCopyPart(ctx, sourceObj, destinationObj, uploadID, partNumber)
+}
+
+func (a *Adapter) UploadCopyPartRange(ctx context.Context, sourceObj, destinationObj block.ObjectPointer, uploadID string, partNumber, startPosition, endPosition int64) (string, error) {
+	return a.inner.UploadCopyPartRange(ctx, sourceObj, destinationObj, uploadID, partNumber, startPosition, endPosition)
+}
+
+func (a *Adapter) AbortMultiPartUpload(ctx context.Context, obj block.ObjectPointer, uploadID string) error {
+	return a.inner.AbortMultiPartUpload(ctx, obj, uploadID)
+}
+
+func (a *Adapter) CompleteMultiPartUpload(ctx context.Context, obj block.ObjectPointer, uploadID string, multipartList *block.MultipartUploadCompletion) (*string, int64, error) {
+	return a.inner.CompleteMultiPartUpload(ctx, obj, uploadID, multipartList)
+}
+
+func (a *Adapter) ValidateConfiguration(ctx context.Context, storageNamespace string) error {
+	return a.inner.ValidateConfiguration(ctx, storageNamespace)
+}
+
+func (a *Adapter) BlockstoreType() string {
+	return a.inner.BlockstoreType()
+}
+
+func (a *Adapter) GetStorageNamespaceInfo() block.StorageNamespaceInfo {
+	return a.inner.GetStorageNamespaceInfo()
+}
+
+func (a *Adapter) GenerateInventory(ctx context.Context, logger logging.Logger, inventoryURL string, shouldSort bool, prefixes []string) (block.Inventory, error) {
+	return a.inner.GenerateInventory(ctx, logger, inventoryURL, shouldSort, prefixes)
+}
+
+func (a *Adapter) RuntimeStats() map[string]string {
+	return a.inner.RuntimeStats()
+}
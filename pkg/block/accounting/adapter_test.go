@@ -0,0 +1,84 @@
+package accounting_test
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/treeverse/lakefs/pkg/block"
+	"github.com/treeverse/lakefs/pkg/block/accounting"
+	"github.com/treeverse/lakefs/pkg/block/mem"
+	"github.com/treeverse/lakefs/pkg/testutil"
+)
+
+func TestAccountingUsage(t *testing.T) {
+	ctx := context.Background()
+	a, usage := accounting.NewAccountingAdapter(mem.New())
+	put := func(namespace, identifier, data string) {
+		obj := block.ObjectPointer{StorageNamespace: namespace, Identifier: identifier}
+		testutil.MustDo(t, "Put "+identifier, a.Put(ctx, obj, int64(len(data)), strings.NewReader(data), block.PutOpts{}))
+	}
+	get := func(namespace, identifier string) {
+		obj := block.ObjectPointer{StorageNamespace: namespace, Identifier: identifier}
+		reader, err := a.Get(ctx, obj, 0)
+		testutil.MustDo(t, "Get "+identifier, err)
+		_, err = ioutil.ReadAll(reader)
+		testutil.MustDo(t, "read "+identifier, err)
+		_ = reader.Close()
+	}
+
+	put("mem://repo1", "one", "12345")
+	put("mem://repo1", "two", "123")
+	put("mem://repo2", "one", "1234567890")
+	get("mem://repo1", "one")
+	get("mem://repo1", "one")
+	get("mem://repo2", "one")
+	rangeReader, err := a.GetRange(ctx, block.ObjectPointer{StorageNamespace: "mem://repo2", Identifier: "one"}, 2, 5)
+	testutil.MustDo(t, "GetRange", err)
+	_, err = ioutil.ReadAll(rangeReader)
+	testutil.MustDo(t, "read range", err)
+	_ = rangeReader.Close()
+
+	expected := map[string]block.Usage{
+		"mem://repo1": {BytesUploaded: 8, BytesDownloaded: 10},
+		"mem://repo2": {BytesUploaded: 10, BytesDownloaded: 14},
+		"mem://repo3": {},
+	}
+	for namespace, want := range expected {
+		if got := usage(namespace); got != want {
+			t.Errorf("usage of %s is %+v, expected %+v", namespace, got, want)
+		}
+	}
+	if accountant, ok := a.(block.Accountant); !ok || accountant.Usage("mem://repo1") != expected["mem://repo1"] {
+		t.Error("adapter does not report usage as a block.Accountant")
+	}
+}
+
+func TestAccountingConcurrentPuts(t *testing.T) {
+	ctx := context.Background()
+	a, usage := accounting.NewAccountingAdapter(mem.New())
+	const (
+		workers = 10
+		puts    = 20
+		data    = "data"
+	)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < puts; j++ {
+				obj := block.ObjectPointer{StorageNamespace: "mem://repo", Identifier: "object"}
+				if err := a.Put(ctx, obj, int64(len(data)), strings.NewReader(data), block.PutOpts{}); err != nil {
+					t.Errorf("Put: %s", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := usage("mem://repo").BytesUploaded; got != int64(workers*puts*len(data)) {
+		t.Errorf("uploaded %d bytes, expected %d", got, workers*puts*len(data))
+	}
+}
@@ -2,12 +2,52 @@ package block
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
+// DefaultMinPartSize is the minimal size of every part of a multipart upload except the last,
+// as required by S3.
+const DefaultMinPartSize = 5 * 1024 * 1024
+
+var (
+	// ErrEntityTooSmall is returned when completing a multipart upload with a non-final part
+	// smaller than the minimal part size.
+	ErrEntityTooSmall = errors.New("entity too small")
+
+	// ErrOperationNotSupported is returned by adapters for optional operations that their
+	// underlying store cannot perform.
+	ErrOperationNotSupported = errors.New("operation not supported")
+
+	// ErrInvalidLimit is returned when paging with a non-positive page size.
+	ErrInvalidLimit = errors.New("invalid limit")
+
+	// ErrDataNotFound is returned for operations on objects that do not exist.
+	ErrDataNotFound = errors.New("not found")
+
+	// ErrUnsupportedChecksum is returned when asked for a checksum with an unknown algorithm.
+	ErrUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+
+	// ErrChecksumMismatch is returned when the contents read of an object do not match the
+	// checksum recorded when it was written.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrInvalidManifest is returned when reading a manifest object that cannot be parsed.
+	ErrInvalidManifest = errors.New("invalid manifest")
+
+	// ErrInvalidLifecycleRule is returned when setting a lifecycle rule with a non-positive
+	// maximal age.
+	ErrInvalidLifecycleRule = errors.New("invalid lifecycle rule")
+
+	// ErrObjectTruncated is returned when an object is smaller than its expected size, e.g.
+	// after a crash while writing it.
+	ErrObjectTruncated = errors.New("object truncated")
+)
+
 type MultipartUploadCompletion struct{ Part []*s3.CompletedPart }
 
 // IdentifierType is the type the ObjectPointer Identifier
@@ -74,6 +114,14 @@ type Properties struct {
 	StorageClass *string
 }
 
+// ObjectProperties describes an object as stored on the underlying block store.
+type ObjectProperties struct {
+	Size         int64
+	ETag         string
+	LastModified time.Time
+	StorageClass *string
+}
+
 // WalkFunc is called for each object visited by the Walk.
 // The id argument contains the argument to Walk as a prefix; that is, if Walk is called with "test/data/",
 // which is a prefix containing the object "test/data/a", the walk function will be called with argument "test/data/a".
@@ -106,6 +154,301 @@ type Adapter interface {
 	RuntimeStats() map[string]string
 }
 
+// Appender is implemented by adapters that can append data to an existing object.  Immutable
+// object stores such as S3, GCS and Azure Blob cannot; adapters wrapping them return
+// ErrOperationNotSupported.
+type Appender interface {
+	// Append writes the contents of reader to the end of obj, creating it if it does not exist,
+	// and returns the new size of obj.
+	Append(ctx context.Context, obj ObjectPointer, reader io.Reader) (int64, error)
+}
+
+// FilePutter is implemented by adapters that can write an object from a local file faster than
+// from a reader, e.g. for uploads buffered to disk.
+type FilePutter interface {
+	// PutFile writes obj with the contents of the file at sourcePath.
+	PutFile(ctx context.Context, obj ObjectPointer, sourcePath string, opts PutOpts) error
+}
+
+// Concatenator is implemented by adapters that can concatenate objects into a new object without
+// passing their contents through the caller, e.g. to merge many small files a pipeline produced.
+type Concatenator interface {
+	// Concat writes destIdentifier under storageNamespace with the contents of
+	// sourceIdentifiers in order, and returns its size.  It returns ErrDataNotFound without
+	// writing if any source does not exist.
+	Concat(ctx context.Context, storageNamespace, destIdentifier string, sourceIdentifiers []string) (int64, error)
+}
+
+// ProgressPutter is implemented by adapters that can report progress while writing an object,
+// e.g. for telemetry of large uploads.
+type ProgressPutter interface {
+	// PutWithProgress is Put, calling progress with the total bytes written so far as it
+	// writes, throttled as by ProgressReader.  The last call is with the size of obj.
+	PutWithProgress(ctx context.Context, obj ObjectPointer, sizeBytes int64, reader io.Reader, opts PutOpts, progress func(written int64)) error
+}
+
+// WalkPager is implemented by adapters that can list objects in bounded pages, rather than
+// visiting all of them at once as Walk does.
+type WalkPager interface {
+	// WalkPage returns the identifiers of at most limit objects under walkOpt, in sorted order
+	// starting after after.  nextAfter continues to the next page, and is empty on the last
+	// page.
+	WalkPage(ctx context.Context, walkOpt WalkOpts, after string, limit int) (identifiers []string, nextAfter string, err error)
+}
+
+// PropertiesWalker is implemented by adapters that can list objects along with their properties,
+// e.g. for listing UIs that would otherwise Stat every object listed.
+type PropertiesWalker interface {
+	// WalkWithProperties calls walkFn with the identifier and properties of each object under
+	// walkOpt.  The ETag may be missing from properties where it is costly to get.
+	WalkWithProperties(ctx context.Context, walkOpt WalkOpts, walkFn func(identifier string, props ObjectProperties) error) error
+}
+
+// SeekableGetter is implemented by adapters that can read objects at random positions, e.g. for
+// formats such as Parquet that are read starting from their footer.  Adapters of remote stores
+// would emulate seeking with ranged reads.
+type SeekableGetter interface {
+	// GetSeekable returns a reader of obj that can seek within it.
+	GetSeekable(ctx context.Context, obj ObjectPointer) (io.ReadSeekCloser, error)
+}
+
+// VerifiedGetter is implemented by adapters that can verify the integrity of objects as they are
+// read.  Integrity is only known once the whole object is read, so unlike Get the reader may
+// fail after returning all of the data.
+type VerifiedGetter interface {
+	// GetVerified returns a reader of obj that fails with ErrChecksumMismatch at EOF if the
+	// contents read do not match the ETag recorded when obj was written.
+	GetVerified(ctx context.Context, obj ObjectPointer) (io.ReadCloser, error)
+}
+
+// Stater is implemented by adapters that can report the properties of an object, including its
+// size.
+type Stater interface {
+	Stat(ctx context.Context, obj ObjectPointer) (ObjectProperties, error)
+}
+
+// Expirer is implemented by adapters that can store scratch objects that expire, e.g. for
+// intermediate data of a computation.
+type Expirer interface {
+	// PutTemp is Put of an object that expires after ttl.  Overwriting it with Put makes it
+	// permanent.
+	PutTemp(ctx context.Context, obj ObjectPointer, sizeBytes int64, reader io.Reader, ttl time.Duration) error
+	// PurgeExpired removes all expired objects under storageNamespace, and returns the number
+	// of objects removed.  Expired objects remain readable until purged.
+	PurgeExpired(ctx context.Context, storageNamespace string) (int, error)
+}
+
+// BatchExistsChecker is implemented by adapters that can check whether many objects exist in a
+// single call, e.g. for garbage collection to check the objects it is about to remove.
+type BatchExistsChecker interface {
+	// ExistsBatch returns whether each of identifiers exists under storageNamespace.  The
+	// returned map has an entry for every identifier.
+	ExistsBatch(ctx context.Context, storageNamespace string, identifiers []string) (map[string]bool, error)
+}
+
+// PropertiesGetter is implemented by adapters that can read an object along with its properties
+// in one call, e.g. to set response headers before streaming the object.
+type PropertiesGetter interface {
+	// GetWithProperties returns a reader of obj and the properties of the object read.
+	GetWithProperties(ctx context.Context, obj ObjectPointer) (io.ReadCloser, ObjectProperties, error)
+}
+
+// ConditionalRemover is implemented by adapters that can remove an object only if it did not
+// change, e.g. to garbage collect an object without racing a concurrent write to it.
+type ConditionalRemover interface {
+	// RemoveIf removes obj if its ETag is expectedETag.  It returns false without an error
+	// if obj has a different ETag.
+	RemoveIf(ctx context.Context, obj ObjectPointer, expectedETag string) (removed bool, err error)
+}
+
+// Toucher is implemented by adapters that can update the last-modified time of an object
+// without rewriting it, e.g. to restart lifecycle or tiering timers that count from it.
+type Toucher interface {
+	// Touch sets the last-modified time of obj to t.  It returns ErrDataNotFound if obj does
+	// not exist.
+	Touch(ctx context.Context, obj ObjectPointer, t time.Time) error
+}
+
+// PreSignMode is the operation allowed by a pre-signed URL.
+type PreSignMode int
+
+const (
+	PreSignModeRead PreSignMode = iota
+	PreSignModeWrite
+)
+
+// PreSigner is implemented by adapters that can sign URLs granting direct access to objects on
+// their underlying store.
+type PreSigner interface {
+	// GetPreSignedURL returns a URL valid for expiry that allows mode access to obj.
+	GetPreSignedURL(ctx context.Context, obj ObjectPointer, mode PreSignMode, expiry time.Duration) (string, error)
+}
+
+// Tierer is implemented by adapters that can move existing objects between storage classes,
+// e.g. to tier cold data.
+type Tierer interface {
+	// SetStorageClass sets the storage class of obj.
+	SetStorageClass(ctx context.Context, obj ObjectPointer, class string) error
+}
+
+// LegalHolder is implemented by adapters that can place legal holds on objects, e.g. for
+// compliance.  An object under a legal hold cannot be removed or overwritten until it is
+// released.
+type LegalHolder interface {
+	// SetLegalHold places a legal hold on obj if on, or releases it otherwise.
+	SetLegalHold(ctx context.Context, obj ObjectPointer, on bool) error
+	// GetLegalHold returns whether obj is under a legal hold.
+	GetLegalHold(ctx context.Context, obj ObjectPointer) (bool, error)
+}
+
+// Usage is the object data transferred to and from a blockstore.
+type Usage struct {
+	BytesUploaded   int64
+	BytesDownloaded int64
+}
+
+// Accountant is implemented by adapters that account for the object data they transfer, e.g. to
+// bill the tenants of a multi-tenant installation.
+type Accountant interface {
+	// Usage returns the data transferred for objects under storageNamespace, the storage
+	// namespace of a repository.
+	Usage(storageNamespace string) Usage
+}
+
+// StorageSizer is implemented by adapters that can measure the storage used by a storage
+// namespace, e.g. to report the usage of each repository.
+type StorageSizer interface {
+	// StorageSize returns the number of bytes stored under storageNamespace.
+	StorageSize(ctx context.Context, storageNamespace string) (int64, error)
+}
+
+// Checksum algorithms supported by Checksummer.
+const (
+	ChecksumMD5    = "md5"
+	ChecksumSHA1   = "sha1"
+	ChecksumSHA256 = "sha256"
+)
+
+// Checksummer is implemented by adapters that can compute checksums of objects in algorithms
+// other than that of their ETags, e.g. for content-addressable pipelines expecting SHA-256.
+type Checksummer interface {
+	// Checksums returns the hex encoded checksum of obj in each of algorithms, keyed by
+	// algorithm.  It returns ErrUnsupportedChecksum for unknown algorithms.
+	Checksums(ctx context.Context, obj ObjectPointer, algorithms []string) (map[string]string, error)
+}
+
+// Trasher is implemented by adapters that can soft delete objects into a trash, from which they
+// can be restored until the trash is emptied, e.g. to undo accidental deletes.
+type Trasher interface {
+	// RestoreFromTrash restores the last removed version of obj.  It returns ErrDataNotFound
+	// if obj is not in the trash.
+	RestoreFromTrash(ctx context.Context, obj ObjectPointer) error
+	// EmptyTrash removes the objects under storageNamespace that were removed at least
+	// olderThan ago from the trash, and returns the number of objects it removed.
+	EmptyTrash(ctx context.Context, storageNamespace string, olderThan time.Duration) (int, error)
+}
+
+// RecoveredUpload is an in-progress multipart upload found in storage.
+type RecoveredUpload struct {
+	UploadID string
+	// Parts are the uploaded parts, ordered by part number.
+	Parts []RecoveredPart
+}
+
+// RecoveredPart is an uploaded part of a RecoveredUpload.
+type RecoveredPart struct {
+	PartNumber int64
+	SizeBytes  int64
+}
+
+// UploadRecoverer is implemented by adapters that can find in-progress multipart uploads in
+// their storage, e.g. to rehydrate a persistent upload ID translator after a restart.
+type UploadRecoverer interface {
+	// RecoverUploads returns the multipart uploads in progress under storageNamespace,
+	// ordered by upload ID.
+	RecoverUploads(ctx context.Context, storageNamespace string) ([]RecoveredUpload, error)
+}
+
+// MultipartUploadInfo summarizes an in-progress multipart upload found in storage.
+type MultipartUploadInfo struct {
+	UploadID string
+	// Parts is the number of parts uploaded so far.
+	Parts int
+	// SizeBytes is the total size of the parts uploaded so far.
+	SizeBytes int64
+	// Initiated is the earliest modified time of the uploaded parts, approximating when the
+	// upload was created.
+	Initiated time.Time
+}
+
+// MultipartUploadLister is implemented by adapters that can summarize the in-progress
+// multipart uploads in their storage.
+type MultipartUploadLister interface {
+	// ListMultipartUploads returns the multipart uploads in progress under storageNamespace
+	// that have uploaded parts, ordered by upload ID.
+	ListMultipartUploads(ctx context.Context, storageNamespace string) ([]MultipartUploadInfo, error)
+}
+
+// PrefixCopier is implemented by adapters that can copy all objects under a prefix.
+type PrefixCopier interface {
+	// CopyPrefix copies every object under sourcePrefix of storageNamespace to the same path
+	// relative to destinationPrefix, and returns the number of objects copied.
+	CopyPrefix(ctx context.Context, storageNamespace, sourcePrefix, destinationPrefix string) (int, error)
+}
+
+// ManifestEntry is an object listed by a manifest.
+type ManifestEntry struct {
+	PhysicalAddress string `json:"physical_address"`
+	// SizeBytes is the size of the object, or -1 if the manifest does not list it.
+	SizeBytes int64  `json:"size_bytes"`
+	ETag      string `json:"etag,omitempty"`
+}
+
+// ManifestReader is implemented by adapters that can read manifest objects, listing other
+// objects e.g. for ingesting or exporting them.  Each line of a manifest is either a JSON
+// ManifestEntry, or a physical address optionally followed by a comma, size, comma and ETag,
+// as in symlink manifests that list only addresses.  Empty lines are skipped.
+type ManifestReader interface {
+	// GetManifest returns the entries of the manifest stored at obj, failing with
+	// ErrInvalidManifest and the line number of the first line that cannot be parsed.
+	GetManifest(ctx context.Context, obj ObjectPointer) ([]ManifestEntry, error)
+}
+
+// LifecycleRule expires the objects under Prefix once they were last modified more than MaxAge
+// ago.
+type LifecycleRule struct {
+	Prefix string
+	MaxAge time.Duration
+}
+
+// LifecycleManager is implemented by adapters that can expire objects according to lifecycle
+// rules, e.g. for automated retention.
+type LifecycleManager interface {
+	// SetLifecycle replaces the lifecycle rules of storageNamespace with rules.  No rules
+	// removes its lifecycle.
+	SetLifecycle(ctx context.Context, storageNamespace string, rules []LifecycleRule) error
+	// ApplyLifecycle removes the objects under storageNamespace that exceed the maximal age of
+	// their rule, and returns the number of objects removed.  An object under several rules
+	// follows the one with the longest prefix.
+	ApplyLifecycle(ctx context.Context, storageNamespace string) (int, error)
+}
+
+// TruncationRepairer is implemented by adapters that can detect objects truncated in their
+// underlying store, e.g. to recover after a crash.
+type TruncationRepairer interface {
+	// RepairTruncated checks that obj is not smaller than expectedSize, its size when it was
+	// committed.  A truncated object is either removed so that it can be fetched again, or
+	// reported with ErrObjectTruncated, depending on the adapter.
+	RepairTruncated(ctx context.Context, obj ObjectPointer, expectedSize int64) error
+}
+
+// Validator is implemented by adapters that can check their underlying store is reachable and
+// usable before serving requests.
+type Validator interface {
+	// Validate returns an error describing why the underlying store cannot be used, or nil.
+	Validate() error
+}
+
 type UploadIDTranslator interface {
 	SetUploadID(uploadID string) string
 	TranslateUploadID(simulationID string) string
@@ -31,7 +31,22 @@ var (
 	ErrAuthMethodNotSupported = errors.New("authentication method not supported")
 )
 
+// BuildBlockAdapter returns the adapter configured by c, after validating it can use its
+// underlying store if it is a block.Validator.
 func BuildBlockAdapter(ctx context.Context, c params.AdapterConfig) (block.Adapter, error) {
+	adapter, err := buildBlockAdapter(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := adapter.(block.Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("validate %s blockstore adapter: %w", adapter.BlockstoreType(), err)
+		}
+	}
+	return adapter, nil
+}
+
+func buildBlockAdapter(ctx context.Context, c params.AdapterConfig) (block.Adapter, error) {
 	blockstore := c.GetBlockstoreType()
 	logging.Default().
 		WithField("type", blockstore).
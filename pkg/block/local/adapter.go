@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -15,6 +16,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/google/uuid"
@@ -24,17 +28,55 @@ import (
 
 const BlockstoreType = "local"
 
+// Objects are written to temporary files under tempDir, which then replace them, so that readers
+// never see partially written objects and readers that opened an object earlier keep reading
+// the contents they opened.
+const tempDir = ".tmp"
+
 type Adapter struct {
 	path               string
 	uploadIDTranslator block.UploadIDTranslator
 	removeEmptyDir     bool
+	checkFreeSpace     bool
+	freeSpaceMargin    int64
+	minPartSize        int64
+	dedup              bool
+	newHash            func() hash.Hash
+	pathLayout         PathLayout
+	immutable          bool
+	retention          time.Duration
+	locks              *stripedLocks
+	completeLeases     *leases
+	failOnConcurrent   bool
+	tryReflink         bool
+	softDelete         bool
+	verifyOnRead       bool
+	maxObjectSize      int64
+	removeTruncated    bool
 }
 
 var (
+	ErrPathNotExist          = errors.New("path provided does not exist")
+	ErrPathNotDirectory      = errors.New("path provided is not a directory")
 	ErrPathNotWritable       = errors.New("path provided is not writable")
 	ErrInventoryNotSupported = errors.New("inventory feature not implemented for local storage adapter")
 	ErrInvalidUploadIDFormat = errors.New("invalid upload id format")
 	ErrBadPath               = errors.New("bad path traversal blocked")
+	ErrInsufficientStorage   = errors.New("insufficient storage")
+	// ErrIdentifierIsDirectory is returned when writing an object whose identifier is the
+	// directory of other objects.  Objects are stored as files at their identifiers, so once
+	// "a/b" is stored "a" cannot be, and vice versa.
+	ErrIdentifierIsDirectory = errors.New("identifier is a directory")
+	ErrInvalidChunkSize      = errors.New("invalid chunk size")
+	// ErrObjectTooLarge is returned when writing an object or part larger than the configured
+	// maximal object size.
+	ErrObjectTooLarge = errors.New("object too large")
+	// ErrPrefixOverlap is returned when copying a prefix to a destination under it, which
+	// would copy the copies.
+	ErrPrefixOverlap = errors.New("destination prefix is under source prefix")
+	// ErrReservedIdentifier is returned for identifiers with a path element ending in a
+	// sidecar suffix such as ".etag", which the adapter keeps for its own use.
+	ErrReservedIdentifier = errors.New("identifier ends with a reserved sidecar suffix")
 )
 
 func WithTranslator(t block.UploadIDTranslator) func(a *Adapter) {
@@ -49,20 +91,122 @@ func WithRemoveEmptyDir(b bool) func(a *Adapter) {
 	}
 }
 
+// WithFreeSpaceCheck makes Put verify that the storage volume has room for objects of known
+// size, keeping at least marginBytes free after the write.
+func WithFreeSpaceCheck(marginBytes int64) func(a *Adapter) {
+	return func(a *Adapter) {
+		a.checkFreeSpace = true
+		a.freeSpaceMargin = marginBytes
+	}
+}
+
+// WithMinPartSize sets the minimal size of all parts but the last when completing a multipart
+// upload.
+func WithMinPartSize(size int64) func(a *Adapter) {
+	return func(a *Adapter) {
+		a.minPartSize = size
+	}
+}
+
+// WithDedup stores object contents by their SHA-256 digest, so that identical contents are
+// stored once and shared between all objects holding them.
+func WithDedup() func(a *Adapter) {
+	return func(a *Adapter) {
+		a.dedup = true
+	}
+}
+
+// WithHash sets the hash function used to compute object ETags.  The default, MD5, computes
+// the same ETags as S3.
+func WithHash(newHash func() hash.Hash) func(a *Adapter) {
+	return func(a *Adapter) {
+		a.newHash = newHash
+	}
+}
+
+// WithPathLayout sets how object keys map to paths on disk.  The default is PathLayoutFlat.
+func WithPathLayout(layout PathLayout) func(a *Adapter) {
+	return func(a *Adapter) {
+		a.pathLayout = layout
+	}
+}
+
+// WithImmutable makes objects write-once: writes fail with ErrObjectExists rather than replace
+// an existing object, and removals fail with ErrRetentionNotExpired until retention has passed
+// since the object was created.
+func WithImmutable(retention time.Duration) func(a *Adapter) {
+	return func(a *Adapter) {
+		a.immutable = true
+		a.retention = retention
+	}
+}
+
+// WithFailOnConcurrentComplete makes CompleteMultiPartUpload fail with ErrUploadInProgress
+// while another upload to the same object is completing, rather than wait for it.
+func WithFailOnConcurrentComplete() func(a *Adapter) {
+	return func(a *Adapter) {
+		a.failOnConcurrent = true
+	}
+}
+
+// WithReflink makes Copy and CompleteMultiPartUpload try to share data extents with their
+// sources instead of copying bytes, falling back to copying where the filesystem does not
+// support it.
+func WithReflink() func(a *Adapter) {
+	return func(a *Adapter) {
+		a.tryReflink = true
+	}
+}
+
+// WithSoftDelete makes Remove and RemoveIf move objects to a trash in their storage namespace,
+// from which RestoreFromTrash can restore them until EmptyTrash removes them.
+func WithSoftDelete() func(a *Adapter) {
+	return func(a *Adapter) {
+		a.softDelete = true
+	}
+}
+
+// WithVerifyOnRead makes GetVerified hash objects as they are read and compare them to their
+// recorded ETags.  Get is unaffected.
+func WithVerifyOnRead() func(a *Adapter) {
+	return func(a *Adapter) {
+		a.verifyOnRead = true
+	}
+}
+
+// WithMaxObjectSize makes Put, PutFile and UploadPart reject objects and parts larger than
+// sizeBytes.  Objects of unknown size are written until they grow past the limit, then removed.
+func WithMaxObjectSize(sizeBytes int64) func(a *Adapter) {
+	return func(a *Adapter) {
+		a.maxObjectSize = sizeBytes
+	}
+}
+
+// WithRemoveTruncated makes RepairTruncated remove truncated objects rather than report them.
+func WithRemoveTruncated() func(a *Adapter) {
+	return func(a *Adapter) {
+		a.removeTruncated = true
+	}
+}
+
 func NewAdapter(path string, opts ...func(a *Adapter)) (*Adapter, error) {
 	// Clean() the path so that misconfiguration does not allow path traversal.
 	path = filepath.Clean(path)
-	err := os.MkdirAll(path, 0700)
-	if err != nil {
-		return nil, err
-	}
-	if !isDirectoryWritable(path) {
-		return nil, ErrPathNotWritable
+	// Create a missing path; Validate reports an existing path that cannot be used.
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(path, 0700); err != nil {
+			return nil, err
+		}
 	}
 	adapter := &Adapter{
 		path:               path,
 		uploadIDTranslator: &block.NoOpTranslator{},
 		removeEmptyDir:     true,
+		minPartSize:        block.DefaultMinPartSize,
+		newHash:            md5.New,
+		pathLayout:         PathLayoutFlat,
+		locks:              &stripedLocks{},
+		completeLeases:     newLeases(),
 	}
 	for _, opt := range opts {
 		opt(adapter)
@@ -70,6 +214,22 @@ func NewAdapter(path string, opts ...func(a *Adapter)) (*Adapter, error) {
 	return adapter, nil
 }
 
+// Validate checks that the adapter path is an existing writable directory.
+func (l *Adapter) Validate() error {
+	info, err := os.Stat(l.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("%s: %w", l.path, ErrPathNotExist)
+	case err != nil:
+		return fmt.Errorf("%s: %w", l.path, err)
+	case !info.IsDir():
+		return fmt.Errorf("%s: %w", l.path, ErrPathNotDirectory)
+	case !isDirectoryWritable(l.path):
+		return fmt.Errorf("%s: %w", l.path, ErrPathNotWritable)
+	}
+	return nil
+}
+
 func resolveNamespace(obj block.ObjectPointer) (block.QualifiedKey, error) {
 	qualifiedKey, err := block.ResolveNamespace(obj.StorageNamespace, obj.Identifier, obj.IdentifierType)
 	if err != nil {
@@ -95,58 +255,324 @@ func (l *Adapter) getPath(identifier block.ObjectPointer) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	p := path.Join(l.path, obj.StorageNamespace, obj.Key)
+	if isReservedKey(obj.Key) {
+		return "", fmt.Errorf("%s: %w", obj.Key, ErrReservedIdentifier)
+	}
+	p := path.Join(l.path, obj.StorageNamespace, l.layoutKey(obj.Key))
 	if err = l.verifyPath(p); err != nil {
 		return "", err
 	}
 	return p, nil
 }
 
-// maybeMkdir verifies path is allowed and runs f(path), but if f fails due to file-not-found
-// MkdirAll's its dir and then runs it again.
-func (l *Adapter) maybeMkdir(path string, f func(p string) (*os.File, error)) (*os.File, error) {
-	if err := l.verifyPath(path); err != nil {
+// createTemp creates a new temporary file to be written and then moved into place by
+// replaceFile.
+func (l *Adapter) createTemp() (*os.File, error) {
+	dir := filepath.Join(l.path, tempDir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
 		return nil, err
 	}
-	ret, err := f(path)
-	if !errors.Is(err, os.ErrNotExist) {
-		return ret, err
+	return ioutil.TempFile(dir, "write-")
+}
+
+// closeAndReplace closes the temporary file f and moves it to p.
+func (l *Adapter) closeAndReplace(f *os.File, p string) error {
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return l.replaceFile(f.Name(), p)
+}
+
+// replaceFile moves the temporary file at tempPath to p, creating its directory if needed and
+// replacing any file at p.  The temporary file is removed if it cannot be moved.
+func (l *Adapter) replaceFile(tempPath, p string) error {
+	err := l.verifyPath(p)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(filepath.Clean(p)), 0750)
 	}
-	d := filepath.Dir(filepath.Clean(path))
-	if err = os.MkdirAll(d, 0750); err != nil {
-		return nil, err
+	if err == nil {
+		err = os.Rename(tempPath, p)
 	}
-	return f(path)
+	if err != nil {
+		_ = os.Remove(tempPath)
+	}
+	return err
 }
 
 func (l *Adapter) Path() string {
 	return l.path
 }
 
-func (l *Adapter) Put(_ context.Context, obj block.ObjectPointer, _ int64, reader io.Reader, _ block.PutOpts) error {
+// verifyFreeSpace returns ErrInsufficientStorage if writing sizeBytes would leave less than the
+// configured margin free on the storage volume.  Unknown (negative) sizes are not checked.
+func (l *Adapter) verifyFreeSpace(sizeBytes int64) error {
+	if !l.checkFreeSpace || sizeBytes < 0 {
+		return nil
+	}
+	available, err := freeSpace(l.path)
+	if err != nil {
+		return err
+	}
+	required := uint64(sizeBytes) + uint64(l.freeSpaceMargin)
+	if available < required {
+		return fmt.Errorf("%w: need %d bytes, %d available", ErrInsufficientStorage, required, available)
+	}
+	return nil
+}
+
+// verifyWritable returns an error if no object can be written at p: ErrIdentifierIsDirectory if
+// p is a directory, ErrObjectExists if the adapter is immutable and an object is stored at p, or
+// ErrLegalHold if the object stored at p is under a legal hold.
+func (l *Adapter) verifyWritable(p string) error {
+	info, err := os.Stat(p)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	case info.IsDir():
+		return ErrIdentifierIsDirectory
+	case l.immutable:
+		return ErrObjectExists
+	}
+	return verifyNotHeld(p)
+}
+
+func (l *Adapter) Put(ctx context.Context, obj block.ObjectPointer, sizeBytes int64, reader io.Reader, opts block.PutOpts) (err error) {
+	defer wrapError(&err, "put", obj.Identifier)
 	p, err := l.getPath(obj)
 	if err != nil {
 		return err
 	}
-	p = filepath.Clean(p)
-	f, err := l.maybeMkdir(p, os.Create)
+	defer l.locks.lock(p)()
+	return l.put(ctx, p, sizeBytes, reader, opts)
+}
+
+// put writes the object at p.  The caller holds the lock of p.
+func (l *Adapter) put(ctx context.Context, p string, sizeBytes int64, reader io.Reader, opts block.PutOpts) error {
+	if err := l.verifyWritable(p); err != nil {
+		return err
+	}
+	reader, err := l.limitObjectSize(sizeBytes, newDeadlineReader(ctx, reader))
+	if err != nil {
+		return err
+	}
+	if l.dedup {
+		if _, err := l.putBlob(p, sizeBytes, reader); err != nil {
+			return err
+		}
+	} else {
+		hashRead := newHashReader(reader, l.newHash())
+		if err := l.writeFile(p, sizeBytes, hashRead); err != nil {
+			return err
+		}
+		if err := writeSidecar(p, etagSidecarSuffix, hashRead.HexSum()); err != nil {
+			return err
+		}
+	}
+	return l.finishPut(p, opts)
+}
+
+// finishPut records the properties of the object just written at p, replacing those of a
+// previous object at p.  Sidecars of a previous object are ignored once older than the object,
+// but objects linked to a blob keep the blob file times, so they must be replaced explicitly.
+func (l *Adapter) finishPut(p string, opts block.PutOpts) error {
+	if err := writeStorageClass(p, opts.StorageClass); err != nil {
+		return err
+	}
+	if err := clearExpiry(p); err != nil {
+		return err
+	}
+	return l.recordCreation(p)
+}
+
+// PutFile implements block.FilePutter.  It copies the file with copy_file_range, which copies
+// within the kernel and may share extents when the file is on the same filesystem, falling back
+// to reading and writing.  As the copy is not read, the ETag of the object is computed when first
+// needed.  Deduplicating adapters hash the contents to find their blob, so they read them.
+func (l *Adapter) PutFile(ctx context.Context, obj block.ObjectPointer, sourcePath string, opts block.PutOpts) (err error) {
+	defer wrapError(&err, "put file", obj.Identifier)
+	src, err := os.Open(filepath.Clean(sourcePath))
 	if err != nil {
 		return err
 	}
 	defer func() {
-		_ = f.Close()
+		_ = src.Close()
 	}()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	p, err := l.getPath(obj)
+	if err != nil {
+		return err
+	}
+	p = filepath.Clean(p)
+	defer l.locks.lock(p)()
+	if l.dedup {
+		return l.put(ctx, p, info.Size(), src, opts)
+	}
+	if err = l.verifyWritable(p); err != nil {
+		return err
+	}
+	if err = l.verifyObjectSize(info.Size()); err != nil {
+		return err
+	}
+	if err = l.verifyFreeSpace(info.Size()); err != nil {
+		return err
+	}
+	dst, err := l.createTemp()
+	if err != nil {
+		return err
+	}
+	_, err = appendFile(dst, src, 0)
+	closeErr := dst.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dst.Name())
+		return err
+	}
+	if err = l.replaceFile(dst.Name(), p); err != nil {
+		return err
+	}
+	if err = os.Remove(p + etagSidecarSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return l.finishPut(p, opts)
+}
+
+// PutWithProgress implements block.ProgressPutter.
+func (l *Adapter) PutWithProgress(ctx context.Context, obj block.ObjectPointer, sizeBytes int64, reader io.Reader, opts block.PutOpts, progress func(written int64)) error {
+	progressReader := block.NewProgressReader(reader, progress)
+	if err := l.Put(ctx, obj, sizeBytes, progressReader, opts); err != nil {
+		return err
+	}
+	// report the end of an object read without reaching EOF
+	progressReader.Flush()
+	return nil
+}
+
+// writeFile writes the contents of reader to the file at p, creating its directory if needed.
+// If reading fails, including when it grows past the maximal object size, p is left unchanged.
+func (l *Adapter) writeFile(p string, sizeBytes int64, reader io.Reader) error {
+	tempPath, err := l.writeTemp(sizeBytes, reader)
+	if err != nil {
+		return err
+	}
+	return l.replaceFile(tempPath, p)
+}
+
+// writeTemp writes the contents of reader to a new temporary file and returns its path.  If
+// reading fails the file is removed.
+func (l *Adapter) writeTemp(sizeBytes int64, reader io.Reader) (string, error) {
+	if err := l.verifyFreeSpace(sizeBytes); err != nil {
+		return "", err
+	}
+	f, err := l.createTemp()
+	if err != nil {
+		return "", err
+	}
 	_, err = io.Copy(f, reader)
-	return err
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// Append implements block.Appender.
+func (l *Adapter) Append(_ context.Context, obj block.ObjectPointer, reader io.Reader) (_ int64, err error) {
+	defer wrapError(&err, "append", obj.Identifier)
+	p, err := l.getPath(obj)
+	if err != nil {
+		return 0, err
+	}
+	p = filepath.Clean(p)
+	defer l.locks.lock(p)()
+	if err = l.verifyWritable(p); err != nil {
+		return 0, err
+	}
+	if l.dedup {
+		size, err := l.appendBlob(p, reader)
+		if err != nil {
+			return 0, err
+		}
+		return size, l.recordCreation(p)
+	}
+	size, err := l.appendFile(p, reader)
+	if err != nil {
+		return 0, err
+	}
+	// the cached ETag no longer matches the contents
+	if err = removeSidecars(p); err != nil {
+		return 0, err
+	}
+	if err = l.recordCreation(p); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// appendFile replaces the file at p with its current contents followed by the contents of
+// reader, and returns its new size.  The current contents are copied sharing extents when
+// possible.
+func (l *Adapter) appendFile(p string, reader io.Reader) (int64, error) {
+	f, err := l.createTemp()
+	if err != nil {
+		return 0, err
+	}
+	size, err := copyCurrent(f, p)
+	if err == nil {
+		_, err = f.Seek(size, io.SeekStart)
+	}
+	if err == nil {
+		var n int64
+		n, err = io.Copy(f, reader)
+		size += n
+	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return 0, err
+	}
+	return size, l.replaceFile(f.Name(), p)
 }
 
-func (l *Adapter) Remove(_ context.Context, obj block.ObjectPointer) error {
+// copyCurrent copies the file at p, if any, to the start of dst, and returns its size.
+func copyCurrent(dst *os.File, p string) (int64, error) {
+	src, err := os.Open(filepath.Clean(p))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	return appendFile(dst, src, 0)
+}
+
+func (l *Adapter) Remove(_ context.Context, obj block.ObjectPointer) (err error) {
+	defer wrapError(&err, "remove", obj.Identifier)
 	p, err := l.getPath(obj)
 	if err != nil {
 		return err
 	}
 	p = filepath.Clean(p)
-	err = os.Remove(p)
+	unlock := l.locks.lock(p)
+	err = l.deleteFile(p, obj)
+	unlock()
 	if err != nil {
 		return err
 	}
@@ -157,6 +583,176 @@ func (l *Adapter) Remove(_ context.Context, obj block.ObjectPointer) error {
 	return nil
 }
 
+// RemoveIf implements block.ConditionalRemover.
+func (l *Adapter) RemoveIf(_ context.Context, obj block.ObjectPointer, expectedETag string) (removed bool, err error) {
+	defer wrapError(&err, "remove if", obj.Identifier)
+	p, err := l.getPath(obj)
+	if err != nil {
+		return false, err
+	}
+	p = filepath.Clean(p)
+	unlock := l.locks.lock(p)
+	etag, err := l.fileETag(p)
+	if err == nil && etag == strings.Trim(expectedETag, `"`) {
+		err = l.deleteFile(p, obj)
+		removed = err == nil
+	}
+	unlock()
+	if err != nil {
+		return false, err
+	}
+	if removed && l.removeEmptyDir {
+		removeEmptyDirUntil(filepath.Dir(p), l.path)
+	}
+	return removed, nil
+}
+
+// Touch implements block.Toucher.  Objects sharing their contents under WithDedup also share
+// their file times, so it is not supported there.
+func (l *Adapter) Touch(_ context.Context, obj block.ObjectPointer, t time.Time) (err error) {
+	defer wrapError(&err, "touch", obj.Identifier)
+	if l.dedup {
+		return fmt.Errorf("%w: objects share last-modified times with deduplication", block.ErrOperationNotSupported)
+	}
+	p, err := l.getPath(obj)
+	if err != nil {
+		return err
+	}
+	p = filepath.Clean(p)
+	defer l.locks.lock(p)()
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	// Sidecars older than their object are ignored: keep valid sidecars as new as the object,
+	// and remove ignored ones rather than revive them.
+	for _, suffix := range sidecarSuffixes {
+		sidecarInfo, err := os.Stat(p + suffix)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			continue
+		case err != nil:
+			return err
+		case sidecarInfo.ModTime().Before(info.ModTime()):
+			err = os.Remove(p + suffix)
+		default:
+			err = os.Chtimes(p+suffix, t, t)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return os.Chtimes(p, t, t)
+}
+
+// removeFile removes the object stored at p along with its sidecars and its blob if unused.
+func (l *Adapter) removeFile(p string) error {
+	if err := verifyNotHeld(p); err != nil {
+		return err
+	}
+	if err := l.verifyRetention(p); err != nil {
+		return err
+	}
+	return l.dropFile(p)
+}
+
+// dropFile removes the object stored at p along with its sidecars and its blob if unused,
+// regardless of holds and retention.
+func (l *Adapter) dropFile(p string) error {
+	digest, err := readBlobDigest(p)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(p); err != nil {
+		return err
+	}
+	if err = removeSidecars(p); err != nil {
+		return err
+	}
+	if digest != "" {
+		return l.releaseBlob(digest)
+	}
+	return nil
+}
+
+// RemovePrefix removes all objects whose key starts with opts.Prefix in opts.StorageNamespace,
+// e.g. to clean up after deleting a repository, and returns the number of objects removed.
+func (l *Adapter) RemovePrefix(_ context.Context, opts block.WalkOpts) (_ int, err error) {
+	defer wrapError(&err, "remove prefix", opts.Prefix)
+	qualifiedPrefix, err := block.ResolveNamespacePrefix(opts.StorageNamespace, opts.Prefix)
+	if err != nil {
+		return 0, err
+	}
+	if qualifiedPrefix.StorageType != block.StorageTypeLocal {
+		return 0, block.ErrInvalidNamespace
+	}
+	namespacePath := path.Join(l.path, qualifiedPrefix.StorageNamespace)
+	if namespacePath == l.path {
+		return 0, fmt.Errorf("%w: cannot remove storage root", ErrBadPath)
+	}
+	if err := l.verifyPath(path.Join(namespacePath, qualifiedPrefix.Prefix)); err != nil {
+		return 0, err
+	}
+	walkRoot := namespacePath
+	if i := strings.LastIndex(qualifiedPrefix.Prefix, "/"); i >= 0 && l.pathLayout != PathLayoutSharded {
+		walkRoot = path.Join(namespacePath, qualifiedPrefix.Prefix[:i])
+	}
+	prefixPath := namespacePath + "/" + qualifiedPrefix.Prefix
+
+	var files, dirs []string
+	err = filepath.Walk(walkRoot, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if l.pathLayout == PathLayoutSharded {
+			// keys with the prefix are spread over all shards
+			key, ok := l.keyOf(strings.TrimPrefix(p, namespacePath+"/"))
+			switch {
+			case info.IsDir():
+				dirs = append(dirs, p)
+			case ok && !isSidecar(p) && strings.HasPrefix(key, qualifiedPrefix.Prefix):
+				files = append(files, p)
+			}
+			return nil
+		}
+		if !strings.HasPrefix(p, prefixPath) && !strings.HasPrefix(prefixPath, p+"/") {
+			if info.IsDir() && p != walkRoot {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case info.IsDir():
+			dirs = append(dirs, p)
+		case !isSidecar(p) && strings.HasPrefix(p, prefixPath):
+			files = append(files, p)
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	for i, p := range files {
+		unlock := l.locks.lock(p)
+		err := l.removeFile(p)
+		unlock()
+		if err != nil {
+			return i, err
+		}
+	}
+	if l.removeEmptyDir {
+		// deepest directories first, so that parents become empty before they are reached
+		for i := len(dirs) - 1; i >= 0; i-- {
+			_ = os.Remove(dirs[i])
+		}
+		removeEmptyDirUntil(filepath.Dir(walkRoot), l.path)
+	}
+	return len(files), nil
+}
+
 func removeEmptyDirUntil(dir string, stopAt string) {
 	if stopAt == "" {
 		return
@@ -176,11 +772,17 @@ func removeEmptyDirUntil(dir string, stopAt string) {
 	}
 }
 
-func (l *Adapter) Copy(_ context.Context, sourceObj, destinationObj block.ObjectPointer) error {
+func (l *Adapter) Copy(_ context.Context, sourceObj, destinationObj block.ObjectPointer) (err error) {
+	defer wrapError(&err, "copy", sourceObj.Identifier+" to "+destinationObj.Identifier)
 	source, err := l.getPath(sourceObj)
 	if err != nil {
 		return err
 	}
+	dest, err := l.getPath(destinationObj)
+	if err != nil {
+		return err
+	}
+	defer l.locks.lockCopy(source, dest)()
 	sourceFile, err := os.Open(filepath.Clean(source))
 	defer func() {
 		_ = sourceFile.Close()
@@ -188,22 +790,48 @@ func (l *Adapter) Copy(_ context.Context, sourceObj, destinationObj block.Object
 	if err != nil {
 		return err
 	}
-	dest, err := l.getPath(destinationObj)
-	if err != nil {
+	if err = l.verifyWritable(dest); err != nil {
 		return err
 	}
-	destinationFile, err := l.maybeMkdir(dest, os.Create)
+	if l.dedup {
+		if err = l.copyBlob(source, dest, sourceFile); err != nil {
+			return err
+		}
+		return l.finishPut(dest, block.PutOpts{})
+	}
+	destinationFile, err := l.createTemp()
 	if err != nil {
 		return err
 	}
-	defer func() {
+	if l.tryReflink && reflink(destinationFile, sourceFile) == nil {
+		if err = l.closeAndReplace(destinationFile, dest); err != nil {
+			return err
+		}
+		// the source ETag still holds for the shared contents
+		if etag, ok, err := readSidecar(source, etagSidecarSuffix); err == nil && ok {
+			if err := writeSidecar(dest, etagSidecarSuffix, etag); err != nil {
+				return err
+			}
+		}
+		return l.finishPut(dest, block.PutOpts{})
+	}
+	hashRead := newHashReader(sourceFile, l.newHash())
+	if _, err = io.Copy(destinationFile, hashRead); err != nil {
 		_ = destinationFile.Close()
-	}()
-	_, err = io.Copy(destinationFile, sourceFile)
-	return err
+		_ = os.Remove(destinationFile.Name())
+		return err
+	}
+	if err = l.closeAndReplace(destinationFile, dest); err != nil {
+		return err
+	}
+	if err = writeSidecar(dest, etagSidecarSuffix, hashRead.HexSum()); err != nil {
+		return err
+	}
+	return l.finishPut(dest, block.PutOpts{})
 }
 
-func (l *Adapter) UploadCopyPart(ctx context.Context, sourceObj, destinationObj block.ObjectPointer, uploadID string, partNumber int64) (string, error) {
+func (l *Adapter) UploadCopyPart(ctx context.Context, sourceObj, destinationObj block.ObjectPointer, uploadID string, partNumber int64) (_ string, err error) {
+	defer wrapError(&err, "upload copy part", destinationObj.Identifier)
 	if err := isValidUploadID(uploadID); err != nil {
 		return "", err
 	}
@@ -211,14 +839,14 @@ func (l *Adapter) UploadCopyPart(ctx context.Context, sourceObj, destinationObj
 	if err != nil {
 		return "", err
 	}
-	md5Read := block.NewHashingReader(r, block.HashFunctionMD5)
-	fName := uploadID + fmt.Sprintf("-%05d", partNumber)
-	err = l.Put(ctx, block.ObjectPointer{StorageNamespace: destinationObj.StorageNamespace, Identifier: fName}, -1, md5Read, block.PutOpts{})
-	etag := "\"" + hex.EncodeToString(md5Read.Md5.Sum(nil)) + "\""
-	return etag, err
+	defer func() {
+		_ = r.Close()
+	}()
+	return l.writePart(destinationObj, uploadID, partNumber, -1, r)
 }
 
-func (l *Adapter) UploadCopyPartRange(ctx context.Context, sourceObj, destinationObj block.ObjectPointer, uploadID string, partNumber, startPosition, endPosition int64) (string, error) {
+func (l *Adapter) UploadCopyPartRange(ctx context.Context, sourceObj, destinationObj block.ObjectPointer, uploadID string, partNumber, startPosition, endPosition int64) (_ string, err error) {
+	defer wrapError(&err, "upload copy part range", destinationObj.Identifier)
 	if err := isValidUploadID(uploadID); err != nil {
 		return "", err
 	}
@@ -226,41 +854,113 @@ func (l *Adapter) UploadCopyPartRange(ctx context.Context, sourceObj, destinatio
 	if err != nil {
 		return "", err
 	}
-	md5Read := block.NewHashingReader(r, block.HashFunctionMD5)
-	fName := uploadID + fmt.Sprintf("-%05d", partNumber)
-	err = l.Put(ctx, block.ObjectPointer{StorageNamespace: destinationObj.StorageNamespace, Identifier: fName}, -1, md5Read, block.PutOpts{})
-	etag := "\"" + hex.EncodeToString(md5Read.Md5.Sum(nil)) + "\""
-	return etag, err
+	defer func() {
+		_ = r.Close()
+	}()
+	return l.writePart(destinationObj, uploadID, partNumber, -1, r)
 }
 
 func (l *Adapter) Get(_ context.Context, obj block.ObjectPointer, _ int64) (reader io.ReadCloser, err error) {
+	defer wrapError(&err, "get", obj.Identifier)
 	p, err := l.getPath(obj)
 	if err != nil {
 		return nil, err
 	}
-	f, err := os.OpenFile(filepath.Clean(p), os.O_RDONLY, 0600)
+	f, err := l.open(p)
+	if err != nil {
+		return nil, err
+	}
+	return newFileReadCloser(f, f), nil
+}
+
+// open opens the object at p for reading.  Writers replace objects rather than modify them, so
+// it only locks p until the file is open: the file keeps the contents it had when opened.
+func (l *Adapter) open(p string) (*os.File, error) {
+	defer l.locks.rlock(p)()
+	return os.OpenFile(filepath.Clean(p), os.O_RDONLY, 0600)
+}
+
+// GetSeekable implements block.SeekableGetter.  Like Get, it reads the contents of the object
+// when it was opened.
+func (l *Adapter) GetSeekable(_ context.Context, obj block.ObjectPointer) (_ io.ReadSeekCloser, err error) {
+	defer wrapError(&err, "get seekable", obj.Identifier)
+	p, err := l.getPath(obj)
 	if err != nil {
 		return nil, err
 	}
-	return f, nil
+	f, err := l.open(p)
+	if err != nil {
+		return nil, err
+	}
+	return &fileReadSeekCloser{fileReadCloser: newFileReadCloser(f, f), Seeker: f}, nil
+}
+
+// GetPrefetch implements block.Prefetcher.  Local reads are fast, so it mostly helps when reading
+// from network or slow disks.
+func (l *Adapter) GetPrefetch(ctx context.Context, obj block.ObjectPointer, readAheadBytes int) (io.ReadCloser, error) {
+	reader, err := l.Get(ctx, obj, 0)
+	if err != nil {
+		return nil, err
+	}
+	return block.NewPrefetchReader(ctx, reader, readAheadBytes), nil
 }
 
-func (l *Adapter) Walk(_ context.Context, walkOpt block.WalkOpts, walkFn block.WalkFunc) error {
-	p := filepath.Clean(path.Join(l.path, walkOpt.StorageNamespace, walkOpt.Prefix))
-	return filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+// GetChunked reads obj in chunks of chunkSize bytes, the last of which may be shorter, and
+// calls fn on each chunk in order.  It stops and returns the error if fn fails or ctx is done.
+// fn must not retain chunk, which is reused for the next chunk.
+func (l *Adapter) GetChunked(ctx context.Context, obj block.ObjectPointer, chunkSize int, fn func(chunk []byte) error) (err error) {
+	if chunkSize <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidChunkSize, chunkSize)
+	}
+	reader, err := l.Get(ctx, obj, 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := reader.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	defer wrapError(&err, "get chunked", obj.Identifier)
+	buf := make([]byte, chunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := io.ReadFull(reader, buf)
+		if n > 0 {
+			if err := fn(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
+	}
+}
 
+// Walk calls walkFn with the path of each object under walkOpt.  Like WalkWithProperties it
+// skips sidecars, the trash and part files of multipart uploads in progress.
+func (l *Adapter) Walk(_ context.Context, walkOpt block.WalkOpts, walkFn block.WalkFunc) (err error) {
+	defer wrapError(&err, "walk", walkOpt.Prefix)
+	return l.walkPrefix(walkOpt, func(key, p string, _ os.FileInfo) error {
+		if _, _, ok := l.uploadPartOf(l.layoutKey(key)); ok {
+			return nil
+		}
 		return walkFn(p)
 	})
 }
 
-func (l *Adapter) Exists(_ context.Context, obj block.ObjectPointer) (bool, error) {
+func (l *Adapter) Exists(_ context.Context, obj block.ObjectPointer) (_ bool, err error) {
+	defer wrapError(&err, "exists", obj.Identifier)
 	p, err := l.getPath(obj)
 	if err != nil {
 		return false, err
 	}
+	defer l.locks.rlock(p)()
 	_, err = os.Stat(p)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -271,35 +971,199 @@ func (l *Adapter) Exists(_ context.Context, obj block.ObjectPointer) (bool, erro
 	return true, nil
 }
 
-func (l *Adapter) GetRange(_ context.Context, obj block.ObjectPointer, start int64, end int64) (io.ReadCloser, error) {
+// ExistsBatch implements block.BatchExistsChecker by checking each identifier in turn.
+func (l *Adapter) ExistsBatch(ctx context.Context, storageNamespace string, identifiers []string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(identifiers))
+	for _, identifier := range identifiers {
+		found, err := l.Exists(ctx, block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: identifier})
+		if err != nil {
+			return nil, err
+		}
+		exists[identifier] = found
+	}
+	return exists, nil
+}
+
+// GetRange returns a reader of bytes start to end (inclusive) of obj.  An end of
+// block.RangeToEnd reads from start to the end of the file.
+func (l *Adapter) GetRange(_ context.Context, obj block.ObjectPointer, start int64, end int64) (_ io.ReadCloser, err error) {
+	defer wrapError(&err, "get range", obj.Identifier)
+	if err := block.ValidateRange(start, end); err != nil {
+		return nil, err
+	}
 	p, err := l.getPath(obj)
 	if err != nil {
 		return nil, err
 	}
-	f, err := os.Open(filepath.Clean(p))
+	f, err := l.open(p)
 	if err != nil {
 		return nil, err
 	}
-	return &struct {
-		io.Reader
-		io.Closer
-	}{
-		Reader: io.NewSectionReader(f, start, end-start+1),
-		Closer: f,
-	}, nil
+	if end == block.RangeToEnd {
+		if _, err := f.Seek(start, io.SeekStart); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return newFileReadCloser(f, f), nil
+	}
+	return newFileReadCloser(io.NewSectionReader(f, start, end-start+1), f), nil
+}
+
+// GetRanges implements block.RangesGetter.  The object is opened once, and closed once all
+// returned readers are closed.
+func (l *Adapter) GetRanges(_ context.Context, obj block.ObjectPointer, ranges []block.Range) (_ []io.ReadCloser, err error) {
+	defer wrapError(&err, "get ranges", obj.Identifier)
+	if err := block.ValidateRanges(ranges); err != nil {
+		return nil, err
+	}
+	p, err := l.getPath(obj)
+	if err != nil {
+		return nil, err
+	}
+	f, err := l.open(p)
+	if err != nil {
+		return nil, err
+	}
+	shared := &sharedFile{f: f, refs: int32(len(ranges))}
+	readers := make([]io.ReadCloser, len(ranges))
+	for i, r := range ranges {
+		readers[i] = newFileReadCloser(io.NewSectionReader(f, r.Start, r.End-r.Start+1), shared.ref())
+	}
+	return readers, nil
+}
+
+// sharedFile is a file closed once all of its references are closed.
+type sharedFile struct {
+	f    *os.File
+	refs int32
+}
+
+type sharedFileRef struct {
+	shared *sharedFile
+	once   sync.Once
+}
+
+func (s *sharedFile) ref() io.Closer {
+	return &sharedFileRef{shared: s}
 }
 
-func (l *Adapter) GetProperties(_ context.Context, obj block.ObjectPointer) (block.Properties, error) {
+func (r *sharedFileRef) Close() error {
+	var err error
+	r.once.Do(func() {
+		if atomic.AddInt32(&r.shared.refs, -1) == 0 {
+			err = r.shared.f.Close()
+		}
+	})
+	return err
+}
+
+func (l *Adapter) GetProperties(_ context.Context, obj block.ObjectPointer) (_ block.Properties, err error) {
+	defer wrapError(&err, "get properties", obj.Identifier)
 	p, err := l.getPath(obj)
 	if err != nil {
 		return block.Properties{}, err
 	}
+	defer l.locks.rlock(p)()
 	_, err = os.Stat(p)
 	if err != nil {
 		return block.Properties{}, err
 	}
-	// No properties, just return that it exists
-	return block.Properties{}, nil
+	storageClass, err := readStorageClass(p)
+	if err != nil {
+		return block.Properties{}, err
+	}
+	return block.Properties{StorageClass: storageClass}, nil
+}
+
+// Stat returns the properties of obj.  The ETag is read from its sidecar when available, and
+// computed from the object contents otherwise.
+func (l *Adapter) Stat(_ context.Context, obj block.ObjectPointer) (_ block.ObjectProperties, err error) {
+	defer wrapError(&err, "stat", obj.Identifier)
+	p, err := l.getPath(obj)
+	if err != nil {
+		return block.ObjectProperties{}, err
+	}
+	defer l.locks.rlock(p)()
+	info, err := os.Stat(p)
+	if err != nil {
+		return block.ObjectProperties{}, err
+	}
+	return l.properties(p, info)
+}
+
+// GetWithProperties implements block.PropertiesGetter.  Properties are read while the object is
+// locked, so they match the contents read even if the object is replaced before reader is
+// closed.
+func (l *Adapter) GetWithProperties(_ context.Context, obj block.ObjectPointer) (_ io.ReadCloser, _ block.ObjectProperties, err error) {
+	defer wrapError(&err, "get with properties", obj.Identifier)
+	p, err := l.getPath(obj)
+	if err != nil {
+		return nil, block.ObjectProperties{}, err
+	}
+	defer l.locks.rlock(p)()
+	f, err := os.OpenFile(filepath.Clean(p), os.O_RDONLY, 0600)
+	if err != nil {
+		return nil, block.ObjectProperties{}, err
+	}
+	reader := newFileReadCloser(f, f)
+	info, err := f.Stat()
+	if err != nil {
+		_ = reader.Close()
+		return nil, block.ObjectProperties{}, err
+	}
+	props, err := l.properties(p, info)
+	if err != nil {
+		_ = reader.Close()
+		return nil, block.ObjectProperties{}, err
+	}
+	return reader, props, nil
+}
+
+// properties returns the properties of the object at p with file info.  The caller holds the
+// lock of p.
+func (l *Adapter) properties(p string, info os.FileInfo) (block.ObjectProperties, error) {
+	etag, err := l.fileETag(p)
+	if err != nil {
+		return block.ObjectProperties{}, err
+	}
+	storageClass, err := readStorageClass(p)
+	if err != nil {
+		return block.ObjectProperties{}, err
+	}
+	modified, err := lastModified(p, info)
+	if err != nil {
+		return block.ObjectProperties{}, err
+	}
+	return block.ObjectProperties{
+		Size:         info.Size(),
+		ETag:         etag,
+		LastModified: modified,
+		StorageClass: storageClass,
+	}, nil
+}
+
+// fileETag returns the ETag of the object stored at p, from its sidecar when available.
+func (l *Adapter) fileETag(p string) (string, error) {
+	etag, ok, err := readSidecar(p, etagSidecarSuffix)
+	if err != nil || ok {
+		return etag, err
+	}
+	return l.computeFileETag(p)
+}
+
+func (l *Adapter) computeFileETag(p string) (string, error) {
+	f, err := os.Open(filepath.Clean(p))
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := l.newHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 // isDirectoryWritable tests that pth, which must not be controllable by user input, is a
@@ -316,7 +1180,8 @@ func isDirectoryWritable(pth string) bool {
 	return true
 }
 
-func (l *Adapter) CreateMultiPartUpload(_ context.Context, obj block.ObjectPointer, _ *http.Request, _ block.CreateMultiPartUploadOpts) (string, error) {
+func (l *Adapter) CreateMultiPartUpload(_ context.Context, obj block.ObjectPointer, _ *http.Request, _ block.CreateMultiPartUploadOpts) (_ string, err error) {
+	defer wrapError(&err, "create multipart upload", obj.Identifier)
 	if strings.Contains(obj.Identifier, "/") {
 		fullPath, err := l.getPath(obj)
 		if err != nil {
@@ -334,51 +1199,116 @@ func (l *Adapter) CreateMultiPartUpload(_ context.Context, obj block.ObjectPoint
 	return uploadID, nil
 }
 
-func (l *Adapter) UploadPart(ctx context.Context, obj block.ObjectPointer, _ int64, reader io.Reader, uploadID string, partNumber int64) (string, error) {
+func (l *Adapter) UploadPart(ctx context.Context, obj block.ObjectPointer, _ int64, reader io.Reader, uploadID string, partNumber int64) (_ string, err error) {
+	defer wrapError(&err, "upload part", obj.Identifier)
 	if err := isValidUploadID(uploadID); err != nil {
 		return "", err
 	}
-	md5Read := block.NewHashingReader(reader, block.HashFunctionMD5)
-	fName := uploadID + fmt.Sprintf("-%05d", partNumber)
-	err := l.Put(ctx, block.ObjectPointer{StorageNamespace: obj.StorageNamespace, Identifier: fName}, -1, md5Read, block.PutOpts{})
-	etag := "\"" + hex.EncodeToString(md5Read.Md5.Sum(nil)) + "\""
+	return l.writePart(obj, uploadID, partNumber, -1, newDeadlineReader(ctx, reader))
+}
+
+// writePart writes the contents of reader as part partNumber of uploadID and returns its ETag.
+func (l *Adapter) writePart(obj block.ObjectPointer, uploadID string, partNumber int64, sizeBytes int64, reader io.Reader) (string, error) {
+	uploadDir, err := l.uploadDir(uploadID, obj)
+	if err != nil {
+		return "", err
+	}
+	p := path.Join(uploadDir, fmt.Sprintf("%s%05d", uploadPartPrefix, partNumber))
+	reader, err = l.limitObjectSize(sizeBytes, reader)
+	if err != nil {
+		return "", err
+	}
+	hashRead := newHashReader(reader, l.newHash())
+	err = l.writeFile(p, sizeBytes, hashRead)
+	etag := "\"" + hashRead.HexSum() + "\""
 	return etag, err
 }
 
-func (l *Adapter) AbortMultiPartUpload(_ context.Context, obj block.ObjectPointer, uploadID string) error {
+func (l *Adapter) AbortMultiPartUpload(_ context.Context, obj block.ObjectPointer, uploadID string) (err error) {
+	defer wrapError(&err, "abort multipart upload", obj.Identifier)
 	if err := isValidUploadID(uploadID); err != nil {
 		return err
 	}
-	files, err := l.getPartFiles(uploadID, obj)
+	uploadDir, err := l.uploadDir(uploadID, obj)
 	if err != nil {
 		return err
 	}
-	if err = l.removePartFiles(files); err != nil {
+	files, err := l.getPartFiles(uploadDir)
+	if err != nil {
+		return err
+	}
+	if err = l.removePartFiles(uploadDir, files); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (l *Adapter) CompleteMultiPartUpload(_ context.Context, obj block.ObjectPointer, uploadID string, multipartList *block.MultipartUploadCompletion) (*string, int64, error) {
+func (l *Adapter) CompleteMultiPartUpload(ctx context.Context, obj block.ObjectPointer, uploadID string, multipartList *block.MultipartUploadCompletion) (_ *string, _ int64, err error) {
+	defer wrapError(&err, "complete multipart upload", obj.Identifier)
 	if err := isValidUploadID(uploadID); err != nil {
 		return nil, -1, err
 	}
-	etag := computeETag(multipartList.Part) + "-" + strconv.Itoa(len(multipartList.Part))
-	partFiles, err := l.getPartFiles(uploadID, obj)
+	// completions to the same object serialize, in particular their checks and part files
+	p, err := l.getPath(obj)
+	if err != nil {
+		return nil, -1, err
+	}
+	release, err := l.completeLeases.acquire(ctx, p, !l.failOnConcurrent)
+	if err != nil {
+		return nil, -1, err
+	}
+	defer release()
+	etag := computeETag(multipartList.Part, l.newHash) + "-" + strconv.Itoa(len(multipartList.Part))
+	uploadDir, err := l.uploadDir(uploadID, obj)
+	if err != nil {
+		return nil, -1, err
+	}
+	partFiles, err := l.getPartFiles(uploadDir)
 	if err != nil {
 		return nil, -1, fmt.Errorf("part files not found for %s: %w", uploadID, err)
 	}
-	size, err := l.unitePartFiles(obj, partFiles)
+	if err = l.verifyPartSizes(uploadID, partFiles); err != nil {
+		return nil, -1, err
+	}
+	defer l.locks.lock(p)()
+	if err = l.verifyWritable(p); err != nil {
+		return nil, -1, err
+	}
+	size, err := l.unitePartFiles(ctx, obj, partFiles)
 	if err != nil {
 		return nil, -1, fmt.Errorf("multipart upload unite for %s: %w", uploadID, err)
 	}
-	if err = l.removePartFiles(partFiles); err != nil {
+	if err = l.removePartFiles(uploadDir, partFiles); err != nil {
+		return nil, -1, err
+	}
+	if err = writeSidecar(p, etagSidecarSuffix, etag); err != nil {
+		return nil, -1, err
+	}
+	if err = l.finishPut(p, block.PutOpts{}); err != nil {
 		return nil, -1, err
 	}
 	return &etag, size, nil
 }
 
-func computeETag(parts []*s3.CompletedPart) string {
+// verifyPartSizes returns ErrEntityTooSmall if any part file except the last is smaller than the
+// configured minimal part size.
+func (l *Adapter) verifyPartSizes(uploadID string, partFiles []string) error {
+	for i := 0; i < len(partFiles)-1; i++ {
+		info, err := os.Stat(partFiles[i])
+		if err != nil {
+			return err
+		}
+		if info.Size() < l.minPartSize {
+			partNumber := strings.TrimPrefix(strings.TrimPrefix(filepath.Base(partFiles[i]), uploadID+legacyPartSeparator), uploadPartPrefix)
+			return fmt.Errorf("%w: part %s size %d is below minimum %d", block.ErrEntityTooSmall, partNumber, info.Size(), l.minPartSize)
+		}
+	}
+	return nil
+}
+
+// computeETag returns the ETag of a multipart upload: the hash of the concatenated hashes of
+// its parts, as computed by S3 when newHash is MD5.
+func computeETag(parts []*s3.CompletedPart, newHash func() hash.Hash) string {
 	var etagHex []string
 	for _, p := range parts {
 		e := *p.ETag
@@ -389,24 +1319,18 @@ func computeETag(parts []*s3.CompletedPart) string {
 	}
 	s := strings.Join(etagHex, "")
 	b, _ := hex.DecodeString(s)
-	md5res := md5.Sum(b) //nolint:gosec
-	csm := hex.EncodeToString(md5res[:])
-	return csm
+	h := newHash()
+	_, _ = h.Write(b)
+	return hex.EncodeToString(h.Sum(nil))
 }
 
-func (l *Adapter) unitePartFiles(identifier block.ObjectPointer, files []string) (int64, error) {
+func (l *Adapter) unitePartFiles(ctx context.Context, identifier block.ObjectPointer, files []string) (int64, error) {
 	p, err := l.getPath(identifier)
 	if err != nil {
 		return 0, err
 	}
-	unitedFile, err := os.Create(p)
-	if err != nil {
-		return 0, fmt.Errorf("create path %s: %w", p, err)
-	}
-	defer func() {
-		_ = unitedFile.Close()
-	}()
 	var readers = []io.Reader{}
+	var partFiles []*os.File
 	for _, name := range files {
 		if err := l.verifyPath(name); err != nil {
 			return 0, err
@@ -416,16 +1340,36 @@ func (l *Adapter) unitePartFiles(identifier block.ObjectPointer, files []string)
 			return 0, fmt.Errorf("open file %s: %w", name, err)
 		}
 		readers = append(readers, f)
+		partFiles = append(partFiles, f)
 		defer func() {
 			_ = f.Close()
 		}()
 	}
-	unitedReader := io.MultiReader(readers...)
-	size, err := io.Copy(unitedFile, unitedReader)
-	return size, err
+	unitedReader := newDeadlineReader(ctx, io.MultiReader(readers...))
+	if l.dedup {
+		return l.putBlob(p, -1, unitedReader)
+	}
+	unitedFile, err := l.createTemp()
+	if err != nil {
+		return 0, fmt.Errorf("create path %s: %w", p, err)
+	}
+	var size int64
+	if l.tryReflink {
+		size, err = appendFiles(ctx, unitedFile, partFiles)
+	} else {
+		size, err = io.Copy(unitedFile, unitedReader)
+	}
+	if err != nil {
+		_ = unitedFile.Close()
+		_ = os.Remove(unitedFile.Name())
+		return 0, err
+	}
+	return size, l.closeAndReplace(unitedFile, p)
 }
 
-func (l *Adapter) removePartFiles(files []string) error {
+// removePartFiles removes files, the part files of the upload stored at uploadDir, and then
+// uploadDir itself.
+func (l *Adapter) removePartFiles(uploadDir string, files []string) error {
 	var firstErr error
 	for _, name := range files {
 		if err := l.verifyPath(name); err != nil {
@@ -436,25 +1380,45 @@ func (l *Adapter) removePartFiles(files []string) error {
 		// If removal fails prefer to skip the error: "only" wasted space.
 		_ = os.Remove(name)
 	}
+	// fails if parts were uploaded since listed, which are then left for a later abort
+	_ = os.Remove(uploadDir)
 	return firstErr
 }
 
-func (l *Adapter) getPartFiles(uploadID string, obj block.ObjectPointer) ([]string, error) {
-	newObj := block.ObjectPointer{
+// uploadDir returns the directory holding the part files of uploadID: the path of uploadID in the
+// configured layout, so that concurrent uploads do not share a directory.
+func (l *Adapter) uploadDir(uploadID string, obj block.ObjectPointer) (string, error) {
+	return l.getPath(block.ObjectPointer{
 		StorageNamespace: obj.StorageNamespace,
 		Identifier:       uploadID,
+	})
+}
+
+// getPartFiles returns the part files of the upload stored at uploadDir ordered by part number.
+// Part numbers are parsed regardless of their zero-padding, so that parts written with a
+// different suffix width can still be completed.  Parts stored next to uploadDir by versions
+// that did not use upload directories are included.
+func (l *Adapter) getPartFiles(uploadDir string) ([]string, error) {
+	partNumbers := make(map[string]int64)
+	partFiles := make([]string, 0)
+	for _, prefix := range []string{path.Join(uploadDir, uploadPartPrefix), uploadDir + legacyPartSeparator} {
+		names, err := filepath.Glob(prefix + "*")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			partNumber, err := strconv.ParseInt(strings.TrimPrefix(name, prefix), 10, 64)
+			if err != nil {
+				continue
+			}
+			partNumbers[name] = partNumber
+			partFiles = append(partFiles, name)
+		}
 	}
-	globPathPattern, err := l.getPath(newObj)
-	if err != nil {
-		return nil, err
-	}
-	globPathPattern += "*"
-	names, err := filepath.Glob(globPathPattern)
-	if err != nil {
-		return nil, err
-	}
-	sort.Strings(names)
-	return names, nil
+	sort.Slice(partFiles, func(i, j int) bool {
+		return partNumbers[partFiles[i]] < partNumbers[partFiles[j]]
+	})
+	return partFiles, nil
 }
 
 func (l *Adapter) ValidateConfiguration(_ context.Context, _ string) error {
@@ -477,6 +1441,37 @@ func (l *Adapter) RuntimeStats() map[string]string {
 	return nil
 }
 
+// wrapError annotates a failed operation on identifier with both, keeping the underlying error
+// available to errors.Is and errors.As.  Errors of missing files also match
+// block.ErrDataNotFound, so that callers can test for missing objects across all operations.
+func wrapError(err *error, op, identifier string) {
+	if *err == nil {
+		return
+	}
+	if errors.Is(*err, os.ErrNotExist) && !errors.Is(*err, block.ErrDataNotFound) {
+		*err = &notFoundError{err: *err}
+	}
+	*err = fmt.Errorf("%s %s: %w", op, identifier, *err)
+}
+
+// notFoundError is the error of an operation on a missing object.  It matches
+// block.ErrDataNotFound as well as the error it wraps, e.g. os.ErrNotExist.
+type notFoundError struct {
+	err error
+}
+
+func (e *notFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == block.ErrDataNotFound
+}
+
 func isValidUploadID(uploadID string) error {
 	_, err := hex.DecodeString(uploadID)
 	if err != nil {
@@ -1,13 +1,25 @@
 package local_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -19,12 +31,14 @@ import (
 
 const testStorageNamespace = "local://test"
 
-func makeAdapter(t *testing.T) *local.Adapter {
+var errPartialRead = errors.New("partial read")
+
+func makeAdapter(t testing.TB, opts ...func(a *local.Adapter)) *local.Adapter {
 	t.Helper()
 	dir, err := ioutil.TempDir("", "testing-local-adapter-*")
 	testutil.MustDo(t, "TempDir", err)
 	testutil.MustDo(t, "NewAdapter", os.MkdirAll(dir, 0700))
-	a, err := local.NewAdapter(dir)
+	a, err := local.NewAdapter(dir, opts...)
 	testutil.MustDo(t, "NewAdapter", err)
 
 	t.Cleanup(func() {
@@ -90,7 +104,7 @@ func TestLocalNotExists(t *testing.T) {
 }
 
 func TestLocalMultipartUpload(t *testing.T) {
-	a := makeAdapter(t)
+	a := makeAdapter(t, local.WithMinPartSize(4))
 	ctx := context.Background()
 
 	cases := []struct {
@@ -132,6 +146,171 @@ func TestLocalMultipartUpload(t *testing.T) {
 	}
 }
 
+func TestLocalMultipartUploadMixedPartNames(t *testing.T) {
+	a := makeAdapter(t, local.WithMinPartSize(4))
+	ctx := context.Background()
+	pointer := makePointer("mixed")
+	uploadID, err := a.CreateMultiPartUpload(ctx, pointer, nil, block.CreateMultiPartUploadOpts{})
+	testutil.MustDo(t, "CreateMultiPartUpload", err)
+
+	// parts written with different suffix widths, e.g. by a version using another naming scheme
+	partFiles := []struct {
+		suffix  string
+		content string
+	}{
+		{"-00000002", "two "},
+		{"-00010", "ten"},
+		{"-00001", "one "},
+	}
+	testutil.MustDo(t, "create upload dir", os.MkdirAll(filepath.Join(a.Path(), "test"), 0700))
+	for _, part := range partFiles {
+		p := filepath.Join(a.Path(), "test", uploadID+part.suffix)
+		testutil.MustDo(t, "write part "+part.suffix, ioutil.WriteFile(p, []byte(part.content), 0600))
+	}
+	_, _, err = a.CompleteMultiPartUpload(ctx, pointer, uploadID, &block.MultipartUploadCompletion{})
+	testutil.MustDo(t, "CompleteMultiPartUpload", err)
+
+	reader, err := a.Get(ctx, pointer, 0)
+	testutil.MustDo(t, "Get", err)
+	got, err := ioutil.ReadAll(reader)
+	_ = reader.Close()
+	testutil.MustDo(t, "ReadAll", err)
+	if string(got) != "one two ten" {
+		t.Errorf("got %q, expected parts in numeric order %q", got, "one two ten")
+	}
+}
+
+func TestLocalMultipartUploadConcurrentParts(t *testing.T) {
+	const partsPerUpload = 200
+	ctx := context.Background()
+	for _, layout := range []local.PathLayout{local.PathLayoutFlat, local.PathLayoutSharded} {
+		t.Run(string(layout), func(t *testing.T) {
+			a := makeAdapter(t, local.WithPathLayout(layout), local.WithMinPartSize(1))
+			pointers := []block.ObjectPointer{makePointer("first"), makePointer("second"), makePointer("aborted")}
+			uploadIDs := make([]string, len(pointers))
+			for i, pointer := range pointers {
+				var err error
+				uploadIDs[i], err = a.CreateMultiPartUpload(ctx, pointer, nil, block.CreateMultiPartUploadOpts{})
+				testutil.MustDo(t, "CreateMultiPartUpload", err)
+			}
+			partContent := func(upload, partNumber int) string {
+				return fmt.Sprintf("%d:%03d,", upload, partNumber)
+			}
+
+			// upload the parts of all uploads concurrently
+			etags := make([][]string, len(pointers))
+			var wg sync.WaitGroup
+			errs := make(chan error, len(pointers)*partsPerUpload)
+			for upload := range pointers {
+				etags[upload] = make([]string, partsPerUpload+1)
+				for partNumber := 1; partNumber <= partsPerUpload; partNumber++ {
+					wg.Add(1)
+					go func(upload, partNumber int) {
+						defer wg.Done()
+						content := partContent(upload, partNumber)
+						etag, err := a.UploadPart(ctx, pointers[upload], int64(len(content)), strings.NewReader(content), uploadIDs[upload], int64(partNumber))
+						if err != nil {
+							errs <- err
+							return
+						}
+						etags[upload][partNumber] = etag
+					}(upload, partNumber)
+				}
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Fatalf("UploadPart: %s", err)
+			}
+
+			uploads, err := a.ListMultipartUploads(ctx, testStorageNamespace)
+			testutil.MustDo(t, "ListMultipartUploads", err)
+			if len(uploads) != len(pointers) {
+				t.Fatalf("ListMultipartUploads found %d uploads, expected %d", len(uploads), len(pointers))
+			}
+			for _, upload := range uploads {
+				if upload.Parts != partsPerUpload {
+					t.Errorf("ListMultipartUploads found %d parts of %s, expected %d", upload.Parts, upload.UploadID, partsPerUpload)
+				}
+			}
+
+			testutil.MustDo(t, "AbortMultiPartUpload", a.AbortMultiPartUpload(ctx, pointers[2], uploadIDs[2]))
+			for upload, pointer := range pointers[:2] {
+				parts := make([]*s3.CompletedPart, 0, partsPerUpload)
+				var expected strings.Builder
+				for partNumber := 1; partNumber <= partsPerUpload; partNumber++ {
+					parts = append(parts, &s3.CompletedPart{
+						ETag:       aws.String(etags[upload][partNumber]),
+						PartNumber: aws.Int64(int64(partNumber)),
+					})
+					expected.WriteString(partContent(upload, partNumber))
+				}
+				_, _, err := a.CompleteMultiPartUpload(ctx, pointer, uploadIDs[upload], &block.MultipartUploadCompletion{Part: parts})
+				testutil.MustDo(t, "CompleteMultiPartUpload", err)
+				reader, err := a.Get(ctx, pointer, 0)
+				testutil.MustDo(t, "Get", err)
+				got, err := ioutil.ReadAll(reader)
+				_ = reader.Close()
+				testutil.MustDo(t, "ReadAll", err)
+				if string(got) != expected.String() {
+					t.Errorf("upload %d assembled %q, expected %q", upload, got, expected.String())
+				}
+			}
+
+			// no upload directory or part file is left behind
+			err = filepath.Walk(filepath.Join(a.Path(), "test"), func(p string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				for _, uploadID := range uploadIDs {
+					if strings.Contains(p, uploadID) {
+						t.Errorf("%s left after completing or aborting its upload", p)
+					}
+				}
+				return nil
+			})
+			testutil.MustDo(t, "Walk", err)
+		})
+	}
+}
+
+func TestLocalMultipartUploadMinPartSize(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t, local.WithMinPartSize(5))
+
+	cases := []struct {
+		name     string
+		partData []string
+		wantErr  error
+	}{
+		{"small last part", []string{"first", "second", "3"}, nil},
+		{"single small part", []string{"1"}, nil},
+		{"small middle part", []string{"first", "2", "third"}, block.ErrEntityTooSmall},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pointer := makePointer(c.name)
+			uploadID, err := a.CreateMultiPartUpload(ctx, pointer, nil, block.CreateMultiPartUploadOpts{})
+			testutil.MustDo(t, "CreateMultiPartUpload", err)
+			parts := make([]*s3.CompletedPart, 0)
+			for i, content := range c.partData {
+				partNumber := int64(i + 1)
+				cs, err := a.UploadPart(ctx, pointer, int64(len(content)), strings.NewReader(content), uploadID, partNumber)
+				testutil.MustDo(t, "UploadPart", err)
+				parts = append(parts, &s3.CompletedPart{ETag: aws.String(cs), PartNumber: aws.Int64(partNumber)})
+			}
+			_, _, err = a.CompleteMultiPartUpload(ctx, pointer, uploadID, &block.MultipartUploadCompletion{Part: parts})
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("CompleteMultiPartUpload() error = %v, expected %v", err, c.wantErr)
+			}
+			if c.wantErr != nil && !strings.Contains(err.Error(), "part 00002") {
+				t.Errorf("CompleteMultiPartUpload() error %q does not identify part 2", err)
+			}
+		})
+	}
+}
+
 func TestLocalCopy(t *testing.T) {
 	a := makeAdapter(t)
 	ctx := context.Background()
@@ -182,27 +361,27 @@ func TestAdapter_Remove(t *testing.T) {
 			name:     "single",
 			path:     "README",
 			wantErr:  false,
-			wantTree: []string{""},
+			wantTree: []string{"", "/.tmp"},
 		},
 
 		{
 			name:     "under folder",
 			path:     "src/tools.go",
 			wantErr:  false,
-			wantTree: []string{""},
+			wantTree: []string{"", "/.tmp"},
 		},
 		{
 			name:     "under multiple folders",
 			path:     "a/b/c/d.txt",
 			wantErr:  false,
-			wantTree: []string{""},
+			wantTree: []string{"", "/.tmp"},
 		},
 		{
 			name:              "file in the way",
 			path:              "a/b/c/d.txt",
 			additionalObjects: []string{"a/b/blocker.txt"},
 			wantErr:           false,
-			wantTree:          []string{"", "/test", "/test/a", "/test/a/b", "/test/a/b/blocker.txt"},
+			wantTree:          []string{"", "/.tmp", "/test", "/test/a", "/test/a/b", "/test/a/b/blocker.txt", "/test/a/b/blocker.txt.etag"},
 		},
 	}
 
@@ -227,3 +406,2248 @@ func TestAdapter_Remove(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalPutFreeSpaceCheck(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "testing-local-adapter-*")
+	testutil.MustDo(t, "TempDir", err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	const contents = "some content"
+	t.Run("enough space", func(t *testing.T) {
+		a, err := local.NewAdapter(dir, local.WithFreeSpaceCheck(0))
+		testutil.MustDo(t, "NewAdapter", err)
+		testutil.MustDo(t, "Put", a.Put(ctx, makePointer("fits"), int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+	})
+	t.Run("insufficient space", func(t *testing.T) {
+		// no volume has this much space left to spare
+		a, err := local.NewAdapter(dir, local.WithFreeSpaceCheck(math.MaxInt64-int64(len(contents))))
+		testutil.MustDo(t, "NewAdapter", err)
+		err = a.Put(ctx, makePointer("too-big"), int64(len(contents)), strings.NewReader(contents), block.PutOpts{})
+		if !errors.Is(err, local.ErrInsufficientStorage) {
+			t.Fatalf("Put() error = %v, expected %s", err, local.ErrInsufficientStorage)
+		}
+		ok, err := a.Exists(ctx, makePointer("too-big"))
+		testutil.MustDo(t, "Exists", err)
+		if ok {
+			t.Error("rejected object was written")
+		}
+	})
+	t.Run("unknown size", func(t *testing.T) {
+		a, err := local.NewAdapter(dir, local.WithFreeSpaceCheck(math.MaxInt64))
+		testutil.MustDo(t, "NewAdapter", err)
+		testutil.MustDo(t, "Put", a.Put(ctx, makePointer("unknown"), -1, strings.NewReader(contents), block.PutOpts{}))
+	})
+}
+
+func TestLocalETagSidecar(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	const contents = "sidecar contents"
+	const contentsMD5 = "89494bf9eb192c090e1203858da7cb16"
+	obj := makePointer("with/sidecar")
+	sidecarPath := filepath.Join(a.Path(), "test", "with", "sidecar.etag")
+
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+	sidecar, err := ioutil.ReadFile(sidecarPath)
+	testutil.MustDo(t, "read sidecar", err)
+	if string(sidecar) != contentsMD5 {
+		t.Errorf("sidecar contains %q, expected %q", sidecar, contentsMD5)
+	}
+
+	// Stat must trust the sidecar rather than read the object
+	const fakeETag = "00112233445566778899aabbccddeeff"
+	testutil.MustDo(t, "write sidecar", ioutil.WriteFile(sidecarPath, []byte(fakeETag), 0600))
+	props, err := a.Stat(ctx, obj)
+	testutil.MustDo(t, "Stat", err)
+	if props.ETag != fakeETag {
+		t.Errorf("Stat() ETag = %s, expected sidecar value %s", props.ETag, fakeETag)
+	}
+	if props.Size != int64(len(contents)) {
+		t.Errorf("Stat() Size = %d, expected %d", props.Size, len(contents))
+	}
+
+	// without a sidecar Stat computes the ETag
+	testutil.MustDo(t, "remove sidecar", os.Remove(sidecarPath))
+	props, err = a.Stat(ctx, obj)
+	testutil.MustDo(t, "Stat", err)
+	if props.ETag != contentsMD5 {
+		t.Errorf("Stat() ETag = %s, expected computed %s", props.ETag, contentsMD5)
+	}
+
+	// overwrite rewrites the sidecar, remove deletes it
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, 0, strings.NewReader(""), block.PutOpts{}))
+	props, err = a.Stat(ctx, obj)
+	testutil.MustDo(t, "Stat", err)
+	if props.ETag != "d41d8cd98f00b204e9800998ecf8427e" {
+		t.Errorf("Stat() after overwrite ETag = %s, expected MD5 of empty object", props.ETag)
+	}
+	testutil.MustDo(t, "Remove", a.Remove(ctx, obj))
+	if _, err := os.Stat(sidecarPath); !os.IsNotExist(err) {
+		t.Errorf("sidecar still exists after Remove: %v", err)
+	}
+
+	// identifiers that would overwrite or shadow sidecars are rejected
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+	for _, key := range []string{"with/sidecar.etag", "with/sidecar.blob/object"} {
+		err := a.Put(ctx, makePointer(key), 1, strings.NewReader("x"), block.PutOpts{})
+		if !errors.Is(err, local.ErrReservedIdentifier) {
+			t.Errorf("Put(%s) error = %v, expected %s", key, err, local.ErrReservedIdentifier)
+		}
+	}
+	sidecar, err = ioutil.ReadFile(sidecarPath)
+	testutil.MustDo(t, "read sidecar", err)
+	if string(sidecar) != contentsMD5 {
+		t.Errorf("sidecar contains %q after rejected Put, expected %q", sidecar, contentsMD5)
+	}
+}
+
+// countBlobs returns the number of content blobs stored by a dedup adapter.
+func countBlobs(t *testing.T, a *local.Adapter) int {
+	t.Helper()
+	blobs, err := filepath.Glob(filepath.Join(a.Path(), ".blobs", "??", "*"))
+	testutil.MustDo(t, "list blobs", err)
+	return len(blobs)
+}
+
+func TestLocalDedup(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t, local.WithDedup())
+	const contents = "deduplicated contents"
+	first := makePointer("dedup/first")
+	second := makePointer("dedup/second")
+
+	for _, obj := range []block.ObjectPointer{first, second} {
+		testutil.MustDo(t, "Put "+obj.Identifier, a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+	}
+	firstInfo, err := os.Stat(filepath.Join(a.Path(), "test", "dedup", "first"))
+	testutil.MustDo(t, "stat first", err)
+	secondInfo, err := os.Stat(filepath.Join(a.Path(), "test", "dedup", "second"))
+	testutil.MustDo(t, "stat second", err)
+	if !os.SameFile(firstInfo, secondInfo) {
+		t.Error("identical objects are not stored in the same file")
+	}
+	if n := countBlobs(t, a); n != 1 {
+		t.Fatalf("got %d blobs after two identical puts, expected 1", n)
+	}
+
+	// removing one object keeps the blob for the other
+	testutil.MustDo(t, "Remove first", a.Remove(ctx, first))
+	if n := countBlobs(t, a); n != 1 {
+		t.Fatalf("got %d blobs after removing one reference, expected 1", n)
+	}
+	reader, err := a.Get(ctx, second, 0)
+	testutil.MustDo(t, "Get second", err)
+	got, err := ioutil.ReadAll(reader)
+	_ = reader.Close()
+	testutil.MustDo(t, "read second", err)
+	if string(got) != contents {
+		t.Errorf("second contains %q, expected %q", got, contents)
+	}
+
+	// removing the last object removes the blob
+	testutil.MustDo(t, "Remove second", a.Remove(ctx, second))
+	if n := countBlobs(t, a); n != 0 {
+		t.Errorf("got %d blobs after removing all references, expected 0", n)
+	}
+}
+
+func TestLocalDedupOverwrite(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t, local.WithDedup())
+	obj := makePointer("dedup/overwritten")
+	copied := makePointer("dedup/copied")
+
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, 3, strings.NewReader("old"), block.PutOpts{}))
+	testutil.MustDo(t, "Copy", a.Copy(ctx, obj, copied))
+	testutil.MustDo(t, "Put overwrite", a.Put(ctx, obj, 3, strings.NewReader("new"), block.PutOpts{}))
+	if n := countBlobs(t, a); n != 2 {
+		t.Fatalf("got %d blobs, expected 2", n)
+	}
+
+	// overwriting an object must not change other objects sharing its old contents
+	reader, err := a.Get(ctx, copied, 0)
+	testutil.MustDo(t, "Get copied", err)
+	got, err := ioutil.ReadAll(reader)
+	_ = reader.Close()
+	testutil.MustDo(t, "read copied", err)
+	if string(got) != "old" {
+		t.Errorf("copied contains %q, expected %q", got, "old")
+	}
+
+	testutil.MustDo(t, "Remove copied", a.Remove(ctx, copied))
+	if n := countBlobs(t, a); n != 1 {
+		t.Errorf("got %d blobs after removing the only reference to old contents, expected 1", n)
+	}
+}
+
+func TestLocalDedupProperties(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t, local.WithDedup())
+	const contents = "shared contents"
+	old := makePointer("dedup/old")
+	testutil.MustDo(t, "Put old", a.Put(ctx, old, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+	// age the blob shared by all objects with these contents
+	longAgo := time.Now().Add(-24 * time.Hour)
+	testutil.MustDo(t, "age blob", os.Chtimes(filepath.Join(a.Path(), "test", "dedup", "old"), longAgo, longAgo))
+
+	before := time.Now().Add(-time.Second)
+	fresh := makePointer("dedup/fresh")
+	testutil.MustDo(t, "Put fresh", a.Put(ctx, fresh, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+	props, err := a.Stat(ctx, fresh)
+	testutil.MustDo(t, "Stat fresh", err)
+	if props.LastModified.Before(before) {
+		t.Errorf("Stat of an object sharing an old blob returned last modified %s, expected after %s", props.LastModified, before)
+	}
+
+	// copying over an object replaces its storage class and expiry
+	copied := makePointer("dedup/copied")
+	testutil.MustDo(t, "PutTemp copied", a.PutTemp(ctx, copied, 3, strings.NewReader("tmp"), time.Hour))
+	testutil.MustDo(t, "SetStorageClass copied", a.SetStorageClass(ctx, copied, "GLACIER"))
+	testutil.MustDo(t, "Copy", a.Copy(ctx, old, copied))
+	props, err = a.Stat(ctx, copied)
+	testutil.MustDo(t, "Stat copied", err)
+	if props.StorageClass != nil {
+		t.Errorf("storage class after Copy = %s, expected none", *props.StorageClass)
+	}
+	if props.LastModified.Before(before) {
+		t.Errorf("Stat after Copy returned last modified %s, expected after %s", props.LastModified, before)
+	}
+	if _, err := os.Stat(filepath.Join(a.Path(), "test", "dedup", "copied.expires")); !os.IsNotExist(err) {
+		t.Errorf("expiry still exists after Copy: %v", err)
+	}
+}
+
+func TestLocalAppend(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		name string
+		opts []func(a *local.Adapter)
+	}{
+		{name: "plain"},
+		{name: "dedup", opts: []func(a *local.Adapter){local.WithDedup()}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			a := makeAdapter(t, tt.opts...)
+			var appender block.Appender = a
+			obj := makePointer("log/appended")
+
+			size, err := appender.Append(ctx, obj, strings.NewReader("first line\n"))
+			testutil.MustDo(t, "first Append", err)
+			if size != 11 {
+				t.Errorf("first Append returned size %d, expected 11", size)
+			}
+			size, err = appender.Append(ctx, obj, strings.NewReader("second line\n"))
+			testutil.MustDo(t, "second Append", err)
+			if size != 23 {
+				t.Errorf("second Append returned size %d, expected 23", size)
+			}
+
+			reader, err := a.Get(ctx, obj, 0)
+			testutil.MustDo(t, "Get", err)
+			got, err := ioutil.ReadAll(reader)
+			_ = reader.Close()
+			testutil.MustDo(t, "read", err)
+			if string(got) != "first line\nsecond line\n" {
+				t.Errorf("object contains %q, expected both appended lines", got)
+			}
+			props, err := a.Stat(ctx, obj)
+			testutil.MustDo(t, "Stat", err)
+			const wantETag = "7565a01bd35f31ba82ab55c978c1b755"
+			if props.Size != 23 || props.ETag != wantETag {
+				t.Errorf("Stat() = size %d ETag %s, expected size 23 ETag %s", props.Size, props.ETag, wantETag)
+			}
+		})
+	}
+}
+
+func TestLocalGetRanges(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	const contents = "0123456789abcdef"
+	obj := makePointer("ranges")
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+
+	readers, err := a.GetRanges(ctx, obj, []block.Range{{Start: 2, End: 4}, {Start: 10, End: 15}})
+	testutil.MustDo(t, "GetRanges", err)
+	var got []string
+	for _, reader := range readers {
+		data, err := ioutil.ReadAll(reader)
+		testutil.MustDo(t, "read range", err)
+		testutil.MustDo(t, "close range", reader.Close())
+		got = append(got, string(data))
+	}
+	if diff := deep.Equal(got, []string{"234", "abcdef"}); diff != nil {
+		t.Errorf("GetRanges read unexpected data: %s", diff)
+	}
+
+	invalid := map[string][]block.Range{
+		"overlapping":  {{Start: 2, End: 6}, {Start: 5, End: 8}},
+		"out of order": {{Start: 10, End: 12}, {Start: 2, End: 4}},
+		"reversed":     {{Start: 4, End: 2}},
+		"empty":        {},
+	}
+	for name, ranges := range invalid {
+		if _, err := a.GetRanges(ctx, obj, ranges); !errors.Is(err, block.ErrInvalidRange) {
+			t.Errorf("GetRanges with %s ranges returned %v, expected %s", name, err, block.ErrInvalidRange)
+		}
+	}
+}
+
+func TestLocalGetRange(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	const contents = "0123456789abcdef"
+	obj := makePointer("range")
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+
+	tests := []struct {
+		name       string
+		start, end int64
+		want       string
+	}{
+		{name: "finite", start: 2, end: 4, want: "234"},
+		{name: "to end", start: 10, end: block.RangeToEnd, want: "abcdef"},
+		{name: "whole", start: 0, end: block.RangeToEnd, want: contents},
+		{name: "past end", start: 14, end: 100, want: "ef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := a.GetRange(ctx, obj, tt.start, tt.end)
+			testutil.MustDo(t, "GetRange", err)
+			data, err := ioutil.ReadAll(reader)
+			testutil.MustDo(t, "read range", err)
+			testutil.MustDo(t, "close range", reader.Close())
+			if string(data) != tt.want {
+				t.Errorf("GetRange(%d, %d) read %q, expected %q", tt.start, tt.end, data, tt.want)
+			}
+		})
+	}
+
+	for _, r := range []block.Range{{Start: -1, End: 4}, {Start: 4, End: 2}, {Start: 4, End: -2}} {
+		if _, err := a.GetRange(ctx, obj, r.Start, r.End); !errors.Is(err, block.ErrInvalidRange) {
+			t.Errorf("GetRange(%d, %d) returned %v, expected %s", r.Start, r.End, err, block.ErrInvalidRange)
+		}
+	}
+}
+
+func TestLocalRemovePrefix(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	objects := []block.ObjectPointer{
+		makePointer("data/a"),
+		makePointer("data/b/c"),
+		makePointer("data/b/d"),
+		makePointer("database"),
+		makePointer("other/e"),
+		{StorageNamespace: "local://test2", Identifier: "data/f"},
+	}
+	for _, obj := range objects {
+		testutil.MustDo(t, "Put "+obj.Identifier, a.Put(ctx, obj, 4, strings.NewReader("data"), block.PutOpts{}))
+	}
+
+	removed, err := a.RemovePrefix(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace, Prefix: "data/"})
+	testutil.MustDo(t, "RemovePrefix", err)
+	if removed != 3 {
+		t.Errorf("RemovePrefix removed %d objects, expected 3", removed)
+	}
+	for i, obj := range objects {
+		exists, err := a.Exists(ctx, obj)
+		testutil.MustDo(t, "Exists "+obj.Identifier, err)
+		if wantExists := i >= 3; exists != wantExists {
+			t.Errorf("%s exists: %t, expected %t", obj.Identifier, exists, wantExists)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(a.Path(), "test", "data")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("emptied prefix directory not removed: %v", err)
+	}
+
+	if _, err := a.RemovePrefix(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace, Prefix: "../../"}); !errors.Is(err, local.ErrBadPath) {
+		t.Errorf("RemovePrefix outside storage root returned %v, expected %s", err, local.ErrBadPath)
+	}
+}
+
+// slowReader returns an endless stream of zeros, slowly.
+type slowReader struct{}
+
+func (slowReader) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestLocalPutDeadline(t *testing.T) {
+	a := makeAdapter(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	obj := makePointer("slow/upload")
+
+	err := a.Put(ctx, obj, -1, slowReader{}, block.PutOpts{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Put past deadline returned %v, expected %s", err, context.DeadlineExceeded)
+	}
+	if _, err := os.Stat(filepath.Join(a.Path(), "test", "slow", "upload")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("partial file not removed after aborted Put: %v", err)
+	}
+}
+
+func TestLocalHash(t *testing.T) {
+	ctx := context.Background()
+	const contents = "hashed contents"
+	parts := []string{"first part ", "second part"}
+	hexHash := func(newHash func() hash.Hash, data []byte) string {
+		h := newHash()
+		_, _ = h.Write(data)
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	tests := []struct {
+		name     string
+		opts     []func(a *local.Adapter)
+		newHash  func() hash.Hash
+		wantETag string
+	}{
+		{name: "default", newHash: md5.New, wantETag: "9e8f5a490280475e879f799e7684d94c"},
+		{name: "md5", opts: []func(a *local.Adapter){local.WithHash(md5.New)}, newHash: md5.New, wantETag: "9e8f5a490280475e879f799e7684d94c"},
+		{name: "sha256", opts: []func(a *local.Adapter){local.WithHash(sha256.New)}, newHash: sha256.New, wantETag: "f38273256d1ffa2caa8ce6f8ac479db26743bafa3e7f4c60241c5c0e75cb0ac9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := makeAdapter(t, append(tt.opts, local.WithMinPartSize(1))...)
+			obj := makePointer("hashed")
+			testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+			props, err := a.Stat(ctx, obj)
+			testutil.MustDo(t, "Stat", err)
+			if props.ETag != tt.wantETag {
+				t.Errorf("Stat() ETag = %s, expected %s", props.ETag, tt.wantETag)
+			}
+			reader, err := a.Get(ctx, obj, 0)
+			testutil.MustDo(t, "Get", err)
+			got, err := ioutil.ReadAll(reader)
+			_ = reader.Close()
+			testutil.MustDo(t, "read", err)
+			if string(got) != contents {
+				t.Errorf("got %q, expected %q", got, contents)
+			}
+
+			// multipart ETag is the hash of the concatenated part hashes, suffixed by the number of parts
+			multipartObj := makePointer("hashed-multipart")
+			uploadID, err := a.CreateMultiPartUpload(ctx, multipartObj, nil, block.CreateMultiPartUploadOpts{})
+			testutil.MustDo(t, "CreateMultiPartUpload", err)
+			var completed []*s3.CompletedPart
+			var partHashes []byte
+			for i, part := range parts {
+				etag, err := a.UploadPart(ctx, multipartObj, int64(len(part)), strings.NewReader(part), uploadID, int64(i+1))
+				testutil.MustDo(t, "UploadPart", err)
+				if want := `"` + hexHash(tt.newHash, []byte(part)) + `"`; etag != want {
+					t.Errorf("UploadPart() ETag = %s, expected %s", etag, want)
+				}
+				completed = append(completed, &s3.CompletedPart{ETag: aws.String(etag), PartNumber: aws.Int64(int64(i + 1))})
+				h := tt.newHash()
+				_, _ = h.Write([]byte(part))
+				partHashes = append(partHashes, h.Sum(nil)...)
+			}
+			etag, _, err := a.CompleteMultiPartUpload(ctx, multipartObj, uploadID, &block.MultipartUploadCompletion{Part: completed})
+			testutil.MustDo(t, "CompleteMultiPartUpload", err)
+			if want := hexHash(tt.newHash, partHashes) + "-2"; *etag != want {
+				t.Errorf("CompleteMultiPartUpload() ETag = %s, expected %s", *etag, want)
+			}
+		})
+	}
+}
+
+func TestLocalErrorWrapping(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+
+	cases := []struct {
+		name     string
+		op       func() error
+		expected []error
+		contains string
+	}{
+		{
+			name: "get missing",
+			op: func() error {
+				_, err := a.Get(ctx, makePointer("missing"), 0)
+				return err
+			},
+			expected: []error{os.ErrNotExist, block.ErrDataNotFound},
+			contains: "get missing",
+		},
+		{
+			name: "get range missing",
+			op: func() error {
+				_, err := a.GetRange(ctx, makePointer("missing"), 0, 1)
+				return err
+			},
+			expected: []error{os.ErrNotExist, block.ErrDataNotFound},
+			contains: "get range missing",
+		},
+		{
+			name: "get properties missing",
+			op: func() error {
+				_, err := a.GetProperties(ctx, makePointer("missing"))
+				return err
+			},
+			expected: []error{os.ErrNotExist, block.ErrDataNotFound},
+			contains: "get properties missing",
+		},
+		{
+			name: "stat missing",
+			op: func() error {
+				_, err := a.Stat(ctx, makePointer("nested/missing"))
+				return err
+			},
+			expected: []error{os.ErrNotExist, block.ErrDataNotFound},
+			contains: "stat nested/missing",
+		},
+		{
+			name: "touch missing",
+			op: func() error {
+				return a.Touch(ctx, makePointer("missing"), time.Now())
+			},
+			expected: []error{os.ErrNotExist, block.ErrDataNotFound},
+			contains: "touch missing",
+		},
+		{
+			name: "restore missing from trash",
+			op: func() error {
+				return a.RestoreFromTrash(ctx, makePointer("missing"))
+			},
+			expected: []error{os.ErrNotExist, block.ErrDataNotFound},
+			contains: "not in trash",
+		},
+		{
+			name: "repair missing",
+			op: func() error {
+				return a.RepairTruncated(ctx, makePointer("missing"), 1)
+			},
+			expected: []error{os.ErrNotExist, block.ErrDataNotFound},
+			contains: "repair truncated missing",
+		},
+		{
+			name: "put outside storage",
+			op: func() error {
+				return a.Put(ctx, makePointer("../../escape"), 3, strings.NewReader("bad"), block.PutOpts{})
+			},
+			expected: []error{local.ErrBadPath},
+			contains: "put ../../escape",
+		},
+		{
+			name: "abort invalid upload",
+			op: func() error {
+				return a.AbortMultiPartUpload(ctx, makePointer("upload"), "not-hex")
+			},
+			expected: []error{local.ErrInvalidUploadIDFormat},
+			contains: "abort multipart upload upload",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.op()
+			for _, expected := range c.expected {
+				if !errors.Is(err, expected) {
+					t.Errorf("got error %v, expected it to wrap %s", err, expected)
+				}
+			}
+			if err == nil || !strings.Contains(err.Error(), c.contains) {
+				t.Errorf("error %v does not mention %q", err, c.contains)
+			}
+		})
+	}
+}
+
+func TestLocalPathLayout(t *testing.T) {
+	ctx := context.Background()
+	shard := func(key string) string {
+		sum := sha256.Sum256([]byte(key))
+		digest := hex.EncodeToString(sum[:])
+		return filepath.Join(digest[:2], digest[2:4])
+	}
+	cases := []struct {
+		layout   local.PathLayout
+		expected func(key string) string
+	}{
+		{layout: local.PathLayoutFlat, expected: func(key string) string { return key }},
+		{layout: local.PathLayoutSharded, expected: func(key string) string { return filepath.Join(shard(key), key) }},
+	}
+	for _, c := range cases {
+		t.Run(string(c.layout), func(t *testing.T) {
+			a := makeAdapter(t, local.WithPathLayout(c.layout), local.WithMinPartSize(1))
+			read := func(key string) string {
+				reader, err := a.Get(ctx, makePointer(key), 0)
+				testutil.MustDo(t, "Get "+key, err)
+				defer func() { _ = reader.Close() }()
+				got, err := ioutil.ReadAll(reader)
+				testutil.MustDo(t, "ReadAll "+key, err)
+				return string(got)
+			}
+
+			testutil.MustDo(t, "Put", a.Put(ctx, makePointer("dir/put"), 4, strings.NewReader("data"), block.PutOpts{}))
+			pointer := makePointer("dir/multipart")
+			uploadID, err := a.CreateMultiPartUpload(ctx, pointer, nil, block.CreateMultiPartUploadOpts{})
+			testutil.MustDo(t, "CreateMultiPartUpload", err)
+			var parts []*s3.CompletedPart
+			for i, content := range []string{"one ", "two"} {
+				partNumber := int64(i + 1)
+				etag, err := a.UploadPart(ctx, pointer, 0, strings.NewReader(content), uploadID, partNumber)
+				testutil.MustDo(t, "UploadPart", err)
+				parts = append(parts, &s3.CompletedPart{ETag: aws.String(etag), PartNumber: aws.Int64(partNumber)})
+			}
+			_, _, err = a.CompleteMultiPartUpload(ctx, pointer, uploadID, &block.MultipartUploadCompletion{Part: parts})
+			testutil.MustDo(t, "CompleteMultiPartUpload", err)
+
+			for key, contents := range map[string]string{"dir/put": "data", "dir/multipart": "one two"} {
+				onDisk, err := ioutil.ReadFile(filepath.Join(a.Path(), "test", c.expected(key)))
+				testutil.MustDo(t, "ReadFile "+key, err)
+				if string(onDisk) != contents {
+					t.Errorf("%s on disk contains %q, expected %q", key, onDisk, contents)
+				}
+				if got := read(key); got != contents {
+					t.Errorf("Get %s returned %q, expected %q", key, got, contents)
+				}
+			}
+
+			removed, err := a.RemovePrefix(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace, Prefix: "dir/"})
+			testutil.MustDo(t, "RemovePrefix", err)
+			if removed != 2 {
+				t.Errorf("RemovePrefix removed %d objects, expected 2", removed)
+			}
+		})
+	}
+}
+
+func TestLocalImmutable(t *testing.T) {
+	ctx := context.Background()
+	const retention = 200 * time.Millisecond
+	a := makeAdapter(t, local.WithImmutable(retention))
+	obj := makePointer("worm/object")
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, 4, strings.NewReader("data"), block.PutOpts{}))
+
+	if err := a.Put(ctx, obj, 5, strings.NewReader("other"), block.PutOpts{}); !errors.Is(err, local.ErrObjectExists) {
+		t.Errorf("Put over existing object returned %v, expected %s", err, local.ErrObjectExists)
+	}
+	testutil.MustDo(t, "Put copy source", a.Put(ctx, makePointer("source"), 6, strings.NewReader("source"), block.PutOpts{}))
+	if err := a.Copy(ctx, makePointer("source"), obj); !errors.Is(err, local.ErrObjectExists) {
+		t.Errorf("Copy over existing object returned %v, expected %s", err, local.ErrObjectExists)
+	}
+	reader, err := a.Get(ctx, obj, 0)
+	testutil.MustDo(t, "Get", err)
+	got, err := ioutil.ReadAll(reader)
+	testutil.MustDo(t, "ReadAll", err)
+	_ = reader.Close()
+	if string(got) != "data" {
+		t.Errorf("object contains %q after rejected overwrites, expected %q", got, "data")
+	}
+
+	if err := a.Remove(ctx, obj); !errors.Is(err, local.ErrRetentionNotExpired) {
+		t.Errorf("Remove within retention returned %v, expected %s", err, local.ErrRetentionNotExpired)
+	}
+	time.Sleep(retention)
+	testutil.MustDo(t, "Remove after retention", a.Remove(ctx, obj))
+	exists, err := a.Exists(ctx, obj)
+	testutil.MustDo(t, "Exists", err)
+	if exists {
+		t.Error("object exists after removal past its retention")
+	}
+	testutil.MustDo(t, "Put after removal", a.Put(ctx, obj, 5, strings.NewReader("fresh"), block.PutOpts{}))
+}
+
+func TestLocalConcurrentPutGet(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	obj := makePointer("contended")
+	const (
+		size       = 64 * 1024
+		writers    = 4
+		readers    = 4
+		iterations = 20
+	)
+	contents := func(i int) string {
+		return strings.Repeat(string(rune('a'+i)), size)
+	}
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, size, strings.NewReader(contents(0)), block.PutOpts{}))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, (writers+readers)*iterations)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				if err := a.Put(ctx, obj, size, strings.NewReader(contents(w)), block.PutOpts{}); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				reader, err := a.Get(ctx, obj, 0)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				got, err := ioutil.ReadAll(reader)
+				_ = reader.Close()
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if len(got) != size || strings.Count(string(got), string(got[:1])) != size {
+					errs <- fmt.Errorf("%w: read %d bytes of mixed contents", errPartialRead, len(got))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// collidingKey returns a key other than key whose object shares its lock stripe, computed as
+// the adapter hashes object paths.
+func collidingKey(t *testing.T, a *local.Adapter, key string) string {
+	t.Helper()
+	const lockStripes = 256
+	stripe := func(k string) uint32 {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(filepath.Join(a.Path(), "test", k)))
+		return h.Sum32() % lockStripes
+	}
+	for i := 0; i < 100*lockStripes; i++ {
+		other := fmt.Sprintf("colliding/%d", i)
+		if stripe(other) == stripe(key) {
+			return other
+		}
+	}
+	t.Fatalf("no key shares the lock stripe of %s", key)
+	return ""
+}
+
+func TestLocalGetThenPut(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	obj := makePointer("read/then/write")
+	other := makePointer(collidingKey(t, a, obj.Identifier))
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, 3, strings.NewReader("old"), block.PutOpts{}))
+
+	reader, err := a.Get(ctx, obj, 0)
+	testutil.MustDo(t, "Get", err)
+	defer func() {
+		_ = reader.Close()
+	}()
+	done := make(chan error, 1)
+	go func() {
+		if err := a.Put(ctx, other, 5, strings.NewReader("other"), block.PutOpts{}); err != nil {
+			done <- err
+			return
+		}
+		done <- a.Put(ctx, obj, 3, strings.NewReader("new"), block.PutOpts{})
+	}()
+	select {
+	case err := <-done:
+		testutil.MustDo(t, "Put while reading", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Put blocked by an open reader")
+	}
+
+	got, err := ioutil.ReadAll(reader)
+	testutil.MustDo(t, "ReadAll", err)
+	if string(got) != "old" {
+		t.Errorf("open reader read %q, expected the contents when opened", got)
+	}
+	reader, err = a.Get(ctx, obj, 0)
+	testutil.MustDo(t, "Get", err)
+	got, err = ioutil.ReadAll(reader)
+	testutil.MustDo(t, "ReadAll", err)
+	if string(got) != "new" {
+		t.Errorf("read %q after Put, expected \"new\"", got)
+	}
+}
+
+func TestLocalConcurrentCompleteMultiPartUpload(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t, local.WithMinPartSize(1))
+	obj := makePointer("completed/twice")
+	const uploads = 2
+	contents := make([]string, uploads)
+	uploadIDs := make([]string, uploads)
+	completions := make([]*block.MultipartUploadCompletion, uploads)
+	for i := range uploadIDs {
+		uploadID, err := a.CreateMultiPartUpload(ctx, obj, nil, block.CreateMultiPartUploadOpts{})
+		testutil.MustDo(t, "CreateMultiPartUpload", err)
+		uploadIDs[i] = uploadID
+		completions[i] = &block.MultipartUploadCompletion{}
+		for partNumber := int64(1); partNumber <= 3; partNumber++ {
+			part := strings.Repeat(string(rune('a'+i)), 1024)
+			contents[i] += part
+			etag, err := a.UploadPart(ctx, obj, 0, strings.NewReader(part), uploadID, partNumber)
+			testutil.MustDo(t, "UploadPart", err)
+			completions[i].Part = append(completions[i].Part, &s3.CompletedPart{ETag: aws.String(etag), PartNumber: aws.Int64(partNumber)})
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, uploads)
+	for i := range uploadIDs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, errs[i] = a.CompleteMultiPartUpload(ctx, obj, uploadIDs[i], completions[i])
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		testutil.MustDo(t, fmt.Sprintf("CompleteMultiPartUpload %d", i), err)
+	}
+
+	reader, err := a.Get(ctx, obj, 0)
+	testutil.MustDo(t, "Get", err)
+	got, err := ioutil.ReadAll(reader)
+	testutil.MustDo(t, "ReadAll", err)
+	_ = reader.Close()
+	if string(got) != contents[0] && string(got) != contents[1] {
+		t.Errorf("completed object holds %d bytes of mixed contents, expected one upload", len(got))
+	}
+}
+
+// TestLocalReflink verifies contents whether or not the filesystem running the test supports
+// reflinks, as the adapter falls back to copying bytes.
+func TestLocalReflink(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t, local.WithReflink(), local.WithMinPartSize(1))
+	read := func(obj block.ObjectPointer) string {
+		reader, err := a.Get(ctx, obj, 0)
+		testutil.MustDo(t, "Get "+obj.Identifier, err)
+		defer func() { _ = reader.Close() }()
+		got, err := ioutil.ReadAll(reader)
+		testutil.MustDo(t, "ReadAll "+obj.Identifier, err)
+		return string(got)
+	}
+
+	source := makePointer("reflink/source")
+	contents := strings.Repeat("0123456789", 10000)
+	testutil.MustDo(t, "Put", a.Put(ctx, source, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+	dest := makePointer("reflink/copy")
+	testutil.MustDo(t, "Copy", a.Copy(ctx, source, dest))
+	if got := read(dest); got != contents {
+		t.Errorf("copy holds %d bytes, expected the %d bytes of its source", len(got), len(contents))
+	}
+	sourceProps, err := a.Stat(ctx, source)
+	testutil.MustDo(t, "Stat source", err)
+	destProps, err := a.Stat(ctx, dest)
+	testutil.MustDo(t, "Stat copy", err)
+	if destProps.ETag != sourceProps.ETag {
+		t.Errorf("copy ETag %s differs from source ETag %s", destProps.ETag, sourceProps.ETag)
+	}
+
+	multipart := makePointer("reflink/multipart")
+	uploadID, err := a.CreateMultiPartUpload(ctx, multipart, nil, block.CreateMultiPartUploadOpts{})
+	testutil.MustDo(t, "CreateMultiPartUpload", err)
+	var parts []*s3.CompletedPart
+	var expected string
+	for partNumber := int64(1); partNumber <= 3; partNumber++ {
+		part := strings.Repeat(string(rune('a'+partNumber)), 5000+int(partNumber))
+		expected += part
+		etag, err := a.UploadPart(ctx, multipart, 0, strings.NewReader(part), uploadID, partNumber)
+		testutil.MustDo(t, "UploadPart", err)
+		parts = append(parts, &s3.CompletedPart{ETag: aws.String(etag), PartNumber: aws.Int64(partNumber)})
+	}
+	_, size, err := a.CompleteMultiPartUpload(ctx, multipart, uploadID, &block.MultipartUploadCompletion{Part: parts})
+	testutil.MustDo(t, "CompleteMultiPartUpload", err)
+	if size != int64(len(expected)) {
+		t.Errorf("completed upload size %d, expected %d", size, len(expected))
+	}
+	if got := read(multipart); got != expected {
+		t.Errorf("completed upload holds %d bytes, expected its %d bytes of parts in order", len(got), len(expected))
+	}
+}
+
+func TestLocalStorageClass(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var tierer block.Tierer = a
+	storageClass := func(obj block.ObjectPointer) *string {
+		props, err := a.Stat(ctx, obj)
+		testutil.MustDo(t, "Stat "+obj.Identifier, err)
+		properties, err := a.GetProperties(ctx, obj)
+		testutil.MustDo(t, "GetProperties "+obj.Identifier, err)
+		if diff := deep.Equal(props.StorageClass, properties.StorageClass); diff != nil {
+			t.Errorf("Stat and GetProperties disagree on storage class: %s", diff)
+		}
+		return props.StorageClass
+	}
+
+	obj := makePointer("tiered")
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, 4, strings.NewReader("cold"), block.PutOpts{StorageClass: aws.String("STANDARD_IA")}))
+	if got := storageClass(obj); got == nil || *got != "STANDARD_IA" {
+		t.Errorf("storage class after Put = %v, expected STANDARD_IA", got)
+	}
+
+	testutil.MustDo(t, "SetStorageClass", tierer.SetStorageClass(ctx, obj, "GLACIER"))
+	if got := storageClass(obj); got == nil || *got != "GLACIER" {
+		t.Errorf("storage class after SetStorageClass = %v, expected GLACIER", got)
+	}
+
+	testutil.MustDo(t, "Put again", a.Put(ctx, obj, 3, strings.NewReader("hot"), block.PutOpts{}))
+	if got := storageClass(obj); got != nil {
+		t.Errorf("storage class after Put without class = %s, expected none", *got)
+	}
+
+	if err := tierer.SetStorageClass(ctx, makePointer("missing"), "GLACIER"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("SetStorageClass on missing object returned %v, expected %s", err, os.ErrNotExist)
+	}
+}
+
+func TestLocalWalkPage(t *testing.T) {
+	layouts := []local.PathLayout{local.PathLayoutFlat, local.PathLayoutSharded}
+	for _, layout := range layouts {
+		t.Run(string(layout), func(t *testing.T) {
+			ctx := context.Background()
+			a := makeAdapter(t, local.WithPathLayout(layout))
+			keys := []string{"pages/e", "pages/a", "pages/c/d", "pages/b", "pages-other", "other/x"}
+			for _, key := range keys {
+				testutil.MustDo(t, "Put "+key, a.Put(ctx, makePointer(key), 1, strings.NewReader("x"), block.PutOpts{}))
+			}
+			var pager block.WalkPager = a
+			walkOpts := block.WalkOpts{StorageNamespace: testStorageNamespace, Prefix: "pages/"}
+
+			pages := []struct {
+				after         string
+				wantKeys      []string
+				wantNextAfter string
+			}{
+				{after: "", wantKeys: []string{"pages/a", "pages/b"}, wantNextAfter: "pages/b"},
+				{after: "pages/a", wantKeys: []string{"pages/b", "pages/c/d"}, wantNextAfter: "pages/c/d"},
+				{after: "pages/b", wantKeys: []string{"pages/c/d", "pages/e"}, wantNextAfter: ""},
+				{after: "pages/c", wantKeys: []string{"pages/c/d", "pages/e"}, wantNextAfter: ""},
+				{after: "pages/c/d", wantKeys: []string{"pages/e"}, wantNextAfter: ""},
+				{after: "pages/e", wantKeys: []string{}, wantNextAfter: ""},
+			}
+			for _, page := range pages {
+				gotKeys, gotNextAfter, err := pager.WalkPage(ctx, walkOpts, page.after, 2)
+				testutil.MustDo(t, "WalkPage after "+page.after, err)
+				if diff := deep.Equal(gotKeys, page.wantKeys); diff != nil {
+					t.Errorf("WalkPage after %q returned unexpected keys: %s", page.after, diff)
+				}
+				if gotNextAfter != page.wantNextAfter {
+					t.Errorf("WalkPage after %q returned next %q, expected %q", page.after, gotNextAfter, page.wantNextAfter)
+				}
+			}
+
+			if _, _, err := pager.WalkPage(ctx, walkOpts, "", 0); !errors.Is(err, block.ErrInvalidLimit) {
+				t.Errorf("WalkPage with limit 0 returned %v, expected %s", err, block.ErrInvalidLimit)
+			}
+			gotKeys, _, err := pager.WalkPage(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace, Prefix: "missing/"}, "", 2)
+			testutil.MustDo(t, "WalkPage missing prefix", err)
+			if len(gotKeys) != 0 {
+				t.Errorf("WalkPage on missing prefix returned %v", gotKeys)
+			}
+		})
+	}
+}
+
+func TestLocalIdentifierIsDirectory(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	testutil.MustDo(t, "Put nested", a.Put(ctx, makePointer("parent/child"), 5, strings.NewReader("child"), block.PutOpts{}))
+
+	parent := makePointer("parent")
+	if err := a.Put(ctx, parent, 6, strings.NewReader("parent"), block.PutOpts{}); !errors.Is(err, local.ErrIdentifierIsDirectory) {
+		t.Errorf("Put on directory returned %v, expected %s", err, local.ErrIdentifierIsDirectory)
+	}
+	if err := a.Copy(ctx, makePointer("parent/child"), parent); !errors.Is(err, local.ErrIdentifierIsDirectory) {
+		t.Errorf("Copy onto directory returned %v, expected %s", err, local.ErrIdentifierIsDirectory)
+	}
+	if _, err := a.Append(ctx, parent, strings.NewReader("parent")); !errors.Is(err, local.ErrIdentifierIsDirectory) {
+		t.Errorf("Append to directory returned %v, expected %s", err, local.ErrIdentifierIsDirectory)
+	}
+
+	reader, err := a.Get(ctx, makePointer("parent/child"), 0)
+	testutil.MustDo(t, "Get nested", err)
+	defer func() { _ = reader.Close() }()
+	got, err := ioutil.ReadAll(reader)
+	testutil.MustDo(t, "read nested", err)
+	if string(got) != "child" {
+		t.Errorf("nested object holds %q after failed writes to its directory, expected %q", got, "child")
+	}
+}
+
+func TestLocalGetPrefetch(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	contents := strings.Repeat("0123456789", 50000)
+	obj := makePointer("prefetch")
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+
+	var prefetcher block.Prefetcher = a
+	reader, err := prefetcher.GetPrefetch(ctx, obj, 64*1024)
+	testutil.MustDo(t, "GetPrefetch", err)
+	got, err := ioutil.ReadAll(reader)
+	testutil.MustDo(t, "ReadAll", err)
+	testutil.MustDo(t, "Close", reader.Close())
+	if string(got) != contents {
+		t.Errorf("GetPrefetch read %d bytes differing from the %d bytes stored", len(got), len(contents))
+	}
+
+	// the read lock is released on Close, so the object can be written again
+	testutil.MustDo(t, "Put again", a.Put(ctx, obj, 3, strings.NewReader("new"), block.PutOpts{}))
+
+	if _, err := prefetcher.GetPrefetch(ctx, makePointer("missing"), 1024); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("GetPrefetch on missing object returned %v, expected %s", err, os.ErrNotExist)
+	}
+}
+
+func TestLocalValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		breakFn func(t *testing.T, dir string)
+		wantErr error
+	}{
+		{name: "ok", breakFn: func(*testing.T, string) {}},
+		{
+			name: "missing",
+			breakFn: func(t *testing.T, dir string) {
+				testutil.MustDo(t, "remove dir", os.RemoveAll(dir))
+			},
+			wantErr: local.ErrPathNotExist,
+		},
+		{
+			name: "not a directory",
+			breakFn: func(t *testing.T, dir string) {
+				testutil.MustDo(t, "remove dir", os.RemoveAll(dir))
+				testutil.MustDo(t, "write file", ioutil.WriteFile(dir, []byte("file"), 0600))
+			},
+			wantErr: local.ErrPathNotDirectory,
+		},
+		{
+			name: "not writable",
+			breakFn: func(t *testing.T, dir string) {
+				if os.Geteuid() == 0 {
+					t.Skip("root can write to any directory")
+				}
+				testutil.MustDo(t, "chmod", os.Chmod(dir, 0500))
+				t.Cleanup(func() { _ = os.Chmod(dir, 0700) })
+			},
+			wantErr: local.ErrPathNotWritable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "blockstore")
+			a, err := local.NewAdapter(dir)
+			testutil.MustDo(t, "NewAdapter", err)
+			tt.breakFn(t, dir)
+
+			err = a.Validate()
+			if tt.wantErr == nil {
+				testutil.MustDo(t, "Validate", err)
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate returned %v, expected %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLocalGetChunked(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	obj := makePointer("chunked")
+	contents := "0123456789abcdefghij" + "xyz"
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+
+	t.Run("boundaries", func(t *testing.T) {
+		var chunks []string
+		err := a.GetChunked(ctx, obj, 10, func(chunk []byte) error {
+			chunks = append(chunks, string(chunk))
+			return nil
+		})
+		testutil.MustDo(t, "GetChunked", err)
+		if diffs := deep.Equal(chunks, []string{"0123456789", "abcdefghij", "xyz"}); diffs != nil {
+			t.Errorf("unexpected chunks: %s", diffs)
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := 0
+		err := a.GetChunked(ctx, obj, 10, func(chunk []byte) error {
+			calls++
+			return errStop
+		})
+		if !errors.Is(err, errStop) {
+			t.Errorf("GetChunked returned %v, expected the callback error %s", err, errStop)
+		}
+		if calls != 1 {
+			t.Errorf("callback called %d times, expected reading to stop after the first", calls)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		calls := 0
+		err := a.GetChunked(ctx, obj, 10, func(chunk []byte) error {
+			calls++
+			cancel()
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("GetChunked returned %v, expected %s", err, context.Canceled)
+		}
+		if calls != 1 {
+			t.Errorf("callback called %d times, expected reading to stop after cancellation", calls)
+		}
+	})
+
+	t.Run("invalid chunk size", func(t *testing.T) {
+		err := a.GetChunked(ctx, obj, 0, func([]byte) error { return nil })
+		if !errors.Is(err, local.ErrInvalidChunkSize) {
+			t.Errorf("GetChunked returned %v, expected %s", err, local.ErrInvalidChunkSize)
+		}
+	})
+}
+
+func TestLocalRemoveIf(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var remover block.ConditionalRemover = a
+	obj := makePointer("conditional")
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, 4, strings.NewReader("data"), block.PutOpts{}))
+	props, err := a.Stat(ctx, obj)
+	testutil.MustDo(t, "Stat", err)
+
+	removed, err := remover.RemoveIf(ctx, obj, "not-the-etag")
+	testutil.MustDo(t, "RemoveIf mismatching", err)
+	if removed {
+		t.Error("RemoveIf with a mismatching ETag removed the object")
+	}
+	if exists, err := a.Exists(ctx, obj); err != nil || !exists {
+		t.Errorf("object exists=%t (err %v) after RemoveIf with a mismatching ETag, expected it to remain", exists, err)
+	}
+
+	// ETags may be quoted, as in HTTP headers
+	removed, err = remover.RemoveIf(ctx, obj, `"`+props.ETag+`"`)
+	testutil.MustDo(t, "RemoveIf matching", err)
+	if !removed {
+		t.Error("RemoveIf with the matching ETag did not remove the object")
+	}
+	if exists, err := a.Exists(ctx, obj); err != nil || exists {
+		t.Errorf("object exists=%t (err %v) after RemoveIf with the matching ETag, expected it removed", exists, err)
+	}
+
+	removed, err = remover.RemoveIf(ctx, obj, props.ETag)
+	if removed || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("RemoveIf on a missing object returned %t, %v, expected %s", removed, err, os.ErrNotExist)
+	}
+}
+
+func TestLocalPutWithProgress(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var putter block.ProgressPutter = a
+	const size = 3*block.ProgressBytesInterval + 1234
+	contents := bytes.Repeat([]byte("x"), size)
+
+	var reports []int64
+	err := putter.PutWithProgress(ctx, makePointer("progress"), size, bytes.NewReader(contents), block.PutOpts{}, func(written int64) {
+		reports = append(reports, written)
+	})
+	testutil.MustDo(t, "PutWithProgress", err)
+	if len(reports) < size/block.ProgressBytesInterval {
+		t.Errorf("got %d progress reports %v, expected at least one per %d bytes", len(reports), reports, block.ProgressBytesInterval)
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i] <= reports[i-1] {
+			t.Errorf("progress report %d of %d bytes follows %d bytes, expected increasing totals", i, reports[i], reports[i-1])
+		}
+	}
+	if len(reports) == 0 || reports[len(reports)-1] != size {
+		t.Errorf("progress reports %v do not end at the object size %d", reports, size)
+	}
+}
+
+func TestLocalPurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var expirer block.Expirer = a
+	put := func(id string) {
+		testutil.MustDo(t, "Put "+id, a.Put(ctx, makePointer(id), 4, strings.NewReader("data"), block.PutOpts{}))
+	}
+	putTemp := func(id string, ttl time.Duration) {
+		testutil.MustDo(t, "PutTemp "+id, expirer.PutTemp(ctx, makePointer(id), 4, strings.NewReader("data"), ttl))
+	}
+	put("permanent")
+	putTemp("scratch/expired", time.Millisecond)
+	putTemp("scratch/live", time.Hour)
+	putTemp("scratch/overwritten", time.Millisecond)
+	put("scratch/overwritten")
+	time.Sleep(10 * time.Millisecond)
+
+	purged, err := expirer.PurgeExpired(ctx, testStorageNamespace)
+	testutil.MustDo(t, "PurgeExpired", err)
+	if purged != 1 {
+		t.Errorf("PurgeExpired removed %d objects, expected 1", purged)
+	}
+	for id, expected := range map[string]bool{
+		"permanent":           true,
+		"scratch/expired":     false,
+		"scratch/live":        true,
+		"scratch/overwritten": true,
+	} {
+		if exists, err := a.Exists(ctx, makePointer(id)); err != nil || exists != expected {
+			t.Errorf("%s exists=%t (err %v) after PurgeExpired, expected %t", id, exists, err, expected)
+		}
+	}
+
+	if err := expirer.PutTemp(ctx, makePointer("no-ttl"), 4, strings.NewReader("data"), 0); !errors.Is(err, local.ErrInvalidTTL) {
+		t.Errorf("PutTemp with no TTL returned %v, expected %s", err, local.ErrInvalidTTL)
+	}
+}
+
+func TestLocalGetWithProperties(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var getter block.PropertiesGetter = a
+	obj := makePointer("with-properties")
+	const contents = "some contents"
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+	expected, err := a.Stat(ctx, obj)
+	testutil.MustDo(t, "Stat", err)
+
+	reader, props, err := getter.GetWithProperties(ctx, obj)
+	testutil.MustDo(t, "GetWithProperties", err)
+	defer func() {
+		_ = reader.Close()
+	}()
+	if diffs := deep.Equal(props, expected); diffs != nil {
+		t.Errorf("GetWithProperties returned properties different from Stat: %s", diffs)
+	}
+	data, err := ioutil.ReadAll(reader)
+	testutil.MustDo(t, "read", err)
+	if string(data) != contents || int64(len(data)) != props.Size {
+		t.Errorf("read %q of size %d, expected %q", data, props.Size, contents)
+	}
+
+	_, _, err = getter.GetWithProperties(ctx, makePointer("missing"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("GetWithProperties of a missing object returned %v, expected %s", err, os.ErrNotExist)
+	}
+}
+
+func TestLocalLegalHold(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var holder block.LegalHolder = a
+	obj := makePointer("held")
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, 4, strings.NewReader("data"), block.PutOpts{}))
+
+	testutil.MustDo(t, "SetLegalHold on", holder.SetLegalHold(ctx, obj, true))
+	if held, err := holder.GetLegalHold(ctx, obj); err != nil || !held {
+		t.Errorf("GetLegalHold returned %t, %v after placing a hold, expected true", held, err)
+	}
+	if err := a.Remove(ctx, obj); !errors.Is(err, local.ErrLegalHold) {
+		t.Errorf("Remove under a legal hold returned %v, expected %s", err, local.ErrLegalHold)
+	}
+	if err := a.Put(ctx, obj, 5, strings.NewReader("other"), block.PutOpts{}); !errors.Is(err, local.ErrLegalHold) {
+		t.Errorf("Put over an object under a legal hold returned %v, expected %s", err, local.ErrLegalHold)
+	}
+	if exists, err := a.Exists(ctx, obj); err != nil || !exists {
+		t.Fatalf("held object exists=%t (err %v), expected it to remain", exists, err)
+	}
+
+	testutil.MustDo(t, "SetLegalHold off", holder.SetLegalHold(ctx, obj, false))
+	if held, err := holder.GetLegalHold(ctx, obj); err != nil || held {
+		t.Errorf("GetLegalHold returned %t, %v after releasing the hold, expected false", held, err)
+	}
+	testutil.MustDo(t, "Remove released", a.Remove(ctx, obj))
+	if exists, err := a.Exists(ctx, obj); err != nil || exists {
+		t.Errorf("released object exists=%t (err %v) after Remove, expected it removed", exists, err)
+	}
+
+	if err := holder.SetLegalHold(ctx, makePointer("missing"), true); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("SetLegalHold on a missing object returned %v, expected %s", err, os.ErrNotExist)
+	}
+}
+
+// writeTempFile writes a temporary file of size bytes, and returns its path and contents.
+func writeTempFile(t testing.TB, size int) (string, []byte) {
+	t.Helper()
+	contents := make([]byte, size)
+	for i := range contents {
+		contents[i] = byte(i * 7)
+	}
+	p := filepath.Join(t.TempDir(), "source")
+	testutil.MustDo(t, "write source", ioutil.WriteFile(p, contents, 0600))
+	return p, contents
+}
+
+func TestLocalPutFile(t *testing.T) {
+	ctx := context.Background()
+	source, contents := writeTempFile(t, 3*1024*1024+17)
+	tests := []struct {
+		name string
+		opts []func(a *local.Adapter)
+	}{
+		{name: "copy file range"},
+		// deduplication reads the file to hash it
+		{name: "dedup", opts: []func(a *local.Adapter){local.WithDedup()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := makeAdapter(t, tt.opts...)
+			var putter block.FilePutter = a
+			obj := makePointer("from-file")
+			// overwrite an object with other contents, so its ETag must not be kept
+			testutil.MustDo(t, "Put", a.Put(ctx, obj, 5, strings.NewReader("other"), block.PutOpts{}))
+			testutil.MustDo(t, "PutFile", putter.PutFile(ctx, obj, source, block.PutOpts{}))
+
+			reader, err := a.Get(ctx, obj, 0)
+			testutil.MustDo(t, "Get", err)
+			data, err := ioutil.ReadAll(reader)
+			_ = reader.Close()
+			testutil.MustDo(t, "read", err)
+			if !bytes.Equal(data, contents) {
+				t.Errorf("read %d bytes differing from the %d bytes of the file", len(data), len(contents))
+			}
+
+			props, err := a.Stat(ctx, obj)
+			testutil.MustDo(t, "Stat", err)
+			other := makePointer("from-reader")
+			testutil.MustDo(t, "Put from reader", a.Put(ctx, other, int64(len(contents)), bytes.NewReader(contents), block.PutOpts{}))
+			expected, err := a.Stat(ctx, other)
+			testutil.MustDo(t, "Stat from reader", err)
+			if props.Size != expected.Size || props.ETag != expected.ETag {
+				t.Errorf("PutFile stored size %d ETag %s, expected size %d ETag %s as by Put", props.Size, props.ETag, expected.Size, expected.ETag)
+			}
+		})
+	}
+}
+
+func BenchmarkLocalPutFile(b *testing.B) {
+	ctx := context.Background()
+	const size = 64 * 1024 * 1024
+	source, _ := writeTempFile(b, size)
+	a := makeAdapter(b)
+	obj := makePointer("benchmark")
+	b.Run("PutFile", func(b *testing.B) {
+		b.SetBytes(size)
+		for i := 0; i < b.N; i++ {
+			testutil.MustDo(b, "PutFile", a.PutFile(ctx, obj, source, block.PutOpts{}))
+		}
+	})
+	b.Run("Put", func(b *testing.B) {
+		b.SetBytes(size)
+		for i := 0; i < b.N; i++ {
+			f, err := os.Open(source)
+			testutil.MustDo(b, "open", err)
+			err = a.Put(ctx, obj, size, f, block.PutOpts{})
+			_ = f.Close()
+			testutil.MustDo(b, "Put", err)
+		}
+	})
+}
+
+func TestLocalExistsBatch(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var checker block.BatchExistsChecker = a
+	for _, id := range []string{"present", "nested/present"} {
+		testutil.MustDo(t, "Put "+id, a.Put(ctx, makePointer(id), 4, strings.NewReader("data"), block.PutOpts{}))
+	}
+
+	exists, err := checker.ExistsBatch(ctx, testStorageNamespace, []string{"present", "absent", "nested/present", "nested/absent"})
+	testutil.MustDo(t, "ExistsBatch", err)
+	if diffs := deep.Equal(exists, map[string]bool{"present": true, "absent": false, "nested/present": true, "nested/absent": false}); diffs != nil {
+		t.Errorf("unexpected ExistsBatch result: %s", diffs)
+	}
+
+	exists, err = checker.ExistsBatch(ctx, testStorageNamespace, nil)
+	testutil.MustDo(t, "ExistsBatch empty", err)
+	if exists == nil || len(exists) != 0 {
+		t.Errorf("ExistsBatch with no identifiers returned %v, expected an empty map", exists)
+	}
+}
+
+func TestLocalTouch(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var toucher block.Toucher = a
+	obj := makePointer("touched")
+	storageClass := "COLD"
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, 4, strings.NewReader("data"), block.PutOpts{StorageClass: &storageClass}))
+
+	for _, modTime := range []time.Time{
+		time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		time.Now().Add(time.Hour).Truncate(time.Second),
+	} {
+		testutil.MustDo(t, "Touch", toucher.Touch(ctx, obj, modTime))
+		props, err := a.Stat(ctx, obj)
+		testutil.MustDo(t, "Stat", err)
+		if !props.LastModified.Equal(modTime) {
+			t.Errorf("Stat returned last modified %s after Touch, expected %s", props.LastModified, modTime)
+		}
+		if props.StorageClass == nil || *props.StorageClass != storageClass {
+			t.Errorf("Stat returned storage class %v after Touch, expected it kept as %s", props.StorageClass, storageClass)
+		}
+	}
+
+	if err := toucher.Touch(ctx, makePointer("missing"), time.Now()); !errors.Is(err, block.ErrDataNotFound) {
+		t.Errorf("Touch on a missing object returned %v, expected %s", err, block.ErrDataNotFound)
+	}
+
+	dedup := makeAdapter(t, local.WithDedup())
+	testutil.MustDo(t, "Put with dedup", dedup.Put(ctx, obj, 4, strings.NewReader("data"), block.PutOpts{}))
+	if err := dedup.Touch(ctx, obj, time.Now()); !errors.Is(err, block.ErrOperationNotSupported) {
+		t.Errorf("Touch with dedup returned %v, expected %s", err, block.ErrOperationNotSupported)
+	}
+}
+
+func TestLocalConcat(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var concatenator block.Concatenator = a
+	parts := map[string]string{"part-0": "first,", "dir/part-1": "second,", "part-2": "third"}
+	for id, data := range parts {
+		testutil.MustDo(t, "Put "+id, a.Put(ctx, makePointer(id), int64(len(data)), strings.NewReader(data), block.PutOpts{}))
+	}
+	sources := []string{"part-0", "dir/part-1", "part-2"}
+
+	size, err := concatenator.Concat(ctx, testStorageNamespace, "united", sources)
+	testutil.MustDo(t, "Concat", err)
+	const expected = "first,second,third"
+	if size != int64(len(expected)) {
+		t.Errorf("Concat returned size %d, expected %d", size, len(expected))
+	}
+	reader, err := a.Get(ctx, makePointer("united"), 0)
+	testutil.MustDo(t, "Get", err)
+	data, err := ioutil.ReadAll(reader)
+	_ = reader.Close()
+	testutil.MustDo(t, "read", err)
+	if string(data) != expected {
+		t.Errorf("concatenated object holds %q, expected %q", data, expected)
+	}
+	props, err := a.Stat(ctx, makePointer("united"))
+	testutil.MustDo(t, "Stat", err)
+	if props.Size != int64(len(expected)) {
+		t.Errorf("concatenated object has size %d, expected %d", props.Size, len(expected))
+	}
+
+	_, err = concatenator.Concat(ctx, testStorageNamespace, "partial", []string{"part-0", "missing"})
+	if !errors.Is(err, block.ErrDataNotFound) {
+		t.Errorf("Concat with a missing source returned %v, expected %s", err, block.ErrDataNotFound)
+	}
+	if exists, _ := a.Exists(ctx, makePointer("partial")); exists {
+		t.Error("Concat with a missing source wrote the destination")
+	}
+
+	_, err = concatenator.Concat(ctx, testStorageNamespace, "part-0", sources)
+	if !errors.Is(err, local.ErrConcatDestinationIsSource) {
+		t.Errorf("Concat into a source returned %v, expected %s", err, local.ErrConcatDestinationIsSource)
+	}
+}
+
+func TestLocalGetSeekable(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var getter block.SeekableGetter = a
+	obj := makePointer("parquet")
+	const contents = "PAR1 row groups footer PAR1"
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+
+	reader, err := getter.GetSeekable(ctx, obj)
+	testutil.MustDo(t, "GetSeekable", err)
+	defer func() {
+		_ = reader.Close()
+	}()
+	const magicSize = 4
+	offset, err := reader.Seek(-magicSize, io.SeekEnd)
+	testutil.MustDo(t, "seek to the end", err)
+	if offset != int64(len(contents)-magicSize) {
+		t.Errorf("seek to the end returned offset %d, expected %d", offset, len(contents)-magicSize)
+	}
+	tail, err := ioutil.ReadAll(reader)
+	testutil.MustDo(t, "read the end", err)
+	if string(tail) != "PAR1" {
+		t.Errorf("read %q at the end, expected PAR1", tail)
+	}
+
+	_, err = reader.Seek(0, io.SeekStart)
+	testutil.MustDo(t, "seek to the start", err)
+	all, err := ioutil.ReadAll(reader)
+	testutil.MustDo(t, "read from the start", err)
+	if string(all) != contents {
+		t.Errorf("read %q from the start, expected %q", all, contents)
+	}
+
+	if _, err := getter.GetSeekable(ctx, makePointer("missing")); err == nil {
+		t.Error("GetSeekable of a missing object succeeded")
+	}
+}
+
+func TestLocalRecoverUploads(t *testing.T) {
+	ctx := context.Background()
+	for _, layout := range []local.PathLayout{local.PathLayoutFlat, local.PathLayoutSharded} {
+		t.Run(string(layout), func(t *testing.T) {
+			a := makeAdapter(t, local.WithPathLayout(layout))
+			testutil.MustDo(t, "Put", a.Put(ctx, makePointer("dir/object"), 4, strings.NewReader("data"), block.PutOpts{}))
+			var expected []block.RecoveredUpload
+			for _, partSizes := range [][]int{{3, 5}, {7}} {
+				pointer := makePointer("dir/multipart")
+				uploadID, err := a.CreateMultiPartUpload(ctx, pointer, nil, block.CreateMultiPartUploadOpts{})
+				testutil.MustDo(t, "CreateMultiPartUpload", err)
+				upload := block.RecoveredUpload{UploadID: uploadID}
+				// upload the parts in reverse order, they are recovered ordered by part number
+				for i := len(partSizes) - 1; i >= 0; i-- {
+					partNumber := int64(i + 1)
+					content := strings.Repeat("x", partSizes[i])
+					_, err := a.UploadPart(ctx, pointer, int64(len(content)), strings.NewReader(content), uploadID, partNumber)
+					testutil.MustDo(t, "UploadPart", err)
+				}
+				for i, size := range partSizes {
+					upload.Parts = append(upload.Parts, block.RecoveredPart{PartNumber: int64(i + 1), SizeBytes: int64(size)})
+				}
+				expected = append(expected, upload)
+			}
+			sort.Slice(expected, func(i, j int) bool {
+				return expected[i].UploadID < expected[j].UploadID
+			})
+
+			// a restarted adapter knows nothing of the uploads but the part files it finds
+			restarted, err := local.NewAdapter(a.Path(), local.WithPathLayout(layout))
+			testutil.MustDo(t, "NewAdapter", err)
+			var recoverer block.UploadRecoverer = restarted
+			uploads, err := recoverer.RecoverUploads(ctx, testStorageNamespace)
+			testutil.MustDo(t, "RecoverUploads", err)
+			if diffs := deep.Equal(uploads, expected); diffs != nil {
+				t.Errorf("unexpected recovered uploads: %s", diffs)
+			}
+
+			uploads, err = recoverer.RecoverUploads(ctx, "local://empty")
+			testutil.MustDo(t, "RecoverUploads of an empty namespace", err)
+			if len(uploads) != 0 {
+				t.Errorf("recovered uploads %+v from an empty namespace", uploads)
+			}
+		})
+	}
+}
+
+func TestLocalListMultipartUploads(t *testing.T) {
+	ctx := context.Background()
+	for _, layout := range []local.PathLayout{local.PathLayoutFlat, local.PathLayoutSharded} {
+		t.Run(string(layout), func(t *testing.T) {
+			a := makeAdapter(t, local.WithPathLayout(layout))
+			var lister block.MultipartUploadLister = a
+			// file modified times may be truncated, to the second on some filesystems
+			started := time.Now().Truncate(time.Second)
+			expected := make(map[string]block.MultipartUploadInfo)
+			for _, partSizes := range [][]int{{3, 5}, {7}} {
+				pointer := makePointer("dir/multipart")
+				uploadID, err := a.CreateMultiPartUpload(ctx, pointer, nil, block.CreateMultiPartUploadOpts{})
+				testutil.MustDo(t, "CreateMultiPartUpload", err)
+				info := block.MultipartUploadInfo{UploadID: uploadID}
+				for i, size := range partSizes {
+					content := strings.Repeat("x", size)
+					_, err := a.UploadPart(ctx, pointer, int64(size), strings.NewReader(content), uploadID, int64(i+1))
+					testutil.MustDo(t, "UploadPart", err)
+					info.Parts++
+					info.SizeBytes += int64(size)
+				}
+				expected[uploadID] = info
+			}
+			finished := time.Now()
+
+			uploads, err := lister.ListMultipartUploads(ctx, testStorageNamespace)
+			testutil.MustDo(t, "ListMultipartUploads", err)
+			if len(uploads) != len(expected) {
+				t.Fatalf("listed %d uploads %+v, expected %d", len(uploads), uploads, len(expected))
+			}
+			for i, upload := range uploads {
+				if i > 0 && uploads[i-1].UploadID >= upload.UploadID {
+					t.Errorf("upload %s listed after %s", upload.UploadID, uploads[i-1].UploadID)
+				}
+				if upload.Initiated.Before(started) || upload.Initiated.After(finished) {
+					t.Errorf("upload %s initiated at %s, expected between %s and %s", upload.UploadID, upload.Initiated, started, finished)
+				}
+				e, ok := expected[upload.UploadID]
+				if !ok {
+					t.Errorf("listed unknown upload %s", upload.UploadID)
+					continue
+				}
+				if upload.Parts != e.Parts || upload.SizeBytes != e.SizeBytes {
+					t.Errorf("upload %s has %d parts of %d bytes, expected %d parts of %d bytes", upload.UploadID, upload.Parts, upload.SizeBytes, e.Parts, e.SizeBytes)
+				}
+			}
+
+			uploads, err = lister.ListMultipartUploads(ctx, "local://empty")
+			testutil.MustDo(t, "ListMultipartUploads of an empty namespace", err)
+			if len(uploads) != 0 {
+				t.Errorf("listed uploads %+v in an empty namespace", uploads)
+			}
+		})
+	}
+}
+
+func TestLocalWalkWithProperties(t *testing.T) {
+	ctx := context.Background()
+	for _, layout := range []local.PathLayout{local.PathLayoutFlat, local.PathLayoutSharded} {
+		t.Run(string(layout), func(t *testing.T) {
+			a := makeAdapter(t, local.WithPathLayout(layout))
+			var walker block.PropertiesWalker = a
+			contents := map[string]string{"dir/a": "one", "dir/b": "three", "top": "seven!!"}
+			for key, data := range contents {
+				testutil.MustDo(t, "Put "+key, a.Put(ctx, makePointer(key), int64(len(data)), strings.NewReader(data), block.PutOpts{}))
+			}
+			uploadID, err := a.CreateMultiPartUpload(ctx, makePointer("dir/multipart"), nil, block.CreateMultiPartUploadOpts{})
+			testutil.MustDo(t, "CreateMultiPartUpload", err)
+			_, err = a.UploadPart(ctx, makePointer("dir/multipart"), 4, strings.NewReader("part"), uploadID, 1)
+			testutil.MustDo(t, "UploadPart", err)
+
+			walked := make(map[string]block.ObjectProperties)
+			err = walker.WalkWithProperties(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace}, func(identifier string, props block.ObjectProperties) error {
+				walked[identifier] = props
+				return nil
+			})
+			testutil.MustDo(t, "WalkWithProperties", err)
+			if len(walked) != len(contents) {
+				t.Errorf("walked %v, expected only the objects %v", walked, contents)
+			}
+			for key, data := range contents {
+				props, ok := walked[key]
+				if !ok {
+					t.Errorf("object %s not walked", key)
+					continue
+				}
+				stat, err := a.Stat(ctx, makePointer(key))
+				testutil.MustDo(t, "Stat "+key, err)
+				if props.Size != int64(len(data)) || !props.LastModified.Equal(stat.LastModified) || props.ETag != stat.ETag {
+					t.Errorf("walked %s with properties %+v, expected size %d and those of Stat %+v", key, props, len(data), stat)
+				}
+			}
+
+			walked = make(map[string]block.ObjectProperties)
+			err = walker.WalkWithProperties(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace, Prefix: "dir/"}, func(identifier string, props block.ObjectProperties) error {
+				walked[identifier] = props
+				return nil
+			})
+			testutil.MustDo(t, "WalkWithProperties of a prefix", err)
+			if _, ok := walked["top"]; ok || len(walked) != 2 {
+				t.Errorf("walked %v under dir/, expected dir/a and dir/b", walked)
+			}
+		})
+	}
+}
+
+func TestLocalWalk(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t, local.WithSoftDelete())
+	for _, key := range []string{"dir/a", "dir/removed", "top"} {
+		testutil.MustDo(t, "Put "+key, a.Put(ctx, makePointer(key), 3, strings.NewReader(key[:3]), block.PutOpts{}))
+	}
+	testutil.MustDo(t, "Remove", a.Remove(ctx, makePointer("dir/removed")))
+	uploadID, err := a.CreateMultiPartUpload(ctx, makePointer("dir/multipart"), nil, block.CreateMultiPartUploadOpts{})
+	testutil.MustDo(t, "CreateMultiPartUpload", err)
+	_, err = a.UploadPart(ctx, makePointer("dir/multipart"), 4, strings.NewReader("part"), uploadID, 1)
+	testutil.MustDo(t, "UploadPart", err)
+	testutil.MustDo(t, "SetLifecycle", a.SetLifecycle(ctx, testStorageNamespace, []block.LifecycleRule{{Prefix: "dir/", MaxAge: time.Hour}}))
+
+	var walked []string
+	err = a.Walk(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace}, func(id string) error {
+		walked = append(walked, id)
+		return nil
+	})
+	testutil.MustDo(t, "Walk", err)
+	sort.Strings(walked)
+	namespacePath := filepath.Join(a.Path(), "test")
+	expected := []string{filepath.Join(namespacePath, "dir", "a"), filepath.Join(namespacePath, "top")}
+	if diffs := deep.Equal(walked, expected); diffs != nil {
+		t.Errorf("unexpected walked objects: %s", diffs)
+	}
+}
+
+func TestLocalSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t, local.WithSoftDelete())
+	var trasher block.Trasher = a
+	obj := makePointer("dir/object")
+	put := func(contents string) {
+		testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+	}
+	read := func() string {
+		reader, err := a.Get(ctx, obj, 0)
+		testutil.MustDo(t, "Get", err)
+		defer func() { _ = reader.Close() }()
+		data, err := ioutil.ReadAll(reader)
+		testutil.MustDo(t, "ReadAll", err)
+		return string(data)
+	}
+
+	put("first")
+	testutil.MustDo(t, "Remove", a.Remove(ctx, obj))
+	if exists, _ := a.Exists(ctx, obj); exists {
+		t.Fatal("object exists after Remove")
+	}
+	keys, _, err := a.WalkPage(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace}, "", 10)
+	testutil.MustDo(t, "WalkPage", err)
+	if len(keys) != 0 {
+		t.Errorf("listed %v after Remove, expected the trash not to be listed", keys)
+	}
+
+	testutil.MustDo(t, "RestoreFromTrash", trasher.RestoreFromTrash(ctx, obj))
+	if got := read(); got != "first" {
+		t.Errorf("restored object holds %q, expected first", got)
+	}
+	if err := trasher.RestoreFromTrash(ctx, obj); !errors.Is(err, block.ErrDataNotFound) {
+		t.Errorf("RestoreFromTrash of a restored object returned %v, expected %s", err, block.ErrDataNotFound)
+	}
+
+	testutil.MustDo(t, "Remove again", a.Remove(ctx, obj))
+	put("second")
+	if err := trasher.RestoreFromTrash(ctx, obj); !errors.Is(err, local.ErrObjectExists) {
+		t.Errorf("RestoreFromTrash over a new object returned %v, expected %s", err, local.ErrObjectExists)
+	}
+	if got := read(); got != "second" {
+		t.Errorf("object holds %q after a rejected restore, expected second", got)
+	}
+}
+
+func TestLocalEmptyTrash(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t, local.WithSoftDelete())
+	for _, key := range []string{"one", "dir/two"} {
+		testutil.MustDo(t, "Put "+key, a.Put(ctx, makePointer(key), 4, strings.NewReader("data"), block.PutOpts{}))
+		testutil.MustDo(t, "Remove "+key, a.Remove(ctx, makePointer(key)))
+	}
+
+	emptied, err := a.EmptyTrash(ctx, testStorageNamespace, time.Hour)
+	testutil.MustDo(t, "EmptyTrash of old objects", err)
+	if emptied != 0 {
+		t.Errorf("EmptyTrash removed %d objects removed less than an hour ago", emptied)
+	}
+	emptied, err = a.EmptyTrash(ctx, testStorageNamespace, 0)
+	testutil.MustDo(t, "EmptyTrash", err)
+	if emptied != 2 {
+		t.Errorf("EmptyTrash removed %d objects, expected 2", emptied)
+	}
+	if err := a.RestoreFromTrash(ctx, makePointer("one")); !errors.Is(err, block.ErrDataNotFound) {
+		t.Errorf("RestoreFromTrash after EmptyTrash returned %v, expected %s", err, block.ErrDataNotFound)
+	}
+	emptied, err = a.EmptyTrash(ctx, "local://empty", 0)
+	testutil.MustDo(t, "EmptyTrash of an empty namespace", err)
+	if emptied != 0 {
+		t.Errorf("EmptyTrash of an empty namespace removed %d objects", emptied)
+	}
+}
+
+func TestLocalZeroLength(t *testing.T) {
+	ctx := context.Background()
+	emptyMD5 := md5.Sum(nil) //nolint:gosec
+	emptyETag := hex.EncodeToString(emptyMD5[:])
+
+	adapters := []struct {
+		name string
+		opts []func(a *local.Adapter)
+	}{
+		{name: "plain"},
+		{name: "dedup", opts: []func(a *local.Adapter){local.WithDedup()}},
+		{name: "reflink", opts: []func(a *local.Adapter){local.WithReflink()}},
+	}
+	for _, ad := range adapters {
+		t.Run(ad.name, func(t *testing.T) {
+			a := makeAdapter(t, ad.opts...)
+
+			t.Run("put", func(t *testing.T) {
+				pointer := makePointer("empty/put")
+				testutil.MustDo(t, "Put", a.Put(ctx, pointer, 0, strings.NewReader(""), block.PutOpts{}))
+				ok, err := a.Exists(ctx, pointer)
+				testutil.MustDo(t, "Exists", err)
+				if !ok {
+					t.Fatal("empty object does not exist")
+				}
+				props, err := a.Stat(ctx, pointer)
+				testutil.MustDo(t, "Stat", err)
+				if props.Size != 0 || props.ETag != emptyETag {
+					t.Errorf("got size %d ETag %s, expected size 0 ETag %s", props.Size, props.ETag, emptyETag)
+				}
+				reader, err := a.Get(ctx, pointer, 0)
+				testutil.MustDo(t, "Get", err)
+				if reader == nil {
+					t.Fatal("Get returned a nil reader for an empty object")
+				}
+				got, err := ioutil.ReadAll(reader)
+				testutil.MustDo(t, "ReadAll", err)
+				testutil.MustDo(t, "Close", reader.Close())
+				if len(got) != 0 {
+					t.Errorf("read %q from an empty object", got)
+				}
+			})
+
+			t.Run("put file", func(t *testing.T) {
+				source, _ := writeTempFile(t, 0)
+				pointer := makePointer("empty/put-file")
+				testutil.MustDo(t, "PutFile", a.PutFile(ctx, pointer, source, block.PutOpts{}))
+				props, err := a.Stat(ctx, pointer)
+				testutil.MustDo(t, "Stat", err)
+				if props.Size != 0 || props.ETag != emptyETag {
+					t.Errorf("got size %d ETag %s, expected size 0 ETag %s", props.Size, props.ETag, emptyETag)
+				}
+			})
+
+			t.Run("missing", func(t *testing.T) {
+				pointer := makePointer("empty/missing")
+				if _, err := a.Stat(ctx, pointer); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("Stat of a missing object returned %v, expected %s", err, os.ErrNotExist)
+				}
+				if _, err := a.Get(ctx, pointer, 0); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("Get of a missing object returned %v, expected %s", err, os.ErrNotExist)
+				}
+			})
+
+			t.Run("multipart", func(t *testing.T) {
+				pointer := makePointer("empty/multipart")
+				uploadID, err := a.CreateMultiPartUpload(ctx, pointer, nil, block.CreateMultiPartUploadOpts{})
+				testutil.MustDo(t, "CreateMultiPartUpload", err)
+				partETag, err := a.UploadPart(ctx, pointer, 0, strings.NewReader(""), uploadID, 1)
+				testutil.MustDo(t, "UploadPart", err)
+				if partETag != `"`+emptyETag+`"` {
+					t.Errorf("got part ETag %s, expected %q", partETag, emptyETag)
+				}
+				etag, size, err := a.CompleteMultiPartUpload(ctx, pointer, uploadID, &block.MultipartUploadCompletion{
+					Part: []*s3.CompletedPart{{ETag: aws.String(partETag), PartNumber: aws.Int64(1)}},
+				})
+				testutil.MustDo(t, "CompleteMultiPartUpload", err)
+				// S3 computes the ETag of a multipart upload as the MD5 of its part MD5s
+				expectedETag := md5.Sum(emptyMD5[:]) //nolint:gosec
+				if size != 0 || etag == nil || *etag != hex.EncodeToString(expectedETag[:])+"-1" {
+					t.Errorf("got size %d ETag %v, expected size 0 ETag %x-1", size, aws.StringValue(etag), expectedETag)
+				}
+				props, err := a.Stat(ctx, pointer)
+				testutil.MustDo(t, "Stat", err)
+				if props.Size != 0 || props.ETag != aws.StringValue(etag) {
+					t.Errorf("got stat size %d ETag %s, expected size 0 ETag %s", props.Size, props.ETag, aws.StringValue(etag))
+				}
+				reader, err := a.Get(ctx, pointer, 0)
+				testutil.MustDo(t, "Get", err)
+				got, err := ioutil.ReadAll(reader)
+				testutil.MustDo(t, "ReadAll", err)
+				testutil.MustDo(t, "Close", reader.Close())
+				if len(got) != 0 {
+					t.Errorf("read %q from an empty multipart object", got)
+				}
+			})
+		})
+	}
+}
+
+func TestLocalGetVerified(t *testing.T) {
+	ctx := context.Background()
+	const contents = "verified contents"
+	readVerified := func(t *testing.T, a *local.Adapter, obj block.ObjectPointer) (string, error) {
+		t.Helper()
+		var getter block.VerifiedGetter = a
+		reader, err := getter.GetVerified(ctx, obj)
+		testutil.MustDo(t, "GetVerified", err)
+		defer func() { _ = reader.Close() }()
+		got, err := ioutil.ReadAll(reader)
+		return string(got), err
+	}
+	corrupt := func(t *testing.T, a *local.Adapter, key string) {
+		t.Helper()
+		p := filepath.Join(a.Path(), "test", key)
+		testutil.MustDo(t, "corrupt "+key, ioutil.WriteFile(p, []byte(strings.ToUpper(contents)), 0600))
+	}
+
+	t.Run("good", func(t *testing.T) {
+		a := makeAdapter(t, local.WithVerifyOnRead())
+		obj := makePointer("good")
+		testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+		got, err := readVerified(t, a, obj)
+		testutil.MustDo(t, "read good object", err)
+		if got != contents {
+			t.Errorf("read %q, expected %q", got, contents)
+		}
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		a := makeAdapter(t, local.WithVerifyOnRead())
+		obj := makePointer("corrupted")
+		testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+		corrupt(t, a, "corrupted")
+		got, err := readVerified(t, a, obj)
+		if !errors.Is(err, block.ErrChecksumMismatch) {
+			t.Errorf("read corrupted object returned error %v, expected %s", err, block.ErrChecksumMismatch)
+		}
+		if got != strings.ToUpper(contents) {
+			t.Errorf("read %q before failing, expected the entire object", got)
+		}
+	})
+
+	t.Run("not verifying", func(t *testing.T) {
+		a := makeAdapter(t)
+		obj := makePointer("unverified")
+		testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+		corrupt(t, a, "unverified")
+		_, err := readVerified(t, a, obj)
+		testutil.MustDo(t, "read corrupted object without verifying", err)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		a := makeAdapter(t, local.WithVerifyOnRead())
+		if _, err := a.GetVerified(ctx, makePointer("missing")); err == nil {
+			t.Error("GetVerified of a missing object succeeded")
+		}
+	})
+}
+
+func TestLocalMaxObjectSize(t *testing.T) {
+	ctx := context.Background()
+	const maxSize = 10
+	for _, dedup := range []bool{false, true} {
+		t.Run(fmt.Sprintf("dedup=%t", dedup), func(t *testing.T) {
+			opts := []func(a *local.Adapter){local.WithMaxObjectSize(maxSize)}
+			if dedup {
+				opts = append(opts, local.WithDedup())
+			}
+			a := makeAdapter(t, opts...)
+			exists := func(key string) bool {
+				t.Helper()
+				ok, err := a.Exists(ctx, makePointer(key))
+				testutil.MustDo(t, "Exists "+key, err)
+				return ok
+			}
+
+			testutil.MustDo(t, "Put at the limit", a.Put(ctx, makePointer("small"), maxSize, strings.NewReader(strings.Repeat("x", maxSize)), block.PutOpts{}))
+			if !exists("small") {
+				t.Error("object at the limit was not stored")
+			}
+
+			contents := strings.Repeat("x", maxSize+1)
+			err := a.Put(ctx, makePointer("known"), int64(len(contents)), strings.NewReader(contents), block.PutOpts{})
+			if !errors.Is(err, local.ErrObjectTooLarge) {
+				t.Errorf("Put of an oversized object returned %v, expected %s", err, local.ErrObjectTooLarge)
+			}
+			if exists("known") {
+				t.Error("oversized object was stored")
+			}
+
+			// a stream of unknown size is only found to be oversized while writing it
+			err = a.Put(ctx, makePointer("streaming"), -1, strings.NewReader(contents), block.PutOpts{})
+			if !errors.Is(err, local.ErrObjectTooLarge) {
+				t.Errorf("Put of an oversized stream returned %v, expected %s", err, local.ErrObjectTooLarge)
+			}
+			if exists("streaming") {
+				t.Error("oversized stream was stored")
+			}
+
+			pointer := makePointer("multipart")
+			uploadID, err := a.CreateMultiPartUpload(ctx, pointer, nil, block.CreateMultiPartUploadOpts{})
+			testutil.MustDo(t, "CreateMultiPartUpload", err)
+			_, err = a.UploadPart(ctx, pointer, -1, strings.NewReader(contents), uploadID, 1)
+			if !errors.Is(err, local.ErrObjectTooLarge) {
+				t.Errorf("UploadPart of an oversized part returned %v, expected %s", err, local.ErrObjectTooLarge)
+			}
+
+			// nothing is left of the rejected writes but the stored object
+			var files []string
+			err = filepath.Walk(a.Path(), func(p string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() || strings.Contains(p, "small") {
+					return err
+				}
+				// the blob of the stored object, but no temporary blob
+				if strings.Contains(p, "/.blobs/") && !strings.Contains(p, "/.blobs/tmp/") {
+					return nil
+				}
+				files = append(files, p)
+				return err
+			})
+			testutil.MustDo(t, "Walk", err)
+			if len(files) > 0 {
+				t.Errorf("rejected writes left files %s", files)
+			}
+		})
+	}
+}
+
+func TestLocalCopyPrefix(t *testing.T) {
+	ctx := context.Background()
+	for _, layout := range []local.PathLayout{local.PathLayoutFlat, local.PathLayoutSharded} {
+		t.Run(string(layout), func(t *testing.T) {
+			a := makeAdapter(t, local.WithPathLayout(layout))
+			var copier block.PrefixCopier = a
+			// an upload in progress whose part files share the source prefix
+			uploadID, err := a.CreateMultiPartUpload(ctx, makePointer("upload"), nil, block.CreateMultiPartUploadOpts{})
+			testutil.MustDo(t, "CreateMultiPartUpload", err)
+			_, err = a.UploadPart(ctx, makePointer("upload"), 4, strings.NewReader("part"), uploadID, 1)
+			testutil.MustDo(t, "UploadPart", err)
+			source := uploadID[:4]
+			objects := map[string]string{
+				source + "/a":       "a",
+				source + "/sub/b":   "b",
+				source + "-sibling": "sibling",
+				"other/c":           "c",
+			}
+			for key, contents := range objects {
+				testutil.MustDo(t, "Put "+key, a.Put(ctx, makePointer(key), int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+			}
+
+			copied, err := copier.CopyPrefix(ctx, testStorageNamespace, source+"/", "copy/")
+			testutil.MustDo(t, "CopyPrefix", err)
+			if copied != 2 {
+				t.Errorf("CopyPrefix copied %d objects, expected 2", copied)
+			}
+			keys, _, err := a.WalkPage(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace, Prefix: "copy/"}, "", 10)
+			testutil.MustDo(t, "WalkPage", err)
+			if diffs := deep.Equal(keys, []string{"copy/a", "copy/sub/b"}); diffs != nil {
+				t.Errorf("unexpected copies: %s", diffs)
+			}
+			for _, key := range []string{"a", "sub/b"} {
+				reader, err := a.Get(ctx, makePointer("copy/"+key), 0)
+				testutil.MustDo(t, "Get copy/"+key, err)
+				got, err := ioutil.ReadAll(reader)
+				_ = reader.Close()
+				testutil.MustDo(t, "ReadAll copy/"+key, err)
+				if expected := objects[source+"/"+key]; string(got) != expected {
+					t.Errorf("copy/%s contains %q, expected %q", key, got, expected)
+				}
+			}
+
+			// without the trailing separator the prefix also matches the part files
+			copied, err = copier.CopyPrefix(ctx, testStorageNamespace, source, "parts/")
+			testutil.MustDo(t, "CopyPrefix of the part files prefix", err)
+			if copied != 3 {
+				t.Errorf("CopyPrefix copied %d objects, expected only the 3 objects and not the part file", copied)
+			}
+		})
+	}
+}
+
+func TestLocalCopyPrefixOverlap(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	testutil.MustDo(t, "Put", a.Put(ctx, makePointer("dir/a"), 1, strings.NewReader("a"), block.PutOpts{}))
+	for _, destination := range []string{"dir/", "dir/copy/", "dir/a"} {
+		_, err := a.CopyPrefix(ctx, testStorageNamespace, "dir/", destination)
+		if !errors.Is(err, local.ErrPrefixOverlap) {
+			t.Errorf("CopyPrefix to %s returned %v, expected %s", destination, err, local.ErrPrefixOverlap)
+		}
+	}
+	keys, _, err := a.WalkPage(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace, Prefix: "dir/"}, "", 10)
+	testutil.MustDo(t, "WalkPage", err)
+	if diffs := deep.Equal(keys, []string{"dir/a"}); diffs != nil {
+		t.Errorf("rejected copies changed objects: %s", diffs)
+	}
+}
+
+func TestLocalGetManifest(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var reader block.ManifestReader = a
+	put := func(key, contents string) block.ObjectPointer {
+		t.Helper()
+		obj := makePointer(key)
+		testutil.MustDo(t, "Put "+key, a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+		return obj
+	}
+
+	t.Run("well formed", func(t *testing.T) {
+		obj := put("manifest", `s3://bucket/symlinked
+s3://bucket/sized,10
+
+s3://bucket/full, 20, etag2
+{"physical_address": "s3://bucket/json", "size_bytes": 30, "etag": "etag3"}
+`)
+		entries, err := reader.GetManifest(ctx, obj)
+		testutil.MustDo(t, "GetManifest", err)
+		expected := []block.ManifestEntry{
+			{PhysicalAddress: "s3://bucket/symlinked", SizeBytes: -1},
+			{PhysicalAddress: "s3://bucket/sized", SizeBytes: 10},
+			{PhysicalAddress: "s3://bucket/full", SizeBytes: 20, ETag: "etag2"},
+			{PhysicalAddress: "s3://bucket/json", SizeBytes: 30, ETag: "etag3"},
+		}
+		if diffs := deep.Equal(entries, expected); diffs != nil {
+			t.Errorf("unexpected manifest entries: %s", diffs)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			contents string
+			line     int
+		}{
+			{name: "size", contents: "s3://bucket/a,1\ns3://bucket/b,big\n", line: 2},
+			{name: "fields", contents: "s3://bucket/a,1,etag,extra\n", line: 1},
+			{name: "json", contents: "s3://bucket/a\n\n{\"physical_address\": \n", line: 3},
+			{name: "no address", contents: "s3://bucket/a\n{\"size_bytes\": 1}\n", line: 2},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				_, err := reader.GetManifest(ctx, put("malformed-"+c.name, c.contents))
+				if !errors.Is(err, block.ErrInvalidManifest) {
+					t.Fatalf("GetManifest returned %v, expected %s", err, block.ErrInvalidManifest)
+				}
+				if lineNumber := fmt.Sprintf("line %d:", c.line); !strings.Contains(err.Error(), lineNumber) {
+					t.Errorf("GetManifest error %q does not name %s", err, lineNumber)
+				}
+			})
+		}
+	})
+
+	if _, err := reader.GetManifest(ctx, makePointer("missing")); err == nil {
+		t.Error("GetManifest of a missing manifest succeeded")
+	}
+}
+
+func TestLocalLifecycle(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t)
+	var manager block.LifecycleManager = a
+	old := time.Now().Add(-48 * time.Hour)
+	objects := map[string]bool{
+		"logs/old":      true,
+		"logs/new":      false,
+		"logs/keep/old": true,
+		"data/old":      true,
+	}
+	for key, isOld := range objects {
+		testutil.MustDo(t, "Put "+key, a.Put(ctx, makePointer(key), 3, strings.NewReader(key[:3]), block.PutOpts{}))
+		if isOld {
+			testutil.MustDo(t, "Touch "+key, a.Touch(ctx, makePointer(key), old))
+		}
+	}
+
+	expired, err := manager.ApplyLifecycle(ctx, testStorageNamespace)
+	testutil.MustDo(t, "ApplyLifecycle without rules", err)
+	if expired != 0 {
+		t.Errorf("ApplyLifecycle without rules expired %d objects", expired)
+	}
+
+	testutil.MustDo(t, "SetLifecycle", manager.SetLifecycle(ctx, testStorageNamespace, []block.LifecycleRule{
+		{Prefix: "logs/", MaxAge: 24 * time.Hour},
+		{Prefix: "logs/keep/", MaxAge: 100 * 24 * time.Hour},
+	}))
+	expired, err = manager.ApplyLifecycle(ctx, testStorageNamespace)
+	testutil.MustDo(t, "ApplyLifecycle", err)
+	if expired != 1 {
+		t.Errorf("ApplyLifecycle expired %d objects, expected 1", expired)
+	}
+	keys, _, err := a.WalkPage(ctx, block.WalkOpts{StorageNamespace: testStorageNamespace}, "", 10)
+	testutil.MustDo(t, "WalkPage", err)
+	if diffs := deep.Equal(keys, []string{"data/old", "logs/keep/old", "logs/new"}); diffs != nil {
+		t.Errorf("unexpected objects after ApplyLifecycle: %s", diffs)
+	}
+
+	expired, err = manager.ApplyLifecycle(ctx, testStorageNamespace)
+	testutil.MustDo(t, "ApplyLifecycle again", err)
+	if expired != 0 {
+		t.Errorf("ApplyLifecycle again expired %d objects", expired)
+	}
+
+	if err := manager.SetLifecycle(ctx, testStorageNamespace, []block.LifecycleRule{{Prefix: "data/"}}); !errors.Is(err, block.ErrInvalidLifecycleRule) {
+		t.Errorf("SetLifecycle without max age returned %v, expected %s", err, block.ErrInvalidLifecycleRule)
+	}
+	testutil.MustDo(t, "SetLifecycle to no rules", manager.SetLifecycle(ctx, testStorageNamespace, nil))
+	testutil.MustDo(t, "Touch logs/new", a.Touch(ctx, makePointer("logs/new"), old))
+	expired, err = manager.ApplyLifecycle(ctx, testStorageNamespace)
+	testutil.MustDo(t, "ApplyLifecycle after removing rules", err)
+	if expired != 0 {
+		t.Errorf("ApplyLifecycle after removing rules expired %d objects", expired)
+	}
+}
+
+func TestLocalLifecycleDedup(t *testing.T) {
+	ctx := context.Background()
+	a := makeAdapter(t, local.WithDedup())
+	// an object stored now in a blob written long ago is new
+	testutil.MustDo(t, "Put", a.Put(ctx, makePointer("logs/new"), 3, strings.NewReader("log"), block.PutOpts{}))
+	old := time.Now().Add(-48 * time.Hour)
+	testutil.MustDo(t, "Chtimes blob", os.Chtimes(filepath.Join(a.Path(), "test", "logs", "new"), old, old))
+	testutil.MustDo(t, "SetLifecycle", a.SetLifecycle(ctx, testStorageNamespace, []block.LifecycleRule{{Prefix: "logs/", MaxAge: 24 * time.Hour}}))
+	expired, err := a.ApplyLifecycle(ctx, testStorageNamespace)
+	testutil.MustDo(t, "ApplyLifecycle", err)
+	if expired != 0 {
+		t.Errorf("ApplyLifecycle expired %d objects, expected none", expired)
+	}
+}
+
+func TestLocalRepairTruncated(t *testing.T) {
+	ctx := context.Background()
+	const contents = "committed contents"
+	for _, removeTruncated := range []bool{false, true} {
+		t.Run(fmt.Sprintf("remove %t", removeTruncated), func(t *testing.T) {
+			var opts []func(*local.Adapter)
+			if removeTruncated {
+				opts = append(opts, local.WithRemoveTruncated())
+			}
+			a := makeAdapter(t, opts...)
+			var repairer block.TruncationRepairer = a
+			for _, key := range []string{"intact", "truncated"} {
+				testutil.MustDo(t, "Put "+key, a.Put(ctx, makePointer(key), int64(len(contents)), strings.NewReader(contents), block.PutOpts{}))
+			}
+			truncatedPath := filepath.Join(a.Path(), "test", "truncated")
+			testutil.MustDo(t, "Truncate", os.Truncate(truncatedPath, 5))
+
+			testutil.MustDo(t, "RepairTruncated intact", repairer.RepairTruncated(ctx, makePointer("intact"), int64(len(contents))))
+			err := repairer.RepairTruncated(ctx, makePointer("truncated"), int64(len(contents)))
+			_, statErr := os.Stat(truncatedPath)
+			if removeTruncated {
+				testutil.MustDo(t, "RepairTruncated truncated", err)
+				if !errors.Is(statErr, os.ErrNotExist) {
+					t.Errorf("truncated object not removed: %v", statErr)
+				}
+			} else {
+				if !errors.Is(err, block.ErrObjectTruncated) {
+					t.Errorf("RepairTruncated truncated returned %v, expected %s", err, block.ErrObjectTruncated)
+				} else if !strings.Contains(err.Error(), fmt.Sprintf("has 5 bytes, expected %d", len(contents))) {
+					t.Errorf("RepairTruncated error %q does not detail sizes", err)
+				}
+				testutil.MustDo(t, "Stat truncated object", statErr)
+			}
+
+			if err := repairer.RepairTruncated(ctx, makePointer("missing"), 1); !errors.Is(err, block.ErrDataNotFound) {
+				t.Errorf("RepairTruncated missing returned %v, expected %s", err, block.ErrDataNotFound)
+			}
+		})
+	}
+}
+
+func TestLocalStorageSize(t *testing.T) {
+	ctx := context.Background()
+	for _, dedup := range []bool{false, true} {
+		t.Run(fmt.Sprintf("dedup=%t", dedup), func(t *testing.T) {
+			var opts []func(a *local.Adapter)
+			if dedup {
+				opts = append(opts, local.WithDedup())
+			}
+			a := makeAdapter(t, opts...)
+			var sizer block.StorageSizer = a
+			size, err := sizer.StorageSize(ctx, testStorageNamespace)
+			testutil.MustDo(t, "StorageSize of empty namespace", err)
+			if size != 0 {
+				t.Errorf("StorageSize of empty namespace = %d, expected 0", size)
+			}
+
+			for _, key := range []string{"usage/a", "usage/b"} {
+				testutil.MustDo(t, "Put "+key, a.Put(ctx, makePointer(key), 4, strings.NewReader("same"), block.PutOpts{}))
+			}
+			testutil.MustDo(t, "Put other", a.Put(ctx, makePointer("usage/other"), 5, strings.NewReader("other"), block.PutOpts{}))
+			expected := int64(13)
+			if dedup {
+				// identical objects share a blob
+				expected = 9
+			}
+			size, err = sizer.StorageSize(ctx, testStorageNamespace)
+			testutil.MustDo(t, "StorageSize", err)
+			if size != expected {
+				t.Errorf("StorageSize = %d, expected %d", size, expected)
+			}
+		})
+	}
+}
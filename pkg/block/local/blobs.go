@@ -0,0 +1,191 @@
+package local
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Content-addressed storage: when enabled, object contents are stored once per distinct
+// SHA-256 digest under blobsDir, and each object is a hard link to its blob.  A blob is
+// referenced by the blob sidecar of every object linked to it, and is removed once the last
+// object linked to it is removed.  Objects linked to a blob share its file times, so the
+// modification time of each is kept in its modified sidecar.
+const (
+	blobsDir              = ".blobs"
+	blobShardSize         = 2
+	modifiedSidecarSuffix = ".modified"
+)
+
+func (l *Adapter) blobPath(digest string) string {
+	return filepath.Join(l.path, blobsDir, digest[:blobShardSize], digest)
+}
+
+// putBlob writes reader into the blob store and links the object at p to the resulting blob.
+// It returns the number of bytes read.
+func (l *Adapter) putBlob(p string, sizeBytes int64, reader io.Reader) (int64, error) {
+	digestRead := newHashReader(reader, sha256.New())
+	hashRead := newHashReader(digestRead, l.newHash())
+	tempPath, err := l.writeTemp(sizeBytes, hashRead)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(tempPath)
+	if err != nil {
+		return 0, err
+	}
+	digest := digestRead.HexSum()
+	blob := l.blobPath(digest)
+	if _, err := os.Stat(blob); err == nil {
+		// identical content already stored
+		if err := os.Remove(tempPath); err != nil {
+			return 0, err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(blob), 0750); err != nil {
+			return 0, err
+		}
+		if err := os.Rename(tempPath, blob); err != nil {
+			return 0, err
+		}
+	}
+	if err := l.linkBlob(p, digest); err != nil {
+		return 0, err
+	}
+	err = writeSidecar(p, etagSidecarSuffix, hashRead.HexSum())
+	return info.Size(), err
+}
+
+// copyBlob links the object at dest to the blob of the object at source, storing sourceFile
+// as a new blob if source does not reference one.
+func (l *Adapter) copyBlob(source, dest string, sourceFile io.Reader) error {
+	digest, err := readBlobDigest(source)
+	if err != nil {
+		return err
+	}
+	if digest == "" {
+		_, err = l.putBlob(dest, -1, sourceFile)
+		return err
+	}
+	etag, ok, err := readSidecar(source, etagSidecarSuffix)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if etag, err = l.computeFileETag(source); err != nil {
+			return err
+		}
+	}
+	if err = l.linkBlob(dest, digest); err != nil {
+		return err
+	}
+	return writeSidecar(dest, etagSidecarSuffix, etag)
+}
+
+// appendBlob links the object at p to a new blob holding its current contents followed by
+// the contents of reader, leaving other objects sharing its current blob unchanged.  It
+// returns the new size of the object.
+func (l *Adapter) appendBlob(p string, reader io.Reader) (int64, error) {
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return l.putBlob(p, -1, reader)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return l.putBlob(p, -1, io.MultiReader(f, reader))
+}
+
+// linkBlob makes the object at p a reference to the blob with digest, releasing any blob p
+// previously referenced.
+func (l *Adapter) linkBlob(p, digest string) error {
+	if err := l.verifyPath(p); err != nil {
+		return err
+	}
+	prevDigest, _ := readBlobDigest(p)
+	tempDirPath := filepath.Join(l.path, tempDir)
+	if err := os.MkdirAll(tempDirPath, 0750); err != nil {
+		return err
+	}
+	tempPath := filepath.Join(tempDirPath, "link-"+uuid.New().String())
+	if err := os.Link(l.blobPath(digest), tempPath); err != nil {
+		return err
+	}
+	if err := l.replaceFile(tempPath, p); err != nil {
+		return err
+	}
+	// renaming does nothing when p is already a link to the same blob
+	if err := os.Remove(tempPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := writeSidecar(p, blobSidecarSuffix, digest); err != nil {
+		return err
+	}
+	if err := writeSidecar(p, modifiedSidecarSuffix, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		return err
+	}
+	// the object may now hold other contents, with a modification time older than its checksums
+	if err := os.Remove(p + checksumsSidecarSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if prevDigest != "" && prevDigest != digest {
+		return l.releaseBlob(prevDigest)
+	}
+	return nil
+}
+
+// releaseBlob removes the blob with digest if no object links to it any longer.
+func (l *Adapter) releaseBlob(digest string) error {
+	blob := l.blobPath(digest)
+	info, err := os.Stat(blob)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	links, ok := linkCount(info)
+	if !ok || links > 1 {
+		// still referenced, or cannot tell: keep it
+		return nil
+	}
+	if err := os.Remove(blob); err != nil {
+		return err
+	}
+	removeEmptyDirUntil(filepath.Dir(blob), l.path)
+	return nil
+}
+
+// readBlobDigest returns the digest of the blob referenced by the object at p, or "" if it
+// references none.
+func readBlobDigest(p string) (string, error) {
+	digest, ok, err := readSidecar(p, blobSidecarSuffix)
+	if err != nil || !ok {
+		return "", err
+	}
+	return digest, nil
+}
+
+// lastModified returns the last modification time of the object at p with file info: the time
+// recorded in its modified sidecar if it is linked to a blob, and the file modification time
+// otherwise.
+func lastModified(p string, info os.FileInfo) (time.Time, error) {
+	value, ok, err := readSidecar(p, modifiedSidecarSuffix)
+	if err != nil || !ok {
+		return info.ModTime(), err
+	}
+	modified, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("modification time of %s: %w", p, err)
+	}
+	return modified, nil
+}
@@ -0,0 +1,122 @@
+package local
+
+import (
+	"context"
+	"crypto/md5"  //nolint:gosec
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// Checksums computed by Checksums are cached in a sidecar, one "algorithm digest" line each.
+const checksumsSidecarSuffix = ".checksums"
+
+var checksumHashes = map[string]func() hash.Hash{
+	block.ChecksumMD5:    md5.New,
+	block.ChecksumSHA1:   sha1.New,
+	block.ChecksumSHA256: sha256.New,
+}
+
+// Checksums implements block.Checksummer.  Checksums missing from the sidecar of obj are all
+// computed in a single read of obj, and added to the sidecar.
+func (l *Adapter) Checksums(_ context.Context, obj block.ObjectPointer, algorithms []string) (_ map[string]string, err error) {
+	defer wrapError(&err, "checksums", obj.Identifier)
+	for _, algorithm := range algorithms {
+		if _, ok := checksumHashes[algorithm]; !ok {
+			return nil, fmt.Errorf("%w: %s", block.ErrUnsupportedChecksum, algorithm)
+		}
+	}
+	p, err := l.getPath(obj)
+	if err != nil {
+		return nil, err
+	}
+	p = filepath.Clean(p)
+	// the sidecar is rewritten, so concurrent calls may not share the lock
+	defer l.locks.lock(p)()
+	cached, err := readChecksums(p)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, algorithm := range algorithms {
+		if _, ok := cached[algorithm]; !ok {
+			missing = append(missing, algorithm)
+		}
+	}
+	if len(missing) > 0 {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		computed, err := computeChecksums(f, missing)
+		if err != nil {
+			return nil, err
+		}
+		for algorithm, digest := range computed {
+			cached[algorithm] = digest
+		}
+		if err := writeChecksums(p, cached); err != nil {
+			return nil, err
+		}
+	}
+	checksums := make(map[string]string, len(algorithms))
+	for _, algorithm := range algorithms {
+		checksums[algorithm] = cached[algorithm]
+	}
+	return checksums, nil
+}
+
+// computeChecksums returns the checksums of reader in each of algorithms, reading it once.
+func computeChecksums(reader io.Reader, algorithms []string) (map[string]string, error) {
+	hashes := make(map[string]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		h := checksumHashes[algorithm]()
+		hashes[algorithm] = h
+		writers = append(writers, h)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), reader); err != nil {
+		return nil, err
+	}
+	checksums := make(map[string]string, len(hashes))
+	for algorithm, h := range hashes {
+		checksums[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+	return checksums, nil
+}
+
+// readChecksums returns the checksums cached in the sidecar of the object at p.
+func readChecksums(p string) (map[string]string, error) {
+	checksums := make(map[string]string)
+	value, ok, err := readSidecar(p, checksumsSidecarSuffix)
+	if err != nil || !ok {
+		return checksums, err
+	}
+	for _, line := range strings.Split(value, "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			checksums[fields[0]] = fields[1]
+		}
+	}
+	return checksums, nil
+}
+
+func writeChecksums(p string, checksums map[string]string) error {
+	lines := make([]string, 0, len(checksums))
+	for algorithm, digest := range checksums {
+		lines = append(lines, algorithm+" "+digest)
+	}
+	sort.Strings(lines)
+	return writeSidecar(p, checksumsSidecarSuffix, strings.Join(lines, "\n")+"\n")
+}
@@ -0,0 +1,91 @@
+package local
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r     io.Reader
+	count int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.count += n
+	return n, err
+}
+
+func TestChecksums(t *testing.T) {
+	ctx := context.Background()
+	const contents = "content-addressable contents"
+	md5Sum := md5.Sum([]byte(contents)) //nolint:gosec
+	sha256Sum := sha256.Sum256([]byte(contents))
+	expected := map[string]string{
+		block.ChecksumMD5:    hex.EncodeToString(md5Sum[:]),
+		block.ChecksumSHA256: hex.EncodeToString(sha256Sum[:]),
+	}
+	algorithms := []string{block.ChecksumMD5, block.ChecksumSHA256}
+
+	reader := &countingReader{r: strings.NewReader(contents)}
+	computed, err := computeChecksums(reader, algorithms)
+	if err != nil {
+		t.Fatalf("computeChecksums: %s", err)
+	}
+	if diffs := deep.Equal(computed, expected); diffs != nil {
+		t.Errorf("unexpected computed checksums: %s", diffs)
+	}
+	if reader.count != len(contents) {
+		t.Errorf("computeChecksums read %d bytes, expected a single read of %d bytes", reader.count, len(contents))
+	}
+
+	a, err := NewAdapter(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewAdapter: %s", err)
+	}
+	obj := block.ObjectPointer{StorageNamespace: "local://test", Identifier: "object"}
+	if err := a.Put(ctx, obj, int64(len(contents)), strings.NewReader(contents), block.PutOpts{}); err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+	checksums, err := a.Checksums(ctx, obj, algorithms)
+	if err != nil {
+		t.Fatalf("Checksums: %s", err)
+	}
+	if diffs := deep.Equal(checksums, expected); diffs != nil {
+		t.Errorf("unexpected checksums: %s", diffs)
+	}
+	p, err := a.getPath(obj)
+	if err != nil {
+		t.Fatalf("getPath: %s", err)
+	}
+	cached, err := readChecksums(p)
+	if err != nil {
+		t.Fatalf("readChecksums: %s", err)
+	}
+	if diffs := deep.Equal(cached, expected); diffs != nil {
+		t.Errorf("unexpected cached checksums: %s", diffs)
+	}
+
+	checksums, err = a.Checksums(ctx, obj, []string{block.ChecksumSHA256})
+	if err != nil {
+		t.Fatalf("Checksums from the sidecar: %s", err)
+	}
+	if diffs := deep.Equal(checksums, map[string]string{block.ChecksumSHA256: expected[block.ChecksumSHA256]}); diffs != nil {
+		t.Errorf("unexpected checksums from the sidecar: %s", diffs)
+	}
+
+	_, err = a.Checksums(ctx, obj, []string{"crc32"})
+	if !errors.Is(err, block.ErrUnsupportedChecksum) {
+		t.Errorf("Checksums with an unknown algorithm returned %v, expected %s", err, block.ErrUnsupportedChecksum)
+	}
+}
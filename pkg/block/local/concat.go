@@ -0,0 +1,63 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// ErrConcatDestinationIsSource is returned when concatenating objects into one of themselves.
+var ErrConcatDestinationIsSource = errors.New("concat destination is one of its sources")
+
+// Concat implements block.Concatenator by uniting the source files as for a multipart upload.
+// All sources are checked to exist before the destination is written.  As the united file is
+// not hashed, its ETag is computed when first needed.
+func (l *Adapter) Concat(ctx context.Context, storageNamespace, destIdentifier string, sourceIdentifiers []string) (_ int64, err error) {
+	defer wrapError(&err, "concat", destIdentifier)
+	destObj := block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: destIdentifier}
+	p, err := l.getPath(destObj)
+	if err != nil {
+		return 0, err
+	}
+	sources := make([]string, len(sourceIdentifiers))
+	for i, identifier := range sourceIdentifiers {
+		sources[i], err = l.getPath(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: identifier})
+		if err != nil {
+			return 0, err
+		}
+		if sources[i] == p {
+			return 0, fmt.Errorf("%w: %s", ErrConcatDestinationIsSource, identifier)
+		}
+	}
+	defer l.locks.lockMany(sources, p)()
+	var totalSize int64
+	for i, source := range sources {
+		info, err := os.Stat(source)
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("source %s: %w", sourceIdentifiers[i], err)
+		}
+		if err != nil {
+			return 0, err
+		}
+		totalSize += info.Size()
+	}
+	if err = l.verifyWritable(p); err != nil {
+		return 0, err
+	}
+	if err = l.verifyFreeSpace(totalSize); err != nil {
+		return 0, err
+	}
+	size, err := l.unitePartFiles(ctx, destObj, sources)
+	if err != nil {
+		return 0, err
+	}
+	if !l.dedup {
+		if err = os.Remove(p + etagSidecarSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, err
+		}
+	}
+	return size, l.finishPut(p, block.PutOpts{})
+}
@@ -0,0 +1,40 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// CopyPrefix implements block.PrefixCopier by copying each object with Copy.  The objects to
+// copy are listed before copying any, and part files of multipart uploads in progress are not
+// objects, so they are not copied.
+func (l *Adapter) CopyPrefix(ctx context.Context, storageNamespace, sourcePrefix, destinationPrefix string) (_ int, err error) {
+	defer wrapError(&err, "copy prefix", sourcePrefix+" to "+destinationPrefix)
+	if strings.HasPrefix(destinationPrefix, sourcePrefix) {
+		return 0, fmt.Errorf("%w: %s is under %s", ErrPrefixOverlap, destinationPrefix, sourcePrefix)
+	}
+	var keys []string
+	err = l.walkPrefix(block.WalkOpts{StorageNamespace: storageNamespace, Prefix: sourcePrefix}, func(key, _ string, _ os.FileInfo) error {
+		if _, _, ok := l.uploadPartOf(l.layoutKey(key)); !ok {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		source := block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: key}
+		destination := block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: destinationPrefix + strings.TrimPrefix(key, sourcePrefix)}
+		if err := l.Copy(ctx, source, destination); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
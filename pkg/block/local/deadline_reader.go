@@ -0,0 +1,34 @@
+package local
+
+import (
+	"context"
+	"io"
+)
+
+// deadlineCheckInterval is the number of bytes read between checks of the context.
+const deadlineCheckInterval = 1024 * 1024
+
+// deadlineReader fails reads with the context error once its context is done, so that long
+// copies abort on timeouts and client disconnects.  The context is checked every
+// deadlineCheckInterval bytes.
+type deadlineReader struct {
+	ctx        context.Context
+	r          io.Reader
+	sinceCheck int
+}
+
+func newDeadlineReader(ctx context.Context, r io.Reader) *deadlineReader {
+	return &deadlineReader{ctx: ctx, r: r, sinceCheck: deadlineCheckInterval}
+}
+
+func (d *deadlineReader) Read(p []byte) (int, error) {
+	if d.sinceCheck >= deadlineCheckInterval {
+		if err := d.ctx.Err(); err != nil {
+			return 0, err
+		}
+		d.sinceCheck = 0
+	}
+	n, err := d.r.Read(p)
+	d.sinceCheck += n
+	return n, err
+}
@@ -0,0 +1,103 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// Scratch objects expire: their expiry time is kept in a sidecar, and PurgeExpired removes them
+// once it passes.  Overwriting a scratch object removes its expiry, so it is permanent.
+const expiresSidecarSuffix = ".expires"
+
+var ErrInvalidTTL = errors.New("invalid TTL")
+
+// PutTemp implements block.Expirer.
+func (l *Adapter) PutTemp(ctx context.Context, obj block.ObjectPointer, sizeBytes int64, reader io.Reader, ttl time.Duration) (err error) {
+	defer wrapError(&err, "put temp", obj.Identifier)
+	if ttl <= 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidTTL, ttl)
+	}
+	p, err := l.getPath(obj)
+	if err != nil {
+		return err
+	}
+	defer l.locks.lock(p)()
+	if err = l.put(ctx, p, sizeBytes, reader, block.PutOpts{}); err != nil {
+		return err
+	}
+	return writeSidecar(p, expiresSidecarSuffix, time.Now().Add(ttl).UTC().Format(time.RFC3339Nano))
+}
+
+// clearExpiry removes the expiry of a scratch object previously written at p.
+func clearExpiry(p string) error {
+	if err := os.Remove(p + expiresSidecarSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// PurgeExpired implements block.Expirer.
+func (l *Adapter) PurgeExpired(ctx context.Context, storageNamespace string) (_ int, err error) {
+	defer wrapError(&err, "purge expired", storageNamespace)
+	keys, err := l.prefixKeys(block.WalkOpts{StorageNamespace: storageNamespace})
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		p, err := l.getPath(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: key, IdentifierType: block.IdentifierTypeRelative})
+		if err != nil {
+			return purged, err
+		}
+		removed, err := l.removeIfExpired(p)
+		if err != nil {
+			return purged, err
+		}
+		if removed {
+			purged++
+			if l.removeEmptyDir {
+				removeEmptyDirUntil(filepath.Dir(p), l.path)
+			}
+		}
+	}
+	return purged, nil
+}
+
+// removeIfExpired removes the object at p if it has an expiry time that passed, and returns
+// whether it did.
+func (l *Adapter) removeIfExpired(p string) (bool, error) {
+	defer l.locks.lock(p)()
+	value, ok, err := readSidecar(p, expiresSidecarSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		// removed since listed
+		return false, nil
+	}
+	if err != nil || !ok {
+		return false, err
+	}
+	expires, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return false, fmt.Errorf("expiry of %s: %w", p, err)
+	}
+	if time.Now().Before(expires) {
+		return false, nil
+	}
+	if held, err := isLegallyHeld(p); err != nil || held {
+		// held objects are kept until released
+		return false, err
+	}
+	if err := l.removeFile(p); err != nil {
+		return false, err
+	}
+	return true, nil
+}
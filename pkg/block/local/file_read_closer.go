@@ -0,0 +1,38 @@
+package local
+
+import (
+	"io"
+	"sync"
+)
+
+// fileReadCloser reads from a reader layered over an open file, and on Close closes every
+// layer that needs cleanup followed by the file itself.  Close returns the first error any of
+// them returned, and is safe to call more than once.
+type fileReadCloser struct {
+	io.Reader
+	closers []io.Closer
+	once    sync.Once
+	err     error
+}
+
+func newFileReadCloser(r io.Reader, closers ...io.Closer) *fileReadCloser {
+	return &fileReadCloser{Reader: r, closers: closers}
+}
+
+// fileReadSeekCloser is a fileReadCloser reading directly from the file, which it can also
+// seek.
+type fileReadSeekCloser struct {
+	*fileReadCloser
+	io.Seeker
+}
+
+func (f *fileReadCloser) Close() error {
+	f.once.Do(func() {
+		for _, c := range f.closers {
+			if err := c.Close(); err != nil && f.err == nil {
+				f.err = err
+			}
+		}
+	})
+	return f.err
+}
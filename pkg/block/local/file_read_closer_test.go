@@ -0,0 +1,48 @@
+package local
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var errCloseFailed = errors.New("close failed")
+
+type countingCloser struct {
+	closes int
+	err    error
+}
+
+func (c *countingCloser) Close() error {
+	c.closes++
+	return c.err
+}
+
+func TestFileReadCloserClose(t *testing.T) {
+	layer := &countingCloser{}
+	file := &countingCloser{err: errCloseFailed}
+	rc := newFileReadCloser(strings.NewReader("data"), layer, file)
+
+	if err := rc.Close(); !errors.Is(err, errCloseFailed) {
+		t.Errorf("Close() returned %v, expected %s", err, errCloseFailed)
+	}
+	if err := rc.Close(); !errors.Is(err, errCloseFailed) {
+		t.Errorf("second Close() returned %v, expected %s", err, errCloseFailed)
+	}
+	if layer.closes != 1 || file.closes != 1 {
+		t.Errorf("closed layer %d times and file %d times, expected once each", layer.closes, file.closes)
+	}
+}
+
+func TestFileReadCloserFirstError(t *testing.T) {
+	errLayer := errors.New("layer failed")
+	file := &countingCloser{err: errCloseFailed}
+	rc := newFileReadCloser(strings.NewReader("data"), &countingCloser{err: errLayer}, file)
+
+	if err := rc.Close(); !errors.Is(err, errLayer) {
+		t.Errorf("Close() returned %v, expected the first error %s", err, errLayer)
+	}
+	if file.closes != 1 {
+		t.Errorf("closed file %d times after layer failed, expected once", file.closes)
+	}
+}
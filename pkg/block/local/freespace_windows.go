@@ -0,0 +1,9 @@
+package local
+
+import "errors"
+
+var ErrFreeSpaceNotSupported = errors.New("free space check not supported on this platform")
+
+func freeSpace(_ string) (uint64, error) {
+	return 0, ErrFreeSpaceNotSupported
+}
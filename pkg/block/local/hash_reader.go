@@ -0,0 +1,26 @@
+package local
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+// hashReader hashes everything read through it.
+type hashReader struct {
+	r io.Reader
+	h hash.Hash
+}
+
+func newHashReader(r io.Reader, h hash.Hash) *hashReader {
+	return &hashReader{r: io.TeeReader(r, h), h: h}
+}
+
+func (h *hashReader) Read(p []byte) (int, error) {
+	return h.r.Read(p)
+}
+
+// HexSum returns the hex encoded hash of everything read so far.
+func (h *hashReader) HexSum() string {
+	return hex.EncodeToString(h.h.Sum(nil))
+}
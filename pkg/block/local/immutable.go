@@ -0,0 +1,55 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Immutable mode: objects are written once.  Writes refuse to replace existing objects, and
+// objects cannot be removed until their retention period since creation is over.  Creation
+// times are kept in a sidecar.
+const createdSidecarSuffix = ".created"
+
+var (
+	ErrObjectExists        = errors.New("object already exists")
+	ErrRetentionNotExpired = errors.New("object retention period not expired")
+)
+
+// recordCreation records the creation time of the object just written at p if the adapter is
+// immutable.
+func (l *Adapter) recordCreation(p string) error {
+	if !l.immutable {
+		return nil
+	}
+	return writeSidecar(p, createdSidecarSuffix, time.Now().UTC().Format(time.RFC3339Nano))
+}
+
+// verifyRetention returns ErrRetentionNotExpired if the adapter is immutable and the object at p
+// is still within its retention period.  Objects with no recorded creation time are retained
+// since their last modification.
+func (l *Adapter) verifyRetention(p string) error {
+	if !l.immutable {
+		return nil
+	}
+	var created time.Time
+	value, ok, err := readSidecar(p, createdSidecarSuffix)
+	if err != nil {
+		return err
+	}
+	if ok {
+		created, err = time.Parse(time.RFC3339Nano, value)
+	}
+	if !ok || err != nil {
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		created = info.ModTime()
+	}
+	if retainUntil := created.Add(l.retention); time.Now().Before(retainUntil) {
+		return fmt.Errorf("%w: retained until %s", ErrRetentionNotExpired, retainUntil.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package local
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"strings"
+)
+
+// PathLayout selects how object keys map to paths under a storage namespace.
+type PathLayout string
+
+const (
+	// PathLayoutFlat stores each object at its key.
+	PathLayoutFlat PathLayout = "flat"
+	// PathLayoutSharded stores each object at its key under shard directories named after the
+	// leading hex digits of the hash of the key, so that no single directory grows huge.
+	PathLayoutSharded PathLayout = "sharded"
+)
+
+const (
+	// shardLevels is the number of shard directories above each object in the sharded layout.
+	shardLevels = 2
+	// shardNameLength is the number of hex digits naming each shard directory.
+	shardNameLength = 2
+)
+
+// layoutKey returns the path of key relative to its storage namespace.
+func (l *Adapter) layoutKey(key string) string {
+	if l.pathLayout != PathLayoutSharded {
+		return key
+	}
+	return path.Join(shardDir(key), key)
+}
+
+// keyOf returns the key stored at relative path rel under its storage namespace, or false if
+// rel is not an object path in the configured layout.
+func (l *Adapter) keyOf(rel string) (string, bool) {
+	if l.softDelete && strings.HasPrefix(rel, trashDir+"/") {
+		return "", false
+	}
+	if l.pathLayout != PathLayoutSharded {
+		return rel, true
+	}
+	parts := strings.SplitN(rel, "/", shardLevels+1)
+	if len(parts) <= shardLevels {
+		return "", false
+	}
+	key := parts[shardLevels]
+	return key, strings.Join(parts[:shardLevels], "/") == shardDir(key)
+}
+
+func shardDir(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	digest := hex.EncodeToString(sum[:])
+	shards := make([]string, shardLevels)
+	for i := range shards {
+		shards[i] = digest[i*shardNameLength : (i+1)*shardNameLength]
+	}
+	return path.Join(shards...)
+}
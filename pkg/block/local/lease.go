@@ -0,0 +1,50 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrUploadInProgress = errors.New("another upload to the object is completing")
+
+// leases grants exclusive leases on keys.  Unlike stripedLocks, waiting for a lease respects
+// the context, and a lease can be requested without waiting.
+type leases struct {
+	mu   sync.Mutex
+	held map[string]chan struct{}
+}
+
+func newLeases() *leases {
+	return &leases{held: make(map[string]chan struct{})}
+}
+
+// acquire takes the lease on key and returns a function that releases it.  If the lease is
+// held it waits for its release until ctx is done, or fails with ErrUploadInProgress if wait
+// is false.
+func (l *leases) acquire(ctx context.Context, key string, wait bool) (func(), error) {
+	for {
+		l.mu.Lock()
+		released, held := l.held[key]
+		if !held {
+			released = make(chan struct{})
+			l.held[key] = released
+			l.mu.Unlock()
+			return func() {
+				l.mu.Lock()
+				delete(l.held, key)
+				l.mu.Unlock()
+				close(released)
+			}, nil
+		}
+		l.mu.Unlock()
+		if !wait {
+			return nil, ErrUploadInProgress
+		}
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLeasesFailFast(t *testing.T) {
+	l := newLeases()
+	release, err := l.acquire(context.Background(), "key", false)
+	if err != nil {
+		t.Fatalf("acquire free lease: %s", err)
+	}
+	if _, err := l.acquire(context.Background(), "key", false); !errors.Is(err, ErrUploadInProgress) {
+		t.Errorf("acquire held lease without waiting returned %v, expected %s", err, ErrUploadInProgress)
+	}
+	if _, err := l.acquire(context.Background(), "other", false); err != nil {
+		t.Errorf("acquire lease on another key: %s", err)
+	}
+	release()
+	if _, err := l.acquire(context.Background(), "key", false); err != nil {
+		t.Errorf("acquire released lease: %s", err)
+	}
+}
+
+func TestLeasesWait(t *testing.T) {
+	l := newLeases()
+	release, err := l.acquire(context.Background(), "key", true)
+	if err != nil {
+		t.Fatalf("acquire free lease: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, "key", true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wait for held lease past deadline returned %v, expected %s", err, context.DeadlineExceeded)
+	}
+
+	acquired := make(chan error)
+	go func() {
+		_, err := l.acquire(context.Background(), "key", true)
+		acquired <- err
+	}()
+	release()
+	if err := <-acquired; err != nil {
+		t.Errorf("wait for released lease: %s", err)
+	}
+}
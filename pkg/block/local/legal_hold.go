@@ -0,0 +1,64 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// Legal holds keep an object from being removed or overwritten until released, regardless of
+// retention.  An object is held while its legal hold sidecar exists.
+const legalHoldSidecarSuffix = ".legalhold"
+
+var ErrLegalHold = errors.New("object under legal hold")
+
+// SetLegalHold implements block.LegalHolder.
+func (l *Adapter) SetLegalHold(_ context.Context, obj block.ObjectPointer, on bool) (err error) {
+	defer wrapError(&err, "set legal hold", obj.Identifier)
+	p, err := l.getPath(obj)
+	if err != nil {
+		return err
+	}
+	defer l.locks.lock(p)()
+	if _, err := os.Stat(p); err != nil {
+		return err
+	}
+	if on {
+		return writeSidecar(p, legalHoldSidecarSuffix, "on")
+	}
+	if err := os.Remove(p + legalHoldSidecarSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// GetLegalHold implements block.LegalHolder.
+func (l *Adapter) GetLegalHold(_ context.Context, obj block.ObjectPointer) (_ bool, err error) {
+	defer wrapError(&err, "get legal hold", obj.Identifier)
+	p, err := l.getPath(obj)
+	if err != nil {
+		return false, err
+	}
+	defer l.locks.rlock(p)()
+	return isLegallyHeld(p)
+}
+
+// isLegallyHeld returns true if the object at p is under a legal hold.
+func isLegallyHeld(p string) (bool, error) {
+	_, ok, err := readSidecar(p, legalHoldSidecarSuffix)
+	return ok, err
+}
+
+// verifyNotHeld returns ErrLegalHold if the object at p is under a legal hold.
+func verifyNotHeld(p string) error {
+	held, err := isLegallyHeld(p)
+	if err != nil {
+		return err
+	}
+	if held {
+		return ErrLegalHold
+	}
+	return nil
+}
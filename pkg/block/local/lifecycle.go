@@ -0,0 +1,215 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// The lifecycle rules of a storage namespace are kept in a sidecar of its directory, so they are
+// not listed as an object of any storage namespace.
+const lifecycleSidecarSuffix = ".lifecycle"
+
+// lifecycleRule is a block.LifecycleRule as stored in a lifecycle sidecar.
+type lifecycleRule struct {
+	Prefix string `json:"prefix"`
+	MaxAge string `json:"max_age"`
+}
+
+// lifecyclePath returns the path of the lifecycle sidecar of storageNamespace.
+func (l *Adapter) lifecyclePath(storageNamespace string) (string, error) {
+	qualifiedPrefix, err := block.ResolveNamespacePrefix(storageNamespace, "")
+	if err != nil {
+		return "", err
+	}
+	if qualifiedPrefix.StorageType != block.StorageTypeLocal {
+		return "", block.ErrInvalidNamespace
+	}
+	p := path.Join(l.path, qualifiedPrefix.StorageNamespace) + lifecycleSidecarSuffix
+	if err := l.verifyPath(p); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// SetLifecycle implements block.LifecycleManager.
+func (l *Adapter) SetLifecycle(_ context.Context, storageNamespace string, rules []block.LifecycleRule) (err error) {
+	defer wrapError(&err, "set lifecycle", storageNamespace)
+	stored := make([]lifecycleRule, len(rules))
+	for i, rule := range rules {
+		if rule.MaxAge <= 0 {
+			return fmt.Errorf("%w: max age %s of prefix %q", block.ErrInvalidLifecycleRule, rule.MaxAge, rule.Prefix)
+		}
+		stored[i] = lifecycleRule{Prefix: rule.Prefix, MaxAge: rule.MaxAge.String()}
+	}
+	p, err := l.lifecyclePath(storageNamespace)
+	if err != nil {
+		return err
+	}
+	defer l.locks.lock(p)()
+	if len(rules) == 0 {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	value, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, value, 0600)
+}
+
+// getLifecycle returns the lifecycle rules of storageNamespace.
+func (l *Adapter) getLifecycle(storageNamespace string) ([]block.LifecycleRule, error) {
+	p, err := l.lifecyclePath(storageNamespace)
+	if err != nil {
+		return nil, err
+	}
+	defer l.locks.rlock(p)()
+	value, err := ioutil.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored []lifecycleRule
+	if err := json.Unmarshal(value, &stored); err != nil {
+		return nil, fmt.Errorf("lifecycle of %s: %w", storageNamespace, err)
+	}
+	rules := make([]block.LifecycleRule, len(stored))
+	for i, rule := range stored {
+		maxAge, err := time.ParseDuration(rule.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("lifecycle of %s: %w", storageNamespace, err)
+		}
+		rules[i] = block.LifecycleRule{Prefix: rule.Prefix, MaxAge: maxAge}
+	}
+	return rules, nil
+}
+
+// lifecycleRuleOf returns the rule with the longest prefix of key, and false if no rule applies
+// to it.
+func lifecycleRuleOf(rules []block.LifecycleRule, key string) (block.LifecycleRule, bool) {
+	var (
+		match block.LifecycleRule
+		found bool
+	)
+	for _, rule := range rules {
+		if strings.HasPrefix(key, rule.Prefix) && (!found || len(rule.Prefix) > len(match.Prefix)) {
+			match, found = rule, true
+		}
+	}
+	return match, found
+}
+
+// ApplyLifecycle implements block.LifecycleManager.  The age of an object is the time since its
+// last modification.  Objects under a legal hold or still retained are kept, and expired objects
+// go to the trash if the adapter soft deletes.
+func (l *Adapter) ApplyLifecycle(ctx context.Context, storageNamespace string) (_ int, err error) {
+	defer wrapError(&err, "apply lifecycle", storageNamespace)
+	rules, err := l.getLifecycle(storageNamespace)
+	if err != nil || len(rules) == 0 {
+		return 0, err
+	}
+	now := time.Now()
+	expiredBefore := make(map[string]time.Time)
+	err = l.walkPrefix(block.WalkOpts{StorageNamespace: storageNamespace}, func(key, p string, info os.FileInfo) error {
+		if _, _, ok := l.uploadPartOf(l.layoutKey(key)); ok {
+			return nil
+		}
+		rule, ok := lifecycleRuleOf(rules, key)
+		if !ok {
+			return nil
+		}
+		modified, err := lastModified(p, info)
+		if err != nil {
+			return err
+		}
+		if cutoff := now.Add(-rule.MaxAge); modified.Before(cutoff) {
+			expiredBefore[key] = cutoff
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	expired := 0
+	for key, cutoff := range expiredBefore {
+		if err := ctx.Err(); err != nil {
+			return expired, err
+		}
+		removed, err := l.removeModifiedBefore(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: key, IdentifierType: block.IdentifierTypeRelative}, cutoff)
+		if err != nil {
+			return expired, err
+		}
+		if removed {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// removeModifiedBefore removes obj if it was last modified before t and is neither held nor
+// retained, and returns whether it did.
+func (l *Adapter) removeModifiedBefore(obj block.ObjectPointer, t time.Time) (bool, error) {
+	p, err := l.getPath(obj)
+	if err != nil {
+		return false, err
+	}
+	p = filepath.Clean(p)
+	unlock := l.locks.lock(p)
+	removed, err := l.deleteIfModifiedBefore(p, obj, t)
+	unlock()
+	if err != nil || !removed {
+		return false, err
+	}
+	if l.removeEmptyDir {
+		removeEmptyDirUntil(filepath.Dir(p), l.path)
+	}
+	return true, nil
+}
+
+func (l *Adapter) deleteIfModifiedBefore(p string, obj block.ObjectPointer, t time.Time) (bool, error) {
+	info, err := os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		// removed since listed
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	modified, err := lastModified(p, info)
+	if err != nil {
+		return false, err
+	}
+	if !modified.Before(t) {
+		// rewritten since listed
+		return false, nil
+	}
+	if held, err := isLegallyHeld(p); err != nil || held {
+		return false, err
+	}
+	if err := l.verifyRetention(p); errors.Is(err, ErrRetentionNotExpired) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if err := l.deleteFile(p, obj); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,8 @@
+package local
+
+import "os"
+
+// linkCount is not available on Windows, so blobs are never released there.
+func linkCount(_ os.FileInfo) (uint64, bool) {
+	return 0, false
+}
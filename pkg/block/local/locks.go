@@ -0,0 +1,89 @@
+package local
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// lockStripes is the number of locks shared by all objects.  Each object path maps to one
+// of them by hash, so locking takes a fixed lockStripes RWMutexes of memory regardless of the
+// number of objects, at the cost of unrelated objects occasionally sharing a lock.
+const lockStripes = 256
+
+// stripedLocks serializes writers of an object with its other writers and with readers opening
+// it.  Writers replace objects by renaming, so readers only lock an object until it is open.
+type stripedLocks struct {
+	stripes [lockStripes]sync.RWMutex
+}
+
+func (s *stripedLocks) stripe(p string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(p))
+	return int(h.Sum32() % lockStripes)
+}
+
+// lock write-locks the object at p and returns a function that unlocks it.
+func (s *stripedLocks) lock(p string) func() {
+	m := &s.stripes[s.stripe(p)]
+	m.Lock()
+	return m.Unlock
+}
+
+// rlock read-locks the object at p and returns a function that unlocks it.
+func (s *stripedLocks) rlock(p string) func() {
+	m := &s.stripes[s.stripe(p)]
+	m.RLock()
+	return m.RUnlock
+}
+
+// lockCopy read-locks the object at source and write-locks the object at dest, and returns a
+// function that unlocks both.  Stripes are locked in order to avoid deadlocks between
+// concurrent copies, and a single stripe shared by both objects is only write-locked.
+func (s *stripedLocks) lockCopy(source, dest string) func() {
+	sourceStripe, destStripe := s.stripe(source), s.stripe(dest)
+	switch {
+	case sourceStripe == destStripe:
+		return s.lock(dest)
+	case sourceStripe < destStripe:
+		unlockSource := s.rlock(source)
+		unlockDest := s.lock(dest)
+		return func() { unlockDest(); unlockSource() }
+	default:
+		unlockDest := s.lock(dest)
+		unlockSource := s.rlock(source)
+		return func() { unlockSource(); unlockDest() }
+	}
+}
+
+// lockMany read-locks the objects at sources and write-locks the object at dest, and returns a
+// function that unlocks all of them.  As in lockCopy, stripes are locked in order and a stripe
+// shared with dest is only write-locked.
+func (s *stripedLocks) lockMany(sources []string, dest string) func() {
+	write := map[int]bool{s.stripe(dest): true}
+	for _, source := range sources {
+		stripe := s.stripe(source)
+		write[stripe] = write[stripe]
+	}
+	stripes := make([]int, 0, len(write))
+	for stripe := range write {
+		stripes = append(stripes, stripe)
+	}
+	sort.Ints(stripes)
+	for _, stripe := range stripes {
+		if write[stripe] {
+			s.stripes[stripe].Lock()
+		} else {
+			s.stripes[stripe].RLock()
+		}
+	}
+	return func() {
+		for i := len(stripes) - 1; i >= 0; i-- {
+			if write[stripes[i]] {
+				s.stripes[stripes[i]].Unlock()
+			} else {
+				s.stripes[stripes[i]].RUnlock()
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package local
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+const (
+	// maxManifestLineSize is the size of the longest manifest line that can be read.
+	maxManifestLineSize = 1024 * 1024
+
+	manifestLineFields = 3
+)
+
+// GetManifest implements block.ManifestReader.
+func (l *Adapter) GetManifest(ctx context.Context, obj block.ObjectPointer) (_ []block.ManifestEntry, err error) {
+	defer wrapError(&err, "get manifest", obj.Identifier)
+	reader, err := l.Get(ctx, obj, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxManifestLineSize)
+	entries := make([]block.ManifestEntry, 0)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		entry, err := parseManifestLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseManifestLine parses a non-empty manifest line into its entry, or returns an error
+// wrapping block.ErrInvalidManifest.
+func parseManifestLine(line string) (block.ManifestEntry, error) {
+	entry := block.ManifestEntry{SizeBytes: -1}
+	if strings.HasPrefix(line, "{") {
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return block.ManifestEntry{}, fmt.Errorf("%w: %s", block.ErrInvalidManifest, err)
+		}
+	} else {
+		fields := strings.Split(line, ",")
+		if len(fields) > manifestLineFields {
+			return block.ManifestEntry{}, fmt.Errorf("%w: got %d fields, expected at most %d", block.ErrInvalidManifest, len(fields), manifestLineFields)
+		}
+		entry.PhysicalAddress = strings.TrimSpace(fields[0])
+		if len(fields) > 1 {
+			size, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+			if err != nil {
+				return block.ManifestEntry{}, fmt.Errorf("%w: invalid size %q", block.ErrInvalidManifest, fields[1])
+			}
+			entry.SizeBytes = size
+		}
+		if len(fields) > 2 {
+			entry.ETag = strings.TrimSpace(fields[2])
+		}
+	}
+	if entry.PhysicalAddress == "" {
+		return block.ManifestEntry{}, fmt.Errorf("%w: missing physical address", block.ErrInvalidManifest)
+	}
+	if entry.SizeBytes < -1 {
+		return block.ManifestEntry{}, fmt.Errorf("%w: invalid size %d", block.ErrInvalidManifest, entry.SizeBytes)
+	}
+	return entry, nil
+}
@@ -0,0 +1,50 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+)
+
+// Reflinks let files share their data extents on filesystems that support it, such as Btrfs
+// and XFS on Linux, so that copies and multipart assembly need not copy any bytes.  Support
+// cannot be detected up front: it depends on the platform, the filesystem and the kernel, so
+// reflinks are attempted and copying falls back to reading and writing when they fail.
+
+var errReflinkNotSupported = errors.New("reflink not supported on this platform")
+
+// appendFile copies src to dst at offset, sharing extents when possible, and returns the
+// number of bytes copied.
+func appendFile(dst, src *os.File, offset int64) (int64, error) {
+	copied, err := copyFileRange(dst, src, offset)
+	if err == nil {
+		return copied, nil
+	}
+	// fall back to copying the rest of src
+	if _, err := src.Seek(copied, io.SeekStart); err != nil {
+		return copied, err
+	}
+	if _, err := dst.Seek(offset+copied, io.SeekStart); err != nil {
+		return copied, err
+	}
+	n, err := io.Copy(dst, src)
+	return copied + n, err
+}
+
+// appendFiles concatenates srcs into dst, sharing extents when possible, and returns the
+// number of bytes copied.  The context is checked between files.
+func appendFiles(ctx context.Context, dst *os.File, srcs []*os.File) (int64, error) {
+	var size int64
+	for _, src := range srcs {
+		if err := ctx.Err(); err != nil {
+			return size, err
+		}
+		n, err := appendFile(dst, src, size)
+		size += n
+		if err != nil {
+			return size, err
+		}
+	}
+	return size, nil
+}
@@ -0,0 +1,30 @@
+package local
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyFileRangeChunk is the most bytes requested from a single copy_file_range call.
+const copyFileRangeChunk = 1 << 30
+
+// reflink makes dst share all extents of src using the FICLONE ioctl.
+func reflink(dst, src *os.File) error {
+	return unix.IoctlFileClone(int(dst.Fd()), int(src.Fd()))
+}
+
+// copyFileRange copies src to dst at offset using copy_file_range, which shares extents on
+// filesystems that support it.  It returns the number of bytes copied before any error.
+func copyFileRange(dst, src *os.File, offset int64) (int64, error) {
+	var srcOffset int64
+	for {
+		n, err := unix.CopyFileRange(int(src.Fd()), &srcOffset, int(dst.Fd()), &offset, copyFileRangeChunk, 0)
+		if err != nil {
+			return srcOffset, err
+		}
+		if n == 0 {
+			return srcOffset, nil
+		}
+	}
+}
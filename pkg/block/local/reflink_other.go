@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package local
+
+import "os"
+
+func reflink(_, _ *os.File) error {
+	return errReflinkNotSupported
+}
+
+func copyFileRange(_, _ *os.File, _ int64) (int64, error) {
+	return 0, errReflinkNotSupported
+}
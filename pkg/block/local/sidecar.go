@@ -0,0 +1,76 @@
+package local
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Sidecar files hold information computed about an object next to it, so that it need not be
+// recomputed on every access.  A sidecar for the object stored at path p is stored at p+suffix.
+// As a result, identifiers with a path element ending in a sidecar suffix are rejected with
+// ErrReservedIdentifier: they would overwrite or shadow the sidecars of other objects.
+const (
+	etagSidecarSuffix = ".etag"
+	blobSidecarSuffix = ".blob"
+)
+
+var sidecarSuffixes = []string{etagSidecarSuffix, blobSidecarSuffix, createdSidecarSuffix, storageClassSidecarSuffix, expiresSidecarSuffix, legalHoldSidecarSuffix, checksumsSidecarSuffix, deletedSidecarSuffix, lifecycleSidecarSuffix, modifiedSidecarSuffix}
+
+func isSidecar(p string) bool {
+	for _, suffix := range sidecarSuffixes {
+		if strings.HasSuffix(p, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReservedKey returns true if an element of key ends with a sidecar suffix.
+func isReservedKey(key string) bool {
+	for _, elem := range strings.Split(key, "/") {
+		if isSidecar(elem) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeSidecar(p, suffix, value string) error {
+	return ioutil.WriteFile(p+suffix, []byte(value), 0600)
+}
+
+// readSidecar returns the value of the sidecar of the object at p, and false if it is missing or
+// older than the object.
+func readSidecar(p, suffix string) (string, bool, error) {
+	objInfo, err := os.Stat(p)
+	if err != nil {
+		return "", false, err
+	}
+	sidecarInfo, err := os.Stat(p + suffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if sidecarInfo.ModTime().Before(objInfo.ModTime()) {
+		return "", false, nil
+	}
+	value, err := ioutil.ReadFile(p + suffix)
+	if err != nil {
+		return "", false, err
+	}
+	return string(value), true, nil
+}
+
+// removeSidecars removes all sidecars of the object at p, ignoring missing sidecars.
+func removeSidecars(p string) error {
+	for _, suffix := range sidecarSuffixes {
+		if err := os.Remove(p + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
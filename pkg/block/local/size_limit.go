@@ -0,0 +1,49 @@
+package local
+
+import (
+	"fmt"
+	"io"
+)
+
+// verifyObjectSize returns ErrObjectTooLarge if an object of sizeBytes exceeds the configured
+// maximal object size.  Unknown (negative) sizes are not checked.
+func (l *Adapter) verifyObjectSize(sizeBytes int64) error {
+	if l.maxObjectSize <= 0 || sizeBytes <= l.maxObjectSize {
+		return nil
+	}
+	return fmt.Errorf("%w: %d bytes, limit is %d", ErrObjectTooLarge, sizeBytes, l.maxObjectSize)
+}
+
+// limitObjectSize returns a reader of the object of sizeBytes read from reader that fails once
+// the object grows past the configured maximal object size, or ErrObjectTooLarge if its known
+// size already exceeds it.
+func (l *Adapter) limitObjectSize(sizeBytes int64, reader io.Reader) (io.Reader, error) {
+	if l.maxObjectSize <= 0 {
+		return reader, nil
+	}
+	if err := l.verifyObjectSize(sizeBytes); err != nil {
+		return nil, err
+	}
+	return newSizeLimitReader(reader, l.maxObjectSize), nil
+}
+
+// sizeLimitReader fails reads with ErrObjectTooLarge once more than limit bytes are read, so
+// that writes of streams of unknown size stop as soon as they grow past the limit.
+type sizeLimitReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func newSizeLimitReader(r io.Reader, limit int64) *sizeLimitReader {
+	return &sizeLimitReader{r: r, limit: limit}
+}
+
+func (s *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.read += int64(n)
+	if s.read > s.limit {
+		return n, fmt.Errorf("%w: read over %d bytes, limit is %d", ErrObjectTooLarge, s.read, s.limit)
+	}
+	return n, err
+}
@@ -0,0 +1,49 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// Storage classes are advisory on the local adapter: all objects are stored the same way, and
+// the class an object was given is only recorded in a sidecar and reported back by Stat and
+// GetProperties.
+const storageClassSidecarSuffix = ".storageclass"
+
+// SetStorageClass implements block.Tierer.
+func (l *Adapter) SetStorageClass(_ context.Context, obj block.ObjectPointer, class string) (err error) {
+	defer wrapError(&err, "set storage class", obj.Identifier)
+	p, err := l.getPath(obj)
+	if err != nil {
+		return err
+	}
+	defer l.locks.lock(p)()
+	if _, err := os.Stat(p); err != nil {
+		return err
+	}
+	return writeSidecar(p, storageClassSidecarSuffix, class)
+}
+
+// writeStorageClass records the storage class of the object just written at p, or removes a
+// class recorded for a previous object at p.
+func writeStorageClass(p string, class *string) error {
+	if class != nil {
+		return writeSidecar(p, storageClassSidecarSuffix, *class)
+	}
+	if err := os.Remove(p + storageClassSidecarSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// readStorageClass returns the storage class recorded for the object at p, or nil if none was.
+func readStorageClass(p string) (*string, error) {
+	class, ok, err := readSidecar(p, storageClassSidecarSuffix)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &class, nil
+}
@@ -0,0 +1,55 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// StorageSize implements block.StorageSizer.  It counts every file stored under the storage
+// namespace except sidecars: objects, part files of uploads in progress and the trash.  Objects
+// sharing a blob under WithDedup are stored once, so they are counted once.
+func (l *Adapter) StorageSize(_ context.Context, storageNamespace string) (_ int64, err error) {
+	defer wrapError(&err, "storage size", storageNamespace)
+	qualifiedPrefix, err := block.ResolveNamespacePrefix(storageNamespace, "")
+	if err != nil {
+		return 0, err
+	}
+	if qualifiedPrefix.StorageType != block.StorageTypeLocal {
+		return 0, block.ErrInvalidNamespace
+	}
+	namespacePath := path.Join(l.path, qualifiedPrefix.StorageNamespace)
+	if err := l.verifyPath(namespacePath); err != nil {
+		return 0, err
+	}
+	var size int64
+	blobs := make(map[string]bool)
+	err = filepath.Walk(namespacePath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || isSidecar(p) {
+			return nil
+		}
+		digest, err := readBlobDigest(p)
+		if err != nil {
+			return err
+		}
+		if digest != "" {
+			if blobs[digest] {
+				return nil
+			}
+			blobs[digest] = true
+		}
+		size += info.Size()
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	return size, err
+}
@@ -0,0 +1,199 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// Soft deletes move removed objects to a trash directory in their storage namespace, along with
+// their sidecars and a sidecar holding their deletion time.  Keys under the trash directory are
+// reserved and not listed.  The trash holds the last removed version of each key.
+const (
+	trashDir             = ".trash"
+	deletedSidecarSuffix = ".deleted"
+)
+
+// trashPath returns the path of obj in the trash.
+func (l *Adapter) trashPath(obj block.ObjectPointer) (string, error) {
+	qualifiedKey, err := resolveNamespace(obj)
+	if err != nil {
+		return "", err
+	}
+	tp := path.Join(l.path, qualifiedKey.StorageNamespace, trashDir, qualifiedKey.Key)
+	if err = l.verifyPath(tp); err != nil {
+		return "", err
+	}
+	return tp, nil
+}
+
+// deleteFile removes obj stored at p, or moves it to the trash if the adapter soft deletes.
+func (l *Adapter) deleteFile(p string, obj block.ObjectPointer) error {
+	if !l.softDelete {
+		return l.removeFile(p)
+	}
+	if err := verifyNotHeld(p); err != nil {
+		return err
+	}
+	if err := l.verifyRetention(p); err != nil {
+		return err
+	}
+	tp, err := l.trashPath(obj)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(p); err != nil {
+		return err
+	}
+	// replace the previously removed version
+	if err := l.dropFile(tp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := moveFile(p, tp); err != nil {
+		return err
+	}
+	return writeSidecar(tp, deletedSidecarSuffix, time.Now().UTC().Format(time.RFC3339Nano))
+}
+
+// moveFile moves the object at source along with its sidecars to dest.
+func moveFile(source, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+		return err
+	}
+	if err := os.Rename(source, dest); err != nil {
+		return err
+	}
+	for _, suffix := range sidecarSuffixes {
+		if err := os.Rename(source+suffix, dest+suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreFromTrash implements block.Trasher.  It returns ErrObjectExists rather than replace an
+// object written since obj was removed.
+func (l *Adapter) RestoreFromTrash(_ context.Context, obj block.ObjectPointer) (err error) {
+	defer wrapError(&err, "restore from trash", obj.Identifier)
+	p, err := l.getPath(obj)
+	if err != nil {
+		return err
+	}
+	p = filepath.Clean(p)
+	tp, err := l.trashPath(obj)
+	if err != nil {
+		return err
+	}
+	defer l.locks.lock(p)()
+	if _, err := os.Stat(tp); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("not in trash: %w", err)
+	} else if err != nil {
+		return err
+	}
+	if _, err := os.Stat(p); err == nil {
+		return ErrObjectExists
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := moveFile(tp, p); err != nil {
+		return err
+	}
+	if err := os.Remove(p + deletedSidecarSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if l.removeEmptyDir {
+		removeEmptyDirUntil(filepath.Dir(tp), l.path)
+	}
+	return nil
+}
+
+// EmptyTrash implements block.Trasher.
+func (l *Adapter) EmptyTrash(ctx context.Context, storageNamespace string, olderThan time.Duration) (_ int, err error) {
+	defer wrapError(&err, "empty trash", storageNamespace)
+	qualifiedPrefix, err := block.ResolveNamespacePrefix(storageNamespace, "")
+	if err != nil {
+		return 0, err
+	}
+	if qualifiedPrefix.StorageType != block.StorageTypeLocal {
+		return 0, block.ErrInvalidNamespace
+	}
+	trashRoot := path.Join(l.path, qualifiedPrefix.StorageNamespace, trashDir)
+	if err := l.verifyPath(trashRoot); err != nil {
+		return 0, err
+	}
+	var keys []string
+	err = filepath.Walk(trashRoot, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !isSidecar(p) {
+			keys = append(keys, strings.TrimPrefix(p, trashRoot+"/"))
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	emptied := 0
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return emptied, err
+		}
+		removed, err := l.removeTrashedBefore(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: key, IdentifierType: block.IdentifierTypeRelative}, time.Now().Add(-olderThan))
+		if err != nil {
+			return emptied, err
+		}
+		if removed {
+			emptied++
+		}
+	}
+	return emptied, nil
+}
+
+// removeTrashedBefore removes obj from the trash if it was removed before t, and returns whether
+// it did.
+func (l *Adapter) removeTrashedBefore(obj block.ObjectPointer, t time.Time) (bool, error) {
+	p, err := l.getPath(obj)
+	if err != nil {
+		return false, err
+	}
+	tp, err := l.trashPath(obj)
+	if err != nil {
+		return false, err
+	}
+	defer l.locks.lock(filepath.Clean(p))()
+	value, ok, err := readSidecar(tp, deletedSidecarSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		// restored since listed
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		deleted, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return false, fmt.Errorf("deletion time of %s: %w", tp, err)
+		}
+		if !deleted.Before(t) {
+			return false, nil
+		}
+	}
+	if err := l.dropFile(tp); err != nil {
+		return false, err
+	}
+	if l.removeEmptyDir {
+		removeEmptyDirUntil(filepath.Dir(tp), l.path)
+	}
+	return true, nil
+}
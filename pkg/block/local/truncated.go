@@ -0,0 +1,52 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// RepairTruncated implements block.TruncationRepairer.  Truncated objects are removed if the
+// adapter removes truncated objects, unless they are held or retained.  Objects larger than
+// expectedSize are not truncated, and are left as they are.
+func (l *Adapter) RepairTruncated(_ context.Context, obj block.ObjectPointer, expectedSize int64) (err error) {
+	defer wrapError(&err, "repair truncated", obj.Identifier)
+	p, err := l.getPath(obj)
+	if err != nil {
+		return err
+	}
+	p = filepath.Clean(p)
+	unlock := l.locks.lock(p)
+	removed, err := l.repairTruncated(p, expectedSize)
+	unlock()
+	if err != nil {
+		return err
+	}
+	if removed && l.removeEmptyDir {
+		removeEmptyDirUntil(filepath.Dir(p), l.path)
+	}
+	return nil
+}
+
+// repairTruncated checks the object at p is at least expectedSize bytes, and returns whether it
+// removed it for being truncated.
+func (l *Adapter) repairTruncated(p string, expectedSize int64) (bool, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return false, err
+	}
+	if info.Size() >= expectedSize {
+		return false, nil
+	}
+	truncatedErr := fmt.Errorf("%w: %s has %d bytes, expected %d", block.ErrObjectTruncated, p, info.Size(), expectedSize)
+	if !l.removeTruncated {
+		return false, truncatedErr
+	}
+	if err := l.removeFile(p); err != nil {
+		return false, err
+	}
+	return true, nil
+}
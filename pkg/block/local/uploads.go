@@ -0,0 +1,159 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// Part files of a multipart upload are stored in a directory at the path of its upload ID, named
+// by the part number after uploadPartPrefix.  Earlier versions stored them next to that path,
+// named by the upload ID and the part number separated by legacyPartSeparator.
+const (
+	uploadPartPrefix    = "part-"
+	legacyPartSeparator = "-"
+)
+
+var (
+	// uploadIDPattern matches upload IDs as generated by CreateMultiPartUpload.
+	uploadIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	// uploadPartPattern matches the names of part files in upload directories.
+	uploadPartPattern = regexp.MustCompile(`^` + uploadPartPrefix + `([0-9]+)$`)
+	// legacyUploadPartPattern matches the names of part files stored next to the path of their
+	// upload ID.
+	legacyUploadPartPattern = regexp.MustCompile(`^([0-9a-f]{32})` + legacyPartSeparator + `([0-9]+)$`)
+)
+
+// uploadPart is a part file of an in-progress multipart upload.
+type uploadPart struct {
+	uploadID   string
+	partNumber int64
+	path       string
+	info       os.FileInfo
+}
+
+// walkUploadParts calls fn with every part file of in-progress multipart uploads under
+// storageNamespace, in no particular order.
+func (l *Adapter) walkUploadParts(storageNamespace string, fn func(part uploadPart)) error {
+	qualifiedPrefix, err := block.ResolveNamespacePrefix(storageNamespace, "")
+	if err != nil {
+		return err
+	}
+	if qualifiedPrefix.StorageType != block.StorageTypeLocal {
+		return block.ErrInvalidNamespace
+	}
+	namespacePath := path.Join(l.path, qualifiedPrefix.StorageNamespace)
+	if err := l.verifyPath(namespacePath); err != nil {
+		return err
+	}
+	err = filepath.Walk(namespacePath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || isSidecar(p) {
+			return nil
+		}
+		uploadID, partNumber, ok := l.uploadPartOf(strings.TrimPrefix(p, namespacePath+"/"))
+		if ok {
+			fn(uploadPart{uploadID: uploadID, partNumber: partNumber, path: p, info: info})
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// uploadPartOf returns the upload ID and part number of the part file at rel, a path relative
+// to its storage namespace, and false if rel is not a part file.
+func (l *Adapter) uploadPartOf(rel string) (string, int64, bool) {
+	dir, name := path.Dir(rel), path.Base(rel)
+	if match := uploadPartPattern.FindStringSubmatch(name); match != nil {
+		// parts are stored in the directory at the path of their upload ID in the configured
+		// layout
+		uploadID := path.Base(dir)
+		if !uploadIDPattern.MatchString(uploadID) || dir != l.layoutKey(uploadID) {
+			return "", 0, false
+		}
+		return parseUploadPart(uploadID, match[1])
+	}
+	if match := legacyUploadPartPattern.FindStringSubmatch(name); match != nil {
+		// legacy parts are stored next to the path of their upload ID
+		if dir != path.Dir(l.layoutKey(match[1])) {
+			return "", 0, false
+		}
+		return parseUploadPart(match[1], match[2])
+	}
+	return "", 0, false
+}
+
+func parseUploadPart(uploadID, partNumber string) (string, int64, bool) {
+	n, err := strconv.ParseInt(partNumber, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return uploadID, n, true
+}
+
+// RecoverUploads implements block.UploadRecoverer by grouping the part files under
+// storageNamespace by their upload IDs.  Only uploads with at least one part can be found.
+func (l *Adapter) RecoverUploads(_ context.Context, storageNamespace string) (_ []block.RecoveredUpload, err error) {
+	defer wrapError(&err, "recover uploads", storageNamespace)
+	parts := make(map[string][]block.RecoveredPart)
+	err = l.walkUploadParts(storageNamespace, func(part uploadPart) {
+		parts[part.uploadID] = append(parts[part.uploadID], block.RecoveredPart{PartNumber: part.partNumber, SizeBytes: part.info.Size()})
+	})
+	if err != nil {
+		return nil, err
+	}
+	uploads := make([]block.RecoveredUpload, 0, len(parts))
+	for uploadID, uploadParts := range parts {
+		sort.Slice(uploadParts, func(i, j int) bool {
+			return uploadParts[i].PartNumber < uploadParts[j].PartNumber
+		})
+		uploads = append(uploads, block.RecoveredUpload{UploadID: uploadID, Parts: uploadParts})
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].UploadID < uploads[j].UploadID
+	})
+	return uploads, nil
+}
+
+// ListMultipartUploads implements block.MultipartUploadLister by grouping the part files under
+// storageNamespace by their upload IDs.
+func (l *Adapter) ListMultipartUploads(_ context.Context, storageNamespace string) (_ []block.MultipartUploadInfo, err error) {
+	defer wrapError(&err, "list multipart uploads", storageNamespace)
+	infos := make(map[string]*block.MultipartUploadInfo)
+	err = l.walkUploadParts(storageNamespace, func(part uploadPart) {
+		info, ok := infos[part.uploadID]
+		if !ok {
+			info = &block.MultipartUploadInfo{UploadID: part.uploadID, Initiated: part.info.ModTime()}
+			infos[part.uploadID] = info
+		}
+		info.Parts++
+		info.SizeBytes += part.info.Size()
+		if part.info.ModTime().Before(info.Initiated) {
+			info.Initiated = part.info.ModTime()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	uploads := make([]block.MultipartUploadInfo, 0, len(infos))
+	for _, info := range infos {
+		uploads = append(uploads, *info)
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].UploadID < uploads[j].UploadID
+	})
+	return uploads, nil
+}
@@ -0,0 +1,114 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// WalkPage implements block.WalkPager.  The file system cannot seek into a walk, so each page
+// collects all keys under the prefix, and returns those sorted after after.
+func (l *Adapter) WalkPage(_ context.Context, walkOpt block.WalkOpts, after string, limit int) (_ []string, _ string, err error) {
+	defer wrapError(&err, "walk page", walkOpt.Prefix)
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("%w: %d", block.ErrInvalidLimit, limit)
+	}
+	keys, err := l.prefixKeys(walkOpt)
+	if err != nil {
+		return nil, "", err
+	}
+	sort.Strings(keys)
+	start := sort.Search(len(keys), func(i int) bool { return keys[i] > after })
+	keys = keys[start:]
+	if len(keys) <= limit {
+		return keys, "", nil
+	}
+	keys = keys[:limit]
+	return keys, keys[limit-1], nil
+}
+
+// prefixKeys returns the keys of all objects under walkOpt, in no particular order.
+func (l *Adapter) prefixKeys(walkOpt block.WalkOpts) ([]string, error) {
+	var keys []string
+	err := l.walkPrefix(walkOpt, func(key, _ string, _ os.FileInfo) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys, err
+}
+
+// walkPrefix calls fn with the key, path and file info of all objects under walkOpt, in no
+// particular order.
+func (l *Adapter) walkPrefix(walkOpt block.WalkOpts, fn func(key, p string, info os.FileInfo) error) error {
+	qualifiedPrefix, err := block.ResolveNamespacePrefix(walkOpt.StorageNamespace, walkOpt.Prefix)
+	if err != nil {
+		return err
+	}
+	if qualifiedPrefix.StorageType != block.StorageTypeLocal {
+		return block.ErrInvalidNamespace
+	}
+	namespacePath := path.Join(l.path, qualifiedPrefix.StorageNamespace)
+	if err := l.verifyPath(path.Join(namespacePath, qualifiedPrefix.Prefix)); err != nil {
+		return err
+	}
+	walkRoot := namespacePath
+	if i := strings.LastIndex(qualifiedPrefix.Prefix, "/"); i >= 0 && l.pathLayout != PathLayoutSharded {
+		walkRoot = path.Join(namespacePath, qualifiedPrefix.Prefix[:i])
+	}
+
+	err = filepath.Walk(walkRoot, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || isSidecar(p) {
+			return nil
+		}
+		key, ok := l.keyOf(strings.TrimPrefix(p, namespacePath+"/"))
+		if ok && strings.HasPrefix(key, qualifiedPrefix.Prefix) {
+			return fn(key, p, info)
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// WalkWithProperties implements block.PropertiesWalker.  Properties come from the file
+// information of the walk and from sidecars, so no object is read: objects whose ETag was not
+// yet computed have none.  Part files of multipart uploads in progress are not objects, and are
+// skipped.
+func (l *Adapter) WalkWithProperties(_ context.Context, walkOpt block.WalkOpts, walkFn func(identifier string, props block.ObjectProperties) error) (err error) {
+	defer wrapError(&err, "walk with properties", walkOpt.Prefix)
+	return l.walkPrefix(walkOpt, func(key, p string, info os.FileInfo) error {
+		if _, _, ok := l.uploadPartOf(l.layoutKey(key)); ok {
+			return nil
+		}
+		etag, _, err := readSidecar(p, etagSidecarSuffix)
+		if err != nil {
+			return err
+		}
+		storageClass, err := readStorageClass(p)
+		if err != nil {
+			return err
+		}
+		modified, err := lastModified(p, info)
+		if err != nil {
+			return err
+		}
+		return walkFn(key, block.ObjectProperties{
+			Size:         info.Size(),
+			ETag:         etag,
+			LastModified: modified,
+			StorageClass: storageClass,
+		})
+	})
+}
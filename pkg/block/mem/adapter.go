@@ -280,9 +280,10 @@ func (a *Adapter) Walk(_ context.Context, walkOpt block.WalkOpts, walkFn block.W
 	defer a.mutex.RUnlock()
 
 	fullPrefix := getPrefix(walkOpt)
+	namespacePrefix := getPrefix(block.WalkOpts{StorageNamespace: walkOpt.StorageNamespace})
 	for k := range a.data {
 		if strings.HasPrefix(k, fullPrefix) {
-			if err := walkFn(k); err != nil {
+			if err := walkFn(strings.TrimPrefix(k, namespacePrefix)); err != nil {
 				return err
 			}
 		}
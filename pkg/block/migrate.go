@@ -0,0 +1,246 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultMigrateConcurrency = 10
+	migratePageSize           = 1000
+)
+
+// MigrateParams configures Migrate.
+type MigrateParams struct {
+	// SourceNamespace is the storage namespace to copy objects from.
+	SourceNamespace string
+	// DestinationNamespace is the storage namespace to copy objects to, under the same
+	// identifiers.
+	DestinationNamespace string
+	// Concurrency is the number of objects copied at once.  Zero uses a default.
+	Concurrency int
+	// Progress, if set, is called for each object once it is in the destination, with
+	// copied false if it was already there.  It is called concurrently by all workers.
+	Progress func(identifier string, copied bool)
+}
+
+// Migrate copies all objects of params.SourceNamespace on src to params.DestinationNamespace on
+// dst, e.g. to move a repository to another blockstore.  It lists src by pages if src is a
+// WalkPager, and otherwise by a single Walk.  Objects already present on dst with the same size
+// (and ETag, if both blockstores are of the same type and report one) are skipped, so a failed
+// migration can be resumed by running it again.  Migrate stops on the first error.
+func Migrate(ctx context.Context, src, dst Adapter, params MigrateParams) error {
+	concurrency := params.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultMigrateConcurrency
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	identifiers := make(chan string)
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for identifier := range identifiers {
+				copied, err := migrateObject(ctx, src, dst, params, identifier)
+				if err != nil {
+					fail(fmt.Errorf("migrate %s: %w", identifier, err))
+					continue
+				}
+				if params.Progress != nil {
+					params.Progress(identifier, copied)
+				}
+			}
+		}()
+	}
+
+	err := listObjects(ctx, src, params.SourceNamespace, func(identifier string) error {
+		select {
+		case identifiers <- identifier:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil {
+		fail(err)
+	}
+	close(identifiers)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// listObjects calls fn with the identifier of each object of namespace on adapter, relative to
+// namespace.
+func listObjects(ctx context.Context, adapter Adapter, namespace string, fn func(identifier string) error) error {
+	walkOpts := WalkOpts{StorageNamespace: namespace}
+	pager, ok := adapter.(WalkPager)
+	if !ok {
+		// Walk reports keys under the root of the bucket, which include the path of
+		// namespace
+		qualifiedPrefix, err := ResolveNamespacePrefix(namespace, "")
+		if err != nil {
+			return err
+		}
+		return adapter.Walk(ctx, walkOpts, func(id string) error {
+			return fn(strings.TrimPrefix(id, qualifiedPrefix.Prefix))
+		})
+	}
+	var after string
+	for {
+		page, nextAfter, err := pager.WalkPage(ctx, walkOpts, after, migratePageSize)
+		if err != nil {
+			return err
+		}
+		for _, identifier := range page {
+			if err := fn(identifier); err != nil {
+				return err
+			}
+		}
+		if nextAfter == "" {
+			return nil
+		}
+		after = nextAfter
+	}
+}
+
+// migrateObject copies identifier from src to dst unless it is already on dst, and returns
+// whether it copied it.
+func migrateObject(ctx context.Context, src, dst Adapter, params MigrateParams, identifier string) (bool, error) {
+	srcObj := ObjectPointer{StorageNamespace: params.SourceNamespace, Identifier: identifier, IdentifierType: IdentifierTypeRelative}
+	dstObj := ObjectPointer{StorageNamespace: params.DestinationNamespace, Identifier: identifier, IdentifierType: IdentifierTypeRelative}
+	exists, err := dst.Exists(ctx, dstObj)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		migrated, err := isMigrated(ctx, src, dst, srcObj, dstObj)
+		if err != nil || migrated {
+			return false, err
+		}
+	}
+	reader, size, err := getSized(ctx, src, srcObj)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	var storageClass *string
+	if props, err := src.GetProperties(ctx, srcObj); err == nil {
+		storageClass = props.StorageClass
+	}
+	if err := dst.Put(ctx, dstObj, size, reader, PutOpts{StorageClass: storageClass}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// isMigrated returns whether dstObj, which exists on dst, holds srcObj: a destination object
+// left by an interrupted migration may be partial or stale.  Sizes are always compared.  ETags
+// are compared only between blockstores of the same type, as each type computes them
+// differently.
+func isMigrated(ctx context.Context, src, dst Adapter, srcObj, dstObj ObjectPointer) (bool, error) {
+	srcProps, err := statObject(ctx, src, srcObj)
+	if err != nil {
+		return false, err
+	}
+	dstProps, err := statObject(ctx, dst, dstObj)
+	if err != nil {
+		return false, err
+	}
+	if srcProps.Size != dstProps.Size {
+		return false, nil
+	}
+	if src.BlockstoreType() == dst.BlockstoreType() && srcProps.ETag != "" && dstProps.ETag != "" {
+		return srcProps.ETag == dstProps.ETag, nil
+	}
+	return true, nil
+}
+
+// statObject returns the properties of obj on adapter.  Adapters that are not Staters cannot
+// report them, so obj is read to count its size and its ETag is left empty.
+func statObject(ctx context.Context, adapter Adapter, obj ObjectPointer) (ObjectProperties, error) {
+	if stater, ok := adapter.(Stater); ok {
+		return stater.Stat(ctx, obj)
+	}
+	reader, err := adapter.Get(ctx, obj, 0)
+	if err != nil {
+		return ObjectProperties{}, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	size, err := io.Copy(ioutil.Discard, reader)
+	if err != nil {
+		return ObjectProperties{}, err
+	}
+	return ObjectProperties{Size: size}, nil
+}
+
+// getSized returns a reader of obj on adapter along with its size.  Adapters that are not
+// Staters cannot report the size up front, so obj is read into a temporary file first.
+func getSized(ctx context.Context, adapter Adapter, obj ObjectPointer) (io.ReadCloser, int64, error) {
+	if stater, ok := adapter.(Stater); ok {
+		props, err := stater.Stat(ctx, obj)
+		if err != nil {
+			return nil, 0, err
+		}
+		reader, err := adapter.Get(ctx, obj, props.Size)
+		return reader, props.Size, err
+	}
+	reader, err := adapter.Get(ctx, obj, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	f, err := ioutil.TempFile("", "lakefs-migrate-")
+	if err != nil {
+		return nil, 0, err
+	}
+	temp := &removeOnClose{f}
+	size, err := io.Copy(temp, reader)
+	if err == nil {
+		_, err = temp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = temp.Close()
+		return nil, 0, err
+	}
+	return temp, size, nil
+}
+
+// removeOnClose is a temporary file that removes itself when closed.
+type removeOnClose struct {
+	*os.File
+}
+
+func (f *removeOnClose) Close() error {
+	err := f.File.Close()
+	if removeErr := os.Remove(f.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
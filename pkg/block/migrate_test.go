@@ -0,0 +1,120 @@
+package block_test
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/pkg/block"
+	"github.com/treeverse/lakefs/pkg/block/local"
+	"github.com/treeverse/lakefs/pkg/block/mem"
+	"github.com/treeverse/lakefs/pkg/testutil"
+)
+
+// pagedAdapter is an adapter that can list by pages but cannot Stat.
+type pagedAdapter struct {
+	block.Adapter
+	block.WalkPager
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	contents := map[string]string{
+		"a":         "first",
+		"data/b":    "second",
+		"data/c/d":  "third",
+		"empty":     "",
+		"models/m1": strings.Repeat("model", 1000),
+	}
+	src, err := local.NewAdapter(t.TempDir())
+	testutil.MustDo(t, "NewAdapter", err)
+	for identifier, data := range contents {
+		obj := block.ObjectPointer{StorageNamespace: "local://src", Identifier: identifier}
+		testutil.MustDo(t, "Put "+identifier, src.Put(ctx, obj, int64(len(data)), strings.NewReader(data), block.PutOpts{}))
+	}
+
+	dstLocal, err := local.NewAdapter(t.TempDir())
+	testutil.MustDo(t, "NewAdapter", err)
+	tests := []struct {
+		name      string
+		src       block.Adapter
+		dst       block.Adapter
+		namespace string
+	}{
+		{name: "local", src: src, dst: dstLocal, namespace: "local://dst"},
+		{name: "mem", src: src, dst: mem.New(), namespace: "mem://dst"},
+		// without Stat objects are read to temporary files to get their sizes
+		{name: "unsized source", src: pagedAdapter{Adapter: src, WalkPager: src}, dst: mem.New(), namespace: "mem://dst"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// an object already on the destination is not copied again
+			existing := block.ObjectPointer{StorageNamespace: tt.namespace, Identifier: "a"}
+			testutil.MustDo(t, "Put existing", tt.dst.Put(ctx, existing, int64(len(contents["a"])), strings.NewReader(contents["a"]), block.PutOpts{}))
+			// but one left partial by an interrupted migration is
+			partial := block.ObjectPointer{StorageNamespace: tt.namespace, Identifier: "models/m1"}
+			testutil.MustDo(t, "Put partial", tt.dst.Put(ctx, partial, 5, strings.NewReader("model"), block.PutOpts{}))
+
+			var mu sync.Mutex
+			copied := make(map[string]bool)
+			err := block.Migrate(ctx, tt.src, tt.dst, block.MigrateParams{
+				SourceNamespace:      "local://src",
+				DestinationNamespace: tt.namespace,
+				Concurrency:          3,
+				Progress: func(identifier string, wasCopied bool) {
+					mu.Lock()
+					defer mu.Unlock()
+					copied[identifier] = wasCopied
+				},
+			})
+			testutil.MustDo(t, "Migrate", err)
+
+			expectedCopied := make(map[string]bool)
+			for identifier := range contents {
+				expectedCopied[identifier] = identifier != "a"
+			}
+			if diffs := deep.Equal(copied, expectedCopied); diffs != nil {
+				t.Errorf("unexpected progress: %s", diffs)
+			}
+			for identifier, data := range contents {
+				reader, err := tt.dst.Get(ctx, block.ObjectPointer{StorageNamespace: tt.namespace, Identifier: identifier}, int64(len(data)))
+				testutil.MustDo(t, "Get "+identifier, err)
+				got, err := ioutil.ReadAll(reader)
+				testutil.MustDo(t, "read "+identifier, err)
+				_ = reader.Close()
+				if string(got) != data {
+					t.Errorf("migrated %s holds %q, expected %q", identifier, got, data)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateUnpagedSource(t *testing.T) {
+	ctx := context.Background()
+	contents := map[string]string{
+		"a":      "first",
+		"data/b": "second",
+	}
+	src := mem.New()
+	for identifier, data := range contents {
+		obj := block.ObjectPointer{StorageNamespace: "mem://src", Identifier: identifier}
+		testutil.MustDo(t, "Put "+identifier, src.Put(ctx, obj, int64(len(data)), strings.NewReader(data), block.PutOpts{}))
+	}
+	dst := mem.New()
+	err := block.Migrate(ctx, src, dst, block.MigrateParams{SourceNamespace: "mem://src", DestinationNamespace: "mem://dst"})
+	testutil.MustDo(t, "Migrate", err)
+	for identifier, data := range contents {
+		reader, err := dst.Get(ctx, block.ObjectPointer{StorageNamespace: "mem://dst", Identifier: identifier}, int64(len(data)))
+		testutil.MustDo(t, "Get "+identifier, err)
+		got, err := ioutil.ReadAll(reader)
+		testutil.MustDo(t, "read "+identifier, err)
+		_ = reader.Close()
+		if string(got) != data {
+			t.Errorf("migrated %s holds %q, expected %q", identifier, got, data)
+		}
+	}
+}
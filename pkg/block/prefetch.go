@@ -0,0 +1,134 @@
+package block
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// prefetchChunkSize is the largest read issued by a prefetching reader to its source.
+const prefetchChunkSize = 64 * 1024
+
+// Prefetcher is implemented by adapters that can read ahead of the consumer of an object, to hide
+// the latency of their underlying store from sequential reads of large objects.
+type Prefetcher interface {
+	// GetPrefetch returns a reader of obj that reads up to readAheadBytes ahead of its
+	// consumer.  Closing the reader stops reading ahead.
+	GetPrefetch(ctx context.Context, obj ObjectPointer, readAheadBytes int) (io.ReadCloser, error)
+}
+
+type prefetchChunk struct {
+	data []byte
+	err  error
+}
+
+// prefetchReader reads its source in a background goroutine into a channel of chunks holding
+// about readAheadBytes.
+type prefetchReader struct {
+	ctx     context.Context
+	source  io.ReadCloser
+	chunks  chan prefetchChunk
+	current []byte
+	err     error
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// NewPrefetchReader returns a reader of source that reads up to about readAheadBytes ahead of
+// its consumer, until source is exhausted, the reader is closed or ctx is done.  Closing the
+// reader waits for reading ahead to stop, including any read of source in progress, and then
+// closes source.
+func NewPrefetchReader(ctx context.Context, source io.ReadCloser, readAheadBytes int) io.ReadCloser {
+	chunkSize := prefetchChunkSize
+	if readAheadBytes < chunkSize {
+		chunkSize = readAheadBytes
+	}
+	if chunkSize <= 0 {
+		chunkSize = 1
+	}
+	r := &prefetchReader{
+		ctx:    ctx,
+		source: source,
+		chunks: make(chan prefetchChunk, readAheadBytes/chunkSize),
+		done:   make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go r.readAhead(chunkSize)
+	return r
+}
+
+func (r *prefetchReader) readAhead(chunkSize int) {
+	defer r.wg.Done()
+	defer close(r.chunks)
+	for {
+		buf := make([]byte, chunkSize)
+		n, err := r.source.Read(buf)
+		if n > 0 {
+			if !r.send(prefetchChunk{data: buf[:n]}) {
+				return
+			}
+		}
+		if err != nil {
+			r.send(prefetchChunk{err: err})
+			return
+		}
+	}
+}
+
+// send passes chunk to the consumer, and returns false if reading ahead should stop instead.
+func (r *prefetchReader) send(chunk prefetchChunk) bool {
+	if r.ctx.Err() != nil {
+		return false
+	}
+	select {
+	case r.chunks <- chunk:
+		return true
+	case <-r.done:
+		return false
+	case <-r.ctx.Done():
+		return false
+	}
+}
+
+func (r *prefetchReader) Read(p []byte) (int, error) {
+	for len(r.current) == 0 {
+		if r.err == nil {
+			r.err = r.ctx.Err()
+		}
+		if r.err != nil {
+			return 0, r.err
+		}
+		select {
+		case chunk, ok := <-r.chunks:
+			switch {
+			case !ok:
+				// reading ahead stopped without an error: ctx is done or the reader is closed
+				r.err = r.ctx.Err()
+				if r.err == nil {
+					r.err = io.ErrClosedPipe
+				}
+			case chunk.err != nil:
+				r.err = chunk.err
+			default:
+				r.current = chunk.data
+			}
+		case <-r.ctx.Done():
+			r.err = r.ctx.Err()
+		}
+	}
+	n := copy(p, r.current)
+	r.current = r.current[n:]
+	return n, nil
+}
+
+func (r *prefetchReader) Close() error {
+	var err error
+	r.once.Do(func() {
+		close(r.done)
+		// source is not safe to close while it is being read
+		r.wg.Wait()
+		err = r.source.Close()
+	})
+	return err
+}
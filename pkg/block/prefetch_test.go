@@ -0,0 +1,158 @@
+package block_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/treeverse/lakefs/pkg/block"
+)
+
+// endlessReader returns zeros forever, counting its reads, until closed.
+type endlessReader struct {
+	mu     sync.Mutex
+	reads  int
+	closed bool
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, io.ErrClosedPipe
+	}
+	r.reads++
+	return len(p), nil
+}
+
+func (r *endlessReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *endlessReader) Reads() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reads
+}
+
+func TestPrefetchReader(t *testing.T) {
+	contents := strings.Repeat("0123456789", 100000)
+	for _, readAhead := range []int{1, 1000, 1 << 20, 10 << 20} {
+		source := ioutil.NopCloser(iotest.HalfReader(strings.NewReader(contents)))
+		reader := block.NewPrefetchReader(context.Background(), source, readAhead)
+		got, err := ioutil.ReadAll(iotest.OneByteReader(io.LimitReader(reader, 100)))
+		if err != nil {
+			t.Fatalf("read ahead %d: read start: %s", readAhead, err)
+		}
+		rest, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("read ahead %d: read rest: %s", readAhead, err)
+		}
+		if err := reader.Close(); err != nil {
+			t.Fatalf("read ahead %d: close: %s", readAhead, err)
+		}
+		if !bytes.Equal(append(got, rest...), []byte(contents)) {
+			t.Errorf("read ahead %d: read %d bytes differing from the %d bytes of source", readAhead, len(got)+len(rest), len(contents))
+		}
+	}
+}
+
+func TestPrefetchReaderSourceError(t *testing.T) {
+	errSource := errors.New("source failed")
+	source := ioutil.NopCloser(io.MultiReader(strings.NewReader("data"), iotest.ErrReader(errSource)))
+	reader := block.NewPrefetchReader(context.Background(), source, 1024)
+	defer func() { _ = reader.Close() }()
+	got, err := ioutil.ReadAll(reader)
+	if !errors.Is(err, errSource) {
+		t.Errorf("read returned %v, expected %s", err, errSource)
+	}
+	if string(got) != "data" {
+		t.Errorf("read %q before the error, expected %q", got, "data")
+	}
+}
+
+func TestPrefetchReaderCloseStopsReadingAhead(t *testing.T) {
+	source := &endlessReader{}
+	reader := block.NewPrefetchReader(context.Background(), source, 1024)
+	if _, err := io.ReadFull(reader, make([]byte, 10)); err != nil {
+		t.Fatalf("read: %s", err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatalf("close: %s", err)
+	}
+	// Close waits for reading ahead to stop, so no more reads may follow it
+	reads := source.Reads()
+	if _, err := io.ReadFull(source, make([]byte, 1)); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("source read after close returned %v, expected it to be closed", err)
+	}
+	if got := source.Reads(); got != reads {
+		t.Errorf("source read %d times after close", got-reads)
+	}
+}
+
+// blockingReader blocks its reads until released, and records being closed during a read.
+type blockingReader struct {
+	reading          chan struct{}
+	release          chan struct{}
+	once             sync.Once
+	closed           bool
+	closedDuringRead bool
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	r.once.Do(func() { close(r.reading) })
+	<-r.release
+	if r.closed {
+		r.closedDuringRead = true
+	}
+	return len(p), nil
+}
+
+func (r *blockingReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+// TestPrefetchReaderCloseDuringRead is meaningful with -race, which reports closing source
+// concurrently with its read.
+func TestPrefetchReaderCloseDuringRead(t *testing.T) {
+	source := &blockingReader{reading: make(chan struct{}), release: make(chan struct{})}
+	reader := block.NewPrefetchReader(context.Background(), source, 1024)
+	<-source.reading
+	closed := make(chan error, 1)
+	go func() { closed <- reader.Close() }()
+	select {
+	case <-closed:
+		t.Fatal("close returned while source was being read")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(source.release)
+	if err := <-closed; err != nil {
+		t.Fatalf("close: %s", err)
+	}
+	if source.closedDuringRead {
+		t.Error("source closed while being read")
+	}
+}
+
+func TestPrefetchReaderContextCanceled(t *testing.T) {
+	source := &endlessReader{}
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := block.NewPrefetchReader(ctx, source, 1024)
+	defer func() { _ = reader.Close() }()
+	cancel()
+	_, err := ioutil.ReadAll(reader)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("read after cancel returned %v, expected %s", err, context.Canceled)
+	}
+}
@@ -0,0 +1,53 @@
+package block
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	// ProgressBytesInterval is the number of bytes read between progress reports.
+	ProgressBytesInterval = 1024 * 1024
+	// ProgressTimeInterval is the time between progress reports while reading slowly.
+	ProgressTimeInterval = 100 * time.Millisecond
+)
+
+// ProgressReader reads from a reader and reports the total number of bytes read so far.  Reports
+// are throttled to one every ProgressBytesInterval bytes or ProgressTimeInterval, whichever
+// comes first, and a final report is made at EOF.
+type ProgressReader struct {
+	reader       io.Reader
+	progress     func(written int64)
+	written      int64
+	reported     int64
+	lastReported time.Time
+}
+
+// NewProgressReader returns a ProgressReader of reader that calls progress with the total bytes
+// read.
+func NewProgressReader(reader io.Reader, progress func(written int64)) *ProgressReader {
+	return &ProgressReader{
+		reader:       reader,
+		progress:     progress,
+		lastReported: time.Now(),
+	}
+}
+
+func (r *ProgressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.written += int64(n)
+	if err == io.EOF || r.written-r.reported >= ProgressBytesInterval || time.Since(r.lastReported) >= ProgressTimeInterval {
+		r.Flush()
+	}
+	return n, err
+}
+
+// Flush reports the bytes read so far, if not already reported.
+func (r *ProgressReader) Flush() {
+	if r.written == r.reported {
+		return
+	}
+	r.reported = r.written
+	r.lastReported = time.Now()
+	r.progress(r.written)
+}
@@ -0,0 +1,57 @@
+package block
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrInvalidRange = errors.New("invalid range")
+
+// Range is a byte range of an object.  Both Start and End are inclusive, as in HTTP Range
+// headers.
+type Range struct {
+	Start int64
+	End   int64
+}
+
+// RangesGetter is implemented by adapters that can read multiple byte ranges of an object at
+// once, e.g. to serve multipart/byteranges responses.
+type RangesGetter interface {
+	// GetRanges returns a reader for each of ranges of obj.  Ranges must be valid according to
+	// ValidateRanges.
+	GetRanges(ctx context.Context, obj ObjectPointer, ranges []Range) ([]io.ReadCloser, error)
+}
+
+// RangeToEnd passed as the end position of a range reads to the end of the object, for
+// adapters that support open-ended ranges.
+const RangeToEnd = -1
+
+// ValidateRange returns ErrInvalidRange unless start is non-negative and end is either
+// RangeToEnd or not before start.
+func ValidateRange(start, end int64) error {
+	if start < 0 || (end != RangeToEnd && end < start) {
+		return fmt.Errorf("%w: %d-%d", ErrInvalidRange, start, end)
+	}
+	return nil
+}
+
+// ValidateRanges returns ErrInvalidRange unless ranges is non-empty, and every range is
+// non-empty and starts after the end of its predecessor.
+func ValidateRanges(ranges []Range) error {
+	if len(ranges) == 0 {
+		return fmt.Errorf("%w: no ranges", ErrInvalidRange)
+	}
+	prevEnd := int64(-1)
+	for i, r := range ranges {
+		if r.Start < 0 || r.End < r.Start {
+			return fmt.Errorf("%w: range %d is %d-%d", ErrInvalidRange, i, r.Start, r.End)
+		}
+		if r.Start <= prevEnd {
+			return fmt.Errorf("%w: range %d (%d-%d) overlaps or precedes range %d", ErrInvalidRange, i, r.Start, r.End, i-1)
+		}
+		prevEnd = r.End
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Package ratelimit wraps a block adapter to limit the rate of its operations and the throughput
+// of its data transfers, e.g. to keep within cloud provider quotas.
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/treeverse/lakefs/pkg/block"
+	"github.com/treeverse/lakefs/pkg/logging"
+	"golang.org/x/time/rate"
+)
+
+type Adapter struct {
+	inner block.Adapter
+	ops   *rate.Limiter
+	bytes *rate.Limiter
+}
+
+// NewRateLimitAdapter returns an adapter that performs at most opsPerSecond operations and
+// transfers at most bytesPerSecond bytes of object data each second through inner.  Operations
+// block until capacity is available or their context is done.  A non-positive limit disables
+// that limit.
+func NewRateLimitAdapter(inner block.Adapter, opsPerSecond, bytesPerSecond int) block.Adapter {
+	return &Adapter{
+		inner: inner,
+		ops:   newLimiter(opsPerSecond),
+		bytes: newLimiter(bytesPerSecond),
+	}
+}
+
+func newLimiter(perSecond int) *rate.Limiter {
+	if perSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), perSecond)
+}
+
+// reader limits the throughput of reads from its underlying reader.
+type reader struct {
+	ctx     context.Context
+	limiter *rate.Limiter
+	r       io.Reader
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if burst := r.limiter.Burst(); r.limiter.Limit() != rate.Inf && len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 && r.limiter.Limit() != rate.Inf {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+type readCloser struct {
+	reader
+	io.Closer
+}
+
+func (a *Adapter) limitReader(ctx context.Context, r io.Reader) io.Reader {
+	return &reader{ctx: ctx, limiter: a.bytes, r: r}
+}
+
+func (a *Adapter) limitReadCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	return &readCloser{reader: reader{ctx: ctx, limiter: a.bytes, r: rc}, Closer: rc}
+}
+
+func (a *Adapter) Put(ctx context.Context, obj block.ObjectPointer, sizeBytes int64, reader io.Reader, opts block.PutOpts) error {
+	if err := a.ops.Wait(ctx); err != nil {
+		return err
+	}
+	return a.inner.Put(ctx, obj, sizeBytes, a.limitReader(ctx, reader), opts)
+}
+
+func (a *Adapter) Get(ctx context.Context, obj block.ObjectPointer, expectedSize int64) (io.ReadCloser, error) {
+	if err := a.ops.Wait(ctx); err != nil {
+		return nil, err
+	}
+	rc, err := a.inner.Get(ctx, obj, expectedSize)
+	if err != nil {
+		return nil, err
+	}
+	return a.limitReadCloser(ctx, rc), nil
+}
+
+func (a *Adapter) Walk(ctx context.Context, walkOpt block.WalkOpts, walkFn block.WalkFunc) error {
+	if err := a.ops.Wait(ctx); err != nil {
+		return err
+	}
+	return a.inner.Walk(ctx, walkOpt, walkFn)
+}
+
+func (a *Adapter) Exists(ctx context.Context, obj block.ObjectPointer) (bool, error) {
+	if err := a.ops.Wait(ctx); err != nil {
+		return false, err
+	}
+	return a.inner.Exists(ctx, obj)
+}
+
+func (a *Adapter) GetRange(ctx context.Context, obj block.ObjectPointer, startPosition int64, endPosition int64) (io.ReadCloser, error) {
+	if err := a.ops.Wait(ctx); err != nil {
+		return nil, err
+	}
+	rc, err := a.inner.GetRange(ctx, obj, startPosition, endPosition)
+	if err != nil {
+		return nil, err
+	}
+	return a.limitReadCloser(ctx, rc), nil
+}
+
+func (a *Adapter) GetProperties(ctx context.Context, obj block.ObjectPointer) (block.Properties, error) {
+	if err := a.ops.Wait(ctx); err != nil {
+		return block.Properties{}, err
+	}
+	return a.inner.GetProperties(ctx, obj)
+}
+
+func (a *Adapter) Remove(ctx context.Context, obj block.ObjectPointer) error {
+	if err := a.ops.Wait(ctx); err != nil {
+		return err
+	}
+	return a.inner.Remove(ctx, obj)
+}
+
+func (a *Adapter) Copy(ctx context.Context, sourceObj, destinationObj block.ObjectPointer) error {
+	if err := a.ops.Wait(ctx); err != nil {
+		return err
+	}
+	return a.inner.Copy(ctx, sourceObj, destinationObj)
+}
+
+func (a *Adapter) CreateMultiPartUpload(ctx context.Context, obj block.ObjectPointer, r *http.Request, opts block.CreateMultiPartUploadOpts) (string, error) {
+	if err := a.ops.Wait(ctx); err != nil {
+		return "", err
+	}
+	return a.inner.CreateMultiPartUpload(ctx, obj, r, opts)
+}
+
+func (a *Adapter) UploadPart(ctx context.Context, obj block.ObjectPointer, sizeBytes int64, reader io.Reader, uploadID string, partNumber int64) (string, error) {
+	if err := a.ops.Wait(ctx); err != nil {
+		return "", err
+	}
+	return a.inner.UploadPart(ctx, obj, sizeBytes, a.limitReader(ctx, reader), uploadID, partNumber)
+}
+
+func (a *Adapter) UploadCopyPart(ctx context.Context, sourceObj, destinationObj block.ObjectPointer, uploadID string, partNumber int64) (string, error) {
+	if err := a.ops.Wait(ctx); err != nil {
+		return "", err
+	}
+	return a.inner.UploadCopyPart(ctx, sourceObj, destinationObj, uploadID, partNumber)
+}
+
+func (a *Adapter) UploadCopyPartRange(ctx context.Context, sourceObj, destinationObj block.ObjectPointer, uploadID string, partNumber, startPosition, endPosition int64) (string, error) {
+	if err := a.ops.Wait(ctx); err != nil {
+		return "", err
+	}
+	return a.inner.UploadCopyPartRange(ctx, sourceObj, destinationObj, uploadID, partNumber, startPosition, endPosition)
+}
+
+func (a *Adapter) AbortMultiPartUpload(ctx context.Context, obj block.ObjectPointer, uploadID string) error {
+	if err := a.ops.Wait(ctx); err != nil {
+		return err
+	}
+	return a.inner.AbortMultiPartUpload(ctx, obj, uploadID)
+}
+
+func (a *Adapter) CompleteMultiPartUpload(ctx context.Context, obj block.ObjectPointer, uploadID string, multipartList *block.MultipartUploadCompletion) (*string, int64, error) {
+	if err := a.ops.Wait(ctx); err != nil {
+		return nil, 0, err
+	}
+	return a.inner.CompleteMultiPartUpload(ctx, obj, uploadID, multipartList)
+}
+
+func (a *Adapter) ValidateConfiguration(ctx context.Context, storageNamespace string) error {
+	return a.inner.ValidateConfiguration(ctx, storageNamespace)
+}
+
+func (a *Adapter) BlockstoreType() string {
+	return a.inner.BlockstoreType()
+}
+
+func (a *Adapter) GetStorageNamespaceInfo() block.StorageNamespaceInfo {
+	return a.inner.GetStorageNamespaceInfo()
+}
+
+func (a *Adapter) GenerateInventory(ctx context.Context, logger logging.Logger, inventoryURL string, shouldSort bool, prefixes []string) (block.Inventory, error) {
+	return a.inner.GenerateInventory(ctx, logger, inventoryURL, shouldSort, prefixes)
+}
+
+func (a *Adapter) RuntimeStats() map[string]string {
+	return a.inner.RuntimeStats()
+}
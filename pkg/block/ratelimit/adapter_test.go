@@ -0,0 +1,72 @@
+package ratelimit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/pkg/block"
+	"github.com/treeverse/lakefs/pkg/block/mem"
+	"github.com/treeverse/lakefs/pkg/block/ratelimit"
+	"github.com/treeverse/lakefs/pkg/testutil"
+)
+
+var obj = block.ObjectPointer{StorageNamespace: "mem://test", Identifier: "object"}
+
+func TestRateLimitOps(t *testing.T) {
+	ctx := context.Background()
+	const opsPerSecond = 20
+	a := ratelimit.NewRateLimitAdapter(mem.New(), opsPerSecond, 0)
+
+	start := time.Now()
+	// the first opsPerSecond operations use the burst, the next ones must wait
+	for i := 0; i < opsPerSecond+opsPerSecond/2; i++ {
+		if _, err := a.Exists(ctx, obj); err != nil {
+			t.Fatalf("Exists: %s", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("%d operations took %s, expected throttling to %d per second", opsPerSecond+opsPerSecond/2, elapsed, opsPerSecond)
+	}
+}
+
+func TestRateLimitBytes(t *testing.T) {
+	ctx := context.Background()
+	const bytesPerSecond = 1000
+	a := ratelimit.NewRateLimitAdapter(mem.New(), 0, bytesPerSecond)
+	data := bytes.Repeat([]byte("x"), bytesPerSecond*3/2)
+
+	start := time.Now()
+	testutil.MustDo(t, "Put", a.Put(ctx, obj, int64(len(data)), bytes.NewReader(data), block.PutOpts{}))
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("Put of %d bytes took %s, expected throttling to %d bytes per second", len(data), elapsed, bytesPerSecond)
+	}
+
+	start = time.Now()
+	reader, err := a.Get(ctx, obj, int64(len(data)))
+	testutil.MustDo(t, "Get", err)
+	got, err := ioutil.ReadAll(reader)
+	testutil.MustDo(t, "read", err)
+	_ = reader.Close()
+	if !bytes.Equal(got, data) {
+		t.Errorf("Get returned %d bytes, expected the %d bytes put", len(got), len(data))
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("Get of %d bytes took %s, expected throttling to %d bytes per second", len(data), elapsed, bytesPerSecond)
+	}
+}
+
+func TestRateLimitCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := ratelimit.NewRateLimitAdapter(mem.New(), 1, 0)
+	if _, err := a.Exists(ctx, obj); err != nil {
+		t.Fatalf("Exists: %s", err)
+	}
+	cancel()
+	if _, err := a.Exists(ctx, obj); !errors.Is(err, context.Canceled) {
+		t.Errorf("Exists with canceled context returned %v, expected %s", err, context.Canceled)
+	}
+}
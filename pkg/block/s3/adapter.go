@@ -260,6 +260,27 @@ func (a *Adapter) Get(ctx context.Context, obj block.ObjectPointer, _ int64) (io
 	return objectOutput.Body, nil
 }
 
+// GetPreSignedURL implements block.PreSigner.
+func (a *Adapter) GetPreSignedURL(_ context.Context, obj block.ObjectPointer, mode block.PreSignMode, expiry time.Duration) (string, error) {
+	qualifiedKey, err := resolveNamespace(obj)
+	if err != nil {
+		return "", err
+	}
+	var req *request.Request
+	if mode == block.PreSignModeWrite {
+		req, _ = a.s3.PutObjectRequest(&s3.PutObjectInput{
+			Bucket: aws.String(qualifiedKey.StorageNamespace),
+			Key:    aws.String(qualifiedKey.Key),
+		})
+	} else {
+		req, _ = a.s3.GetObjectRequest(&s3.GetObjectInput{
+			Bucket: aws.String(qualifiedKey.StorageNamespace),
+			Key:    aws.String(qualifiedKey.Key),
+		})
+	}
+	return req.Presign(expiry)
+}
+
 func (a *Adapter) Exists(ctx context.Context, obj block.ObjectPointer) (bool, error) {
 	var err error
 	defer reportMetrics("Exists", time.Now(), nil, &err)
@@ -276,6 +276,19 @@ func (c *Catalog) DeleteRepository(ctx context.Context, repository string) error
 	return c.Store.DeleteRepository(ctx, repositoryID)
 }
 
+// SetDefaultBranch sets the default branch of a repository to an existing branch
+func (c *Catalog) SetDefaultBranch(ctx context.Context, repository string, branch string) error {
+	repositoryID := graveler.RepositoryID(repository)
+	branchID := graveler.BranchID(branch)
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"branch", branchID, ValidateBranchID},
+	}); err != nil {
+		return err
+	}
+	return c.Store.SetDefaultBranch(ctx, repositoryID, branchID)
+}
+
 // ListRepositories list repositories information, the bool returned is true when more repositories can be listed.
 // In this case pass the last repository name as 'after' on the next call to ListRepositories
 func (c *Catalog) ListRepositories(ctx context.Context, limit int, prefix, after string) ([]*Repository, bool, error) {
@@ -620,13 +633,42 @@ func EntryFromCatalogEntry(entry DBEntry) *Entry {
 	return &Entry{
 		Address:      entry.PhysicalAddress,
 		AddressType:  addressTypeToProto(entry.AddressType),
-		Metadata:     entry.Metadata,
+		Metadata:     metadataWithContentType(entry.Metadata, entry.ContentType),
 		LastModified: timestamppb.New(entry.CreationDate),
 		ETag:         entry.Checksum,
 		Size:         entry.Size,
 	}
 }
 
+// metadataWithContentType returns metadata with the content-type under its reserved key.
+func metadataWithContentType(metadata Metadata, contentType string) Metadata {
+	if contentType == "" {
+		return metadata
+	}
+	res := make(Metadata, len(metadata)+1)
+	for k, v := range metadata {
+		res[k] = v
+	}
+	res[contentTypeMetadataKey] = contentType
+	return res
+}
+
+// splitContentType returns entry metadata without the reserved content-type key, and the
+// content-type it held.
+func splitContentType(metadata map[string]string) (Metadata, string) {
+	contentType, ok := metadata[contentTypeMetadataKey]
+	if !ok {
+		return metadata, ""
+	}
+	res := make(Metadata, len(metadata)-1)
+	for k, v := range metadata {
+		if k != contentTypeMetadataKey {
+			res[k] = v
+		}
+	}
+	return res, contentType
+}
+
 func addressTypeToProto(t AddressType) Entry_AddressType {
 	switch t {
 	case AddressTypeByPrefixDeprecated:
@@ -811,6 +853,29 @@ func (c *Catalog) Commit(ctx context.Context, repository string, branch string,
 	return catalogCommitLog, nil
 }
 
+func (c *Catalog) AmendCommit(ctx context.Context, repository, branch, headCommitID, message, committer string, metadata Metadata, force bool) (*CommitLog, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	branchID := graveler.BranchID(branch)
+	headID := graveler.CommitID(headCommitID)
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"branchID", branchID, ValidateBranchID},
+		{"headCommitID", headID, MakeValidateOptional(ValidateCommitID)},
+		{"message", message, ValidateRequiredString},
+	}); err != nil {
+		return nil, err
+	}
+	commitID, err := c.Store.AmendCommit(ctx, repositoryID, branchID, headID, force, graveler.CommitParams{
+		Committer: committer,
+		Message:   message,
+		Metadata:  map[string]string(metadata),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.GetCommit(ctx, repository, commitID.String())
+}
+
 func (c *Catalog) GetCommit(ctx context.Context, repository string, reference string) (*CommitLog, error) {
 	repositoryID := graveler.RepositoryID(repository)
 	if err := Validate([]ValidateArg{
@@ -973,6 +1038,36 @@ func (c *Catalog) Compare(ctx context.Context, repository, leftReference string,
 	return listDiffHelper(it, params.Prefix, params.Delimiter, params.Limit, params.After)
 }
 
+// FindMergeBase returns the best common ancestor commit of leftReference and rightReference.
+func (c *Catalog) FindMergeBase(ctx context.Context, repository string, leftReference string, rightReference string) (*CommitLog, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	left := graveler.Ref(leftReference)
+	right := graveler.Ref(rightReference)
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"left", left, ValidateRef},
+		{"right", right, ValidateRef},
+	}); err != nil {
+		return nil, err
+	}
+	base, err := c.Store.FindMergeBase(ctx, repositoryID, left, right)
+	if err != nil {
+		return nil, err
+	}
+	commit := &CommitLog{
+		Reference:    base.CommitID.String(),
+		Committer:    base.Committer,
+		Message:      base.Message,
+		CreationDate: base.CreationDate,
+		MetaRangeID:  string(base.MetaRangeID),
+		Metadata:     Metadata(base.Metadata),
+	}
+	for _, parent := range base.Parents {
+		commit.Parents = append(commit.Parents, string(parent))
+	}
+	return commit, nil
+}
+
 func (c *Catalog) DiffUncommitted(ctx context.Context, repository, branch, prefix, delimiter string, limit int, after string) (Differences, bool, error) {
 	repositoryID := graveler.RepositoryID(repository)
 	branchID := graveler.BranchID(branch)
@@ -1083,7 +1178,7 @@ func listDiffHelper(it EntryDiffIterator, prefix, delimiter string, limit int, a
 	return diffs, hasMore, nil
 }
 
-func (c *Catalog) Merge(ctx context.Context, repository string, destinationBranch string, sourceRef string, committer string, message string, metadata Metadata) (*MergeResult, error) {
+func (c *Catalog) Merge(ctx context.Context, repository string, destinationBranch string, sourceRef string, committer string, message string, metadata Metadata, squash bool, resolutions map[string]string) (*MergeResult, error) {
 	repositoryID := graveler.RepositoryID(repository)
 	destination := graveler.BranchID(destinationBranch)
 	source := graveler.Ref(sourceRef)
@@ -1094,7 +1189,11 @@ func (c *Catalog) Merge(ctx context.Context, repository string, destinationBranc
 		Metadata:  meta,
 	}
 	if commitParams.Message == "" {
-		commitParams.Message = fmt.Sprintf("Merge '%s' into '%s'", source, destination)
+		mergeKind := "Merge"
+		if squash {
+			mergeKind = "Squash merge"
+		}
+		commitParams.Message = fmt.Sprintf("%s '%s' into '%s'", mergeKind, source, destination)
 	}
 	if err := Validate([]ValidateArg{
 		{"repositoryID", repositoryID, ValidateRepositoryID},
@@ -1102,10 +1201,18 @@ func (c *Catalog) Merge(ctx context.Context, repository string, destinationBranc
 		{"source", source, ValidateRef},
 		{"committer", commitParams.Committer, ValidateRequiredString},
 		{"message", commitParams.Message, ValidateRequiredString},
+		{"resolutions", resolutions, ValidateMergeResolutions},
 	}); err != nil {
 		return nil, err
 	}
-	commitID, summary, err := c.Store.Merge(ctx, repositoryID, destination, source, commitParams)
+	var mergeResolutions graveler.MergeResolutions
+	if len(resolutions) > 0 {
+		mergeResolutions = make(graveler.MergeResolutions, len(resolutions))
+		for path, resolution := range resolutions {
+			mergeResolutions[path] = graveler.MergeResolution(resolution)
+		}
+	}
+	commitID, summary, err := c.Store.Merge(ctx, repositoryID, destination, source, commitParams, squash, mergeResolutions)
 	if errors.Is(err, graveler.ErrConflictFound) {
 		// for compatibility with old Catalog
 		return &MergeResult{
@@ -1129,6 +1236,24 @@ func (c *Catalog) Merge(ctx context.Context, repository string, destinationBranc
 	}, nil
 }
 
+func (c *Catalog) FastForward(ctx context.Context, repository string, destinationBranch string, sourceRef string) (string, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	destination := graveler.BranchID(destinationBranch)
+	source := graveler.Ref(sourceRef)
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"destination", destination, ValidateBranchID},
+		{"source", source, ValidateRef},
+	}); err != nil {
+		return "", err
+	}
+	commitID, err := c.Store.FastForward(ctx, repositoryID, destination, source)
+	if err != nil {
+		return "", err
+	}
+	return commitID.String(), nil
+}
+
 func (c *Catalog) DumpCommits(ctx context.Context, repositoryID string) (string, error) {
 	metaRangeID, err := c.Store.DumpCommits(ctx, graveler.RepositoryID(repositoryID))
 	if err != nil {
@@ -1181,6 +1306,36 @@ func (c *Catalog) SetGarbageCollectionRules(ctx context.Context, repositoryID st
 	return c.Store.SetGarbageCollectionRules(ctx, graveler.RepositoryID(repositoryID), rules)
 }
 
+func (c *Catalog) GetBranchProtectionRules(ctx context.Context, repository string) ([]string, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	return c.Store.GetBranchProtectionRules(ctx, repositoryID)
+}
+
+func (c *Catalog) CreateBranchProtectionRule(ctx context.Context, repository string, pattern string) error {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	return c.Store.CreateBranchProtectionRule(ctx, repositoryID, pattern)
+}
+
+func (c *Catalog) DeleteBranchProtectionRule(ctx context.Context, repository string, pattern string) error {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	return c.Store.DeleteBranchProtectionRule(ctx, repositoryID, pattern)
+}
+
 func (c *Catalog) PrepareExpiredCommits(ctx context.Context, repository string, previousRunID string) (*graveler.GarbageCollectionRunMetadata, error) {
 	repositoryID := graveler.RepositoryID(repository)
 	if err := Validate([]ValidateArg{
@@ -1224,7 +1379,7 @@ func newCatalogEntryFromEntry(commonPrefix bool, path string, ent *Entry) DBEntr
 		catEnt.CreationDate = ent.LastModified.AsTime()
 		catEnt.Size = ent.Size
 		catEnt.Checksum = ent.ETag
-		catEnt.Metadata = ent.Metadata
+		catEnt.Metadata, catEnt.ContentType = splitContentType(ent.Metadata)
 		catEnt.Expired = false
 		catEnt.AddressType = addressTypeToCatalog(ent.AddressType)
 	}
@@ -510,3 +510,27 @@ func TestCatalog_ListEntries(t *testing.T) {
 		})
 	}
 }
+
+func TestEntryContentTypeRoundTrip(t *testing.T) {
+	dbEntry := DBEntry{
+		Path:            "a/b.json",
+		PhysicalAddress: "address",
+		AddressType:     AddressTypeRelative,
+		CreationDate:    time.Unix(1000, 0).UTC(),
+		Size:            3,
+		Checksum:        "etag",
+		Metadata:        Metadata{"foo": "bar"},
+		ContentType:     "application/json",
+	}
+	ent := EntryFromCatalogEntry(dbEntry)
+	if ent.Metadata[contentTypeMetadataKey] != "application/json" {
+		t.Fatalf("entry metadata %v missing content-type", ent.Metadata)
+	}
+	if _, ok := dbEntry.Metadata[contentTypeMetadataKey]; ok {
+		t.Fatal("converting entry modified its user metadata")
+	}
+	got := newCatalogEntryFromEntry(false, dbEntry.Path, ent)
+	if diff := deep.Equal(got, dbEntry); diff != nil {
+		t.Fatal("round trip entry diff:", diff)
+	}
+}
@@ -39,6 +39,18 @@ func (g *FakeGraveler) SetGarbageCollectionRules(ctx context.Context, repository
 	panic("implement me")
 }
 
+func (g *FakeGraveler) GetBranchProtectionRules(ctx context.Context, repositoryID graveler.RepositoryID) ([]string, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) CreateBranchProtectionRule(ctx context.Context, repositoryID graveler.RepositoryID, pattern string) error {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) DeleteBranchProtectionRule(ctx context.Context, repositoryID graveler.RepositoryID, pattern string) error {
+	panic("implement me")
+}
+
 func (g *FakeGraveler) CreateBareRepository(ctx context.Context, repositoryID graveler.RepositoryID, storageNamespace graveler.StorageNamespace, branchID graveler.BranchID) (*graveler.Repository, error) {
 	panic("implement me")
 }
@@ -130,6 +142,10 @@ func (g *FakeGraveler) DeleteRepository(ctx context.Context, repositoryID gravel
 	panic("implement me")
 }
 
+func (g *FakeGraveler) SetDefaultBranch(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) error {
+	panic("implement me")
+}
+
 func (g *FakeGraveler) CreateBranch(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, ref graveler.Ref) (*graveler.Branch, error) {
 	panic("implement me")
 }
@@ -220,7 +236,15 @@ func (g *FakeGraveler) Revert(_ context.Context, _ graveler.RepositoryID, _ grav
 	panic("implement me")
 }
 
-func (g *FakeGraveler) Merge(ctx context.Context, repositoryID graveler.RepositoryID, destination graveler.BranchID, source graveler.Ref, _ graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
+func (g *FakeGraveler) Merge(ctx context.Context, repositoryID graveler.RepositoryID, destination graveler.BranchID, source graveler.Ref, _ graveler.CommitParams, _ bool, _ graveler.MergeResolutions) (graveler.CommitID, graveler.DiffSummary, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) AmendCommit(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID, _ graveler.CommitID, _ bool, _ graveler.CommitParams) (graveler.CommitID, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) FastForward(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID, _ graveler.Ref) (graveler.CommitID, error) {
 	panic("implement me")
 }
 
@@ -245,6 +269,10 @@ func (g *FakeGraveler) Compare(_ context.Context, _ graveler.RepositoryID, _, _
 	return g.DiffIteratorFactory(), nil
 }
 
+func (g *FakeGraveler) FindMergeBase(_ context.Context, _ graveler.RepositoryID, _, _ graveler.Ref) (*graveler.CommitRecord, error) {
+	panic("implement me")
+}
+
 func (g *FakeGraveler) SetHooksHandler(handler graveler.HooksHandler) {
 	g.hooks = handler
 }
@@ -63,6 +63,9 @@ type Interface interface {
 	// DeleteRepository delete a repository
 	DeleteRepository(ctx context.Context, repository string) error
 
+	// SetDefaultBranch sets the default branch of a repository to an existing branch
+	SetDefaultBranch(ctx context.Context, repository string, branch string) error
+
 	// ListRepositories list repositories information, the bool returned is true when more repositories can be listed.
 	// In this case pass the last repository name as 'after' on the next call to ListRepositories
 	ListRepositories(ctx context.Context, limit int, prefix, after string) ([]*Repository, bool, error)
@@ -100,9 +103,20 @@ type Interface interface {
 
 	Diff(ctx context.Context, repository, leftReference string, rightReference string, params DiffParams) (Differences, bool, error)
 	Compare(ctx context.Context, repository, leftReference string, rightReference string, params DiffParams) (Differences, bool, error)
+	FindMergeBase(ctx context.Context, repository string, leftReference string, rightReference string) (*CommitLog, error)
 	DiffUncommitted(ctx context.Context, repository, branch, prefix, delimiter string, limit int, after string) (Differences, bool, error)
 
-	Merge(ctx context.Context, repository, destinationBranch, sourceRef, committer, message string, metadata Metadata) (*MergeResult, error)
+	Merge(ctx context.Context, repository, destinationBranch, sourceRef, committer, message string, metadata Metadata, squash bool, resolutions map[string]string) (*MergeResult, error)
+
+	// AmendCommit replaces the head commit of branch with a commit of the same content and
+	// parents, but with message and metadata.  If headCommitID is set, it fails unless that is
+	// still the head commit of branch.  Unless force is set, it fails if branch is protected
+	// from commits.
+	AmendCommit(ctx context.Context, repository, branch, headCommitID, message, committer string, metadata Metadata, force bool) (*CommitLog, error)
+
+	// FastForward moves the destination branch to the commit of the source reference if its head
+	// is an ancestor of it, and returns that commit.
+	FastForward(ctx context.Context, repository, destinationBranch, sourceRef string) (string, error)
 
 	// dump/load metadata
 	DumpCommits(ctx context.Context, repositoryID string) (string, error)
@@ -120,5 +134,9 @@ type Interface interface {
 	SetGarbageCollectionRules(ctx context.Context, repositoryID string, rules *graveler.GarbageCollectionRules) error
 	PrepareExpiredCommits(ctx context.Context, repositoryID string, previousRunID string) (*graveler.GarbageCollectionRunMetadata, error)
 
+	GetBranchProtectionRules(ctx context.Context, repository string) ([]string, error)
+	CreateBranchProtectionRule(ctx context.Context, repository string, pattern string) error
+	DeleteBranchProtectionRule(ctx context.Context, repository string, pattern string) error
+
 	io.Closer
 }
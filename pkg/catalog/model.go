@@ -12,6 +12,10 @@ import (
 const (
 	DBEntryFieldChecksum        = "checksum"
 	DBEntryFieldPhysicalAddress = "physical_address"
+
+	// contentTypeMetadataKey is the reserved entry metadata key holding the object content-type.
+	// It is never exposed as user metadata.
+	contentTypeMetadataKey = "::lakefs::content-type"
 )
 
 type Metadata map[string]string
@@ -33,6 +37,7 @@ type DBEntry struct {
 	Metadata        Metadata    `db:"metadata"`
 	Expired         bool        `db:"is_expired"`
 	AddressType     AddressType `db:"address_type"`
+	ContentType     string
 }
 
 type CommitLog struct {
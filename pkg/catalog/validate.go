@@ -201,5 +201,21 @@ func ValidateNonNegativeInt(v interface{}) error {
 	return nil
 }
 
+// ValidateMergeResolutions accepts a map from path to a merge resolution: "source" or "dest"
+func ValidateMergeResolutions(v interface{}) error {
+	resolutions, ok := v.(map[string]string)
+	if !ok {
+		panic(ErrInvalidType)
+	}
+	for path, resolution := range resolutions {
+		switch graveler.MergeResolution(resolution) {
+		case graveler.MergeResolutionSource, graveler.MergeResolutionDest:
+		default:
+			return fmt.Errorf("%w: %s resolution %s", ErrInvalidValue, path, resolution)
+		}
+	}
+	return nil
+}
+
 var ValidatePathOptional = MakeValidateOptional(ValidatePath)
 var ValidateTagIDOptional = MakeValidateOptional(ValidateTagID)
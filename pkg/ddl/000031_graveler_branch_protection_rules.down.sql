@@ -0,0 +1,3 @@
+BEGIN;
+DROP TABLE IF EXISTS graveler_branch_protection_rules;
+COMMIT;
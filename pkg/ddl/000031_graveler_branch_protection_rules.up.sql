@@ -0,0 +1,9 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS graveler_branch_protection_rules
+(
+    repository_id text NOT NULL,
+    pattern       text NOT NULL,
+
+    PRIMARY KEY (repository_id, pattern)
+);
+COMMIT;
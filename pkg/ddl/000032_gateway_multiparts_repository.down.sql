@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS gateway_multiparts_repository_idx;
+
+ALTER TABLE gateway_multiparts DROP COLUMN IF EXISTS repository;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE gateway_multiparts ADD COLUMN IF NOT EXISTS repository character varying;
+
+CREATE INDEX IF NOT EXISTS gateway_multiparts_repository_idx ON gateway_multiparts (repository, upload_id);
+
+COMMIT;
@@ -11,14 +11,18 @@ import (
 
 type MultipartUpload struct {
 	UploadID        string    `db:"upload_id"`
+	Repository      string    `db:"repository"`
 	Path            string    `db:"path"`
 	CreationDate    time.Time `db:"creation_date"`
 	PhysicalAddress string    `db:"physical_address"`
 }
 
 type Tracker interface {
-	Create(ctx context.Context, uploadID, path, physicalAddress string, creationTime time.Time) error
+	Create(ctx context.Context, repository, uploadID, path, physicalAddress string, creationTime time.Time) error
 	Get(ctx context.Context, uploadID string) (*MultipartUpload, error)
+	// List returns up to amount uploads to repository ordered by upload ID, starting after
+	// after, and whether there are more.
+	List(ctx context.Context, repository, after string, amount int) ([]*MultipartUpload, bool, error)
 	Delete(ctx context.Context, uploadID string) error
 }
 
@@ -37,14 +41,14 @@ func NewTracker(adb db.Database) Tracker {
 	}
 }
 
-func (m *tracker) Create(ctx context.Context, uploadID, path, physicalAddress string, creationTime time.Time) error {
+func (m *tracker) Create(ctx context.Context, repository, uploadID, path, physicalAddress string, creationTime time.Time) error {
 	if uploadID == "" {
 		return ErrInvalidUploadID
 	}
 	_, err := m.db.Transact(ctx, func(tx db.Tx) (interface{}, error) {
-		_, err := tx.Exec(`INSERT INTO gateway_multiparts (upload_id,path,creation_date,physical_address)
-			VALUES ($1, $2, $3, $4)`,
-			uploadID, path, creationTime, physicalAddress)
+		_, err := tx.Exec(`INSERT INTO gateway_multiparts (upload_id,repository,path,creation_date,physical_address)
+			VALUES ($1, $2, $3, $4, $5)`,
+			uploadID, repository, path, creationTime, physicalAddress)
 		return nil, err
 	})
 	return err
@@ -57,7 +61,7 @@ func (m *tracker) Get(ctx context.Context, uploadID string) (*MultipartUpload, e
 	res, err := m.db.Transact(ctx, func(tx db.Tx) (interface{}, error) {
 		var m MultipartUpload
 		if err := tx.Get(&m, `
-			SELECT upload_id, path, creation_date, physical_address 
+			SELECT upload_id, COALESCE(repository, '') AS repository, path, creation_date, physical_address
 			FROM gateway_multiparts
 			WHERE upload_id = $1`,
 			uploadID); err != nil {
@@ -71,6 +75,29 @@ func (m *tracker) Get(ctx context.Context, uploadID string) (*MultipartUpload, e
 	return res.(*MultipartUpload), nil
 }
 
+func (m *tracker) List(ctx context.Context, repository, after string, amount int) ([]*MultipartUpload, bool, error) {
+	res, err := m.db.Transact(ctx, func(tx db.Tx) (interface{}, error) {
+		var uploads []*MultipartUpload
+		// fetch one more upload to tell whether there are more
+		err := tx.Select(&uploads, `
+			SELECT upload_id, repository, path, creation_date, physical_address
+			FROM gateway_multiparts
+			WHERE repository = $1 AND upload_id > $2
+			ORDER BY upload_id
+			LIMIT $3`,
+			repository, after, amount+1)
+		return uploads, err
+	}, db.ReadOnly())
+	if err != nil {
+		return nil, false, err
+	}
+	uploads := res.([]*MultipartUpload)
+	if len(uploads) > amount {
+		return uploads[:amount], true, nil
+	}
+	return uploads, false, nil
+}
+
 func (m *tracker) Delete(ctx context.Context, uploadID string) error {
 	if uploadID == "" {
 		return ErrInvalidUploadID
@@ -22,7 +22,7 @@ func TestTracker_Get(t *testing.T) {
 
 	creationTime := time.Now().Round(time.Second) // round in order to remove the monotonic clock
 	// setup test data
-	if err := tracker.Create(ctx, "upload1", "/path1", "/file1", creationTime); err != nil {
+	if err := tracker.Create(ctx, "repo1", "upload1", "/path1", "/file1", creationTime); err != nil {
 		t.Fatal("create multipart upload for testing", err)
 	}
 
@@ -40,6 +40,7 @@ func TestTracker_Get(t *testing.T) {
 			args: args{uploadID: "upload1"},
 			want: &multiparts.MultipartUpload{
 				UploadID:        "upload1",
+				Repository:      "repo1",
 				Path:            "/path1",
 				CreationDate:    creationTime,
 				PhysicalAddress: "/file1",
@@ -78,7 +79,7 @@ func TestTracker_Delete(t *testing.T) {
 	c := testTracker(t)
 
 	// setup test data
-	if err := c.Create(ctx, "uploadX", "/pathX", "/fileX", time.Now()); err != nil {
+	if err := c.Create(ctx, "repo1", "uploadX", "/pathX", "/fileX", time.Now()); err != nil {
 		t.Fatal("create multipart upload for testing", err)
 	}
 
@@ -108,7 +109,7 @@ func TestTracker_Create(t *testing.T) {
 	tracker := testTracker(t)
 
 	// setup test data
-	if err := tracker.Create(ctx, "uploadX", "/pathX", "/fileX", time.Now()); err != nil {
+	if err := tracker.Create(ctx, "repo1", "uploadX", "/pathX", "/fileX", time.Now()); err != nil {
 		t.Fatal("create multipart upload for testing", err)
 	}
 
@@ -151,7 +152,7 @@ func TestTracker_Create(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tracker.Create(ctx, tt.args.uploadID, tt.args.path, tt.args.physicalAddress, tt.args.creationTime)
+			err := tracker.Create(ctx, "repo1", tt.args.uploadID, tt.args.path, tt.args.physicalAddress, tt.args.creationTime)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -169,3 +170,45 @@ func TestTracker_Create(t *testing.T) {
 		})
 	}
 }
+
+func TestTracker_List(t *testing.T) {
+	ctx := context.Background()
+	tracker := testTracker(t)
+
+	// setup test data
+	for _, upload := range []struct{ repository, uploadID string }{
+		{"repo1", "upload3"}, {"repo1", "upload1"}, {"repo2", "upload2"}, {"repo1", "upload4"},
+	} {
+		if err := tracker.Create(ctx, upload.repository, upload.uploadID, "/path", "/file", time.Now()); err != nil {
+			t.Fatal("create multipart upload for testing", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		after       string
+		amount      int
+		wantUploads []string
+		wantHasMore bool
+	}{
+		{name: "all", amount: 10, wantUploads: []string{"upload1", "upload3", "upload4"}, wantHasMore: false},
+		{name: "first page", amount: 2, wantUploads: []string{"upload1", "upload3"}, wantHasMore: true},
+		{name: "last page", after: "upload3", amount: 2, wantUploads: []string{"upload4"}, wantHasMore: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploads, hasMore, err := tracker.List(ctx, "repo1", tt.after, tt.amount)
+			testutil.MustDo(t, "List", err)
+			got := make([]string, len(uploads))
+			for i, upload := range uploads {
+				got[i] = upload.UploadID
+				if upload.Repository != "repo1" {
+					t.Errorf("List() returned upload %s of repository %s", upload.UploadID, upload.Repository)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantUploads) || hasMore != tt.wantHasMore {
+				t.Errorf("List() got = %v, %t, want %v, %t", got, hasMore, tt.wantUploads, tt.wantHasMore)
+			}
+		})
+	}
+}
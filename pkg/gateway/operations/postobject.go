@@ -3,6 +3,7 @@ package operations
 import (
 	"encoding/hex"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -11,7 +12,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/treeverse/lakefs/pkg/block"
-	"github.com/treeverse/lakefs/pkg/gateway/errors"
+	gatewayerrors "github.com/treeverse/lakefs/pkg/gateway/errors"
 	"github.com/treeverse/lakefs/pkg/gateway/path"
 	"github.com/treeverse/lakefs/pkg/gateway/serde"
 	"github.com/treeverse/lakefs/pkg/httputil"
@@ -44,13 +45,13 @@ func (controller *PostObject) HandleCreateMultipartUpload(w http.ResponseWriter,
 	uploadID, err := o.BlockStore.CreateMultiPartUpload(req.Context(), block.ObjectPointer{StorageNamespace: o.Repository.StorageNamespace, Identifier: objName}, req, opts)
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not create multipart upload")
-		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
 		return
 	}
-	err = o.MultipartsTracker.Create(req.Context(), uploadID, o.Path, objName, time.Now())
+	err = o.MultipartsTracker.Create(req.Context(), o.Repository.Name, uploadID, o.Path, objName, time.Now())
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not write multipart upload to DB")
-		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
 		return
 	}
 	o.EncodeResponse(w, req, &serde.InitiateMultipartUploadResult{
@@ -73,7 +74,7 @@ func (controller *PostObject) HandleCompleteMultipartUpload(w http.ResponseWrite
 	multiPart, err := o.MultipartsTracker.Get(req.Context(), uploadID)
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not read multipart record")
-		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
 		return
 	}
 	objName := multiPart.PhysicalAddress
@@ -81,27 +82,34 @@ func (controller *PostObject) HandleCompleteMultipartUpload(w http.ResponseWrite
 	xmlMultipartComplete, err := ioutil.ReadAll(req.Body)
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not read request body")
-		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
 		return
 	}
 	var MultipartList block.MultipartUploadCompletion
 	err = xml.Unmarshal(xmlMultipartComplete, &MultipartList)
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not parse multipart XML on complete multipart")
-		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
 		return
 	}
 	etag, size, err = o.BlockStore.CompleteMultiPartUpload(req.Context(), block.ObjectPointer{StorageNamespace: o.Repository.StorageNamespace, Identifier: objName}, uploadID, &MultipartList)
+	if errors.Is(err, block.ErrEntityTooSmall) {
+		o.Log(req).WithError(err).Warn("multipart upload part too small")
+		apiErr := gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrEntityTooSmall)
+		apiErr.Description = err.Error()
+		_ = o.EncodeError(w, req, apiErr)
+		return
+	}
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not complete multipart upload")
-		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
 		return
 	}
 	ch := trimQuotes(*etag)
 	checksum := strings.Split(ch, "-")[0]
 	err = o.finishUpload(req, checksum, objName, size, true)
 	if err != nil {
-		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
 		return
 	}
 	err = o.MultipartsTracker.Delete(req.Context(), uploadID)
@@ -0,0 +1,61 @@
+package graveler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BranchProtectionCacheExpiry bounds how long branch protection rules read for a repository are
+// used to check writes.  Rule changes made through this Graveler apply at once; changes made
+// through another server apply once the rules cached here expire.
+const BranchProtectionCacheExpiry = 5 * time.Second
+
+type branchProtectionEntry struct {
+	patterns []string
+	expires  time.Time
+}
+
+// branchProtectionCache holds the branch protection rules of each repository, so that staging
+// writes need not read them from the RefManager each time.
+type branchProtectionCache struct {
+	mu      sync.Mutex
+	entries map[RepositoryID]branchProtectionEntry
+	// generation counts invalidations, so rules read before one are not cached after it
+	generation uint64
+}
+
+func newBranchProtectionCache() *branchProtectionCache {
+	return &branchProtectionCache{entries: make(map[RepositoryID]branchProtectionEntry)}
+}
+
+// get returns the rules of repositoryID, calling fetch to read them if they are not cached or
+// have expired.
+func (c *branchProtectionCache) get(ctx context.Context, repositoryID RepositoryID, fetch func(context.Context, RepositoryID) ([]string, error)) ([]string, error) {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[repositoryID]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.patterns, nil
+	}
+	patterns, err := fetch(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.generation == generation {
+		c.entries[repositoryID] = branchProtectionEntry{patterns: patterns, expires: now.Add(BranchProtectionCacheExpiry)}
+	}
+	c.mu.Unlock()
+	return patterns, nil
+}
+
+// invalidate drops the cached rules of repositoryID, once they change.
+func (c *branchProtectionCache) invalidate(repositoryID RepositoryID) {
+	c.mu.Lock()
+	delete(c.entries, repositoryID)
+	c.generation++
+	c.mu.Unlock()
+}
@@ -104,7 +104,7 @@ func (c *committedManager) diffWithRanges(ctx context.Context, ns graveler.Stora
 	return NewDiffIterator(ctx, leftIt, rightIt), nil
 }
 
-func (c *committedManager) Merge(ctx context.Context, ns graveler.StorageNamespace, destination, source, base graveler.MetaRangeID) (graveler.MetaRangeID, graveler.DiffSummary, error) {
+func (c *committedManager) Merge(ctx context.Context, ns graveler.StorageNamespace, destination, source, base graveler.MetaRangeID, resolutions graveler.MergeResolutions) (graveler.MetaRangeID, graveler.DiffSummary, error) {
 	diffIt, err := c.diffWithRanges(ctx, ns, destination, source)
 	if err != nil {
 		return "", graveler.DiffSummary{}, fmt.Errorf("diff: %w", err)
@@ -115,7 +115,7 @@ func (c *committedManager) Merge(ctx context.Context, ns graveler.StorageNamespa
 		return "", graveler.DiffSummary{}, fmt.Errorf("get base iterator: %w", err)
 	}
 	defer baseIt.Close()
-	patchIterator := NewMergeIterator(ctx, diffIt, baseIt)
+	patchIterator := NewMergeIterator(ctx, diffIt, baseIt, resolutions)
 	defer patchIterator.Close()
 	return c.applyOnDiffWithRanges(ctx, ns, destination, patchIterator)
 }
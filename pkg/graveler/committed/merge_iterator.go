@@ -11,6 +11,7 @@ import (
 type compareIterator struct {
 	ctx             context.Context
 	errorOnConflict bool
+	resolutions     graveler.MergeResolutions
 	diffIt          DiffIterator
 	val             *graveler.Diff
 	rng             *RangeDiff
@@ -27,14 +28,16 @@ var ErrUnsupportedRangeDiffType = errors.New("range diff type unsupported - supp
 // NewMergeIterator accepts an iterator describing a diff from the merge destination to the source.
 // It returns an Iterator with the changes to perform on the destination branch, in order to merge the source into it,
 // relative to base as the merge base.
-// When reaching a conflict, the iterator will enter an error state with the graveler.ErrConflictFound error.
-func NewMergeIterator(ctx context.Context, diffDestToSource DiffIterator, base Iterator) *mergeIterator {
+// Conflicts on keys found in resolutions are resolved by taking the chosen side.
+// When reaching any other conflict, the iterator will enter an error state with the graveler.ErrConflictFound error.
+func NewMergeIterator(ctx context.Context, diffDestToSource DiffIterator, base Iterator, resolutions graveler.MergeResolutions) *mergeIterator {
 	return &mergeIterator{
 		compareIterator: &compareIterator{
 			ctx:             ctx,
 			diffIt:          diffDestToSource,
 			base:            base,
 			errorOnConflict: true,
+			resolutions:     resolutions,
 		},
 	}
 }
@@ -77,15 +80,27 @@ func (d *compareIterator) valueFromBase(key graveler.Key) (*graveler.ValueRecord
 	return val, nil
 }
 
-func (d *compareIterator) handleConflict() bool {
+// handleConflict is called when the current diff value conflicts with the destination
+// returns hasNext if iterator has more, and done if the step is over (same as stepValue)
+func (d *compareIterator) handleConflict() (hasNext, done bool) {
+	val, rngDiff := d.diffIt.Value()
+	switch d.resolutions[string(val.Key)] {
+	case graveler.MergeResolutionSource:
+		// take the change from source
+		d.val = val.Copy()
+		d.setRangeDiff(rngDiff)
+		return true, true
+	case graveler.MergeResolutionDest:
+		// keep destination as is - next value
+		return d.diffIt.Next(), false
+	}
 	if d.errorOnConflict {
 		d.err = graveler.ErrConflictFound
-		return false
+		return false, true
 	}
-	val, _ := d.diffIt.Value()
 	d.val = val.Copy()
 	d.val.Type = graveler.DiffTypeConflict
-	return true
+	return true, true
 }
 func (d *compareIterator) setRangeDiff(r *RangeDiff) {
 	if r != nil {
@@ -148,12 +163,12 @@ func (d *compareIterator) stepValue() (hasNext, done bool) {
 		}
 		if !bytes.Equal(baseVal.Identity, val.Value.Identity) {
 			// removed on dest, but changed on source
-			return d.handleConflict(), true
+			return d.handleConflict()
 		}
 	case graveler.DiffTypeChanged:
 		if baseVal == nil {
 			// added on dest and source, with different identities
-			return d.handleConflict(), true
+			return d.handleConflict()
 		}
 		if bytes.Equal(baseVal.Identity, val.Value.Identity) {
 			// changed on dest, but not on source
@@ -161,7 +176,7 @@ func (d *compareIterator) stepValue() (hasNext, done bool) {
 		}
 		if !bytes.Equal(baseVal.Identity, val.LeftIdentity) {
 			// changed on dest and source, to different identities
-			return d.handleConflict(), true
+			return d.handleConflict()
 		}
 		// changed only on source
 		d.val = val.Copy()
@@ -177,7 +192,7 @@ func (d *compareIterator) stepValue() (hasNext, done bool) {
 				return true, true
 			}
 			// changed on dest, removed on source
-			return d.handleConflict(), true
+			return d.handleConflict()
 		}
 		// added on dest, but not on source - next value
 	}
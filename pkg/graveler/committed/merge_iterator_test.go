@@ -125,7 +125,7 @@ func TestMerge(t *testing.T) {
 			defer diffIt.Close()
 			base := makeBaseIterator(tst.baseKeys)
 			ctx := context.Background()
-			it := committed.NewMergeIterator(ctx, committed.NewDiffIteratorWrapper(diffIt), base)
+			it := committed.NewMergeIterator(ctx, committed.NewDiffIteratorWrapper(diffIt), base, nil)
 			var gotValues, gotKeys []string
 			idx := 0
 			for it.Next() {
@@ -412,7 +412,7 @@ func TestMergeRange(t *testing.T) {
 
 			// test merge iterator
 			ctx := context.Background()
-			it := committed.NewMergeIterator(ctx, diffIt, baseIt)
+			it := committed.NewMergeIterator(ctx, diffIt, baseIt, nil)
 			gotKeys := make([]string, 0)
 			gotIDs := make([]string, 0)
 			gotRangesIDs := make([]string, 0)
@@ -469,7 +469,7 @@ func TestMergeNextRange(t *testing.T) {
 	diffIt.AddValueRecords(makeDV(added, "k4", "i4", ""), makeDV(added, "k5", "i5", ""))
 
 	ctx := context.Background()
-	it := committed.NewMergeIterator(ctx, diffIt, baseIt)
+	it := committed.NewMergeIterator(ctx, diffIt, baseIt, nil)
 
 	if !it.Next() {
 		t.Fatalf("expected it.Next() to return true (error:%v)", it.Err())
@@ -511,6 +511,63 @@ func TestMergeNextRange(t *testing.T) {
 	}
 }
 
+func TestMergeResolutions(t *testing.T) {
+	diffs := []graveler.Diff{
+		testMergeNewDiff(added, "k1", "i1", ""),
+		testMergeNewDiff(changed, "k6", "i6a", "i6b"),
+		testMergeNewDiff(added, "k7", "i7", ""),
+		testMergeNewDiff(changed, "k9", "i9a", "i9"),
+	}
+	baseKeys := []string{"k6"}
+	tests := map[string]struct {
+		resolutions        graveler.MergeResolutions
+		expectedKeys       []string
+		expectedIdentities []string
+		expectedErr        error
+	}{
+		"unresolved": {
+			resolutions:        graveler.MergeResolutions{"k9": graveler.MergeResolutionSource},
+			expectedKeys:       []string{"k1"},
+			expectedIdentities: []string{"i1"},
+			expectedErr:        graveler.ErrConflictFound,
+		},
+		"source and dest": {
+			resolutions:        graveler.MergeResolutions{"k6": graveler.MergeResolutionDest, "k9": graveler.MergeResolutionSource},
+			expectedKeys:       []string{"k1", "k7", "k9"},
+			expectedIdentities: []string{"i1", "i7", "i9a"},
+		},
+		"all source": {
+			resolutions:        graveler.MergeResolutions{"k6": graveler.MergeResolutionSource, "k9": graveler.MergeResolutionSource},
+			expectedKeys:       []string{"k1", "k6", "k7", "k9"},
+			expectedIdentities: []string{"i1", "i6a", "i7", "i9a"},
+		},
+	}
+	for name, tst := range tests {
+		t.Run(name, func(t *testing.T) {
+			diffIt := testutil.NewDiffIter(diffs)
+			defer diffIt.Close()
+			base := makeBaseIterator(baseKeys)
+			it := committed.NewMergeIterator(context.Background(), committed.NewDiffIteratorWrapper(diffIt), base, tst.resolutions)
+			defer it.Close()
+			var gotKeys, gotIdentities []string
+			for it.Next() {
+				val, _ := it.Value()
+				gotKeys = append(gotKeys, string(val.Key))
+				gotIdentities = append(gotIdentities, string(val.Value.Identity))
+			}
+			if err := it.Err(); !errors.Is(err, tst.expectedErr) {
+				t.Fatalf("Err() = %v, expected %v", err, tst.expectedErr)
+			}
+			if diff := deep.Equal(gotKeys, tst.expectedKeys); diff != nil {
+				t.Errorf("unexpected keys: %s", diff)
+			}
+			if diff := deep.Equal(gotIdentities, tst.expectedIdentities); diff != nil {
+				t.Errorf("unexpected identities: %s", diff)
+			}
+		})
+	}
+}
+
 func TestMergeCancelContext(t *testing.T) {
 	diffs := []graveler.Diff{testMergeNewDiff(added, "k3", "i3", "")}
 	diffIt := testutil.NewDiffIter(diffs)
@@ -519,7 +576,7 @@ func TestMergeCancelContext(t *testing.T) {
 	base := makeBaseIterator(baseKeys)
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
-	it := committed.NewMergeIterator(ctx, committed.NewDiffIteratorWrapper(diffIt), base)
+	it := committed.NewMergeIterator(ctx, committed.NewDiffIteratorWrapper(diffIt), base, nil)
 	if it.Next() {
 		t.Fatal("Next() should return false")
 	}
@@ -544,7 +601,7 @@ func TestMergeSeek(t *testing.T) {
 	baseKeys := []string{"k2", "k3", "k4", "k6"}
 	base := makeBaseIterator(baseKeys)
 	ctx := context.Background()
-	it := committed.NewMergeIterator(ctx, committed.NewDiffIteratorWrapper(diffIt), base)
+	it := committed.NewMergeIterator(ctx, committed.NewDiffIteratorWrapper(diffIt), base, nil)
 	// expected diffs, +k1, -k2, Chng:k3,+k7, Conf:k9,
 	defer it.Close()
 	tests := []struct {
@@ -809,7 +866,7 @@ func TestMergeSeekWithRange(t *testing.T) {
 		}).
 		AddValueRecords(makeV("k8", "i8"), makeV("k9", "i9"))
 	ctx := context.Background()
-	it := committed.NewMergeIterator(ctx, diffIt, baseIt)
+	it := committed.NewMergeIterator(ctx, diffIt, baseIt, nil)
 	// expected diffs, +k1, -k2, Chng:k3,+k7, Conf:k9,
 	defer it.Close()
 	tests := []struct {
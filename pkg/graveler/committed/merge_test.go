@@ -598,7 +598,7 @@ func Test_merge(t *testing.T) {
 			metaRangeId := graveler.MetaRangeID("merge")
 			writer.EXPECT().Close().Return(&metaRangeId, nil).AnyTimes()
 			committedManager := committed.NewCommittedManager(metaRangeManager)
-			_, summary, err := committedManager.Merge(ctx, "ns", "dest", "source", "base")
+			_, summary, err := committedManager.Merge(ctx, "ns", "dest", "source", "base", nil)
 			if err != tst.expectedErr {
 				t.Fatal(err)
 			}
@@ -29,6 +29,7 @@ var (
 	ErrRefAmbiguous           = fmt.Errorf("reference is ambiguous: %w", ErrNotFound)
 	ErrNoChanges              = wrapError(ErrUserVisible, "no changes")
 	ErrConflictFound          = errors.New("conflict found")
+	ErrNotFastForward         = errors.New("not a fast-forward")
 	ErrCommitNotHeadBranch    = errors.New("commit is not head of branch")
 	ErrBranchExists           = fmt.Errorf("branch already exists: %w", ErrNotUnique)
 	ErrTagAlreadyExists       = fmt.Errorf("tag already exists: %w", ErrNotUnique)
@@ -41,6 +42,15 @@ var (
 	ErrRevertParentOutOfRange = errors.New("given commit does not have the given parent number")
 )
 
+// Branch protection errors
+var (
+	ErrWriteToProtectedBranch       = wrapError(ErrUserVisible, "cannot write to protected branch")
+	ErrCommitToProtectedBranch      = wrapError(ErrUserVisible, "cannot commit to protected branch")
+	ErrBranchProtectionRuleExists   = fmt.Errorf("branch protection rule already exists: %w", ErrNotUnique)
+	ErrBranchProtectionRuleNotFound = fmt.Errorf("branch protection rule %w", ErrNotFound)
+	ErrInvalidBranchPattern         = fmt.Errorf("branch pattern: %w", ErrInvalidValue)
+)
+
 // wrappedError is an error for wrapping another error while ignoring its message.
 type wrappedError struct {
 	err error
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
 	"strings"
 	"time"
 
@@ -28,6 +29,18 @@ const (
 	DiffTypeConflict
 )
 
+// MergeResolution selects which side of a merge wins on a conflicting key
+type MergeResolution string
+
+const (
+	MergeResolutionSource MergeResolution = "source"
+	MergeResolutionDest   MergeResolution = "dest"
+)
+
+// MergeResolutions maps conflicting keys to the side chosen to resolve them.  Conflicts on
+// keys without a resolution still fail the merge.
+type MergeResolutions map[string]MergeResolution
+
 type RefModType rune
 
 const (
@@ -325,6 +338,9 @@ type VersionController interface {
 	// DeleteRepository deletes the repository
 	DeleteRepository(ctx context.Context, repositoryID RepositoryID) error
 
+	// SetDefaultBranch sets the default branch of the repository to an existing branch
+	SetDefaultBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID) error
+
 	// CreateBranch creates branch on repository pointing to ref
 	CreateBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID, ref Ref) (*Branch, error)
 
@@ -359,6 +375,13 @@ type VersionController interface {
 	//   ErrNothingToCommit in case there is no data in stage
 	Commit(ctx context.Context, repositoryID RepositoryID, branchID BranchID, commitParams CommitParams) (CommitID, error)
 
+	// AmendCommit replaces the head commit of the branch with a commit of the same content and
+	// parents but the message and metadata of commitParams, and returns its commit ID.
+	// Staged changes are kept.  If headCommitID is set and is no longer the head commit of the
+	// branch, it returns ErrPreconditionFailed.  Unless force is set, it returns
+	// ErrCommitToProtectedBranch for a branch protected from commits.
+	AmendCommit(ctx context.Context, repositoryID RepositoryID, branchID BranchID, headCommitID CommitID, force bool, commitParams CommitParams) (CommitID, error)
+
 	// WriteMetaRange accepts a ValueIterator and writes the entire iterator to a new MetaRange
 	// and returns the result ID.
 	WriteMetaRange(ctx context.Context, repositoryID RepositoryID, it ValueIterator) (*MetaRangeID, error)
@@ -397,7 +420,13 @@ type VersionController interface {
 	Revert(ctx context.Context, repositoryID RepositoryID, branchID BranchID, ref Ref, parentNumber int, commitParams CommitParams) (CommitID, DiffSummary, error)
 
 	// Merge merges 'source' into 'destination' and returns the commit id for the created merge commit, and a summary of results.
-	Merge(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref, commitParams CommitParams) (CommitID, DiffSummary, error)
+	// If squash is set, the created commit holds the net changes of 'source' with 'destination' as its only parent.
+	// Conflicts on keys found in resolutions are resolved by taking the chosen side.
+	Merge(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref, commitParams CommitParams, squash bool, resolutions MergeResolutions) (CommitID, DiffSummary, error)
+
+	// FastForward moves 'destination' to the commit of 'source' without creating a commit, and returns that commit id.
+	// It fails with ErrNotFastForward unless the head of 'destination' is an ancestor of 'source'.
+	FastForward(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref) (CommitID, error)
 
 	// DiffUncommitted returns iterator to scan the changes made on the branch
 	DiffUncommitted(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (DiffIterator, error)
@@ -410,6 +439,10 @@ type VersionController interface {
 	// This is similar to a three-dot (from...to) diff in git.
 	Compare(ctx context.Context, repositoryID RepositoryID, from, to Ref) (DiffIterator, error)
 
+	// FindMergeBase returns the best common ancestor of the commits of 'left' and 'right': the base of a
+	// three-dot diff or merge between them.  It fails with ErrNoMergeBase if they share no history.
+	FindMergeBase(ctx context.Context, repositoryID RepositoryID, left, right Ref) (*CommitRecord, error)
+
 	// SetHooksHandler set handler for all graveler hooks
 	SetHooksHandler(handler HooksHandler)
 
@@ -421,6 +454,16 @@ type VersionController interface {
 
 	SetGarbageCollectionRules(ctx context.Context, repositoryID RepositoryID, rules *GarbageCollectionRules) error
 
+	// GetBranchProtectionRules returns the patterns of branch names protected in the repository
+	GetBranchProtectionRules(ctx context.Context, repositoryID RepositoryID) ([]string, error)
+
+	// CreateBranchProtectionRule protects branches whose name matches pattern from staged
+	// writes and commits.  They can still be merged into.
+	CreateBranchProtectionRule(ctx context.Context, repositoryID RepositoryID, pattern string) error
+
+	// DeleteBranchProtectionRule removes the protection of branches matching pattern
+	DeleteBranchProtectionRule(ctx context.Context, repositoryID RepositoryID, pattern string) error
+
 	// SaveGarbageCollectionCommits saves the sets of active and expired commits, according to the branch rules for garbage collection.
 	// Returns
 	//	- run id which can later be used to retrieve the set of commits.
@@ -547,6 +590,9 @@ type RefManager interface {
 	// DeleteRepository deletes the repository
 	DeleteRepository(ctx context.Context, repositoryID RepositoryID) error
 
+	// SetDefaultBranch sets the default branch of the repository to an existing branch
+	SetDefaultBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID) error
+
 	// ParseRef returns parsed 'ref' information as RawRef
 	ParseRef(ref Ref) (RawRef, error)
 
@@ -583,10 +629,10 @@ type RefManager interface {
 	// AddCommit stores the Commit object, returning its ID
 	AddCommit(ctx context.Context, repositoryID RepositoryID, commit Commit) (CommitID, error)
 
-	// FindMergeBase returns the merge-base for the given CommitIDs
+	// FindMergeBase returns the merge-base for the given CommitIDs, along with its CommitID
 	// see: https://git-scm.com/docs/git-merge-base
 	// and internally: https://github.com/treeverse/lakeFS/blob/09954804baeb36ada74fa17d8fdc13a38552394e/index/dag/commits.go
-	FindMergeBase(ctx context.Context, repositoryID RepositoryID, commitIDs ...CommitID) (*Commit, error)
+	FindMergeBase(ctx context.Context, repositoryID RepositoryID, commitIDs ...CommitID) (*CommitRecord, error)
 
 	// Log returns an iterator starting at commit ID up to repository root
 	Log(ctx context.Context, repositoryID RepositoryID, commitID CommitID) (CommitIterator, error)
@@ -597,6 +643,15 @@ type RefManager interface {
 	// FillGenerations computes and updates the generation field for all commits in a repository.
 	// It should be used for restoring commits from a commit-dump which was performed before the field was introduced.
 	FillGenerations(ctx context.Context, repositoryID RepositoryID) error
+
+	// GetBranchProtectionRules returns the branch name patterns protected in the repository
+	GetBranchProtectionRules(ctx context.Context, repositoryID RepositoryID) ([]string, error)
+
+	// CreateBranchProtectionRule stores a branch name pattern to protect
+	CreateBranchProtectionRule(ctx context.Context, repositoryID RepositoryID, pattern string) error
+
+	// DeleteBranchProtectionRule deletes a stored branch name pattern
+	DeleteBranchProtectionRule(ctx context.Context, repositoryID RepositoryID, pattern string) error
 }
 
 // CommittedManager reads and applies committed snapshots
@@ -624,8 +679,9 @@ type CommittedManager interface {
 
 	// Merge applies changes from 'source' to 'destination', relative to a merge base 'base' and
 	// returns the ID of the new metarange and a summary of diffs.  This is similar to a
-	// git merge operation. The resulting tree is expected to be immediately addressable.
-	Merge(ctx context.Context, ns StorageNamespace, destination, source, base MetaRangeID) (MetaRangeID, DiffSummary, error)
+	// git merge operation. Conflicts on keys found in resolutions are resolved by taking the
+	// chosen side. The resulting tree is expected to be immediately addressable.
+	Merge(ctx context.Context, ns StorageNamespace, destination, source, base MetaRangeID, resolutions MergeResolutions) (MetaRangeID, DiffSummary, error)
 
 	// Apply is the act of taking an existing metaRange (snapshot) and applying a set of changes to it.
 	// A change is either an entity to write/overwrite, or a tombstone to mark a deletion
@@ -716,6 +772,7 @@ type Graveler struct {
 	branchLocker             BranchLocker
 	hooks                    HooksHandler
 	garbageCollectionManager GarbageCollectionManager
+	branchProtection         *branchProtectionCache
 	log                      logging.Logger
 }
 
@@ -727,6 +784,7 @@ func NewGraveler(branchLocker BranchLocker, committedManager CommittedManager, s
 		branchLocker:             branchLocker,
 		hooks:                    &HooksNoOp{},
 		garbageCollectionManager: gcManager,
+		branchProtection:         newBranchProtectionCache(),
 		log:                      logging.Default().WithField("service_name", "graveler_graveler"),
 	}
 }
@@ -778,6 +836,10 @@ func (g *Graveler) DeleteRepository(ctx context.Context, repositoryID Repository
 	return g.RefManager.DeleteRepository(ctx, repositoryID)
 }
 
+func (g *Graveler) SetDefaultBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID) error {
+	return g.RefManager.SetDefaultBranch(ctx, repositoryID, branchID)
+}
+
 func (g *Graveler) GetCommit(ctx context.Context, repositoryID RepositoryID, commitID CommitID) (*Commit, error) {
 	return g.RefManager.GetCommit(ctx, repositoryID, commitID)
 }
@@ -945,6 +1007,44 @@ func (g *Graveler) SetGarbageCollectionRules(ctx context.Context, repositoryID R
 	return g.garbageCollectionManager.SaveRules(ctx, repo.StorageNamespace, rules)
 }
 
+func (g *Graveler) GetBranchProtectionRules(ctx context.Context, repositoryID RepositoryID) ([]string, error) {
+	if _, err := g.RefManager.GetRepository(ctx, repositoryID); err != nil {
+		return nil, err
+	}
+	return g.RefManager.GetBranchProtectionRules(ctx, repositoryID)
+}
+
+func (g *Graveler) CreateBranchProtectionRule(ctx context.Context, repositoryID RepositoryID, pattern string) error {
+	if _, err := path.Match(pattern, ""); pattern == "" || err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidBranchPattern, pattern)
+	}
+	if _, err := g.RefManager.GetRepository(ctx, repositoryID); err != nil {
+		return err
+	}
+	defer g.branchProtection.invalidate(repositoryID)
+	return g.RefManager.CreateBranchProtectionRule(ctx, repositoryID, pattern)
+}
+
+func (g *Graveler) DeleteBranchProtectionRule(ctx context.Context, repositoryID RepositoryID, pattern string) error {
+	defer g.branchProtection.invalidate(repositoryID)
+	return g.RefManager.DeleteBranchProtectionRule(ctx, repositoryID, pattern)
+}
+
+// checkBranchProtection returns protectedErr if branchID matches a branch protection rule of
+// the repository.
+func (g *Graveler) checkBranchProtection(ctx context.Context, repositoryID RepositoryID, branchID BranchID, protectedErr error) error {
+	patterns, err := g.branchProtection.get(ctx, repositoryID, g.RefManager.GetBranchProtectionRules)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, branchID.String()); matched {
+			return fmt.Errorf("%w: %s", protectedErr, branchID)
+		}
+	}
+	return nil
+}
+
 func (g *Graveler) SaveGarbageCollectionCommits(ctx context.Context, repositoryID RepositoryID, previousRunID string) (*GarbageCollectionRunMetadata, error) {
 	rules, err := g.GetGarbageCollectionRules(ctx, repositoryID)
 	if err != nil {
@@ -1011,6 +1111,9 @@ func (g *Graveler) Get(ctx context.Context, repositoryID RepositoryID, ref Ref,
 }
 
 func (g *Graveler) Set(ctx context.Context, repositoryID RepositoryID, branchID BranchID, key Key, value Value, writeConditions ...WriteConditionOption) error {
+	if err := g.checkBranchProtection(ctx, repositoryID, branchID, ErrWriteToProtectedBranch); err != nil {
+		return err
+	}
 	_, err := g.branchLocker.Writer(ctx, repositoryID, branchID, func() (interface{}, error) {
 		branch, err := g.GetBranch(ctx, repositoryID, branchID)
 		if err != nil {
@@ -1052,6 +1155,9 @@ func isStagedTombstone(ctx context.Context, manager StagingManager, token Stagin
 }
 
 func (g *Graveler) Delete(ctx context.Context, repositoryID RepositoryID, branchID BranchID, key Key) error {
+	if err := g.checkBranchProtection(ctx, repositoryID, branchID, ErrWriteToProtectedBranch); err != nil {
+		return err
+	}
 	_, err := g.branchLocker.Writer(ctx, repositoryID, branchID, func() (interface{}, error) {
 		repo, err := g.RefManager.GetRepository(ctx, repositoryID)
 		if err != nil {
@@ -1132,6 +1238,9 @@ func (g *Graveler) List(ctx context.Context, repositoryID RepositoryID, ref Ref)
 }
 
 func (g *Graveler) Commit(ctx context.Context, repositoryID RepositoryID, branchID BranchID, params CommitParams) (CommitID, error) {
+	if err := g.checkBranchProtection(ctx, repositoryID, branchID, ErrCommitToProtectedBranch); err != nil {
+		return "", err
+	}
 	var preRunID string
 	var commit Commit
 	var storageNamespace StorageNamespace
@@ -1245,6 +1354,100 @@ func (g *Graveler) Commit(ctx context.Context, repositoryID RepositoryID, branch
 	return newCommitID, nil
 }
 
+func (g *Graveler) AmendCommit(ctx context.Context, repositoryID RepositoryID, branchID BranchID, headCommitID CommitID, force bool, params CommitParams) (CommitID, error) {
+	if !force {
+		if err := g.checkBranchProtection(ctx, repositoryID, branchID, ErrCommitToProtectedBranch); err != nil {
+			return "", err
+		}
+	}
+	var preRunID string
+	var commit Commit
+	var storageNamespace StorageNamespace
+	res, err := g.branchLocker.MetadataUpdater(ctx, repositoryID, branchID, func() (interface{}, error) {
+		repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+		if err != nil {
+			return "", fmt.Errorf("get repository: %w", err)
+		}
+		storageNamespace = repo.StorageNamespace
+
+		branch, err := g.RefManager.GetBranch(ctx, repositoryID, branchID)
+		if err != nil {
+			return "", fmt.Errorf("get branch: %w", err)
+		}
+		if branch.CommitID == "" {
+			return "", ErrCommitNotFound
+		}
+		if headCommitID != "" && branch.CommitID != headCommitID {
+			return "", fmt.Errorf("branch %s head is %s, not %s: %w", branchID, branch.CommitID, headCommitID, ErrPreconditionFailed)
+		}
+		head, err := g.RefManager.GetCommit(ctx, repositoryID, branch.CommitID)
+		if err != nil {
+			return "", fmt.Errorf("get commit: %w", err)
+		}
+
+		// the amended commit replaces the head: same content and place in history
+		commit = NewCommit()
+		commit.Committer = params.Committer
+		commit.Message = params.Message
+		commit.Metadata = params.Metadata
+		commit.MetaRangeID = head.MetaRangeID
+		commit.Parents = head.Parents
+		commit.Generation = head.Generation
+
+		preRunID = NewRunID()
+		err = g.hooks.PreCommitHook(ctx, HookRecord{
+			RunID:            preRunID,
+			EventType:        EventTypePreCommit,
+			SourceRef:        branchID.Ref(),
+			RepositoryID:     repositoryID,
+			StorageNamespace: storageNamespace,
+			BranchID:         branchID,
+			Commit:           commit,
+		})
+		if err != nil {
+			return "", &HookAbortError{
+				EventType: EventTypePreCommit,
+				RunID:     preRunID,
+				Err:       err,
+			}
+		}
+
+		newCommit, err := g.RefManager.AddCommit(ctx, repositoryID, commit)
+		if err != nil {
+			return "", fmt.Errorf("add commit: %w", err)
+		}
+		branch.CommitID = newCommit
+		err = g.RefManager.SetBranch(ctx, repositoryID, branchID, *branch)
+		if err != nil {
+			return "", fmt.Errorf("set branch commit %s: %w", newCommit, err)
+		}
+		return newCommit, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	newCommitID := res.(CommitID)
+	postRunID := NewRunID()
+	err = g.hooks.PostCommitHook(ctx, HookRecord{
+		EventType:        EventTypePostCommit,
+		RunID:            postRunID,
+		RepositoryID:     repositoryID,
+		StorageNamespace: storageNamespace,
+		SourceRef:        branchID.Ref(),
+		BranchID:         branchID,
+		Commit:           commit,
+		CommitID:         newCommitID,
+		PreRunID:         preRunID,
+	})
+	if err != nil {
+		g.log.WithError(err).
+			WithField("run_id", postRunID).
+			WithField("pre_run_id", preRunID).
+			Error("Post-commit hook failed")
+	}
+	return newCommitID, nil
+}
+
 func newStagingToken(repositoryID RepositoryID, branchID BranchID) StagingToken {
 	v := strings.Join([]string{repositoryID.String(), branchID.String(), uuid.New().String()}, "-")
 	return StagingToken(v)
@@ -1429,6 +1632,9 @@ type CommitIDAndSummary struct {
 // That is, try to apply the diff from C2 to C1 on the tip of the branch.
 // If the commit is a merge commit, 'parentNumber' is the parent number (1-based) relative to which the revert is done.
 func (g *Graveler) Revert(ctx context.Context, repositoryID RepositoryID, branchID BranchID, ref Ref, parentNumber int, commitParams CommitParams) (CommitID, DiffSummary, error) {
+	if err := g.checkBranchProtection(ctx, repositoryID, branchID, ErrCommitToProtectedBranch); err != nil {
+		return "", DiffSummary{}, err
+	}
 	commitRecord, err := g.getCommitRecordFromRef(ctx, repositoryID, ref)
 	if err != nil {
 		return "", DiffSummary{}, fmt.Errorf("get commit from ref %s: %w", ref, err)
@@ -1471,7 +1677,7 @@ func (g *Graveler) Revert(ctx context.Context, repositoryID RepositoryID, branch
 			return "", fmt.Errorf("get commit from ref %s: %w", branch.CommitID, err)
 		}
 		// merge from the parent to the top of the branch, with the given ref as the merge base:
-		metaRangeID, summary, err := g.CommittedManager.Merge(ctx, repo.StorageNamespace, branchCommit.MetaRangeID, parentMetaRangeID, commitRecord.MetaRangeID)
+		metaRangeID, summary, err := g.CommittedManager.Merge(ctx, repo.StorageNamespace, branchCommit.MetaRangeID, parentMetaRangeID, commitRecord.MetaRangeID, nil)
 		if err != nil {
 			if !errors.Is(err, ErrUserVisible) {
 				err = fmt.Errorf("merge: %w", err)
@@ -1505,7 +1711,7 @@ func (g *Graveler) Revert(ctx context.Context, repositoryID RepositoryID, branch
 	return c.ID, c.Summary, nil
 }
 
-func (g *Graveler) Merge(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref, commitParams CommitParams) (CommitID, DiffSummary, error) {
+func (g *Graveler) Merge(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref, commitParams CommitParams, squash bool, resolutions MergeResolutions) (CommitID, DiffSummary, error) {
 	var preRunID string
 	var storageNamespace StorageNamespace
 	var commit Commit
@@ -1531,7 +1737,7 @@ func (g *Graveler) Merge(ctx context.Context, repositoryID RepositoryID, destina
 		if err != nil {
 			return "", err
 		}
-		metaRangeID, summary, err := g.CommittedManager.Merge(ctx, storageNamespace, toCommit.MetaRangeID, fromCommit.MetaRangeID, baseCommit.MetaRangeID)
+		metaRangeID, summary, err := g.CommittedManager.Merge(ctx, storageNamespace, toCommit.MetaRangeID, fromCommit.MetaRangeID, baseCommit.MetaRangeID, resolutions)
 		if err != nil {
 			if !errors.Is(err, ErrUserVisible) {
 				err = fmt.Errorf("merge in CommitManager: %w", err)
@@ -1542,10 +1748,15 @@ func (g *Graveler) Merge(ctx context.Context, repositoryID RepositoryID, destina
 		commit.Committer = commitParams.Committer
 		commit.Message = commitParams.Message
 		commit.MetaRangeID = metaRangeID
-		commit.Parents = []CommitID{toCommit.CommitID, fromCommit.CommitID}
-		if toCommit.Generation > fromCommit.Generation {
+		switch {
+		case squash:
+			commit.Parents = []CommitID{toCommit.CommitID}
 			commit.Generation = toCommit.Generation + 1
-		} else {
+		case toCommit.Generation > fromCommit.Generation:
+			commit.Parents = []CommitID{toCommit.CommitID, fromCommit.CommitID}
+			commit.Generation = toCommit.Generation + 1
+		default:
+			commit.Parents = []CommitID{toCommit.CommitID, fromCommit.CommitID}
 			commit.Generation = fromCommit.Generation + 1
 		}
 		commit.Metadata = commitParams.Metadata
@@ -1603,6 +1814,86 @@ func (g *Graveler) Merge(ctx context.Context, repositoryID RepositoryID, destina
 	return c.ID, c.Summary, nil
 }
 
+func (g *Graveler) FastForward(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref) (CommitID, error) {
+	var preRunID string
+	var storageNamespace StorageNamespace
+	var fromCommit *CommitRecord
+	_, err := g.branchLocker.MetadataUpdater(ctx, repositoryID, destination, func() (interface{}, error) {
+		repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+		if err != nil {
+			return nil, err
+		}
+		storageNamespace = repo.StorageNamespace
+
+		branch, err := g.GetBranch(ctx, repositoryID, destination)
+		if err != nil {
+			return nil, fmt.Errorf("get branch: %w", err)
+		}
+		empty, err := g.stagingEmpty(ctx, branch)
+		if err != nil {
+			return nil, fmt.Errorf("check if staging empty: %w", err)
+		}
+		if !empty {
+			return nil, ErrDirtyBranch
+		}
+		var toCommit, baseCommit *CommitRecord
+		fromCommit, toCommit, baseCommit, err = g.getCommitsForMerge(ctx, repositoryID, source, Ref(destination))
+		if err != nil {
+			return nil, err
+		}
+		// the destination head is an ancestor of the source iff it is their merge base
+		if baseCommit.CommitID != toCommit.CommitID {
+			return nil, ErrNotFastForward
+		}
+		preRunID = NewRunID()
+		err = g.hooks.PreMergeHook(ctx, HookRecord{
+			EventType:        EventTypePreMerge,
+			RunID:            preRunID,
+			RepositoryID:     repositoryID,
+			StorageNamespace: storageNamespace,
+			BranchID:         destination,
+			SourceRef:        fromCommit.CommitID.Ref(),
+			Commit:           *fromCommit.Commit,
+		})
+		if err != nil {
+			return nil, &HookAbortError{
+				EventType: EventTypePreMerge,
+				RunID:     preRunID,
+				Err:       err,
+			}
+		}
+		branch.CommitID = fromCommit.CommitID
+		err = g.RefManager.SetBranch(ctx, repositoryID, destination, *branch)
+		if err != nil {
+			return nil, fmt.Errorf("update branch %s: %w", destination, err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	postRunID := NewRunID()
+	err = g.hooks.PostMergeHook(ctx, HookRecord{
+		EventType:        EventTypePostMerge,
+		RunID:            postRunID,
+		RepositoryID:     repositoryID,
+		StorageNamespace: storageNamespace,
+		BranchID:         destination,
+		SourceRef:        source,
+		Commit:           *fromCommit.Commit,
+		CommitID:         fromCommit.CommitID,
+		PreRunID:         preRunID,
+	})
+	if err != nil {
+		g.log.
+			WithError(err).
+			WithField("run_id", postRunID).
+			WithField("pre_run_id", preRunID).
+			Error("Post-merge hook failed")
+	}
+	return fromCommit.CommitID, nil
+}
+
 func (g *Graveler) DiffUncommitted(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (DiffIterator, error) {
 	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
 	if err != nil {
@@ -1679,6 +1970,11 @@ func (g *Graveler) Compare(ctx context.Context, repositoryID RepositoryID, from,
 	return g.CommittedManager.Compare(ctx, repo.StorageNamespace, toCommit.MetaRangeID, fromCommit.MetaRangeID, baseCommit.MetaRangeID)
 }
 
+func (g *Graveler) FindMergeBase(ctx context.Context, repositoryID RepositoryID, left, right Ref) (*CommitRecord, error) {
+	_, _, baseCommit, err := g.getCommitsForMerge(ctx, repositoryID, left, right)
+	return baseCommit, err
+}
+
 func (g *Graveler) SetHooksHandler(handler HooksHandler) {
 	if handler == nil {
 		g.hooks = &HooksNoOp{}
@@ -1687,7 +1983,7 @@ func (g *Graveler) SetHooksHandler(handler HooksHandler) {
 	}
 }
 
-func (g *Graveler) getCommitsForMerge(ctx context.Context, repositoryID RepositoryID, from Ref, to Ref) (*CommitRecord, *CommitRecord, *Commit, error) {
+func (g *Graveler) getCommitsForMerge(ctx context.Context, repositoryID RepositoryID, from Ref, to Ref) (*CommitRecord, *CommitRecord, *CommitRecord, error) {
 	fromCommit, err := g.getCommitRecordFromRef(ctx, repositoryID, from)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("get commit by ref %s: %w", from, err)
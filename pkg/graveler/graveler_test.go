@@ -10,6 +10,7 @@ import (
 	"github.com/treeverse/lakefs/pkg/graveler"
 	"github.com/treeverse/lakefs/pkg/graveler/ref"
 	"github.com/treeverse/lakefs/pkg/graveler/testutil"
+	"github.com/treeverse/lakefs/pkg/ident"
 	tu "github.com/treeverse/lakefs/pkg/testutil"
 )
 
@@ -661,7 +662,7 @@ func TestGraveler_PreMergeHook(t *testing.T) {
 				Committer: commitCommitter,
 				Message:   mergeMessage,
 				Metadata:  mergeMetadata,
-			})
+			}, false, nil)
 			// verify we got an error
 			if !errors.Is(err, tt.err) {
 				t.Fatalf("Merge err=%v, pre-merge error expected=%v", err, tt.err)
@@ -706,6 +707,230 @@ func TestGraveler_PreMergeHook(t *testing.T) {
 	}
 }
 
+func TestGraveler_SquashMerge(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	ctx := context.Background()
+	const (
+		repositoryID        = graveler.RepositoryID("repoID")
+		destination         = graveler.BranchID("destinationID")
+		sourceCommitID      = graveler.CommitID("sourceCommitID")
+		destinationCommitID = graveler.CommitID("destinationCommitID")
+		mergedCommitID      = graveler.CommitID("mergedCommitID")
+		expectedRangeID     = graveler.MetaRangeID("expectedRangeID")
+	)
+	refManager := &testutil.RefsFake{
+		CommitID: mergedCommitID,
+		Branch:   &graveler.Branch{CommitID: destinationCommitID},
+		Refs: map[graveler.Ref]*graveler.ResolvedRef{
+			graveler.Ref(destination): {
+				Type:     graveler.ReferenceTypeBranch,
+				BranchID: destination,
+				CommitID: destinationCommitID,
+			},
+		},
+		Commits: map[graveler.CommitID]*graveler.Commit{
+			sourceCommitID:      {MetaRangeID: expectedRangeID, Generation: 5},
+			destinationCommitID: {MetaRangeID: expectedRangeID, Generation: 2},
+		},
+	}
+	committedManager := &testutil.CommittedFake{MetaRangeID: expectedRangeID}
+	stagingManager := &testutil.StagingFake{ValueIterator: testutil.NewValueIteratorFake(nil)}
+	g := graveler.NewGraveler(branchLocker, committedManager, stagingManager, refManager, nil)
+	commitID, _, err := g.Merge(ctx, repositoryID, destination, sourceCommitID.Ref(), graveler.CommitParams{
+		Committer: "committer",
+		Message:   "squash",
+	}, true, nil)
+	if err != nil {
+		t.Fatalf("Merge with squash: %s", err)
+	}
+	if commitID != mergedCommitID {
+		t.Errorf("Merge with squash returned commit %s, expected %s", commitID, mergedCommitID)
+	}
+	if diff := deep.Equal(refManager.AddedCommit.Parents, graveler.CommitParents{destinationCommitID}); diff != nil {
+		t.Errorf("squash merge commit parents differ from the destination alone: %s", diff)
+	}
+}
+
+func TestGraveler_BranchProtection(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	ctx := context.Background()
+	const repositoryID = graveler.RepositoryID("repoID")
+	refManager := &testutil.RefsFake{
+		Branch:            &graveler.Branch{CommitID: "c1"},
+		Commits:           map[graveler.CommitID]*graveler.Commit{"c1": {}},
+		ProtectedBranches: []string{"main", "release-*"},
+	}
+	stagingManager := &testutil.StagingFake{ValueIterator: testutil.NewValueIteratorFake(nil)}
+	g := graveler.NewGraveler(branchLocker, &testutil.CommittedFake{}, stagingManager, refManager, nil)
+
+	for _, branchID := range []graveler.BranchID{"main", "release-1"} {
+		if err := g.Set(ctx, repositoryID, branchID, graveler.Key("key"), graveler.Value{}); !errors.Is(err, graveler.ErrWriteToProtectedBranch) {
+			t.Errorf("Set on %s returned %v, expected %s", branchID, err, graveler.ErrWriteToProtectedBranch)
+		}
+		if err := g.Delete(ctx, repositoryID, branchID, graveler.Key("key")); !errors.Is(err, graveler.ErrWriteToProtectedBranch) {
+			t.Errorf("Delete on %s returned %v, expected %s", branchID, err, graveler.ErrWriteToProtectedBranch)
+		}
+		if _, err := g.Commit(ctx, repositoryID, branchID, graveler.CommitParams{Committer: "committer", Message: "message"}); !errors.Is(err, graveler.ErrCommitToProtectedBranch) {
+			t.Errorf("Commit on %s returned %v, expected %s", branchID, err, graveler.ErrCommitToProtectedBranch)
+		}
+	}
+	if stagingManager.LastSetValueRecord != nil {
+		t.Errorf("protected branch staged %s", stagingManager.LastSetValueRecord.Key)
+	}
+	if err := g.Set(ctx, repositoryID, "feature", graveler.Key("key"), graveler.Value{}); err != nil {
+		t.Errorf("Set on unprotected branch: %s", err)
+	}
+	if refManager.ProtectionReads != 1 {
+		t.Errorf("branch protection rules read %d times, expected once", refManager.ProtectionReads)
+	}
+
+	// a new rule applies at once
+	if err := g.CreateBranchProtectionRule(ctx, repositoryID, "feature"); err != nil {
+		t.Fatalf("create branch protection rule: %s", err)
+	}
+	if err := g.Set(ctx, repositoryID, "feature", graveler.Key("key"), graveler.Value{}); !errors.Is(err, graveler.ErrWriteToProtectedBranch) {
+		t.Errorf("Set on newly protected branch returned %v, expected %s", err, graveler.ErrWriteToProtectedBranch)
+	}
+}
+
+func TestGraveler_FastForward(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	const (
+		repositoryID    = graveler.RepositoryID("repoID")
+		destination     = graveler.BranchID("destinationID")
+		sourceCommitID  = graveler.CommitID("sourceCommitID")
+		expectedRangeID = graveler.MetaRangeID("expectedRangeID")
+	)
+	destinationCommit := &graveler.Commit{Message: "destination", MetaRangeID: expectedRangeID}
+	destinationCommitID := graveler.CommitID(ident.NewHexAddressProvider().ContentAddress(destinationCommit))
+	otherBase := &graveler.Commit{Message: "other", MetaRangeID: expectedRangeID}
+	tests := []struct {
+		name      string
+		mergeBase *graveler.CommitRecord
+		err       error
+	}{
+		{name: "destination is ancestor", mergeBase: &graveler.CommitRecord{CommitID: destinationCommitID, Commit: destinationCommit}},
+		{name: "diverged", mergeBase: &graveler.CommitRecord{CommitID: "otherBaseID", Commit: otherBase}, err: graveler.ErrNotFastForward},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			committedManager := &testutil.CommittedFake{MetaRangeID: expectedRangeID}
+			stagingManager := &testutil.StagingFake{ValueIterator: testutil.NewValueIteratorFake(nil)}
+			refManager := &testutil.RefsFake{
+				CommitID: sourceCommitID,
+				Branch:   &graveler.Branch{CommitID: destinationCommitID},
+				Refs: map[graveler.Ref]*graveler.ResolvedRef{
+					graveler.Ref(destination): {
+						Type:     graveler.ReferenceTypeBranch,
+						BranchID: destination,
+						CommitID: destinationCommitID,
+					},
+				},
+				Commits: map[graveler.CommitID]*graveler.Commit{
+					sourceCommitID:      {Message: "source", MetaRangeID: expectedRangeID},
+					destinationCommitID: destinationCommit,
+				},
+				MergeBase: tt.mergeBase,
+			}
+			g := graveler.NewGraveler(branchLocker, committedManager, stagingManager, refManager, nil)
+			commitID, err := g.FastForward(ctx, repositoryID, destination, sourceCommitID.Ref())
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("FastForward err=%v, expected=%v", err, tt.err)
+			}
+			if tt.err != nil {
+				if refManager.UpdatedBranch != nil {
+					t.Errorf("FastForward failed but updated the branch to %s", refManager.UpdatedBranch.CommitID)
+				}
+				return
+			}
+			if commitID != sourceCommitID {
+				t.Errorf("FastForward returned commit %s, expected %s", commitID, sourceCommitID)
+			}
+			if refManager.UpdatedBranch == nil || refManager.UpdatedBranch.CommitID != sourceCommitID {
+				t.Errorf("FastForward updated branch to %+v, expected commit %s", refManager.UpdatedBranch, sourceCommitID)
+			}
+			if refManager.AddedCommit.MetaRangeID != "" {
+				t.Errorf("FastForward added commit %+v", refManager.AddedCommit)
+			}
+		})
+	}
+}
+
+func TestGraveler_AmendCommit(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	ctx := context.Background()
+	const (
+		repositoryID     = graveler.RepositoryID("repoID")
+		branchID         = graveler.BranchID("branchID")
+		headCommitID     = graveler.CommitID("headCommitID")
+		parentCommitID   = graveler.CommitID("parentCommitID")
+		amendedCommitID  = graveler.CommitID("amendedCommitID")
+		expectedRangeID  = graveler.MetaRangeID("expectedRangeID")
+		stagingToken     = graveler.StagingToken("stagingToken")
+		amendedMessage   = "amended message"
+		amendedCommitter = "amender"
+	)
+	amendedMetadata := graveler.Metadata{"key": "value"}
+	refManager := &testutil.RefsFake{
+		CommitID: amendedCommitID,
+		Branch:   &graveler.Branch{CommitID: headCommitID, StagingToken: stagingToken},
+		Commits: map[graveler.CommitID]*graveler.Commit{
+			headCommitID: {Message: "original", MetaRangeID: expectedRangeID, Parents: graveler.CommitParents{parentCommitID}, Generation: 2},
+		},
+		ProtectedBranches: []string{branchID.String()},
+	}
+	g := graveler.NewGraveler(branchLocker, &testutil.CommittedFake{}, &testutil.StagingFake{}, refManager, nil)
+	params := graveler.CommitParams{
+		Committer: amendedCommitter,
+		Message:   amendedMessage,
+		Metadata:  amendedMetadata,
+	}
+	// the branch is protected, and the amend is not forced
+	_, err := g.AmendCommit(ctx, repositoryID, branchID, headCommitID, false, params)
+	if !errors.Is(err, graveler.ErrCommitToProtectedBranch) {
+		t.Fatalf("AmendCommit of a protected branch err=%v, expected %s", err, graveler.ErrCommitToProtectedBranch)
+	}
+	if refManager.AddedCommit.Message != "" || refManager.UpdatedBranch != nil {
+		t.Fatalf("AmendCommit of a protected branch added %+v and updated the branch to %+v", refManager.AddedCommit, refManager.UpdatedBranch)
+	}
+
+	// the branch moved past the commit to amend
+	_, err = g.AmendCommit(ctx, repositoryID, branchID, parentCommitID, true, params)
+	if !errors.Is(err, graveler.ErrPreconditionFailed) {
+		t.Fatalf("AmendCommit of a commit that is not the head err=%v, expected %s", err, graveler.ErrPreconditionFailed)
+	}
+	if refManager.AddedCommit.Message != "" || refManager.UpdatedBranch != nil {
+		t.Fatalf("AmendCommit of a commit that is not the head added %+v and updated the branch to %+v", refManager.AddedCommit, refManager.UpdatedBranch)
+	}
+
+	commitID, err := g.AmendCommit(ctx, repositoryID, branchID, headCommitID, true, params)
+	if err != nil {
+		t.Fatalf("AmendCommit: %s", err)
+	}
+	if commitID != amendedCommitID {
+		t.Errorf("AmendCommit returned commit %s, expected %s", commitID, amendedCommitID)
+	}
+	expectedCommit := testutil.AddedCommitData{
+		Committer:   amendedCommitter,
+		Message:     amendedMessage,
+		MetaRangeID: expectedRangeID,
+		Parents:     graveler.CommitParents{parentCommitID},
+		Metadata:    amendedMetadata,
+	}
+	if diff := deep.Equal(refManager.AddedCommit, expectedCommit); diff != nil {
+		t.Errorf("AmendCommit added unexpected commit: %s", diff)
+	}
+	expectedBranch := &graveler.Branch{CommitID: amendedCommitID, StagingToken: stagingToken}
+	if diff := deep.Equal(refManager.UpdatedBranch, expectedBranch); diff != nil {
+		t.Errorf("AmendCommit updated unexpected branch: %s", diff)
+	}
+}
+
 func TestGraveler_AddCommitToBranchHead(t *testing.T) {
 	conn, _ := tu.GetDB(t, databaseURI)
 	branchLocker := ref.NewBranchLocker(conn)
@@ -127,6 +127,10 @@ func (m *Manager) DeleteRepository(ctx context.Context, repositoryID graveler.Re
 		if err != nil {
 			return nil, err
 		}
+		_, err = tx.Exec(`DELETE FROM graveler_branch_protection_rules WHERE repository_id = $1`, repositoryID)
+		if err != nil {
+			return nil, err
+		}
 		r, err := tx.Exec(`DELETE FROM graveler_repositories WHERE id = $1`, repositoryID)
 		if err != nil {
 			return nil, err
@@ -142,6 +146,32 @@ func (m *Manager) DeleteRepository(ctx context.Context, repositoryID graveler.Re
 	return err
 }
 
+func (m *Manager) SetDefaultBranch(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) error {
+	_, err := m.db.Transact(ctx, func(tx db.Tx) (interface{}, error) {
+		var exists bool
+		err := tx.GetPrimitive(&exists, `SELECT EXISTS (SELECT 1 FROM graveler_branches WHERE repository_id = $1 AND id = $2)`,
+			repositoryID, branchID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, graveler.ErrBranchNotFound
+		}
+		r, err := tx.Exec(`UPDATE graveler_repositories SET default_branch = $2 WHERE id = $1`, repositoryID, branchID)
+		if err != nil {
+			return nil, err
+		}
+		if r.RowsAffected() == 0 {
+			return nil, db.ErrNotFound
+		}
+		return nil, nil
+	})
+	if errors.Is(err, db.ErrNotFound) {
+		return graveler.ErrRepositoryNotFound
+	}
+	return err
+}
+
 func (m *Manager) ParseRef(ref graveler.Ref) (graveler.RawRef, error) {
 	return ParseRef(ref)
 }
@@ -373,7 +403,7 @@ func (m *Manager) addCommit(tx db.Tx, repositoryID graveler.RepositoryID, commit
 	return err
 }
 
-func (m *Manager) FindMergeBase(ctx context.Context, repositoryID graveler.RepositoryID, commitIDs ...graveler.CommitID) (*graveler.Commit, error) {
+func (m *Manager) FindMergeBase(ctx context.Context, repositoryID graveler.RepositoryID, commitIDs ...graveler.CommitID) (*graveler.CommitRecord, error) {
 	const allowedCommitsToCompare = 2
 	if len(commitIDs) != allowedCommitsToCompare {
 		return nil, graveler.ErrInvalidMergeBase
@@ -414,3 +444,47 @@ func (m *Manager) FillGenerations(ctx context.Context, repositoryID graveler.Rep
 	})
 	return err
 }
+
+func (m *Manager) GetBranchProtectionRules(ctx context.Context, repositoryID graveler.RepositoryID) ([]string, error) {
+	patterns, err := m.db.Transact(ctx, func(tx db.Tx) (interface{}, error) {
+		var patterns []string
+		err := tx.Select(&patterns, `SELECT pattern FROM graveler_branch_protection_rules WHERE repository_id = $1 ORDER BY pattern`,
+			repositoryID)
+		return patterns, err
+	}, db.ReadOnly())
+	if err != nil {
+		return nil, err
+	}
+	return patterns.([]string), nil
+}
+
+func (m *Manager) CreateBranchProtectionRule(ctx context.Context, repositoryID graveler.RepositoryID, pattern string) error {
+	_, err := m.db.Transact(ctx, func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`INSERT INTO graveler_branch_protection_rules (repository_id, pattern) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`,
+			repositoryID, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, graveler.ErrBranchProtectionRuleExists
+		}
+		return nil, nil
+	})
+	return err
+}
+
+func (m *Manager) DeleteBranchProtectionRule(ctx context.Context, repositoryID graveler.RepositoryID, pattern string) error {
+	_, err := m.db.Transact(ctx, func(tx db.Tx) (interface{}, error) {
+		r, err := tx.Exec(`DELETE FROM graveler_branch_protection_rules WHERE repository_id = $1 AND pattern = $2`,
+			repositoryID, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if r.RowsAffected() == 0 {
+			return nil, graveler.ErrBranchProtectionRuleNotFound
+		}
+		return nil, nil
+	})
+	return err
+}
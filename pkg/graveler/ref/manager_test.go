@@ -152,6 +152,42 @@ func TestManager_DeleteRepository(t *testing.T) {
 	})
 }
 
+func TestManager_SetDefaultBranch(t *testing.T) {
+	r := testRefManager(t)
+	ctx := context.Background()
+	testutil.Must(t, r.CreateRepository(ctx, "repo1", graveler.Repository{
+		StorageNamespace: "s3://foo",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "main",
+	}, ""))
+	mainBranch, err := r.GetBranch(ctx, "repo1", "main")
+	testutil.Must(t, err)
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "dev", graveler.Branch{CommitID: mainBranch.CommitID}))
+
+	t.Run("branch_exists", func(t *testing.T) {
+		testutil.Must(t, r.SetDefaultBranch(ctx, "repo1", "dev"))
+		repo, err := r.GetRepository(ctx, "repo1")
+		testutil.Must(t, err)
+		if repo.DefaultBranchID != "dev" {
+			t.Fatalf("got default branch %s, expected dev", repo.DefaultBranchID)
+		}
+	})
+
+	t.Run("branch_does_not_exist", func(t *testing.T) {
+		err := r.SetDefaultBranch(ctx, "repo1", "missing")
+		if !errors.Is(err, graveler.ErrBranchNotFound) {
+			t.Fatalf("expected ErrBranchNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("repo_does_not_exist", func(t *testing.T) {
+		err := r.SetDefaultBranch(ctx, "example-repo11111", "main")
+		if !errors.Is(err, graveler.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got: %v", err)
+		}
+	})
+}
+
 func TestManager_GetBranch(t *testing.T) {
 	r := testRefManager(t)
 	t.Run("get_branch_exists", func(t *testing.T) {
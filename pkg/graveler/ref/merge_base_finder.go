@@ -20,7 +20,7 @@ const (
 
 // FindMergeBase finds the best common ancestor according to the definition in the git-merge-base documentation: https://git-scm.com/docs/git-merge-base
 // One common ancestor is better than another common ancestor if the latter is an ancestor of the former.
-func FindMergeBase(ctx context.Context, getter CommitGetter, repositoryID graveler.RepositoryID, leftID, rightID graveler.CommitID) (*graveler.Commit, error) {
+func FindMergeBase(ctx context.Context, getter CommitGetter, repositoryID graveler.RepositoryID, leftID, rightID graveler.CommitID) (*graveler.CommitRecord, error) {
 	var commitRecord *graveler.CommitRecord
 	queue := NewCommitsGenerationPriorityQueue()
 	reached := make(map[graveler.CommitID]reachedFlags)
@@ -38,7 +38,7 @@ func FindMergeBase(ctx context.Context, getter CommitGetter, repositoryID gravel
 		commitFlags := reached[commitRecord.CommitID]
 		if commitFlags&fromLeft != 0 && commitFlags&fromRight != 0 {
 			// commit was reached from both left and right nodes
-			return commitRecord.Commit, nil
+			return commitRecord, nil
 		}
 		for _, parent := range commitRecord.Parents {
 			parentCommit, err := getter.GetCommit(ctx, repositoryID, parent)
@@ -289,7 +289,7 @@ func TestGrid(t *testing.T) {
 	verifyResult(t, c, []string{"6-6"})
 }
 
-func verifyResult(t *testing.T, base *graveler.Commit, expected []string) {
+func verifyResult(t *testing.T, base *graveler.CommitRecord, expected []string) {
 	if base == nil {
 		if len(expected) != 0 {
 			t.Fatalf("got nil result, expected %s", expected)
@@ -297,7 +297,8 @@ func verifyResult(t *testing.T, base *graveler.Commit, expected []string) {
 		return
 	}
 	for _, expectedKey := range expected {
-		if base.Message == expectedKey {
+		// commits are keyed by their messages
+		if base.Message == expectedKey && string(base.CommitID) == expectedKey {
 			return
 		}
 	}
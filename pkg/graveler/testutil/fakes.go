@@ -70,7 +70,7 @@ func (c *CommittedFake) Compare(context.Context, graveler.StorageNamespace, grav
 	return c.DiffIterator, nil
 }
 
-func (c *CommittedFake) Merge(_ context.Context, _ graveler.StorageNamespace, _, _, _ graveler.MetaRangeID) (graveler.MetaRangeID, graveler.DiffSummary, error) {
+func (c *CommittedFake) Merge(_ context.Context, _ graveler.StorageNamespace, _, _, _ graveler.MetaRangeID, _ graveler.MergeResolutions) (graveler.MetaRangeID, graveler.DiffSummary, error) {
 	if c.Err != nil {
 		return "", graveler.DiffSummary{}, c.Err
 	}
@@ -198,12 +198,30 @@ type RefsFake struct {
 	AddedCommit         AddedCommitData
 	CommitID            graveler.CommitID
 	Commits             map[graveler.CommitID]*graveler.Commit
+	MergeBase           *graveler.CommitRecord
+	UpdatedBranch       *graveler.Branch
+	ProtectedBranches   []string
+	ProtectionReads     int
 }
 
 func (m *RefsFake) FillGenerations(ctx context.Context, repositoryID graveler.RepositoryID) error {
 	panic("implement me")
 }
 
+func (m *RefsFake) GetBranchProtectionRules(context.Context, graveler.RepositoryID) ([]string, error) {
+	m.ProtectionReads++
+	return m.ProtectedBranches, nil
+}
+
+func (m *RefsFake) CreateBranchProtectionRule(_ context.Context, _ graveler.RepositoryID, pattern string) error {
+	m.ProtectedBranches = append(m.ProtectedBranches, pattern)
+	return nil
+}
+
+func (m *RefsFake) DeleteBranchProtectionRule(context.Context, graveler.RepositoryID, string) error {
+	panic("implement me")
+}
+
 func (m *RefsFake) CreateBareRepository(ctx context.Context, repositoryID graveler.RepositoryID, repository graveler.Repository) error {
 	panic("implement me")
 }
@@ -255,11 +273,16 @@ func (m *RefsFake) DeleteRepository(context.Context, graveler.RepositoryID) erro
 	return nil
 }
 
+func (m *RefsFake) SetDefaultBranch(context.Context, graveler.RepositoryID, graveler.BranchID) error {
+	return nil
+}
+
 func (m *RefsFake) GetBranch(context.Context, graveler.RepositoryID, graveler.BranchID) (*graveler.Branch, error) {
 	return m.Branch, m.Err
 }
 
-func (m *RefsFake) SetBranch(context.Context, graveler.RepositoryID, graveler.BranchID, graveler.Branch) error {
+func (m *RefsFake) SetBranch(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID, branch graveler.Branch) error {
+	m.UpdatedBranch = &branch
 	return nil
 }
 
@@ -309,8 +332,11 @@ func (m *RefsFake) AddCommit(_ context.Context, _ graveler.RepositoryID, commit
 	return m.CommitID, nil
 }
 
-func (m *RefsFake) FindMergeBase(context.Context, graveler.RepositoryID, ...graveler.CommitID) (*graveler.Commit, error) {
-	return &graveler.Commit{}, nil
+func (m *RefsFake) FindMergeBase(context.Context, graveler.RepositoryID, ...graveler.CommitID) (*graveler.CommitRecord, error) {
+	if m.MergeBase != nil {
+		return m.MergeBase, nil
+	}
+	return &graveler.CommitRecord{Commit: &graveler.Commit{}}, nil
 }
 
 func (m *RefsFake) Log(context.Context, graveler.RepositoryID, graveler.CommitID) (graveler.CommitIterator, error) {
@@ -21,6 +21,7 @@ import (
 	"github.com/treeverse/lakefs/pkg/config"
 	"github.com/treeverse/lakefs/pkg/db"
 	dbparams "github.com/treeverse/lakefs/pkg/db/params"
+	"github.com/treeverse/lakefs/pkg/gateway/multiparts"
 	"github.com/treeverse/lakefs/pkg/logging"
 	"github.com/treeverse/lakefs/pkg/stats"
 	"github.com/treeverse/lakefs/pkg/testutil"
@@ -96,6 +97,7 @@ func TestLocalLoad(t *testing.T) {
 		&nullCollector{},
 		nil,
 		actionsService,
+		multiparts.NewTracker(conn),
 		logging.Default(),
 		nil,
 	)
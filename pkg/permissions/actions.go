@@ -15,6 +15,7 @@ const (
 	ReadRepositoryAction     = "fs:ReadRepository"
 	CreateRepositoryAction   = "fs:CreateRepository"
 	DeleteRepositoryAction   = "fs:DeleteRepository"
+	UpdateRepositoryAction   = "fs:UpdateRepository"
 	ListRepositoriesAction   = "fs:ListRepositories"
 	ReadObjectAction         = "fs:ReadObject"
 	WriteObjectAction        = "fs:WriteObject"
@@ -33,6 +34,10 @@ const (
 	ReadTagAction            = "fs:ReadTag"
 	ListTagsAction           = "fs:ListTags"
 	ReadStorageConfiguration = "fs:ReadConfig"
+	ExportObjectsAction      = "fs:ExportObjects"
+
+	GetBranchProtectionRulesAction = "fs:ReadBranchProtectionRules"
+	SetBranchProtectionRulesAction = "fs:SetBranchProtectionRules"
 
 	ReadUserAction          = "auth:ReadUser"
 	CreateUserAction        = "auth:CreateUser"
@@ -28,6 +28,12 @@ func TagArn(repoID, tagID string) string {
 	return fSArnPrefix + "repository/" + repoID + "/tag/" + tagID
 }
 
+// StorageNamespaceArn is the resource of objects written outside of repositories, under
+// storageNamespace.
+func StorageNamespaceArn(storageNamespace string) string {
+	return fSArnPrefix + "namespace/" + storageNamespace
+}
+
 func UserArn(userID string) string {
 	return authArnPrefix + "user/" + userID
 }
@@ -16,11 +16,17 @@ type Blob struct {
 	Size            int64
 }
 
+// NewPhysicalAddress returns a new unique address, relative to the storage namespace, for
+// writing an object.
+func NewPhysicalAddress() string {
+	uid := uuid.New()
+	return hex.EncodeToString(uid[:])
+}
+
 func WriteBlob(ctx context.Context, adapter block.Adapter, bucketName string, body io.Reader, contentLength int64, opts block.PutOpts) (*Blob, error) {
 	// handle the upload itself
 	hashReader := block.NewHashingReader(body, block.HashFunctionMD5, block.HashFunctionSHA256)
-	uid := uuid.New()
-	address := hex.EncodeToString(uid[:])
+	address := NewPhysicalAddress()
 	err := adapter.Put(ctx, block.ObjectPointer{
 		StorageNamespace: bucketName,
 		Identifier:       address,